@@ -0,0 +1,123 @@
+package apiary
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/pprof"
+	"strconv"
+	"strings"
+
+	"github.com/chnm/apiary/internal/httpx"
+)
+
+// purgeResult is the body of a successful cache purge.
+type purgeResult struct {
+	Removed int `json:"removed"`
+}
+
+// AdminHandler returns the handler for the admin listener: the probes,
+// /metrics, the admin API, and the runtime profiles under /debug/pprof/.
+// Profiles are never served on the public port.
+func (s *Server) AdminHandler() http.Handler {
+	mux := http.NewServeMux()
+	s.handleOperational(mux)
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	return httpx.RequestID(mux)
+}
+
+// handleOperational registers the probes, /metrics, and the admin API, if it
+// is enabled.
+func (s *Server) handleOperational(mux *http.ServeMux) {
+	mux.Handle("/healthz", s.HealthHandler())
+	mux.Handle("/readyz", s.ReadinessHandler())
+	if s.Metrics != nil {
+		mux.Handle("/metrics", s.Metrics.Handler())
+	}
+	if s.Config.Admin.Token != "" {
+		mux.Handle("/admin/cache/purge", s.requireAdminToken(s.CachePurgeHandler()))
+	}
+}
+
+// requireAdminToken allows only requests that present the configured admin
+// token as a bearer token.
+func (s *Server) requireAdminToken(next http.Handler) http.Handler {
+	// Compare digests so that the comparison takes the same time whatever
+	// the length of the presented token.
+	want := sha256.Sum256([]byte(s.Config.Admin.Token))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-store")
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		got := sha256.Sum256([]byte(token))
+		if !ok || subtle.ConstantTimeCompare(got[:], want[:]) != 1 {
+			httpx.Unauthorized(w, r, "a valid admin token is required")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// CachePurgeHandler removes responses from the server's cache and reports how
+// many it removed. Exactly one of these query parameters selects them:
+//
+//   - url: the responses for one path and query string, in every format
+//   - prefix: the responses for every path that begins with the prefix
+//   - all=true: every response
+func (s *Server) CachePurgeHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			httpx.WriteProblem(w, r, httpx.Problem{
+				Status: http.StatusMethodNotAllowed,
+				Code:   httpx.CodeMethodNotAllowed,
+				Detail: "only POST requests are supported",
+			})
+			return
+		}
+
+		query := r.URL.Query()
+		var given []string
+		for _, name := range []string{"url", "prefix", "all"} {
+			if query.Has(name) {
+				given = append(given, name)
+			}
+		}
+		if len(given) != 1 {
+			httpx.BadRequest(w, r, errors.New("give exactly one of the url, prefix, or all parameters"))
+			return
+		}
+
+		var removed int
+		switch given[0] {
+		case "url":
+			n, err := s.Cache.PurgeURL(query.Get("url"))
+			if err != nil {
+				httpx.InvalidParameter(w, r, "url", err.Error())
+				return
+			}
+			removed = n
+		case "prefix":
+			prefix := query.Get("prefix")
+			if !strings.HasPrefix(prefix, "/") {
+				httpx.InvalidParameter(w, r, "prefix", "the prefix must begin with /")
+				return
+			}
+			removed = s.Cache.PurgePrefix(prefix)
+		case "all":
+			if all, err := strconv.ParseBool(query.Get("all")); err != nil || !all {
+				httpx.InvalidParameter(w, r, "all", "use all=true to purge every response")
+				return
+			}
+			removed = s.Cache.PurgeAll()
+		}
+
+		slog.InfoContext(r.Context(), "purged cached responses", "removed", removed, "query", r.URL.RawQuery)
+		httpx.WriteJSON(w, r, purgeResult{Removed: removed})
+	}
+}
@@ -0,0 +1,169 @@
+package apiary
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/chnm/apiary/internal/datasets"
+	"github.com/chnm/apiary/internal/httpx"
+	"github.com/chnm/apiary/internal/testsupport"
+	"github.com/gorilla/mux"
+)
+
+const testAdminToken = "0123456789abcdef0123456789abcdef"
+
+// newAdminServer returns a server with the admin API enabled and a few
+// cacheable routes, along with the number of requests that reached each
+// route.
+func newAdminServer(t *testing.T) (*Server, map[string]int) {
+	t.Helper()
+	calls := make(map[string]int)
+	router := mux.NewRouter()
+	for _, path := range []string{"/bom/bills", "/bom/parishes", "/ahcb/states"} {
+		router.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			calls[r.URL.Path]++
+			_, _ = w.Write([]byte("ok"))
+		})
+	}
+	server := &Server{Router: router, Cache: newTestCache(t), Datasets: datasets.NewRegistry()}
+	server.Config.Admin.Token = testAdminToken
+	server.Routes()
+	server.Middleware()
+	return server, calls
+}
+
+func purge(t *testing.T, server *Server, query, token string) *httptest.ResponseRecorder {
+	t.Helper()
+	request := httptest.NewRequest(http.MethodPost, "/admin/cache/purge?"+query, nil)
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+	response := httptest.NewRecorder()
+	server.Handler().ServeHTTP(response, request)
+	return response
+}
+
+func TestCachePurge(t *testing.T) {
+	tests := []struct {
+		query       string
+		wantRemoved int
+		wantFresh   []string // Paths whose next request misses the cache
+	}{
+		{"url=/bom/bills?year=1665%26count=2", 1, []string{"/bom/bills"}},
+		{"url=/bom/bills", 0, nil},
+		{"prefix=/bom/", 2, []string{"/bom/bills", "/bom/parishes"}},
+		{"all=true", 3, []string{"/bom/bills", "/bom/parishes", "/ahcb/states"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			server, calls := newAdminServer(t)
+			urls := []string{"/bom/bills?count=2&year=1665", "/bom/parishes", "/ahcb/states"}
+			for _, url := range urls {
+				server.Handler().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, url, nil))
+			}
+
+			response := purge(t, server, tt.query, testAdminToken)
+			if response.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d: %s", response.Code, http.StatusOK, response.Body)
+			}
+			var result purgeResult
+			if err := json.NewDecoder(response.Body).Decode(&result); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if result.Removed != tt.wantRemoved {
+				t.Errorf("removed = %d, want %d", result.Removed, tt.wantRemoved)
+			}
+
+			for _, url := range urls {
+				server.Handler().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, url, nil))
+			}
+			for _, url := range urls {
+				path, _, _ := strings.Cut(url, "?")
+				want := 1
+				for _, fresh := range tt.wantFresh {
+					if fresh == path {
+						want = 2
+					}
+				}
+				if calls[path] != want {
+					t.Errorf("%s handler calls = %d, want %d", path, calls[path], want)
+				}
+			}
+		})
+	}
+}
+
+func TestCachePurgeRejectsInvalidRequests(t *testing.T) {
+	server, _ := newAdminServer(t)
+	tests := []struct {
+		name, query, token string
+		status             int
+		code, parameter    string
+	}{
+		{"no token", "all=true", "", http.StatusUnauthorized, httpx.CodeUnauthorized, ""},
+		{"wrong token", "all=true", strings.Repeat("x", len(testAdminToken)), http.StatusUnauthorized, httpx.CodeUnauthorized, ""},
+		{"no selector", "", testAdminToken, http.StatusBadRequest, httpx.CodeInvalidParameter, ""},
+		{"two selectors", "all=true&prefix=/bom/", testAdminToken, http.StatusBadRequest, httpx.CodeInvalidParameter, ""},
+		{"relative prefix", "prefix=bom", testAdminToken, http.StatusBadRequest, httpx.CodeInvalidParameter, "prefix"},
+		{"absolute url", "url=http://example.com/bom/bills", testAdminToken, http.StatusBadRequest, httpx.CodeInvalidParameter, "url"},
+		{"all false", "all=false", testAdminToken, http.StatusBadRequest, httpx.CodeInvalidParameter, "all"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := purge(t, server, tt.query, tt.token)
+			testsupport.AssertProblem(t, response, tt.status, tt.code, tt.parameter)
+		})
+	}
+
+	response := purge(t, server, "all=true", "")
+	if got := response.Header().Get("WWW-Authenticate"); !strings.HasPrefix(got, "Bearer") {
+		t.Errorf("WWW-Authenticate = %q, want a Bearer challenge", got)
+	}
+
+	request := httptest.NewRequest(http.MethodGet, "/admin/cache/purge?all=true", nil)
+	request.Header.Set("Authorization", "Bearer "+testAdminToken)
+	get := httptest.NewRecorder()
+	server.Handler().ServeHTTP(get, request)
+	testsupport.AssertProblem(t, get, http.StatusMethodNotAllowed, httpx.CodeMethodNotAllowed, "")
+}
+
+func TestCachePurgeIsDisabledWithoutToken(t *testing.T) {
+	server, _ := newAdminServer(t)
+	server.Config.Admin.Token = ""
+
+	response := purge(t, server, "all=true", "")
+	testsupport.AssertProblem(t, response, http.StatusNotFound, httpx.CodeRouteNotFound, "")
+}
+
+func TestAdminListener(t *testing.T) {
+	server, _ := newAdminServer(t)
+	server.Config.Admin.Port = 8091
+
+	// The public handler no longer serves operational endpoints or profiles.
+	for _, path := range []string{"/healthz", "/admin/cache/purge?all=true", "/debug/pprof/"} {
+		response := httptest.NewRecorder()
+		server.Handler().ServeHTTP(response, httptest.NewRequest(http.MethodGet, path, nil))
+		testsupport.AssertProblem(t, response, http.StatusNotFound, httpx.CodeRouteNotFound, "")
+	}
+
+	for _, path := range []string{"/healthz", "/debug/pprof/"} {
+		response := httptest.NewRecorder()
+		server.AdminHandler().ServeHTTP(response, httptest.NewRequest(http.MethodGet, path, nil))
+		if response.Code != http.StatusOK {
+			t.Errorf("admin listener: GET %s status = %d, want %d", path, response.Code, http.StatusOK)
+		}
+	}
+	request := httptest.NewRequest(http.MethodPost, "/admin/cache/purge?all=true", nil)
+	request.Header.Set("Authorization", "Bearer "+testAdminToken)
+	response := httptest.NewRecorder()
+	server.AdminHandler().ServeHTTP(response, request)
+	if response.Code != http.StatusOK {
+		t.Errorf("admin listener: purge status = %d, want %d", response.Code, http.StatusOK)
+	}
+	if response.Header().Get(httpx.RequestIDHeader) == "" {
+		t.Errorf("admin listener response has no %s", httpx.RequestIDHeader)
+	}
+}
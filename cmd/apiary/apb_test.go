@@ -15,11 +15,7 @@ func TestAPBFeaturedVerses(t *testing.T) {
 	checkResponseCode(t, http.StatusOK, response.Code)
 
 	// Get the data
-	var data []apb.APBIndexItem
-	err := json.Unmarshal(response.Body.Bytes(), &data)
-	if err != nil {
-		t.Error(err)
-	}
+	data := decodePage[apb.APBIndexItem](t, response)
 
 	if len(data) == 0 {
 		t.Error("No featured verses returned.")
@@ -34,11 +30,7 @@ func TestAPBTopVerses(t *testing.T) {
 	checkResponseCode(t, http.StatusOK, response.Code)
 
 	// Get the data
-	var data []apb.APBIndexItem
-	err := json.Unmarshal(response.Body.Bytes(), &data)
-	if err != nil {
-		t.Error(err)
-	}
+	data := decodePage[apb.APBIndexItem](t, response)
 
 	if len(data) == 0 {
 		t.Error("No top verses returned.")
@@ -53,11 +45,7 @@ func TestAPBVersePeaks(t *testing.T) {
 	checkResponseCode(t, http.StatusOK, response.Code)
 
 	// Get the data
-	var data []apb.APBIndexItem
-	err := json.Unmarshal(response.Body.Bytes(), &data)
-	if err != nil {
-		t.Error(err)
-	}
+	data := decodePage[apb.APBIndexItem](t, response)
 
 	if len(data) == 0 {
 		t.Error("No verse peaks returned.")
@@ -163,11 +151,7 @@ func TestAPBVerseQuotations(t *testing.T) {
 	checkResponseCode(t, http.StatusOK, response.Code)
 
 	// Get the data
-	var data []apb.VerseQuotation
-	err := json.Unmarshal(response.Body.Bytes(), &data)
-	if err != nil {
-		t.Error(err)
-	}
+	data := decodePage[apb.VerseQuotation](t, response)
 
 	if len(data) == 0 {
 		t.Error("No verse quotations returned.")
@@ -188,11 +172,7 @@ func TestBiblicalIndex(t *testing.T) {
 	checkResponseCode(t, http.StatusOK, response.Code)
 
 	// Get the data
-	var data []apb.BibleBook
-	err := json.Unmarshal(response.Body.Bytes(), &data)
-	if err != nil {
-		t.Error(err)
-	}
+	decodePage[apb.BibleBook](t, response)
 }
 
 func TestBibleAllIndex(t *testing.T) {
@@ -202,11 +182,7 @@ func TestBibleAllIndex(t *testing.T) {
 	checkResponseCode(t, http.StatusOK, response.Code)
 
 	// Get the data
-	var data []apb.APBIndexItemText
-	err := json.Unmarshal(response.Body.Bytes(), &data)
-	if err != nil {
-		t.Error(err)
-	}
+	decodePage[apb.APBIndexItemText](t, response)
 }
 
 func TestBibleSimilarity(t *testing.T) {
@@ -216,11 +192,7 @@ func TestBibleSimilarity(t *testing.T) {
 	checkResponseCode(t, http.StatusOK, response.Code)
 
 	// Get the data
-	var data []apb.BibleSimilarityEdge
-	err := json.Unmarshal(response.Body.Bytes(), &data)
-	if err != nil {
-		t.Error(err)
-	}
+	decodePage[apb.BibleSimilarityEdge](t, response)
 }
 
 func TestBibleBooks(t *testing.T) {
@@ -230,9 +202,5 @@ func TestBibleBooks(t *testing.T) {
 	checkResponseCode(t, http.StatusOK, response.Code)
 
 	// Get the data
-	var data []apb.BibleBook
-	err := json.Unmarshal(response.Body.Bytes(), &data)
-	if err != nil {
-		t.Error(err)
-	}
+	decodePage[apb.BibleBook](t, response)
 }
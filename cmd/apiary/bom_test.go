@@ -4,10 +4,11 @@ import (
 	"encoding/json"
 	"net/http"
 	"strconv"
-	"strings"
 	"testing"
 
 	"github.com/chnm/apiary/internal/datasets/bom"
+	"github.com/chnm/apiary/internal/httpx/pagination"
+	"github.com/chnm/apiary/internal/testsupport"
 )
 
 func TestBomParishes(t *testing.T) {
@@ -17,11 +18,7 @@ func TestBomParishes(t *testing.T) {
 	checkResponseCode(t, http.StatusOK, response.Code)
 
 	// Get the data
-	var data []bom.Parish
-	err := json.Unmarshal(response.Body.Bytes(), &data)
-	if err != nil {
-		t.Error(err)
-	}
+	data := decodePage[bom.Parish](t, response)
 
 	if len(data) == 0 {
 		t.Fatal("expected BOM parishes")
@@ -46,16 +43,52 @@ func TestBomBills(t *testing.T) {
 	checkResponseCode(t, http.StatusOK, response.Code)
 
 	// Get the data
-	var data bom.PaginatedResponse
-	err := json.Unmarshal(response.Body.Bytes(), &data)
-	if err != nil {
-		t.Error(err)
-	}
+	data := decodeResponse[pagination.Page[bom.ParishByYear]](t, response)
 	if data.Data == nil {
 		t.Error("expected paginated bill data, got nil")
 	}
 }
 
+func TestBomBillsFollowsLinkHeader(t *testing.T) {
+	const path = "/bom/bills?start-year=1669&end-year=1754&limit=2"
+	req, _ := http.NewRequest(http.MethodGet, path, nil)
+	response := executeRequest(req)
+	checkResponseCode(t, http.StatusOK, response.Code)
+	first := decodeResponse[pagination.Page[bom.ParishByYear]](t, response)
+	if !first.HasMore {
+		t.Skipf("Need more than two bills to follow a next link; got %d", len(first.Data))
+	}
+	if link := response.Header().Get("Link"); link != "<"+first.Next+`>; rel="next"` {
+		t.Fatalf("Link = %q, want the envelope's next link %q", link, first.Next)
+	}
+
+	req, _ = http.NewRequest(http.MethodGet, first.Next, nil)
+	response = executeRequest(req)
+	checkResponseCode(t, http.StatusOK, response.Code)
+	second := decodeResponse[pagination.Page[bom.ParishByYear]](t, response)
+	if len(second.Data) == 0 {
+		t.Fatal("next page is empty")
+	}
+	if second.Prev == "" {
+		t.Error("next page has no prev link")
+	}
+
+	// The second page continues where the first stopped, so the two overlap
+	// with a single limit=4 page.
+	req, _ = http.NewRequest(http.MethodGet, "/bom/bills?start-year=1669&end-year=1754&limit=4", nil)
+	response = executeRequest(req)
+	checkResponseCode(t, http.StatusOK, response.Code)
+	both := decodeResponse[pagination.Page[bom.ParishByYear]](t, response)
+	if len(both.Data) < 2+len(second.Data) {
+		t.Fatalf("limit=4 page has %d bills, want at least %d", len(both.Data), 2+len(second.Data))
+	}
+	for index, bill := range second.Data {
+		if want := both.Data[2+index]; bill.CanonicalName != want.CanonicalName || bill.WeekNumber != want.WeekNumber || bill.Year != want.Year {
+			t.Fatalf("next page bill %d = %+v, want %+v", index, bill, want)
+		}
+	}
+}
+
 func TestBomTotalBills(t *testing.T) {
 	req, _ := http.NewRequest("GET", "/bom/totalbills?type=weekly", nil)
 	response := executeRequest(req)
@@ -75,10 +108,7 @@ func TestBomStatistics(t *testing.T) {
 	response := executeRequest(req)
 	checkResponseCode(t, http.StatusOK, response.Code)
 
-	var data []bom.YearlySummary
-	if err := json.Unmarshal(response.Body.Bytes(), &data); err != nil {
-		t.Fatal(err)
-	}
+	data := decodePage[bom.YearlySummary](t, response)
 	if len(data) == 0 {
 		t.Fatal("expected yearly statistics")
 	}
@@ -91,11 +121,7 @@ func TestBomChristenings(t *testing.T) {
 	checkResponseCode(t, http.StatusOK, response.Code)
 
 	// Get the data
-	var data []bom.ChristeningsByYear
-	err := json.Unmarshal(response.Body.Bytes(), &data)
-	if err != nil {
-		t.Error(err)
-	}
+	decodePage[bom.ChristeningsByYear](t, response)
 }
 
 func TestBomCauses(t *testing.T) {
@@ -103,11 +129,7 @@ func TestBomCauses(t *testing.T) {
 	response := executeRequest(req)
 	checkResponseCode(t, http.StatusOK, response.Code)
 
-	var data []bom.DeathCauses
-	err := json.Unmarshal(response.Body.Bytes(), &data)
-	if err != nil {
-		t.Error(err)
-	}
+	decodePage[bom.DeathCauses](t, response)
 }
 
 func TestBomListChristenings(t *testing.T) {
@@ -115,11 +137,7 @@ func TestBomListChristenings(t *testing.T) {
 	response := executeRequest(req)
 	checkResponseCode(t, http.StatusOK, response.Code)
 
-	var data []bom.Christenings
-	err := json.Unmarshal(response.Body.Bytes(), &data)
-	if err != nil {
-		t.Error(err)
-	}
+	decodePage[bom.Christenings](t, response)
 }
 
 func TestBomListCauses(t *testing.T) {
@@ -127,11 +145,7 @@ func TestBomListCauses(t *testing.T) {
 	response := executeRequest(req)
 	checkResponseCode(t, http.StatusOK, response.Code)
 
-	var data []bom.Causes
-	err := json.Unmarshal(response.Body.Bytes(), &data)
-	if err != nil {
-		t.Error(err)
-	}
+	decodePage[bom.Causes](t, response)
 }
 
 func TestBomShapefiles(t *testing.T) {
@@ -368,8 +382,8 @@ func TestBomBillsInvalidParish(t *testing.T) {
 	)
 	response := executeRequest(req)
 	checkResponseCode(t, http.StatusBadRequest, response.Code)
-	if body := strings.TrimSpace(response.Body.String()); body != "invalid parish ID(s): 2147483647" {
-		t.Errorf("unexpected error body: %q", body)
+	if problem := testsupport.DecodeProblem(t, response); problem.Parameter != "parish" || problem.Detail != "invalid parish ID(s): 2147483647" {
+		t.Errorf("unexpected problem: %+v", problem)
 	}
 
 	// Multiple unknown IDs are all reported, comma-space separated, in request order.
@@ -380,15 +394,15 @@ func TestBomBillsInvalidParish(t *testing.T) {
 	)
 	response = executeRequest(req)
 	checkResponseCode(t, http.StatusBadRequest, response.Code)
-	if body := strings.TrimSpace(response.Body.String()); body != "invalid parish ID(s): 2147483646, 2147483647" {
-		t.Errorf("unexpected error body: %q", body)
+	if problem := testsupport.DecodeProblem(t, response); problem.Parameter != "parish" || problem.Detail != "invalid parish ID(s): 2147483646, 2147483647" {
+		t.Errorf("unexpected problem: %+v", problem)
 	}
 
 	// Select a valid parish ID from the database rather than assuming one.
 	parishRequest, _ := http.NewRequest(http.MethodGet, "/bom/parishes", nil)
 	parishResponse := executeRequest(parishRequest)
 	checkResponseCode(t, http.StatusOK, parishResponse.Code)
-	parishes := decodeResponse[[]bom.Parish](t, parishResponse)
+	parishes := decodePage[bom.Parish](t, parishResponse)
 	if len(parishes) == 0 {
 		t.Fatal("expected at least one parish")
 	}
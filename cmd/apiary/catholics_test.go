@@ -13,7 +13,7 @@ func TestCatholicDioceses(t *testing.T) {
 	response := executeRequest(req)
 	checkResponseCode(t, http.StatusOK, response.Code)
 
-	data := decodeResponse[[]catholic.CatholicDiocese](t, response)
+	data := decodePage[catholic.CatholicDiocese](t, response)
 	if len(data) == 0 {
 		t.Fatal("expected Catholic dioceses")
 	}
@@ -30,7 +30,7 @@ func TestCatholicDiocesesPerDecade(t *testing.T) {
 	response := executeRequest(req)
 	checkResponseCode(t, http.StatusOK, response.Code)
 
-	data := decodeResponse[[]catholic.CatholicDiocesesPerDecade](t, response)
+	data := decodePage[catholic.CatholicDiocesesPerDecade](t, response)
 	if len(data) == 0 {
 		t.Fatal("expected Catholic diocese decade statistics")
 	}
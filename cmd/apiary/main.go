@@ -2,24 +2,218 @@ package main
 
 import (
 	"context"
-	"log"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
+	"text/tabwriter"
 	"time"
 
 	apiary "github.com/chnm/apiary"
+	"github.com/chnm/apiary/db"
+	"github.com/chnm/apiary/internal/auth"
+	"github.com/chnm/apiary/internal/config"
 	"github.com/chnm/apiary/internal/lifecycle"
+	"github.com/chnm/apiary/internal/logging"
+	"github.com/chnm/apiary/internal/migrate"
+	"github.com/chnm/apiary/internal/tracing"
+	"gopkg.in/yaml.v3"
 )
 
-const gracefulShutdownTimeout = 8 * time.Second
+const usage = `Usage:
+  apiary [flags]                   serve the API
+  apiary config print [flags]      show the effective configuration
+  apiary key generate [flags]      create an API key and its configuration entry
+  apiary migrate up [flags]        apply the pending database migrations
+  apiary migrate down [N] [flags]  revert the last N migrations (default 1)
+  apiary migrate status [flags]    list the migrations and when they were applied
+
+Run apiary -h for the list of flags.
+`
 
 func main() {
-	if err := run(); err != nil {
-		log.Fatal("error running the server: ", err)
+	err := run(os.Args[1:], os.Stdout, os.Stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		slog.Error("apiary: " + err.Error())
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdout, stderr io.Writer) error {
+	if len(args) > 0 && args[0] == "config" {
+		return configCommand(args[1:], stdout, stderr)
+	}
+	if len(args) > 0 && args[0] == "key" {
+		return keyCommand(args[1:], stdout, stderr)
+	}
+	if len(args) > 0 && args[0] == "migrate" {
+		return migrateCommand(args[1:], stdout, stderr)
+	}
+
+	cfg, err := loadConfig("apiary", args, stderr)
+	if err != nil {
+		return err
+	}
+	if err := cfg.Validate(); err != nil {
+		return invalidConfig(err)
+	}
+	logger, err := logging.New(stderr, cfg.Logging.Format, cfg.Logging.SlogLevel())
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(logger)
+
+	ctx, stop := signal.NotifyContext(
+		context.Background(),
+		syscall.SIGINT,
+		syscall.SIGTERM,
+	)
+	defer stop()
+
+	shutdownTracing, err := tracing.Setup(ctx, tracing.Options{
+		Exporter:    cfg.Tracing.Exporter,
+		Endpoint:    cfg.Tracing.Endpoint,
+		SampleRatio: cfg.Tracing.SampleRatio,
+		Stdout:      stdout,
+	})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// Flush spans with a fresh context, since ctx is canceled by now.
+		flush, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(flush); err != nil {
+			slog.Warn("could not flush traces", "error", err)
+		}
+	}()
+
+	server, err := apiary.NewServer(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	if cfg.TLS.Enabled() {
+		// SIGHUP would otherwise stop the server.
+		hup := make(chan os.Signal, 1)
+		signal.Notify(hup, syscall.SIGHUP)
+		defer signal.Stop(hup)
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-hup:
+					server.ReloadCertificates(ctx)
+				}
+			}
+		}()
+	}
+	return lifecycle.Run(ctx, cfg.HTTP.ShutdownTimeout.Duration, server.Servers()...)
+}
+
+// configCommand runs the config subcommands.
+func configCommand(args []string, stdout, stderr io.Writer) error {
+	if len(args) == 0 || args[0] != "print" {
+		fmt.Fprint(stderr, usage)
+		return errors.New("unknown config command; use apiary config print")
+	}
+
+	cfg, err := loadConfig("apiary config print", args[1:], stderr)
+	if err != nil {
+		return err
+	}
+	out, err := cfg.Redacted().YAML()
+	if err != nil {
+		return fmt.Errorf("encode configuration: %w", err)
 	}
+	fmt.Fprintln(stdout, "# Effective configuration; secrets are redacted.")
+	if _, err := stdout.Write(out); err != nil {
+		return err
+	}
+	// Print first so that the invalid values can be seen in context.
+	if err := cfg.Validate(); err != nil {
+		return invalidConfig(err)
+	}
+	return nil
 }
 
-func run() error {
+// keyCommand runs the key subcommands.
+func keyCommand(args []string, stdout, stderr io.Writer) error {
+	if len(args) == 0 || args[0] != "generate" {
+		fmt.Fprint(stderr, usage)
+		return errors.New("unknown key command; use apiary key generate")
+	}
+
+	fs := flag.NewFlagSet("apiary key generate", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	name := fs.String("name", "", "name of the key's holder, shown in logs")
+	datasets := fs.String("datasets", "", "comma-separated datasets the key may read, or * for all")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if *name == "" || *datasets == "" {
+		return errors.New("-name and -datasets are required")
+	}
+
+	secret, hash, err := auth.NewSecret()
+	if err != nil {
+		return fmt.Errorf("generate key: %w", err)
+	}
+	var scope []string
+	for _, dataset := range strings.Split(*datasets, ",") {
+		if dataset = strings.TrimSpace(dataset); dataset != "" {
+			scope = append(scope, dataset)
+		}
+	}
+	entry, err := yaml.Marshal([]config.APIKey{{Name: *name, Hash: hash, Datasets: scope}})
+	if err != nil {
+		return fmt.Errorf("encode key: %w", err)
+	}
+	fmt.Fprintf(stdout, "# Give this key to %s. It is not stored and cannot be shown again.\n", *name)
+	fmt.Fprintf(stdout, "# %s\n", secret)
+	fmt.Fprintln(stdout, "# Add this entry to auth.keys in the configuration file:")
+	_, err = stdout.Write(entry)
+	return err
+}
+
+// migrateCommand runs the migrate subcommands.
+func migrateCommand(args []string, stdout, stderr io.Writer) error {
+	if len(args) == 0 || (args[0] != "up" && args[0] != "down" && args[0] != "status") {
+		fmt.Fprint(stderr, usage)
+		return errors.New("unknown migrate command; use apiary migrate up, down, or status")
+	}
+	command, args := args[0], args[1:]
+	steps := 1
+	if command == "down" && len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n <= 0 {
+			return fmt.Errorf("number of migrations to revert must be a positive number, not %q", args[0])
+		}
+		steps, args = n, args[1:]
+	}
+
+	cfg, err := loadConfig("apiary migrate "+command, args, stderr)
+	if err != nil {
+		return err
+	}
+	if err := cfg.Validate(); err != nil {
+		return invalidConfig(err)
+	}
+	logger, err := logging.New(stderr, cfg.Logging.Format, cfg.Logging.SlogLevel())
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(logger)
+
 	ctx, stop := signal.NotifyContext(
 		context.Background(),
 		syscall.SIGINT,
@@ -27,6 +221,67 @@ func run() error {
 	)
 	defer stop()
 
-	server := apiary.NewServer(ctx)
-	return lifecycle.Run(ctx, server, gracefulShutdownTimeout)
+	migrations, err := migrate.Embedded()
+	if err != nil {
+		return err
+	}
+	poolConfig, err := cfg.Database.PoolConfig()
+	if err != nil {
+		return errors.New("invalid database connection string")
+	}
+	dbTimeout, cancel := context.WithTimeout(ctx, cfg.Database.ConnectTimeout.Duration)
+	defer cancel()
+	pool, err := db.ConnectConfig(dbTimeout, poolConfig)
+	if err != nil {
+		return fmt.Errorf("connect to the database: %w", err)
+	}
+	defer pool.Close()
+	migrator := migrate.New(pool, migrations)
+
+	switch command {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			slog.Info("the database is up to date")
+		}
+	case "down":
+		if _, err := migrator.Down(ctx, steps); err != nil {
+			return err
+		}
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED")
+		for _, status := range statuses {
+			applied := "pending"
+			if !status.AppliedAt.IsZero() {
+				applied = status.AppliedAt.Local().Format(time.DateTime)
+			}
+			if status.Unknown {
+				applied += " (not in this build)"
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", status.Version, status.Name, applied)
+		}
+		return w.Flush()
+	}
+	return nil
+}
+
+func loadConfig(name string, args []string, stderr io.Writer) (config.Config, error) {
+	cfg, err := config.Load(name, args, os.LookupEnv, stderr)
+	if err != nil && !errors.Is(err, flag.ErrHelp) {
+		return cfg, fmt.Errorf("load configuration: %w", err)
+	}
+	return cfg, err
+}
+
+// invalidConfig lists each validation error on its own line.
+func invalidConfig(err error) error {
+	return fmt.Errorf("invalid configuration:\n  %s", strings.ReplaceAll(err.Error(), "\n", "\n  "))
 }
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/chnm/apiary/internal/migrate"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestMigrationsApplied(t *testing.T) {
+	if testDatabase == nil {
+		t.Skip("APIARY_DB may be migrated by a different build")
+	}
+	migrations, err := migrate.Embedded()
+	if err != nil {
+		t.Fatalf("Embedded() error = %v", err)
+	}
+	statuses, err := migrate.New(s.DB, migrations).Status(context.Background())
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	if len(statuses) != len(migrations) {
+		t.Fatalf("Status() lists %d migrations, want %d", len(statuses), len(migrations))
+	}
+	for _, status := range statuses {
+		if status.AppliedAt.IsZero() || status.Unknown {
+			t.Errorf("migration %04d_%s = %+v, want applied", status.Version, status.Name, status)
+		}
+	}
+}
+
+// TestMigrationsRoundTrip migrates an empty database in the test container
+// down and up again, and adopts a database whose tables already exist.
+func TestMigrationsRoundTrip(t *testing.T) {
+	if testDatabase == nil {
+		t.Skip("needs the test container to create a scratch database")
+	}
+	ctx := context.Background()
+	if _, err := s.DB.Exec(ctx, "CREATE DATABASE migrations_round_trip"); err != nil {
+		t.Fatalf("create scratch database: %v", err)
+	}
+	cfg := s.DB.Config().Copy()
+	cfg.ConnConfig.Database = "migrations_round_trip"
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		t.Fatalf("connect to scratch database: %v", err)
+	}
+	t.Cleanup(func() {
+		pool.Close()
+		if _, err := s.DB.Exec(context.Background(), "DROP DATABASE migrations_round_trip"); err != nil {
+			t.Errorf("drop scratch database: %v", err)
+		}
+	})
+
+	migrations, err := migrate.Embedded()
+	if err != nil {
+		t.Fatalf("Embedded() error = %v", err)
+	}
+	migrator := migrate.New(pool, migrations)
+	applied := func() int {
+		t.Helper()
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			t.Fatalf("Status() error = %v", err)
+		}
+		n := 0
+		for _, status := range statuses {
+			if !status.AppliedAt.IsZero() {
+				n++
+			}
+		}
+		return n
+	}
+
+	if done, err := migrator.Up(ctx); err != nil || len(done) != len(migrations) {
+		t.Fatalf("Up() applied %d, error = %v; want %d", len(done), err, len(migrations))
+	}
+	if done, err := migrator.Up(ctx); err != nil || len(done) != 0 {
+		t.Fatalf("second Up() applied %d, error = %v; want none", len(done), err)
+	}
+	if done, err := migrator.Down(ctx, 2); err != nil || len(done) != 2 || done[0].Version != len(migrations) {
+		t.Fatalf("Down(2) reverted %+v, error = %v; want the latest two, newest first", done, err)
+	}
+	if got := applied(); got != len(migrations)-2 {
+		t.Fatalf("%d migrations applied after Down(2), want %d", got, len(migrations)-2)
+	}
+	if done, err := migrator.Down(ctx, len(migrations)); err != nil || len(done) != len(migrations)-2 {
+		t.Fatalf("Down() reverted %d, error = %v; want the rest", len(done), err)
+	}
+	if got := applied(); got != 0 {
+		t.Fatalf("%d migrations applied after reverting all of them", got)
+	}
+
+	// A database created before the migrations has the tables but no record
+	// of them.
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+	if _, err := pool.Exec(ctx, "DROP TABLE apiary.schema_migrations"); err != nil {
+		t.Fatalf("drop the migration records: %v", err)
+	}
+	if done, err := migrator.Up(ctx); err != nil || len(done) != len(migrations) {
+		t.Fatalf("Up() over existing tables applied %d, error = %v; want %d", len(done), err, len(migrations))
+	}
+}
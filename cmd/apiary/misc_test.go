@@ -2,6 +2,7 @@ package main
 
 import (
 	"net/http"
+	"net/http/httptest"
 	"testing"
 )
 
@@ -17,3 +18,10 @@ func Test404(t *testing.T) {
 	response := executeRequest(req)
 	checkResponseCode(t, http.StatusNotFound, response.Code)
 }
+
+func TestReadiness(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/readyz", nil)
+	response := httptest.NewRecorder()
+	s.Handler().ServeHTTP(response, req)
+	checkResponseCode(t, http.StatusOK, response.Code)
+}
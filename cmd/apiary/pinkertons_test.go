@@ -10,6 +10,7 @@ import (
 	"testing"
 
 	"github.com/chnm/apiary/internal/datasets/pinkertons"
+	"github.com/chnm/apiary/internal/httpx/pagination"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -44,11 +45,7 @@ func fetchDetectiveActivities(t *testing.T, path string) []pinkertons.Activity {
 	response := executeRequest(req)
 	checkResponseCode(t, http.StatusOK, response.Code)
 
-	var activities []pinkertons.Activity
-	if err := json.Unmarshal(response.Body.Bytes(), &activities); err != nil {
-		t.Fatalf("Unmarshal response: %v", err)
-	}
-	return activities
+	return decodePage[pinkertons.Activity](t, response)
 }
 
 func TestDetectivesActivities(t *testing.T) {
@@ -58,11 +55,7 @@ func TestDetectivesActivities(t *testing.T) {
 	checkResponseCode(t, http.StatusOK, response.Code)
 
 	// Get the data
-	var data []pinkertons.Activity
-	err := json.Unmarshal(response.Body.Bytes(), &data)
-	if err != nil {
-		t.Error("Failed to unmarshal response:", err)
-	}
+	data := decodePage[pinkertons.Activity](t, response)
 
 	// Check that we got an array (even if empty)
 	if data == nil {
@@ -191,16 +184,12 @@ func TestDetectivesActivitiesOffsetPagination(t *testing.T) {
 
 func TestDetectivesActivitiesWithLocations(t *testing.T) {
 	// Check that we get activities with locations included
-	req, _ := http.NewRequest("GET", "/pinkertons/activities?include_locations=true&limit=10", nil)
+	req, _ := http.NewRequest("GET", "/pinkertons/activities?limit=10", nil)
 	response := executeRequest(req)
 	checkResponseCode(t, http.StatusOK, response.Code)
 
 	// Get the data
-	var data []pinkertons.Activity
-	err := json.Unmarshal(response.Body.Bytes(), &data)
-	if err != nil {
-		t.Error("Failed to unmarshal response:", err)
-	}
+	data := decodePage[pinkertons.Activity](t, response)
 
 	// Check that we got an array
 	if data == nil {
@@ -222,11 +211,7 @@ func TestDetectivesActivitiesFilterByOperative(t *testing.T) {
 	response := executeRequest(req)
 	checkResponseCode(t, http.StatusOK, response.Code)
 
-	var data []pinkertons.Activity
-	err := json.Unmarshal(response.Body.Bytes(), &data)
-	if err != nil {
-		t.Error("Failed to unmarshal response:", err)
-	}
+	data := decodePage[pinkertons.Activity](t, response)
 
 	// Check that all returned activities have the specified operative
 	for _, activity := range data {
@@ -242,11 +227,7 @@ func TestDetectivesActivitiesFilterByDateRange(t *testing.T) {
 	response := executeRequest(req)
 	checkResponseCode(t, http.StatusOK, response.Code)
 
-	var data []pinkertons.Activity
-	err := json.Unmarshal(response.Body.Bytes(), &data)
-	if err != nil {
-		t.Error("Failed to unmarshal response:", err)
-	}
+	data := decodePage[pinkertons.Activity](t, response)
 
 	// Just verify we can parse the response
 	if data == nil {
@@ -263,8 +244,9 @@ func TestDetectivesActivityByID(t *testing.T) {
 		t.Skip("Skipping test: no activities available")
 	}
 
-	var activities []pinkertons.Activity
-	err := json.Unmarshal(response.Body.Bytes(), &activities)
+	var page pagination.Page[pinkertons.Activity]
+	err := json.Unmarshal(response.Body.Bytes(), &page)
+	activities := page.Data
 	if err != nil || len(activities) == 0 {
 		t.Skip("Skipping test: no activities available")
 	}
@@ -312,11 +294,7 @@ func TestDetectivesLocations(t *testing.T) {
 	checkResponseCode(t, http.StatusOK, response.Code)
 
 	// Get the data
-	var data []pinkertons.Location
-	err := json.Unmarshal(response.Body.Bytes(), &data)
-	if err != nil {
-		t.Error("Failed to unmarshal response:", err)
-	}
+	data := decodePage[pinkertons.Location](t, response)
 
 	// Check that we got an array
 	if data == nil {
@@ -339,11 +317,7 @@ func TestDetectivesOperatives(t *testing.T) {
 	checkResponseCode(t, http.StatusOK, response.Code)
 
 	// Get the data
-	var data []string
-	err := json.Unmarshal(response.Body.Bytes(), &data)
-	if err != nil {
-		t.Error("Failed to unmarshal response:", err)
-	}
+	data := decodePage[string](t, response)
 
 	// Check that we got an array
 	if data == nil {
@@ -358,11 +332,7 @@ func TestDetectivesSubjects(t *testing.T) {
 	checkResponseCode(t, http.StatusOK, response.Code)
 
 	// Get the data
-	var data []string
-	err := json.Unmarshal(response.Body.Bytes(), &data)
-	if err != nil {
-		t.Error("Failed to unmarshal response:", err)
-	}
+	data := decodePage[string](t, response)
 
 	// Check that we got an array
 	if data == nil {
@@ -372,18 +342,21 @@ func TestDetectivesSubjects(t *testing.T) {
 
 func TestDetectivesCombinedFilters(t *testing.T) {
 	// Test combining multiple filters
-	req, _ := http.NewRequest("GET", "/pinkertons/activities?operative=TestOp&start_date=1900-01-01&end_date=1900-12-31&include_locations=true", nil)
+	req, _ := http.NewRequest("GET", "/pinkertons/activities?operative=TestOp&start_date=1900-01-01&end_date=1900-12-31", nil)
 	response := executeRequest(req)
 	checkResponseCode(t, http.StatusOK, response.Code)
 
-	var data []pinkertons.Activity
-	err := json.Unmarshal(response.Body.Bytes(), &data)
-	if err != nil {
-		t.Error("Failed to unmarshal response:", err)
-	}
+	data := decodePage[pinkertons.Activity](t, response)
 
 	// Verify response structure
 	if data == nil {
 		t.Error("Expected array of activities, got nil")
 	}
 }
+
+func TestDetectivesRequireKey(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/pinkertons/locations", nil)
+	response := httptest.NewRecorder()
+	s.Router.ServeHTTP(response, req)
+	checkResponseCode(t, http.StatusUnauthorized, response.Code)
+}
@@ -78,7 +78,7 @@ func fetchCountiesInState(t *testing.T, state string) []popplaces.PlaceCounty {
 	)
 	response := executeRequest(request)
 	checkResponseCode(t, http.StatusOK, response.Code)
-	data := decodeResponse[[]popplaces.PlaceCounty](t, response)
+	data := decodePage[popplaces.PlaceCounty](t, response)
 	if len(data) == 0 {
 		t.Fatalf("expected populated-place counties for state %q", state)
 	}
@@ -95,7 +95,7 @@ func fetchPlacesInCounty(t *testing.T, county string) []popplaces.Place {
 	)
 	response := executeRequest(request)
 	checkResponseCode(t, http.StatusOK, response.Code)
-	data := decodeResponse[[]popplaces.Place](t, response)
+	data := decodePage[popplaces.Place](t, response)
 	if len(data) == 0 {
 		t.Fatalf("expected populated places for county %q", county)
 	}
@@ -13,7 +13,7 @@ func TestPresbyterians(t *testing.T) {
 	response := executeRequest(req)
 	checkResponseCode(t, http.StatusOK, response.Code)
 
-	data := decodeResponse[[]presbyterians.PresbyteriansByYear](t, response)
+	data := decodePage[presbyterians.PresbyteriansByYear](t, response)
 	if len(data) == 0 {
 		t.Fatal("expected Presbyterian statistics")
 	}
@@ -14,7 +14,7 @@ func TestRelCensusDenominations(t *testing.T) {
 	response := executeRequest(req)
 	checkResponseCode(t, http.StatusOK, response.Code)
 
-	data := decodeResponse[[]relcensus.Denomination](t, response)
+	data := decodePage[relcensus.Denomination](t, response)
 	if len(data) == 0 {
 		t.Fatal("expected Religious Census denominations")
 	}
@@ -26,23 +26,21 @@ func TestRelCensusFamilies(t *testing.T) {
 	response := executeRequest(req)
 	checkResponseCode(t, http.StatusOK, response.Code)
 
-	data := decodeResponse[struct {
-		FamilyRelec []relcensus.DenominationFamily `json:"family_relec"`
-	}](t, response)
-	if len(data.FamilyRelec) == 0 {
+	data := decodePage[relcensus.DenominationFamily](t, response)
+	if len(data) == 0 {
 		t.Fatal("expected Religious Census denomination families")
 	}
 
-	for index, family := range data.FamilyRelec {
+	for index, family := range data {
 		if family.Name == "" {
 			t.Errorf("family %d has an empty name", index)
 		}
-		if index > 0 && family.Name < data.FamilyRelec[index-1].Name {
+		if index > 0 && family.Name < data[index-1].Name {
 			t.Errorf(
 				"families are not sorted at rows %d and %d: %q, %q",
 				index-1,
 				index,
-				data.FamilyRelec[index-1].Name,
+				data[index-1].Name,
 				family.Name,
 			)
 		}
@@ -55,7 +53,7 @@ func fetchRelCensusDenominations(t *testing.T) []relcensus.Denomination {
 	request, _ := http.NewRequest(http.MethodGet, "/relcensus/denominations", nil)
 	response := executeRequest(request)
 	checkResponseCode(t, http.StatusOK, response.Code)
-	data := decodeResponse[[]relcensus.Denomination](t, response)
+	data := decodePage[relcensus.Denomination](t, response)
 	if len(data) == 0 {
 		t.Fatal("expected Religious Census denominations")
 	}
@@ -68,13 +66,11 @@ func fetchRelCensusFamilies(t *testing.T) []relcensus.DenominationFamily {
 	request, _ := http.NewRequest(http.MethodGet, "/relcensus/denomination-families", nil)
 	response := executeRequest(request)
 	checkResponseCode(t, http.StatusOK, response.Code)
-	data := decodeResponse[struct {
-		FamilyRelec []relcensus.DenominationFamily `json:"family_relec"`
-	}](t, response)
-	if len(data.FamilyRelec) == 0 {
+	data := decodePage[relcensus.DenominationFamily](t, response)
+	if len(data) == 0 {
 		t.Fatal("expected Religious Census denomination families")
 	}
-	return data.FamilyRelec
+	return data
 }
 
 func TestRelCensusCityDenominations(t *testing.T) {
@@ -88,7 +84,7 @@ func TestRelCensusCityDenominations(t *testing.T) {
 	response := executeRequest(req)
 	checkResponseCode(t, http.StatusOK, response.Code)
 
-	data := decodeResponse[[]relcensus.CityMembership](t, response)
+	data := decodePage[relcensus.CityMembership](t, response)
 	for index, row := range data {
 		if row.Year != 1926 || row.Group != denomination {
 			t.Errorf(
@@ -113,7 +109,7 @@ func TestRelCensusCityFamilies(t *testing.T) {
 	response := executeRequest(req)
 	checkResponseCode(t, http.StatusOK, response.Code)
 
-	data := decodeResponse[[]relcensus.CityMembership](t, response)
+	data := decodePage[relcensus.CityMembership](t, response)
 	for index, row := range data {
 		if row.Year != 1926 || row.Group != family {
 			t.Errorf(
@@ -133,7 +129,7 @@ func TestRelCensusCityAggregates(t *testing.T) {
 	response := executeRequest(req)
 	checkResponseCode(t, http.StatusOK, response.Code)
 
-	data := decodeResponse[[]relcensus.CityMembership](t, response)
+	data := decodePage[relcensus.CityMembership](t, response)
 	if len(data) == 0 {
 		t.Fatal("expected aggregated Religious Census city membership")
 	}
@@ -154,7 +150,7 @@ func TestRelCensusLocations(t *testing.T) {
 	response := executeRequest(req)
 	checkResponseCode(t, http.StatusOK, response.Code)
 
-	data := decodeResponse[[]relcensus.LocationInfo](t, response)
+	data := decodePage[relcensus.LocationInfo](t, response)
 	if len(data) == 0 {
 		t.Fatal("expected Religious Census locations")
 	}
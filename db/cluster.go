@@ -0,0 +1,250 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Querier runs read-only queries. Dataset handlers take one rather than a
+// pool, so that they can be given a Cluster; *pgxpool.Pool implements it too.
+type Querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+const (
+	// replicaCheckInterval is how often healthy replicas are pinged.
+	replicaCheckInterval = 10 * time.Second
+	// replicaPingTimeout bounds each ping, so that a replica that hangs is
+	// treated as down.
+	replicaPingTimeout = 2 * time.Second
+	// maxReplicaRetryInterval caps the wait between attempts to reach a
+	// replica that is down.
+	maxReplicaRetryInterval = time.Minute
+)
+
+// Cluster is a primary database and its read replicas. Queries go to the
+// healthy replicas in turn, or to the primary when none is healthy. A replica
+// that fails to answer a query, or a periodic ping, is taken out of turn
+// until it answers a ping again, and the query is retried on the primary.
+//
+// Replicas can lag behind the primary, so only queries that can tolerate
+// slightly stale data should go through a Cluster.
+type Cluster struct {
+	primary  *pgxpool.Pool
+	replicas []*replica
+	next     atomic.Uint64 // Round-robin position
+
+	ctx    context.Context // Canceled by Close to stop health checks
+	cancel context.CancelFunc
+	mu     sync.Mutex // Guards closed and adding to wg
+	closed bool
+	wg     sync.WaitGroup
+}
+
+type replica struct {
+	pool    *pgxpool.Pool
+	address string // For logs
+	healthy atomic.Bool
+}
+
+// NewCluster returns a Cluster that reads from replicas, with failover to
+// primary. The replica configurations get the same query tracing and
+// statement timeouts as pools from ConnectConfig. Unlike the primary,
+// replicas need not be reachable yet: each joins the turn once it answers a
+// ping, retrying with backoff until then.
+func NewCluster(primary *pgxpool.Pool, replicas []*pgxpool.Config) (*Cluster, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &Cluster{primary: primary, ctx: ctx, cancel: cancel}
+	for _, cfg := range replicas {
+		configure(cfg)
+		pool, err := pgxpool.NewWithConfig(ctx, cfg)
+		if err != nil {
+			c.Close()
+			return nil, err
+		}
+		c.replicas = append(c.replicas, &replica{
+			pool:    pool,
+			address: net.JoinHostPort(cfg.ConnConfig.Host, strconv.Itoa(int(cfg.ConnConfig.Port))),
+		})
+	}
+	for _, r := range c.replicas {
+		c.start(func() { c.recover(r) })
+	}
+	if len(c.replicas) > 0 {
+		c.start(c.check)
+	}
+	return c, nil
+}
+
+// Primary returns the primary's pool, for queries that must see the latest
+// data.
+func (c *Cluster) Primary() *pgxpool.Pool {
+	return c.primary
+}
+
+// Query runs a query on a healthy replica, or on the primary.
+func (c *Cluster) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	if r := c.replica(); r != nil {
+		rows, err := r.pool.Query(ctx, sql, args...)
+		if err == nil || !unavailable(ctx, err) {
+			return rows, err
+		}
+		c.markDown(r, err)
+	}
+	return c.primary.Query(ctx, sql, args...)
+}
+
+// QueryRow runs a query that returns at most one row on a healthy replica, or
+// on the primary. Errors are deferred until the row is scanned.
+func (c *Cluster) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	rows, err := c.Query(ctx, sql, args...)
+	return &row{rows: rows, err: err}
+}
+
+// Close stops checking the replicas and closes their pools. It does not close
+// the primary, which belongs to the caller.
+func (c *Cluster) Close() {
+	c.mu.Lock()
+	c.closed = true
+	c.mu.Unlock()
+	c.cancel()
+	c.wg.Wait()
+	for _, r := range c.replicas {
+		r.pool.Close()
+	}
+}
+
+// start runs f in a goroutine that Close waits for, unless the cluster is
+// closed.
+func (c *Cluster) start(f func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return
+	}
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		f()
+	}()
+}
+
+// replica returns the next healthy replica, or nil if none is healthy.
+func (c *Cluster) replica() *replica {
+	n := uint64(len(c.replicas))
+	if n == 0 {
+		return nil
+	}
+	start := c.next.Add(1)
+	for i := range n {
+		if r := c.replicas[(start+i)%n]; r.healthy.Load() {
+			return r
+		}
+	}
+	return nil
+}
+
+// markDown takes r out of turn and starts trying to reach it again.
+func (c *Cluster) markDown(r *replica, err error) {
+	if !r.healthy.CompareAndSwap(true, false) {
+		return
+	}
+	slog.Warn("database replica is down", "replica", r.address, "error", err)
+	c.start(func() { c.recover(r) })
+}
+
+// recover pings r with backoff, as ConnectConfig connects to the primary but
+// without giving up, and puts it back in turn once it answers.
+func (c *Cluster) recover(r *replica) {
+	policy := backoff.NewExponentialBackOff()
+	policy.MaxInterval = maxReplicaRetryInterval
+	policy.MaxElapsedTime = 0
+	if err := retry(c.ctx, policy, func() error { return r.ping(c.ctx) }); err != nil {
+		return // Closed
+	}
+	r.healthy.Store(true)
+	slog.Info("database replica is up", "replica", r.address)
+}
+
+// check pings the healthy replicas periodically until the cluster is closed,
+// so that one that fails is taken out of turn before queries reach it.
+func (c *Cluster) check() {
+	ticker := time.NewTicker(replicaCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-ticker.C:
+			for _, r := range c.replicas {
+				if !r.healthy.Load() {
+					continue
+				}
+				if err := r.ping(c.ctx); err != nil && c.ctx.Err() == nil {
+					c.markDown(r, err)
+				}
+			}
+		}
+	}
+}
+
+func (r *replica) ping(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, replicaPingTimeout)
+	defer cancel()
+	return r.pool.Ping(ctx)
+}
+
+// unavailable reports whether err means that a replica could not run a
+// query, rather than that the query failed, so that it is worth retrying on
+// the primary.
+func unavailable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		// Connection exceptions, and a server shutting down, starting up,
+		// or in recovery.
+		return strings.HasPrefix(pgErr.Code, "08") || strings.HasPrefix(pgErr.Code, "57P")
+	}
+	var connectErr *pgconn.ConnectError
+	var netErr net.Error
+	return errors.As(err, &connectErr) || errors.As(err, &netErr) || pgconn.SafeToRetry(err)
+}
+
+// row is a pgx.Row over the rows of a query, like the one pgx returns.
+type row struct {
+	rows pgx.Rows
+	err  error
+}
+
+func (r *row) Scan(dest ...any) error {
+	if r.err != nil {
+		return r.err
+	}
+	defer r.rows.Close()
+	if !r.rows.Next() {
+		if err := r.rows.Err(); err != nil {
+			return err
+		}
+		return pgx.ErrNoRows
+	}
+	if err := r.rows.Scan(dest...); err != nil {
+		return err
+	}
+	r.rows.Close()
+	return r.rows.Err()
+}
@@ -11,39 +11,60 @@ import (
 
 // Connect returns a pool of connections to the database using the pgx native interface.
 func Connect(ctx context.Context, connstr string) (*pgxpool.Pool, error) {
-
-	var pool *pgxpool.Pool
-
 	cfg, err := pgxpool.ParseConfig(connstr)
 	if err != nil {
 		return nil, err
 	}
+	return ConnectConfig(ctx, cfg)
+}
 
-	connectWithRetry := func() error {
-		select {
-		case <-ctx.Done():
-			return backoff.Permanent(errors.New("cancelled attempt to connect to database"))
-		default:
-			conn, err := pgxpool.NewWithConfig(ctx, cfg)
-			if err != nil {
-				return fmt.Errorf("error creating connection pool: %w", err)
-			}
-
-			err = conn.Ping(ctx)
-			if err != nil {
-				conn.Close()
-				return fmt.Errorf("error pinging database: %w", err)
-			}
-			pool = conn
+// ConnectConfig is like Connect but takes a parsed pool configuration, so
+// that callers can size the pool. Queries are traced alongside any tracer
+// the configuration already has, and run with the statement timeout of
+// their context.
+func ConnectConfig(ctx context.Context, cfg *pgxpool.Config) (*pgxpool.Pool, error) {
+	configure(cfg)
+	var pool *pgxpool.Pool
+
+	connect := func() error {
+		conn, err := pgxpool.NewWithConfig(ctx, cfg)
+		if err != nil {
+			return fmt.Errorf("error creating connection pool: %w", err)
 		}
+
+		err = conn.Ping(ctx)
+		if err != nil {
+			conn.Close()
+			return fmt.Errorf("error pinging database: %w", err)
+		}
+		pool = conn
 		return nil
 	}
 
 	policy := backoff.WithMaxRetries(backoff.NewExponentialBackOff(), 5)
-	err = backoff.Retry(connectWithRetry, policy)
+	err := retry(ctx, policy, connect)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
 	return pool, nil
 }
+
+// configure adds the query tracer and statement timeouts to cfg.
+func configure(cfg *pgxpool.Config) {
+	cfg.ConnConfig.Tracer = withQueryTracer(cfg.ConnConfig.Tracer)
+	withStatementTimeouts(cfg)
+}
+
+// retry calls op with backoff until it succeeds, policy gives up, or ctx is
+// canceled.
+func retry(ctx context.Context, policy backoff.BackOff, op func() error) error {
+	return backoff.Retry(func() error {
+		select {
+		case <-ctx.Done():
+			return backoff.Permanent(errors.New("cancelled attempt to connect to database"))
+		default:
+			return op()
+		}
+	}, backoff.WithContext(policy, ctx))
+}
@@ -0,0 +1,75 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type statementTimeoutKey struct{}
+
+// WithStatementTimeout returns a context whose queries run with PostgreSQL's
+// statement_timeout set to d, so that the server stops a query that outlives
+// its request even if the cancellation from the context is lost. Queries
+// without one run with the session's default.
+func WithStatementTimeout(ctx context.Context, d time.Duration) context.Context {
+	return context.WithValue(ctx, statementTimeoutKey{}, d)
+}
+
+// StatementTimeoutFromContext returns the statement timeout set by
+// WithStatementTimeout, or zero.
+func StatementTimeoutFromContext(ctx context.Context) time.Duration {
+	d, _ := ctx.Value(statementTimeoutKey{}).(time.Duration)
+	return d
+}
+
+// currentStatementTimeout keys the statement timeout a connection was last
+// set to in its custom data. Zero means the session default.
+const currentStatementTimeout = "apiary.statement_timeout"
+
+// prepareStatementTimeout sets the statement_timeout of a connection being
+// acquired to the one its context asks for. Connections remember their
+// setting, so the SET is only sent when it changes.
+func prepareStatementTimeout(ctx context.Context, conn *pgx.Conn) (bool, error) {
+	want := StatementTimeoutFromContext(ctx)
+	data := conn.PgConn().CustomData()
+	if current, _ := data[currentStatementTimeout].(time.Duration); current == want {
+		return true, nil
+	}
+
+	sql := "RESET statement_timeout"
+	if want > 0 {
+		sql = fmt.Sprintf("SET statement_timeout = %d", max(want.Milliseconds(), 1))
+	}
+	if _, err := conn.Exec(ctx, sql); err != nil {
+		// The setting is unknown, so close the connection rather than
+		// reuse it.
+		return false, fmt.Errorf("set statement timeout: %w", err)
+	}
+	data[currentStatementTimeout] = want
+	return true, nil
+}
+
+// withStatementTimeouts runs prepareStatementTimeout before the pool's own
+// hook, if it has one.
+func withStatementTimeouts(cfg *pgxpool.Config) {
+	prepare := cfg.PrepareConn
+	if prepare == nil && cfg.BeforeAcquire != nil {
+		beforeAcquire := cfg.BeforeAcquire
+		prepare = func(ctx context.Context, conn *pgx.Conn) (bool, error) {
+			return beforeAcquire(ctx, conn), nil
+		}
+	}
+	cfg.PrepareConn = func(ctx context.Context, conn *pgx.Conn) (bool, error) {
+		if ok, err := prepareStatementTimeout(ctx, conn); !ok || err != nil {
+			return ok, err
+		}
+		if prepare == nil {
+			return true, nil
+		}
+		return prepare(ctx, conn)
+	}
+}
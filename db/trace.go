@@ -0,0 +1,84 @@
+package db
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/multitracer"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.32.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentation names the tracer of query spans.
+const instrumentation = "github.com/chnm/apiary/db"
+
+// QueryTracer starts an OpenTelemetry span for each query made while a
+// request is being traced, recording the statement, the server that ran it,
+// and the number of rows.
+// Queries made outside a traced request, such as health checks, get no span.
+type QueryTracer struct{}
+
+type querySpanKey struct{}
+
+// TraceQueryStart implements pgx.QueryTracer.
+func (QueryTracer) TraceQueryStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	if !trace.SpanFromContext(ctx).IsRecording() {
+		return ctx
+	}
+	sql := strings.Join(strings.Fields(data.SQL), " ")
+	operation := queryOperation(sql)
+	attributes := []attribute.KeyValue{
+		semconv.DBSystemNamePostgreSQL,
+		semconv.DBOperationName(operation),
+		semconv.DBQueryText(sql),
+	}
+	if conn != nil {
+		// Tells queries on a replica from those on the primary.
+		attributes = append(attributes,
+			semconv.ServerAddress(conn.Config().Host),
+			semconv.ServerPort(int(conn.Config().Port)),
+		)
+	}
+	ctx, span := otel.Tracer(instrumentation).Start(ctx, operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attributes...),
+	)
+	return context.WithValue(ctx, querySpanKey{}, span)
+}
+
+// TraceQueryEnd implements pgx.QueryTracer.
+func (QueryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	span, ok := ctx.Value(querySpanKey{}).(trace.Span)
+	if !ok {
+		return
+	}
+	if data.Err != nil {
+		span.RecordError(data.Err)
+		span.SetStatus(codes.Error, data.Err.Error())
+	} else {
+		span.SetAttributes(semconv.DBResponseReturnedRows(int(data.CommandTag.RowsAffected())))
+	}
+	span.End()
+}
+
+// queryOperation returns the SQL keyword that starts sql, such as SELECT or
+// WITH, to name the query's span.
+func queryOperation(sql string) string {
+	keyword, _, _ := strings.Cut(sql, " ")
+	if keyword == "" {
+		return "query"
+	}
+	return strings.ToUpper(keyword)
+}
+
+// withQueryTracer adds a QueryTracer to tracer, which may be nil.
+func withQueryTracer(tracer pgx.QueryTracer) pgx.QueryTracer {
+	if tracer == nil {
+		return QueryTracer{}
+	}
+	return multitracer.New(tracer, QueryTracer{})
+}
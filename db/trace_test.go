@@ -0,0 +1,61 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestQueryTracer(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	ctx, request := provider.Tracer("test").Start(context.Background(), "GET /bom/bills")
+	tracer := QueryTracer{}
+
+	queryCtx := tracer.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{SQL: "\n\tselect id\n\tFROM bom.bills\n"})
+	tracer.TraceQueryEnd(queryCtx, nil, pgx.TraceQueryEndData{CommandTag: pgconn.NewCommandTag("SELECT 3")})
+	failedCtx := tracer.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{SQL: "WITH x AS (SELECT 1) SELECT * FROM x"})
+	tracer.TraceQueryEnd(failedCtx, nil, pgx.TraceQueryEndData{Err: errors.New("boom")})
+	// Queries outside a traced request, such as readiness checks, are skipped.
+	untraced := tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{SQL: "SELECT 1"})
+	tracer.TraceQueryEnd(untraced, nil, pgx.TraceQueryEndData{})
+	request.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("recorded %d spans, want 2 queries and the request", len(spans))
+	}
+	query, failed := spans[0], spans[1]
+	if query.Name() != "SELECT" || query.SpanKind() != trace.SpanKindClient || query.Parent().SpanID() != request.SpanContext().SpanID() {
+		t.Errorf("query span = %q (%s) beneath %s", query.Name(), query.SpanKind(), query.Parent().SpanID())
+	}
+	attrs := make(map[string]any)
+	for _, kv := range query.Attributes() {
+		attrs[string(kv.Key)] = kv.Value.AsInterface()
+	}
+	want := map[string]any{
+		"db.system.name":            "postgresql",
+		"db.operation.name":         "SELECT",
+		"db.query.text":             "select id FROM bom.bills",
+		"db.response.returned_rows": int64(3),
+	}
+	for key, value := range want {
+		if attrs[key] != value {
+			t.Errorf("%s = %v, want %v", key, attrs[key], value)
+		}
+	}
+	if failed.Name() != "WITH" || failed.Status().Code != codes.Error {
+		t.Errorf("failed query span = %q with status %v", failed.Name(), failed.Status())
+	}
+}
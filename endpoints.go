@@ -3,19 +3,11 @@ package apiary
 import (
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"strings"
 
-	"github.com/chnm/apiary/internal/datasets/ahcb"
-	"github.com/chnm/apiary/internal/datasets/apb"
-	"github.com/chnm/apiary/internal/datasets/bom"
-	"github.com/chnm/apiary/internal/datasets/catholic"
-	"github.com/chnm/apiary/internal/datasets/naturalearth"
-	"github.com/chnm/apiary/internal/datasets/pinkertons"
-	"github.com/chnm/apiary/internal/datasets/popplaces"
-	"github.com/chnm/apiary/internal/datasets/presbyterians"
-	"github.com/chnm/apiary/internal/datasets/relcensus"
+	"github.com/chnm/apiary/internal/auth"
 	"github.com/chnm/apiary/internal/httpx"
 )
 
@@ -64,26 +56,36 @@ func (s *Server) EndpointsHandler() http.HandlerFunc {
 		}
 		baseurl := proto + r.Host
 
-		var endpoints []Endpoint
-		endpoints = appendDatasetEndpoints(endpoints, ahcb.Endpoints(baseurl))
-		endpoints = appendDatasetEndpoints(endpoints, apb.Endpoints(baseurl))
-		endpoints = appendDatasetEndpoints(endpoints, bom.Endpoints(baseurl))
-		endpoints = appendDatasetEndpoints(endpoints, catholic.Endpoints(baseurl))
-		endpoints = appendDatasetEndpoints(endpoints, naturalearth.Endpoints(baseurl))
-		endpoints = appendDatasetEndpoints(endpoints, popplaces.Endpoints(baseurl))
-		endpoints = appendDatasetEndpoints(endpoints, presbyterians.Endpoints(baseurl))
-		endpoints = appendDatasetEndpoints(endpoints, relcensus.Endpoints(baseurl))
-		endpoints = appendDatasetEndpoints(endpoints, pinkertons.Endpoints(baseurl))
+		key, _ := auth.KeyFromContext(r.Context())
+		endpoints := appendDatasetEndpoints([]Endpoint{}, s.visibleEndpoints(key, s.Datasets.Endpoints(baseurl)))
 
 		response, err := json.MarshalIndent(endpoints, "", "  ")
 		if err != nil {
-			internalServerError(w, "error marshaling endpoint index", err)
+			internalServerError(w, r, "error marshaling endpoint index", err)
 			return
 		}
 		resp := strings.Replace(string(response), "\\u0026", "&", -1)
+		if len(s.restrictedPaths) > 0 {
+			w.Header().Add("Vary", "Authorization")
+		}
 		w.Header().Set("Content-Type", "application/json")
 		if _, err := fmt.Fprint(w, resp); err != nil {
-			log.Printf("error writing endpoint index: %v", err)
+			slog.WarnContext(r.Context(), "error writing endpoint index", "error", err)
+		}
+	}
+}
+
+// visibleEndpoints returns the endpoints that key may use; the zero Key is an
+// anonymous caller. Endpoints on restricted routes are left out unless the
+// key's scope includes their dataset, so that datasets that are not yet
+// public are not advertised.
+func (s *Server) visibleEndpoints(key auth.Key, endpoints []httpx.Endpoint) []httpx.Endpoint {
+	visible := make([]httpx.Endpoint, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		if dataset, restricted := s.restrictedPaths[endpoint.Path]; restricted && !key.Allows(dataset) {
+			continue
 		}
+		visible = append(visible, endpoint)
 	}
+	return visible
 }
@@ -0,0 +1,74 @@
+package apiary
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/chnm/apiary/internal/httpx"
+)
+
+// readinessTimeout bounds the database ping made by each readiness check, so
+// that probes fail rather than hang when the database is unreachable.
+const readinessTimeout = 2 * time.Second
+
+// healthStatus is the body of successful health and readiness checks.
+type healthStatus struct {
+	Status string `json:"status"`
+}
+
+// HealthHandler reports that the process is alive and able to serve HTTP. It
+// does not depend on the database, so that an outage there does not cause
+// the orchestrator to restart every instance.
+func (s *Server) HealthHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-store")
+		httpx.WriteJSON(w, r, healthStatus{Status: "ok"})
+	}
+}
+
+// ReadinessHandler reports whether the server should receive traffic: it is
+// not draining for shutdown and it can reach the database.
+func (s *Server) ReadinessHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-store")
+		if s.draining.Load() {
+			httpx.WriteProblem(w, r, httpx.Problem{
+				Status: http.StatusServiceUnavailable,
+				Code:   httpx.CodeUnavailable,
+				Detail: "the server is shutting down",
+			})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+		defer cancel()
+		if err := s.DB.Ping(ctx); err != nil {
+			slog.WarnContext(r.Context(), "readiness check failed", "error", err)
+			httpx.WriteProblem(w, r, httpx.Problem{
+				Status: http.StatusServiceUnavailable,
+				Code:   httpx.CodeUnavailable,
+				Detail: "the database is unreachable",
+			})
+			return
+		}
+		httpx.WriteJSON(w, r, healthStatus{Status: "ready"})
+	}
+}
+
+// Drain starts failing readiness checks and then waits for the drain period,
+// giving load balancers time to stop routing new requests here before
+// Shutdown closes the listener. Keep-alives are disabled so that clients open
+// their next connection elsewhere.
+func (s *Server) Drain() {
+	s.draining.Store(true)
+	if s.Server != nil {
+		s.Server.SetKeepAlivesEnabled(false)
+	}
+	if s.Config.HTTP.DrainPeriod.Duration <= 0 {
+		return
+	}
+	slog.Info("draining before shutting down", "drain_period", s.Config.HTTP.DrainPeriod.String())
+	time.Sleep(s.Config.HTTP.DrainPeriod.Duration)
+}
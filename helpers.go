@@ -2,7 +2,6 @@ package apiary
 
 import (
 	"net/http"
-	"os"
 	"time"
 
 	"github.com/chnm/apiary/internal/httpx"
@@ -11,25 +10,15 @@ import (
 
 // internalServerError logs an internal error and returns a generic response to
 // the client so database and encoding details are never exposed.
-func internalServerError(w http.ResponseWriter, operation string, err error) {
-	httpx.InternalServerError(w, operation, err)
+func internalServerError(w http.ResponseWriter, r *http.Request, operation string, err error) {
+	httpx.InternalServerError(w, r, operation, err)
 }
 
 // writeJSONResponse marshals a response before writing headers, allowing
 // encoding failures to return a generic 500 response. Write failures can only
 // be logged because the response may already be partially sent.
-func writeJSONResponse(w http.ResponseWriter, value any) {
-	httpx.WriteJSON(w, value)
-}
-
-// getEnv either returns the value of an environment variable or, if that
-// environment variables does not exist, returns the fallback value provided.
-func getEnv(key, fallback string) string {
-	value, exists := os.LookupEnv(key)
-	if !exists {
-		value = fallback
-	}
-	return value
+func writeJSONResponse(w http.ResponseWriter, r *http.Request, value any) {
+	httpx.WriteJSON(w, r, value)
 }
 
 // dateInRange takes in a string which should be parsed to a date. That date is
@@ -6,11 +6,11 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
-	"os"
 	"reflect"
-	"strings"
 	"testing"
 	"time"
+
+	"github.com/chnm/apiary/internal/httpx"
 )
 
 type failingJSONMarshaler struct{}
@@ -22,8 +22,9 @@ func (failingJSONMarshaler) MarshalJSON() ([]byte, error) {
 func TestWriteJSONResponse(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		response := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodGet, "/", nil)
 
-		writeJSONResponse(response, struct {
+		writeJSONResponse(response, request, struct {
 			Status string `json:"status"`
 		}{Status: "ok"})
 
@@ -40,18 +41,19 @@ func TestWriteJSONResponse(t *testing.T) {
 
 	t.Run("encoding failure", func(t *testing.T) {
 		response := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodGet, "/", nil)
 
-		writeJSONResponse(response, failingJSONMarshaler{})
+		writeJSONResponse(response, request, failingJSONMarshaler{})
 
 		if response.Code != http.StatusInternalServerError {
 			t.Fatalf("status = %d, want %d", response.Code, http.StatusInternalServerError)
 		}
-		if body := strings.TrimSpace(response.Body.String()); body != http.StatusText(http.StatusInternalServerError) {
-			t.Fatalf(
-				"body = %q, want %q",
-				body,
-				http.StatusText(http.StatusInternalServerError),
-			)
+		var problem httpx.Problem
+		if err := json.Unmarshal(response.Body.Bytes(), &problem); err != nil {
+			t.Fatalf("decode problem: %v", err)
+		}
+		if problem.Code != httpx.CodeInternal {
+			t.Fatalf("code = %q, want %q", problem.Code, httpx.CodeInternal)
 		}
 	})
 }
@@ -230,30 +232,3 @@ func TestIntsToString(t *testing.T) {
 		t.Errorf(`intsToString([7]) = %q, want "7"`, got)
 	}
 }
-
-func TestGetEnv(t *testing.T) {
-	const key = "APIARY_HELPERS_TEST_ENV"
-
-	original, existed := os.LookupEnv(key)
-	if err := os.Unsetenv(key); err != nil {
-		t.Fatalf("unset test environment variable: %v", err)
-	}
-	t.Cleanup(func() {
-		if existed {
-			_ = os.Setenv(key, original)
-			return
-		}
-		_ = os.Unsetenv(key)
-	})
-
-	if got := getEnv(key, "fallback"); got != "fallback" {
-		t.Fatalf("getEnv missing = %q, want fallback", got)
-	}
-
-	if err := os.Setenv(key, "configured"); err != nil {
-		t.Fatalf("set test environment variable: %v", err)
-	}
-	if got := getEnv(key, "fallback"); got != "configured" {
-		t.Fatalf("getEnv configured = %q, want configured", got)
-	}
-}
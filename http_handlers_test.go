@@ -5,9 +5,17 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/chnm/apiary/internal/auth"
+	"github.com/chnm/apiary/internal/config"
+	"github.com/chnm/apiary/internal/cors"
+	"github.com/chnm/apiary/internal/httpx"
+	"github.com/chnm/apiary/internal/openapi"
+	"github.com/chnm/apiary/internal/testsupport"
 	"github.com/gorilla/mux"
 )
 
@@ -39,7 +47,7 @@ func TestEndpointsHandler(t *testing.T) {
 			}
 			response := httptest.NewRecorder()
 
-			(&Server{}).EndpointsHandler().ServeHTTP(response, request)
+			(&Server{Datasets: newDatasetRegistry(nil)}).EndpointsHandler().ServeHTTP(response, request)
 
 			if response.Code != http.StatusOK {
 				t.Fatalf("status = %d, want %d", response.Code, http.StatusOK)
@@ -67,24 +75,84 @@ func TestEndpointsHandler(t *testing.T) {
 	}
 }
 
-func TestCORSMiddleware(t *testing.T) {
-	handler := corsMiddleware(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
-		w.WriteHeader(http.StatusNoContent)
-	}))
-	response := httptest.NewRecorder()
+func TestCORS(t *testing.T) {
+	policy, err := cors.New(cors.Options{
+		AllowedOrigins: []string{"https://*.rrchnm.org"},
+		AllowedHeaders: []string{"Authorization"},
+		ExposedHeaders: []string{"X-Request-ID"},
+		MaxAge:         time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("cors.New() error = %v", err)
+	}
+	server := &Server{
+		Router:   mux.NewRouter(),
+		Cache:    newTestCache(t),
+		Datasets: newDatasetRegistry(nil),
+		CORS:     policy,
+	}
+	server.Routes()
+	server.Middleware()
+	serve := func(method, path, origin string, header http.Header) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(method, path, nil)
+		for key, values := range header {
+			request.Header[key] = values
+		}
+		if origin != "" {
+			request.Header.Set("Origin", origin)
+		}
+		response := httptest.NewRecorder()
+		server.Handler().ServeHTTP(response, request)
+		return response
+	}
 
-	handler.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/", nil))
+	response := serve(http.MethodGet, "/", "https://datascapes.rrchnm.org", nil)
+	if got := response.Header().Get("Access-Control-Allow-Origin"); got != "https://datascapes.rrchnm.org" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want the origin", got)
+	}
+	if got := response.Header().Get("Access-Control-Expose-Headers"); got != "X-Request-ID" {
+		t.Errorf("Access-Control-Expose-Headers = %q", got)
+	}
+	response = serve(http.MethodGet, "/", "https://example.org", nil)
+	if got := response.Header().Get("Access-Control-Allow-Origin"); got != "" || response.Code != http.StatusOK {
+		t.Errorf("other origin: status = %d, Access-Control-Allow-Origin = %q", response.Code, got)
+	}
 
+	// A preflight for a restricted route needs no key of its own.
+	response = serve(http.MethodOptions, "/pinkertons/activities/1", "https://datascapes.rrchnm.org", http.Header{
+		"Access-Control-Request-Method":  {"GET"},
+		"Access-Control-Request-Headers": {"authorization"},
+	})
+	want := map[string]string{
+		"Allow":                        "GET, HEAD, OPTIONS",
+		"Access-Control-Allow-Origin":  "https://datascapes.rrchnm.org",
+		"Access-Control-Allow-Methods": "GET, HEAD",
+		"Access-Control-Allow-Headers": "Authorization",
+		"Access-Control-Max-Age":       "3600",
+	}
 	if response.Code != http.StatusNoContent {
-		t.Fatalf("status = %d, want %d", response.Code, http.StatusNoContent)
+		t.Fatalf("preflight status = %d, want %d", response.Code, http.StatusNoContent)
 	}
-	if origin := response.Header().Get("Access-Control-Allow-Origin"); origin != "*" {
-		t.Fatalf("Access-Control-Allow-Origin = %q, want *", origin)
+	for key, value := range want {
+		if got := response.Header().Get(key); got != value {
+			t.Errorf("preflight %s = %q, want %q", key, got, value)
+		}
+	}
+
+	response = serve(http.MethodOptions, "/bom/parishes", "https://example.org", http.Header{"Access-Control-Request-Method": {"GET"}})
+	if got := response.Header().Get("Access-Control-Allow-Origin"); got != "" || response.Code != http.StatusNoContent {
+		t.Errorf("preflight from another origin: status = %d, Access-Control-Allow-Origin = %q", response.Code, got)
+	}
+	testsupport.AssertProblem(t, serve(http.MethodOptions, "/not-a-route", "https://datascapes.rrchnm.org", nil), http.StatusNotFound, httpx.CodeRouteNotFound, "")
+	response = serve(http.MethodPost, "/bom/parishes", "", nil)
+	testsupport.AssertProblem(t, response, http.StatusMethodNotAllowed, httpx.CodeMethodNotAllowed, "")
+	if got := response.Header().Get("Allow"); got != "GET, HEAD, OPTIONS" {
+		t.Errorf("405 Allow = %q", got)
 	}
 }
 
 func TestRoutesRegistersHandlersAndNotFound(t *testing.T) {
-	server := &Server{Router: mux.NewRouter()}
+	server := &Server{Router: mux.NewRouter(), Datasets: newDatasetRegistry(nil)}
 	server.Routes()
 
 	for _, path := range []string{
@@ -101,19 +169,190 @@ func TestRoutesRegistersHandlersAndNotFound(t *testing.T) {
 	}
 
 	post := httptest.NewRequest(http.MethodPost, "/apb/bible-books", nil)
-	if matched := server.Router.Match(post, &mux.RouteMatch{}); matched {
-		t.Fatal("POST unexpectedly matched a GET/HEAD-only route")
+	var match mux.RouteMatch
+	if server.Router.Match(post, &match); match.MatchErr != mux.ErrMethodMismatch {
+		t.Fatalf("POST match error = %v, want %v", match.MatchErr, mux.ErrMethodMismatch)
+	}
+
+	for _, tt := range []struct {
+		method string
+		path   string
+		status int
+		code   string
+	}{
+		{http.MethodGet, "/not-a-route", http.StatusNotFound, httpx.CodeRouteNotFound},
+		{http.MethodPost, "/apb/bible-books", http.StatusMethodNotAllowed, httpx.CodeMethodNotAllowed},
+	} {
+		response := httptest.NewRecorder()
+		server.Handler().ServeHTTP(response, httptest.NewRequest(tt.method, tt.path, nil))
+		if response.Code != tt.status {
+			t.Fatalf("%s %s status = %d, want %d", tt.method, tt.path, response.Code, tt.status)
+		}
+		if contentType := response.Header().Get("Content-Type"); contentType != httpx.ProblemContentType {
+			t.Fatalf("%s %s Content-Type = %q, want %q", tt.method, tt.path, contentType, httpx.ProblemContentType)
+		}
+		var problem httpx.Problem
+		if err := json.Unmarshal(response.Body.Bytes(), &problem); err != nil {
+			t.Fatalf("decode problem: %v", err)
+		}
+		if problem.Code != tt.code || problem.Status != tt.status {
+			t.Fatalf("%s %s problem = %+v, want code %q and status %d", tt.method, tt.path, problem, tt.code, tt.status)
+		}
+		if problem.RequestID == "" || problem.RequestID != response.Header().Get(httpx.RequestIDHeader) {
+			t.Fatalf("problem request ID = %q, header = %q", problem.RequestID, response.Header().Get(httpx.RequestIDHeader))
+		}
+	}
+}
+
+func TestRecoveryMiddleware(t *testing.T) {
+	handler := httpx.RequestID(recoveryMiddleware(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic("boom")
+	})))
+	response := httptest.NewRecorder()
+
+	handler.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+	if response.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want %d", response.Code, http.StatusInternalServerError)
+	}
+	var problem httpx.Problem
+	if err := json.Unmarshal(response.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("decode problem: %v", err)
+	}
+	if problem.Code != httpx.CodeInternal {
+		t.Fatalf("code = %q, want %q", problem.Code, httpx.CodeInternal)
+	}
+}
+
+func TestRoutesOmitsDisabledDatasets(t *testing.T) {
+	registry, err := newDatasetRegistry(nil).Select(nil, []string{"pinkertons"})
+	if err != nil {
+		t.Fatalf("select datasets: %v", err)
+	}
+	server := &Server{Router: mux.NewRouter(), Datasets: registry}
+	server.Routes()
+
+	request := httptest.NewRequest(http.MethodGet, "/pinkertons/activities/1", nil)
+	var match mux.RouteMatch
+	if server.Router.Match(request, &match) && match.Route != nil {
+		t.Fatal("route for a disabled dataset was registered")
 	}
 
 	response := httptest.NewRecorder()
-	server.Router.ServeHTTP(
-		response,
-		httptest.NewRequest(http.MethodGet, "/not-a-route", nil),
-	)
-	if response.Code != http.StatusNotFound {
-		t.Fatalf("not-found status = %d, want %d", response.Code, http.StatusNotFound)
+	server.EndpointsHandler().ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/", nil))
+	var endpoints []Endpoint
+	if err := json.Unmarshal(response.Body.Bytes(), &endpoints); err != nil {
+		t.Fatalf("unmarshal endpoint index: %v", err)
+	}
+	if len(endpoints) != 36 {
+		t.Fatalf("endpoint count = %d, want 36", len(endpoints))
+	}
+	for _, endpoint := range endpoints {
+		if strings.Contains(endpoint.URL, "/pinkertons/") {
+			t.Fatalf("endpoint index lists disabled dataset endpoint %q", endpoint.URL)
+		}
+	}
+}
+
+func TestRestrictedDatasets(t *testing.T) {
+	store, err := auth.NewStaticStore([]auth.Key{
+		{Name: "editors", Hash: auth.Hash("editors-key"), Datasets: []string{"pinkertons"}},
+		{Name: "others", Hash: auth.Hash("others-key"), Datasets: []string{"bom"}},
+	})
+	if err != nil {
+		t.Fatalf("create key store: %v", err)
+	}
+	server := &Server{
+		Router:   mux.NewRouter(),
+		Cache:    newTestCache(t),
+		Datasets: newDatasetRegistry(nil),
+		Auth:     auth.New(store, nil),
+	}
+	server.Routes()
+	server.Middleware()
+
+	get := func(path, key string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodGet, path, nil)
+		if key != "" {
+			request.Header.Set("Authorization", "Bearer "+key)
+		}
+		response := httptest.NewRecorder()
+		server.Handler().ServeHTTP(response, request)
+		return response
+	}
+
+	testsupport.AssertProblem(t, get("/pinkertons/locations", ""), http.StatusUnauthorized, httpx.CodeUnauthorized, "")
+	testsupport.AssertProblem(t, get("/pinkertons/locations", "others-key"), http.StatusForbidden, httpx.CodeForbidden, "")
+
+	for _, tt := range []struct {
+		key  string
+		want int
+	}{{"", 36}, {"others-key", 36}, {"editors-key", 41}} {
+		response := get("/", tt.key)
+		if response.Code != http.StatusOK {
+			t.Fatalf("catalog with key %q: status = %d", tt.key, response.Code)
+		}
+		if got := strings.Join(response.Header().Values("Vary"), ", "); !strings.Contains(got, "Authorization") {
+			t.Errorf("catalog Vary = %q, want Authorization", got)
+		}
+		var endpoints []Endpoint
+		if err := json.Unmarshal(response.Body.Bytes(), &endpoints); err != nil {
+			t.Fatalf("unmarshal endpoint index: %v", err)
+		}
+		if len(endpoints) != tt.want {
+			t.Errorf("catalog with key %q lists %d endpoints, want %d", tt.key, len(endpoints), tt.want)
+		}
+
+		var doc openapi.Document
+		if err := json.Unmarshal(get("/openapi.json", tt.key).Body.Bytes(), &doc); err != nil {
+			t.Fatalf("unmarshal OpenAPI document: %v", err)
+		}
+		if len(doc.Paths) != tt.want {
+			t.Errorf("OpenAPI document with key %q has %d paths, want %d", tt.key, len(doc.Paths), tt.want)
+		}
+		_, listed := doc.Paths["/pinkertons/locations"]
+		if hasTag := slices.ContainsFunc(doc.Tags, func(tag openapi.Tag) bool { return tag.Name == "pinkertons" }); hasTag != listed {
+			t.Errorf("OpenAPI document with key %q: pinkertons tag = %v, paths listed = %v", tt.key, hasTag, listed)
+		}
+	}
+}
+
+func TestInvalidKeysAreLimitedWithRateLimitsOff(t *testing.T) {
+	cfg := config.Default()
+	if cfg.RateLimit.Enabled {
+		t.Fatal("rate limits are on by default; the test needs them off")
+	}
+	store, err := auth.NewStaticStore([]auth.Key{{Name: "editors", Hash: auth.Hash("editors-key")}})
+	if err != nil {
+		t.Fatalf("create key store: %v", err)
+	}
+	failures, err := newAuthFailureLimiter(cfg.RateLimit)
+	if err != nil {
+		t.Fatalf("newAuthFailureLimiter() error = %v", err)
 	}
-	if body := strings.TrimSpace(response.Body.String()); body != "404 Not found." {
-		t.Fatalf("not-found body = %q, want %q", body, "404 Not found.")
+	server := &Server{
+		Router:   mux.NewRouter(),
+		Cache:    newTestCache(t),
+		Datasets: newDatasetRegistry(nil),
+		Auth:     auth.New(store, failures),
+	}
+	server.Routes()
+	server.Middleware()
+
+	get := func(key string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodGet, "/", nil)
+		request.Header.Set("Authorization", "Bearer "+key)
+		response := httptest.NewRecorder()
+		server.Handler().ServeHTTP(response, request)
+		return response
+	}
+
+	for i := range cfg.RateLimit.AuthFailures.Burst {
+		if response := get("guessed-key"); response.Code != http.StatusUnauthorized {
+			t.Fatalf("guess %d: status = %d, want %d", i+1, response.Code, http.StatusUnauthorized)
+		}
 	}
+	testsupport.AssertProblem(t, get("guessed-key"), http.StatusTooManyRequests, httpx.CodeRateLimited, "")
+	// The address is refused before any key is looked up, valid or not.
+	testsupport.AssertProblem(t, get("editors-key"), http.StatusTooManyRequests, httpx.CodeRateLimited, "")
 }
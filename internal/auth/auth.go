@@ -0,0 +1,132 @@
+// Package auth authenticates API keys and restricts routes to the keys whose
+// scope includes the route's dataset.
+//
+// Keys are random secrets that clients send as bearer tokens. Only the
+// SHA-256 digest of each key is stored, so a leaked key list or database dump
+// does not leak usable keys.
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// AllDatasets is the scope entry that grants a key every dataset.
+const AllDatasets = "*"
+
+// Key is a stored API key. The secret itself is not kept, only its hash.
+type Key struct {
+	Name     string   // Identifies the holder in logs and rate limits
+	Hash     string   // Hex SHA-256 digest of the secret, as returned by Hash
+	Datasets []string // Datasets the key may read, or AllDatasets
+}
+
+// Allows reports whether the key's scope includes dataset.
+func (k Key) Allows(dataset string) bool {
+	return slices.Contains(k.Datasets, dataset) || slices.Contains(k.Datasets, AllDatasets)
+}
+
+// Hash returns the hex SHA-256 digest of a secret, the form in which keys are
+// stored. Keys are long random values, so an unsalted fast hash is enough:
+// there is no dictionary to try.
+func Hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// ValidHash reports whether s has the form of a hash returned by Hash.
+func ValidHash(s string) bool {
+	if len(s) != 2*sha256.Size {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
+// secretBytes is the number of random bytes in a generated key.
+const secretBytes = 32
+
+// NewSecret returns a new random key and its hash.
+func NewSecret() (secret, hash string, err error) {
+	b := make([]byte, secretBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	secret = hex.EncodeToString(b)
+	return secret, Hash(secret), nil
+}
+
+// ErrNoKey is returned by a Store that holds no key with the given hash.
+var ErrNoKey = errors.New("no such API key")
+
+// A Store looks up keys by hash.
+type Store interface {
+	// Lookup returns the key with the given hash, or ErrNoKey. Other errors
+	// mean that the store could not be read.
+	Lookup(ctx context.Context, hash string) (Key, error)
+}
+
+// StaticStore is a fixed set of keys, such as those listed in the
+// configuration file.
+type StaticStore struct {
+	keys map[string]Key
+}
+
+// NewStaticStore returns a store holding keys. It reports keys whose hash is
+// malformed or shared with another key.
+func NewStaticStore(keys []Key) (*StaticStore, error) {
+	s := &StaticStore{keys: make(map[string]Key, len(keys))}
+	for _, key := range keys {
+		key.Hash = strings.ToLower(key.Hash)
+		if !ValidHash(key.Hash) {
+			return nil, fmt.Errorf("API key %q does not have a valid SHA-256 hash", key.Name)
+		}
+		if _, ok := s.keys[key.Hash]; ok {
+			return nil, fmt.Errorf("API key %q has the same hash as another key", key.Name)
+		}
+		s.keys[key.Hash] = key
+	}
+	return s, nil
+}
+
+// Lookup implements Store.
+func (s *StaticStore) Lookup(_ context.Context, hash string) (Key, error) {
+	key, ok := s.keys[hash]
+	if !ok {
+		return Key{}, ErrNoKey
+	}
+	return key, nil
+}
+
+// Stores searches several stores in order and returns the first key found.
+type Stores []Store
+
+// Lookup implements Store.
+func (stores Stores) Lookup(ctx context.Context, hash string) (Key, error) {
+	for _, store := range stores {
+		key, err := store.Lookup(ctx, hash)
+		if !errors.Is(err, ErrNoKey) {
+			return key, err
+		}
+	}
+	return Key{}, ErrNoKey
+}
+
+type keyContextKey struct{}
+
+// WithKey returns a context that carries the authenticated key.
+func WithKey(ctx context.Context, key Key) context.Context {
+	return context.WithValue(ctx, keyContextKey{}, key)
+}
+
+// KeyFromContext returns the key that authenticated the request, if any.
+func KeyFromContext(ctx context.Context) (Key, bool) {
+	key, ok := ctx.Value(keyContextKey{}).(Key)
+	return key, ok
+}
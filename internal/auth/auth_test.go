@@ -0,0 +1,96 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestHash(t *testing.T) {
+	// echo -n secret | sha256sum
+	const want = "2bb80d537b1da3e38bd30361aa855686bde0eacd7162fef6a25fe97bf527a25b"
+	if got := Hash("secret"); got != want {
+		t.Fatalf("Hash() = %q, want %q", got, want)
+	}
+	if !ValidHash(want) {
+		t.Errorf("ValidHash(%q) = false", want)
+	}
+	for _, hash := range []string{"", "secret", want[:63], want + "0", strings.Replace(want, "2", "g", 1)} {
+		if ValidHash(hash) {
+			t.Errorf("ValidHash(%q) = true", hash)
+		}
+	}
+}
+
+func TestNewSecret(t *testing.T) {
+	secret, hash, err := NewSecret()
+	if err != nil {
+		t.Fatalf("NewSecret() error = %v", err)
+	}
+	if len(secret) != 2*secretBytes || Hash(secret) != hash {
+		t.Fatalf("NewSecret() = %q, %q", secret, hash)
+	}
+	other, _, _ := NewSecret()
+	if other == secret {
+		t.Fatal("NewSecret() returned the same key twice")
+	}
+}
+
+func TestKeyAllows(t *testing.T) {
+	scoped := Key{Datasets: []string{"pinkertons"}}
+	if !scoped.Allows("pinkertons") || scoped.Allows("bom") {
+		t.Errorf("scoped key allows pinkertons = %v, bom = %v", scoped.Allows("pinkertons"), scoped.Allows("bom"))
+	}
+	all := Key{Datasets: []string{AllDatasets}}
+	if !all.Allows("bom") {
+		t.Error("key for all datasets does not allow bom")
+	}
+	if (Key{}).Allows("bom") {
+		t.Error("zero key allows bom")
+	}
+}
+
+func TestStaticStore(t *testing.T) {
+	hash := Hash("secret")
+	store, err := NewStaticStore([]Key{{Name: "editors", Hash: strings.ToUpper(hash), Datasets: []string{"pinkertons"}}})
+	if err != nil {
+		t.Fatalf("NewStaticStore() error = %v", err)
+	}
+	key, err := store.Lookup(context.Background(), hash)
+	if err != nil || key.Name != "editors" {
+		t.Fatalf("Lookup() = %+v, %v", key, err)
+	}
+	if _, err := store.Lookup(context.Background(), Hash("other")); !errors.Is(err, ErrNoKey) {
+		t.Fatalf("Lookup(unknown) error = %v, want ErrNoKey", err)
+	}
+
+	if _, err := NewStaticStore([]Key{{Name: "bad", Hash: "secret"}}); err == nil {
+		t.Error("NewStaticStore() accepted a malformed hash")
+	}
+	if _, err := NewStaticStore([]Key{{Name: "a", Hash: hash}, {Name: "b", Hash: hash}}); err == nil {
+		t.Error("NewStaticStore() accepted a duplicate hash")
+	}
+}
+
+type failingStore struct{}
+
+func (failingStore) Lookup(context.Context, string) (Key, error) {
+	return Key{}, errors.New("database is down")
+}
+
+func TestStores(t *testing.T) {
+	first, _ := NewStaticStore([]Key{{Name: "first", Hash: Hash("one")}})
+	second, _ := NewStaticStore([]Key{{Name: "second", Hash: Hash("two")}})
+	stores := Stores{first, second}
+
+	if key, err := stores.Lookup(context.Background(), Hash("two")); err != nil || key.Name != "second" {
+		t.Fatalf("Lookup() = %+v, %v", key, err)
+	}
+	if _, err := stores.Lookup(context.Background(), Hash("three")); !errors.Is(err, ErrNoKey) {
+		t.Fatalf("Lookup(unknown) error = %v, want ErrNoKey", err)
+	}
+	if _, err := (Stores{first, failingStore{}}).Lookup(context.Background(), Hash("three")); err == nil || errors.Is(err, ErrNoKey) {
+		t.Fatalf("Lookup() with a failing store error = %v", err)
+	}
+}
@@ -0,0 +1,169 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/chnm/apiary/internal/httpx"
+	"github.com/chnm/apiary/internal/ratelimit"
+	"github.com/gorilla/mux"
+)
+
+// restrictedHandler carries the dataset that a route belongs to alongside its
+// handler so that the middleware can find it from the matched route.
+type restrictedHandler struct {
+	http.Handler
+	dataset string
+}
+
+// Unwrap implements httpx.Declaration.
+func (h restrictedHandler) Unwrap() http.Handler { return h.Handler }
+
+// Restricted returns a function that declares that the handlers it wraps
+// serve dataset and may only be used with a key whose scope includes it, for
+// use when registering routes:
+//
+//	restricted := auth.Restricted("pinkertons")
+//	router.Handle("/pinkertons/locations", cached(restricted(h.LocationsHandler())))
+//
+// Routes that are not declared restricted are public.
+func Restricted(dataset string) func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return restrictedHandler{Handler: h, dataset: dataset}
+	}
+}
+
+// RouteDataset returns the dataset that a route is restricted to, and false if
+// the route is public.
+func RouteDataset(route *mux.Route) (string, bool) {
+	restricted, ok := httpx.RouteDeclaration[restrictedHandler](route)
+	return restricted.dataset, ok
+}
+
+// DatasetFor returns the dataset that the route that matched r is restricted
+// to, and false if the route is public.
+func DatasetFor(r *http.Request) (string, bool) {
+	return RouteDataset(mux.CurrentRoute(r))
+}
+
+var routeVariablePattern = regexp.MustCompile(`\{([^{}:]+):(?:[^{}]|\{[^{}]*\})*\}`)
+
+// RestrictedPaths returns the restricted routes of router by path template in
+// OpenAPI form, such as /pinkertons/activities/{id}, with the dataset each is
+// restricted to. Catalogs use it to hide endpoints that a caller cannot use.
+func RestrictedPaths(router *mux.Router) (map[string]string, error) {
+	paths := make(map[string]string)
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		dataset, ok := RouteDataset(route)
+		if !ok {
+			return nil
+		}
+		template, err := route.GetPathTemplate()
+		if err != nil {
+			return err
+		}
+		paths[routeVariablePattern.ReplaceAllString(template, "{$1}")] = dataset
+		return nil
+	})
+	return paths, err
+}
+
+// Authenticator checks the API keys that requests present.
+type Authenticator struct {
+	store    Store
+	failures *ratelimit.Limiter
+}
+
+// New returns an Authenticator that looks up keys in store. Invalid keys are
+// counted against the address that presents them in failures' AuthFailures
+// class, and an address over that limit is refused before its keys are
+// looked up, so that made-up keys cannot flood the store. failures may be
+// nil, which counts nothing.
+func New(store Store, failures *ratelimit.Limiter) *Authenticator {
+	return &Authenticator{store: store, failures: failures}
+}
+
+// Middleware authenticates the key in a request's Authorization header, if it
+// has one, and enforces the restrictions of the matched route. A request to a
+// restricted route without a key, or with any invalid key, gets 401
+// Unauthorized; a valid key whose scope does not include the route's dataset
+// gets 403 Forbidden, and an address with too many invalid keys gets 429 Too
+// Many Requests. The key is added to the request's context and becomes
+// its client ID for rate limiting, so it must run before the rate limiter. It
+// must run inside the router so that it can find the route.
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		dataset, restricted := DatasetFor(r)
+		if restricted {
+			// Responses depend on the key, so shared caches must not
+			// serve one caller's response to another.
+			w.Header().Add("Vary", "Authorization")
+		}
+
+		secret, presented, err := bearerToken(r)
+		if err != nil {
+			unauthorized(w, r, err.Error())
+			return
+		}
+		if !presented {
+			if restricted {
+				unauthorized(w, r, fmt.Sprintf("the %s dataset is not yet public; an API key is required", dataset))
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if a.failures.Refuse(w, r, ratelimit.AuthFailures) {
+			return
+		}
+		key, err := a.store.Lookup(r.Context(), Hash(secret))
+		if errors.Is(err, ErrNoKey) {
+			a.failures.Fail(r, ratelimit.AuthFailures)
+			unauthorized(w, r, "the API key is not valid")
+			return
+		}
+		if err != nil {
+			w.Header().Set("Cache-Control", "no-store")
+			httpx.InternalServerError(w, r, "error authenticating API key", err)
+			return
+		}
+		if restricted && !key.Allows(dataset) {
+			w.Header().Set("Cache-Control", "no-store")
+			httpx.WriteProblem(w, r, httpx.Problem{
+				Status: http.StatusForbidden,
+				Code:   httpx.CodeForbidden,
+				Detail: fmt.Sprintf("this API key does not grant access to the %s dataset", dataset),
+			})
+			return
+		}
+
+		ctx := WithKey(r.Context(), key)
+		ctx = ratelimit.WithClientID(ctx, "key:"+key.Name)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// bearerToken returns the bearer token in r's Authorization header. It
+// reports whether the header was present, and an error if it does not hold a
+// bearer token.
+func bearerToken(r *http.Request) (string, bool, error) {
+	header := r.Header.Get("Authorization")
+	if header == "" {
+		return "", false, nil
+	}
+	scheme, token, _ := strings.Cut(header, " ")
+	token = strings.TrimSpace(token)
+	if !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", true, errors.New("send the API key as a bearer token: Authorization: Bearer <key>")
+	}
+	return token, true, nil
+}
+
+func unauthorized(w http.ResponseWriter, r *http.Request, detail string) {
+	w.Header().Set("Cache-Control", "no-store")
+	httpx.Unauthorized(w, r, detail)
+}
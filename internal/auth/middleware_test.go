@@ -0,0 +1,209 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/chnm/apiary/internal/cachex"
+	"github.com/chnm/apiary/internal/httpx"
+	"github.com/chnm/apiary/internal/ratelimit"
+	"github.com/chnm/apiary/internal/testsupport"
+	"github.com/gorilla/mux"
+)
+
+// newTestRouter returns a router guarded by store, with a public route and
+// routes restricted to the pinkertons dataset. Handlers echo the name of the
+// key that authenticated the request.
+func newTestRouter(t *testing.T, store Store) *mux.Router {
+	t.Helper()
+	echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key, _ := KeyFromContext(r.Context())
+		_, _ = w.Write([]byte(key.Name))
+	})
+	restricted := Restricted("pinkertons")
+	router := mux.NewRouter()
+	router.Handle("/public", echo)
+	router.Handle("/pinkertons/locations", restricted(echo))
+	router.Handle("/pinkertons/activities/{id:[0-9]+}", cachex.TTL(cachex.Stable)(restricted(echo)))
+	router.Use(New(store, nil).Middleware)
+	return router
+}
+
+func newTestStore(t *testing.T) Store {
+	t.Helper()
+	store, err := NewStaticStore([]Key{
+		{Name: "editors", Hash: Hash("editors-key"), Datasets: []string{"pinkertons"}},
+		{Name: "others", Hash: Hash("others-key"), Datasets: []string{"bom"}},
+	})
+	if err != nil {
+		t.Fatalf("NewStaticStore() error = %v", err)
+	}
+	return store
+}
+
+func serve(router http.Handler, path, authorization string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodGet, path, nil)
+	if authorization != "" {
+		request.Header.Set("Authorization", authorization)
+	}
+	response := httptest.NewRecorder()
+	router.ServeHTTP(response, request)
+	return response
+}
+
+func TestMiddleware(t *testing.T) {
+	router := newTestRouter(t, newTestStore(t))
+
+	tests := []struct {
+		name, path, authorization string
+		status                    int
+		code                      string
+		body                      string
+	}{
+		{name: "public without key", path: "/public", status: http.StatusOK},
+		{name: "public with key", path: "/public", authorization: "Bearer others-key", status: http.StatusOK, body: "others"},
+		{name: "public with invalid key", path: "/public", authorization: "Bearer wrong", status: http.StatusUnauthorized, code: httpx.CodeUnauthorized},
+		{name: "restricted without key", path: "/pinkertons/locations", status: http.StatusUnauthorized, code: httpx.CodeUnauthorized},
+		{name: "restricted with invalid key", path: "/pinkertons/locations", authorization: "Bearer wrong", status: http.StatusUnauthorized, code: httpx.CodeUnauthorized},
+		{name: "restricted with basic credentials", path: "/pinkertons/locations", authorization: "Basic ZWRpdG9yczprZXk=", status: http.StatusUnauthorized, code: httpx.CodeUnauthorized},
+		{name: "restricted out of scope", path: "/pinkertons/locations", authorization: "Bearer others-key", status: http.StatusForbidden, code: httpx.CodeForbidden},
+		{name: "restricted in scope", path: "/pinkertons/locations", authorization: "Bearer editors-key", status: http.StatusOK, body: "editors"},
+		{name: "restricted beneath another declaration", path: "/pinkertons/activities/1", authorization: "bearer editors-key", status: http.StatusOK, body: "editors"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := serve(router, tt.path, tt.authorization)
+			if tt.code != "" {
+				testsupport.AssertProblem(t, response, tt.status, tt.code, "")
+				if got := response.Header().Get("Cache-Control"); got != "no-store" {
+					t.Errorf("Cache-Control = %q, want no-store", got)
+				}
+				return
+			}
+			if response.Code != tt.status || response.Body.String() != tt.body {
+				t.Fatalf("response = %d %q, want %d %q", response.Code, response.Body, tt.status, tt.body)
+			}
+		})
+	}
+
+	unauthorized := serve(router, "/pinkertons/locations", "")
+	if got := unauthorized.Header().Get("WWW-Authenticate"); got == "" {
+		t.Error("401 response has no WWW-Authenticate header")
+	}
+	if got := unauthorized.Header().Get("Vary"); got != "Authorization" {
+		t.Errorf("restricted Vary = %q, want Authorization", got)
+	}
+	if got := serve(router, "/public", "").Header().Get("Vary"); got != "" {
+		t.Errorf("public Vary = %q, want none", got)
+	}
+}
+
+func TestMiddlewareStoreError(t *testing.T) {
+	router := newTestRouter(t, failingStore{})
+
+	response := serve(router, "/pinkertons/locations", "Bearer editors-key")
+	testsupport.AssertProblem(t, response, http.StatusInternalServerError, httpx.CodeInternal, "")
+}
+
+func TestMiddlewareIdentifiesClientsByKey(t *testing.T) {
+	limiter, err := ratelimit.New(ratelimit.Options{
+		Limits: map[ratelimit.Class]ratelimit.Limit{ratelimit.Default: {PerMinute: 1, Burst: 1}},
+	})
+	if err != nil {
+		t.Fatalf("ratelimit.New() error = %v", err)
+	}
+	router := newTestRouter(t, newTestStore(t))
+	router.Use(limiter.Middleware)
+
+	fromAddress := func(addr, authorization string) int {
+		request := httptest.NewRequest(http.MethodGet, "/public", nil)
+		request.RemoteAddr = addr
+		request.Header.Set("Authorization", authorization)
+		response := httptest.NewRecorder()
+		router.ServeHTTP(response, request)
+		return response.Code
+	}
+	if got := fromAddress("192.0.2.1:1234", "Bearer editors-key"); got != http.StatusOK {
+		t.Fatalf("first status = %d, want %d", got, http.StatusOK)
+	}
+	// The same key from another address shares the allowance; another key
+	// has its own.
+	if got := fromAddress("192.0.2.2:1234", "Bearer editors-key"); got != http.StatusTooManyRequests {
+		t.Errorf("same key status = %d, want %d", got, http.StatusTooManyRequests)
+	}
+	if got := fromAddress("192.0.2.2:1234", "Bearer others-key"); got != http.StatusOK {
+		t.Errorf("other key status = %d, want %d", got, http.StatusOK)
+	}
+}
+
+func TestMiddlewareLimitsInvalidKeysByAddress(t *testing.T) {
+	limiter, err := ratelimit.New(ratelimit.Options{
+		Limits: map[ratelimit.Class]ratelimit.Limit{
+			ratelimit.Default:      {PerMinute: 60, Burst: 60},
+			ratelimit.AuthFailures: {PerMinute: 1, Burst: 2},
+		},
+	})
+	if err != nil {
+		t.Fatalf("ratelimit.New() error = %v", err)
+	}
+	store := &countingStore{Store: newTestStore(t)}
+	router := mux.NewRouter()
+	router.Handle("/public", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {}))
+	router.Use(New(store, limiter).Middleware)
+
+	fromAddress := func(addr, authorization string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodGet, "/public", nil)
+		request.RemoteAddr = addr
+		request.Header.Set("Authorization", authorization)
+		response := httptest.NewRecorder()
+		router.ServeHTTP(response, request)
+		return response
+	}
+	for i := range 2 {
+		if got := fromAddress("192.0.2.1:1234", fmt.Sprintf("Bearer guess-%d", i)).Code; got != http.StatusUnauthorized {
+			t.Fatalf("guess %d status = %d, want %d", i, got, http.StatusUnauthorized)
+		}
+	}
+	// Once the address is over its limit, its keys are not looked up, even
+	// valid ones; other addresses are unaffected.
+	lookups := store.lookups
+	testsupport.AssertProblem(t, fromAddress("192.0.2.1:1234", "Bearer guess-2"), http.StatusTooManyRequests, httpx.CodeRateLimited, "")
+	testsupport.AssertProblem(t, fromAddress("192.0.2.1:1234", "Bearer editors-key"), http.StatusTooManyRequests, httpx.CodeRateLimited, "")
+	if store.lookups != lookups {
+		t.Errorf("store looked up %d keys for a refused address, want none", store.lookups-lookups)
+	}
+	if got := fromAddress("192.0.2.2:1234", "Bearer editors-key").Code; got != http.StatusOK {
+		t.Errorf("other address status = %d, want %d", got, http.StatusOK)
+	}
+}
+
+// countingStore counts the keys it is asked to look up.
+type countingStore struct {
+	Store
+	lookups int
+}
+
+func (s *countingStore) Lookup(ctx context.Context, hash string) (Key, error) {
+	s.lookups++
+	return s.Store.Lookup(ctx, hash)
+}
+
+func TestRestrictedPaths(t *testing.T) {
+	router := newTestRouter(t, newTestStore(t))
+
+	paths, err := RestrictedPaths(router)
+	if err != nil {
+		t.Fatalf("RestrictedPaths() error = %v", err)
+	}
+	want := map[string]string{
+		"/pinkertons/locations":       "pinkertons",
+		"/pinkertons/activities/{id}": "pinkertons",
+	}
+	if !reflect.DeepEqual(paths, want) {
+		t.Fatalf("RestrictedPaths() = %v, want %v", paths, want)
+	}
+}
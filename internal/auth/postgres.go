@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// postgresCacheTTL is how long a lookup is remembered, which is also how
+	// long a revoked key keeps working.
+	postgresCacheTTL = time.Minute
+	// postgresCacheSize bounds the remembered lookups, so that requests with
+	// made-up keys cannot grow the cache without limit.
+	postgresCacheSize = 10_000
+)
+
+const keyLookupQuery = `
+SELECT name, datasets
+FROM apiary.api_keys
+WHERE key_hash = $1 AND revoked_at IS NULL;
+`
+
+// PostgresStore looks up keys in the apiary.api_keys table, so that keys can
+// be issued and revoked without restarting the server. Lookups, including
+// failed ones, are remembered for a minute to spare the database.
+type PostgresStore struct {
+	db  *pgxpool.Pool
+	now func() time.Time
+
+	mu    sync.Mutex
+	cache map[string]cachedLookup
+}
+
+type cachedLookup struct {
+	key     Key
+	err     error // nil or ErrNoKey
+	expires time.Time
+}
+
+// NewPostgresStore returns a store that reads keys from db.
+func NewPostgresStore(db *pgxpool.Pool) *PostgresStore {
+	return &PostgresStore{db: db, now: time.Now, cache: make(map[string]cachedLookup)}
+}
+
+// Lookup implements Store.
+func (s *PostgresStore) Lookup(ctx context.Context, hash string) (Key, error) {
+	now := s.now()
+	s.mu.Lock()
+	cached, ok := s.cache[hash]
+	s.mu.Unlock()
+	if ok && now.Before(cached.expires) {
+		return cached.key, cached.err
+	}
+
+	key := Key{Hash: hash}
+	err := s.db.QueryRow(ctx, keyLookupQuery, hash).Scan(&key.Name, &key.Datasets)
+	if errors.Is(err, pgx.ErrNoRows) {
+		key, err = Key{}, ErrNoKey
+	} else if err != nil {
+		return Key{}, fmt.Errorf("look up API key: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.cache) >= postgresCacheSize {
+		s.evict(now)
+	}
+	s.cache[hash] = cachedLookup{key: key, err: err, expires: now.Add(postgresCacheTTL)}
+	return key, err
+}
+
+// evict makes room in a full cache. It drops expired lookups, then failed
+// ones, so that a flood of made-up keys cannot push out the valid keys that
+// real clients present. Only if every lookup is of a valid key does it drop
+// one of those. It is called with s.mu held.
+func (s *PostgresStore) evict(now time.Time) {
+	for hash, cached := range s.cache {
+		if !now.Before(cached.expires) {
+			delete(s.cache, hash)
+		}
+	}
+	if len(s.cache) < postgresCacheSize {
+		return
+	}
+	for hash, cached := range s.cache {
+		if cached.err != nil {
+			delete(s.cache, hash)
+		}
+	}
+	for hash := range s.cache {
+		if len(s.cache) < postgresCacheSize {
+			break
+		}
+		delete(s.cache, hash)
+	}
+}
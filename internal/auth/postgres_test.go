@@ -0,0 +1,39 @@
+package auth
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestPostgresStoreEvictKeepsValidKeys(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := &PostgresStore{cache: make(map[string]cachedLookup)}
+	store.cache["valid"] = cachedLookup{key: Key{Name: "editors"}, expires: now.Add(time.Minute)}
+	for i := len(store.cache); i < postgresCacheSize; i++ {
+		store.cache[fmt.Sprintf("made-up-%d", i)] = cachedLookup{err: ErrNoKey, expires: now.Add(time.Minute)}
+	}
+
+	store.evict(now)
+
+	if _, ok := store.cache["valid"]; !ok {
+		t.Fatal("evict dropped a valid key")
+	}
+	if len(store.cache) != 1 {
+		t.Fatalf("cache holds %d lookups after eviction, want only the valid key", len(store.cache))
+	}
+}
+
+func TestPostgresStoreEvictMakesRoomAmongValidKeys(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := &PostgresStore{cache: make(map[string]cachedLookup)}
+	for i := range postgresCacheSize {
+		store.cache[fmt.Sprintf("key-%d", i)] = cachedLookup{key: Key{Name: "editors"}, expires: now.Add(time.Minute)}
+	}
+
+	store.evict(now)
+
+	if len(store.cache) != postgresCacheSize-1 {
+		t.Fatalf("cache holds %d lookups after eviction, want %d", len(store.cache), postgresCacheSize-1)
+	}
+}
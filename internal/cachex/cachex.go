@@ -0,0 +1,240 @@
+// Package cachex caches responses on the server according to a policy that
+// each route declares, in a backend chosen by configuration.
+package cachex
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chnm/apiary/internal/httpx"
+	"github.com/gorilla/mux"
+	cache "github.com/victorspringer/http-cache"
+)
+
+// Policy says how long the server may cache a route's responses.
+type Policy struct {
+	// TTL is how long responses stay cached. Zero means the default TTL.
+	TTL time.Duration
+	// Disabled routes are never cached.
+	Disabled bool
+}
+
+// Common TTLs for routes, by how often their data changes.
+const (
+	// Static suits data that is complete and never changes, such as
+	// historical boundaries.
+	Static = 30 * 24 * time.Hour
+	// Stable suits data that changes only with an occasional import.
+	Stable = 24 * time.Hour
+)
+
+// policyHandler carries a route's policy alongside its handler so that the
+// cache middleware can find it from the matched route.
+type policyHandler struct {
+	http.Handler
+	policy Policy
+}
+
+// Unwrap implements httpx.Declaration.
+func (h policyHandler) Unwrap() http.Handler { return h.Handler }
+
+// TTL returns a function that declares that the handlers it wraps may be
+// cached for ttl, for use when registering routes:
+//
+//	cached := cachex.TTL(cachex.Static)
+//	router.Handle("/ahcb/states/{date}/", cached(h.AHCBStatesHandler()))
+func TTL(ttl time.Duration) func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return policyHandler{Handler: h, policy: Policy{TTL: ttl}}
+	}
+}
+
+// NoCache declares that the responses of h must never be cached.
+func NoCache(h http.Handler) http.Handler {
+	return policyHandler{Handler: h, policy: Policy{Disabled: true}}
+}
+
+// PolicyFor returns the policy declared by the route that matched r. Routes
+// that declare none get the default policy.
+func PolicyFor(r *http.Request) Policy {
+	p, _ := httpx.RouteDeclaration[policyHandler](mux.CurrentRoute(r))
+	return p.policy
+}
+
+// Cache stores responses in a single backend and applies each route's
+// policy. Responses with different TTLs share the backend, since the TTL is
+// not part of the cache key.
+type Cache struct {
+	adapter    cache.Adapter
+	index      Index
+	defaultTTL time.Duration
+	observer   cache.Observer
+	options    []cache.ClientOption
+
+	mu      sync.Mutex
+	clients map[time.Duration]*cache.Client
+}
+
+// New returns a Cache that stores responses in adapter. observer, if not nil,
+// is called for every cache event, including purges. options are applied to
+// the cache client for every TTL; they must not set the adapter, TTL, or
+// observer.
+func New(adapter cache.Adapter, defaultTTL time.Duration, observer cache.Observer, options ...cache.ClientOption) (*Cache, error) {
+	c := &Cache{
+		adapter:    adapter,
+		defaultTTL: defaultTTL,
+		observer:   observer,
+		options:    options,
+		clients:    make(map[time.Duration]*cache.Client),
+	}
+	if index, ok := adapter.(Index); ok {
+		c.index = index
+	} else {
+		c.index = newMemoryIndex(func(key uint64) bool {
+			_, ok := adapter.Get(key)
+			return ok
+		})
+	}
+	// Build the default client now so that invalid options are reported at
+	// startup rather than on the first request.
+	if _, err := c.client(defaultTTL); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// client returns the cache client for ttl, creating it on first use.
+func (c *Cache) client(ttl time.Duration) (*cache.Client, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if client, ok := c.clients[ttl]; ok {
+		return client, nil
+	}
+	options := append([]cache.ClientOption{
+		cache.ClientWithAdapter(c.adapter),
+		cache.ClientWithTTL(ttl),
+		cache.ClientWithObserver(c.observe),
+	}, c.options...)
+	client, err := cache.NewClient(options...)
+	if err != nil {
+		return nil, fmt.Errorf("create cache client with TTL %s: %w", ttl, err)
+	}
+	c.clients[ttl] = client
+	return client, nil
+}
+
+// Middleware serves responses from the cache according to the policy of the
+// matched route, so it must run inside the router. Responses that may be
+// stored carry the time they were generated in Last-Modified; others have no
+// Last-Modified, since it would change on every request.
+func (c *Cache) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		policy := PolicyFor(r)
+		if policy.Disabled {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ttl := policy.TTL
+		if ttl <= 0 {
+			ttl = c.defaultTTL
+		}
+		client, err := c.client(ttl)
+		if err != nil {
+			// The options were already accepted for the default TTL, so
+			// only the TTL can be at fault. Serve uncached rather than fail.
+			next.ServeHTTP(w, r)
+			return
+		}
+		client.Middleware(httpx.LastModified(next)).ServeHTTP(w, r)
+	})
+}
+
+// observe keeps the index up to date and passes events on to the observer.
+func (c *Cache) observe(event cache.CacheEvent) {
+	switch event.Type {
+	case cache.CacheEventStore:
+		c.index.AddURL(event.Key, event.Request.URL.RequestURI())
+	case cache.CacheEventRefresh, cache.CacheEventStale, cache.CacheEventPurge:
+		c.index.RemoveURL(event.Key)
+	}
+	if c.observer != nil {
+		c.observer(event)
+	}
+}
+
+// PurgeURL removes the cached responses for a path and query string, such as
+// /bom/bills?year=1665, in every format. The order of the query parameters
+// does not matter. It returns the number of responses removed.
+func (c *Cache) PurgeURL(rawURL string) (int, error) {
+	u, err := url.ParseRequestURI(rawURL)
+	if err != nil || u.IsAbs() {
+		return 0, fmt.Errorf("%q is not a path and query string", rawURL)
+	}
+	want := normalizeURL(u)
+	return c.purge(func(indexed string) bool {
+		u, err := url.ParseRequestURI(indexed)
+		return err == nil && normalizeURL(u) == want
+	}), nil
+}
+
+// PurgePrefix removes the cached responses for every URL whose path begins
+// with prefix, such as /bom/. It returns the number of responses removed.
+func (c *Cache) PurgePrefix(prefix string) int {
+	return c.purge(func(indexed string) bool {
+		path, _, _ := strings.Cut(indexed, "?")
+		return strings.HasPrefix(path, prefix)
+	})
+}
+
+// PurgeAll removes every cached response. It returns the number removed.
+func (c *Cache) PurgeAll() int {
+	return c.purge(func(string) bool { return true })
+}
+
+// purge removes the responses whose URLs match, counting those that were
+// still stored.
+func (c *Cache) purge(match func(url string) bool) int {
+	removed := 0
+	for key, url := range c.index.URLs() {
+		if !match(url) {
+			continue
+		}
+		if _, ok := c.adapter.Get(key); ok {
+			removed++
+			if c.observer != nil {
+				c.observer(cache.CacheEvent{Type: cache.CacheEventPurge, Key: key})
+			}
+		}
+		c.adapter.Release(key)
+		c.index.RemoveURL(key)
+	}
+	return removed
+}
+
+// normalizeURL sorts the query parameters and their values, as the cache
+// does before computing a key.
+func normalizeURL(u *url.URL) string {
+	query := u.Query()
+	for _, values := range query {
+		sort.Strings(values)
+	}
+	if len(query) == 0 {
+		return u.EscapedPath()
+	}
+	return u.EscapedPath() + "?" + query.Encode()
+}
+
+// Close releases the backend's resources, such as a connection to a cache
+// server.
+func (c *Cache) Close() error {
+	if closer, ok := c.adapter.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+package cachex
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	cache "github.com/victorspringer/http-cache"
+	"github.com/victorspringer/http-cache/adapter/memory"
+)
+
+// recordingAdapter wraps the memory adapter and records the expiration of
+// each stored response.
+type recordingAdapter struct {
+	cache.Adapter
+	expirations []time.Time
+}
+
+func (a *recordingAdapter) Set(key uint64, response []byte, expiration time.Time) {
+	a.expirations = append(a.expirations, expiration)
+	a.Adapter.Set(key, response, expiration)
+}
+
+func newRecordingAdapter(t *testing.T) *recordingAdapter {
+	t.Helper()
+	adapter, err := memory.NewAdapter(
+		memory.AdapterWithAlgorithm(memory.LRU),
+		memory.AdapterWithCapacity(16),
+	)
+	if err != nil {
+		t.Fatalf("create memory adapter: %v", err)
+	}
+	return &recordingAdapter{Adapter: adapter}
+}
+
+func TestMiddlewareAppliesRoutePolicy(t *testing.T) {
+	adapter := newRecordingAdapter(t)
+	c, err := New(adapter, time.Hour, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	calls := make(map[string]int)
+	counter := func(w http.ResponseWriter, r *http.Request) {
+		calls[r.URL.Path]++
+		_, _ = w.Write([]byte(strconv.Itoa(calls[r.URL.Path])))
+	}
+	router := mux.NewRouter()
+	router.Handle("/static", TTL(Static)(http.HandlerFunc(counter)))
+	router.Handle("/live", NoCache(http.HandlerFunc(counter)))
+	router.HandleFunc("/default", counter)
+	router.Use(c.Middleware)
+
+	tests := []struct {
+		path      string
+		wantCalls int
+		wantTTL   time.Duration
+	}{
+		{"/static", 1, Static},
+		{"/live", 2, 0},
+		{"/default", 1, time.Hour},
+	}
+	for _, tt := range tests {
+		adapter.expirations = nil
+		start := time.Now()
+		for range 2 {
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.path, nil))
+		}
+		if calls[tt.path] != tt.wantCalls {
+			t.Errorf("%s: handler calls = %d, want %d", tt.path, calls[tt.path], tt.wantCalls)
+		}
+		if tt.wantTTL == 0 {
+			if len(adapter.expirations) != 0 {
+				t.Errorf("%s: stored %d responses, want none", tt.path, len(adapter.expirations))
+			}
+			continue
+		}
+		if len(adapter.expirations) == 0 {
+			t.Errorf("%s: no response stored", tt.path)
+			continue
+		}
+		if ttl := adapter.expirations[0].Sub(start); ttl < tt.wantTTL || ttl > tt.wantTTL+time.Minute {
+			t.Errorf("%s: stored for %s, want %s", tt.path, ttl, tt.wantTTL)
+		}
+	}
+}
+
+func TestMiddlewareSetsLastModifiedOnStoredResponses(t *testing.T) {
+	c, err := New(newRecordingAdapter(t), time.Hour, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	ok := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { _, _ = w.Write([]byte("ok")) })
+	router := mux.NewRouter()
+	router.Handle("/cached", ok)
+	router.Handle("/live", NoCache(ok))
+	router.Use(c.Middleware)
+
+	get := func(path string) string {
+		response := httptest.NewRecorder()
+		router.ServeHTTP(response, httptest.NewRequest(http.MethodGet, path, nil))
+		return response.Header().Get("Last-Modified")
+	}
+
+	stored := get("/cached")
+	if stored == "" {
+		t.Fatal("stored response has no Last-Modified")
+	}
+	time.Sleep(time.Second)
+	if hit := get("/cached"); hit != stored {
+		t.Errorf("hit Last-Modified = %q, want the stored %q", hit, stored)
+	}
+	if live := get("/live"); live != "" {
+		t.Errorf("uncached response Last-Modified = %q, want none", live)
+	}
+}
+
+func TestPolicyFor(t *testing.T) {
+	var got Policy
+	record := http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) { got = PolicyFor(r) })
+	router := mux.NewRouter()
+	router.Handle("/ttl", TTL(time.Minute)(record))
+	router.Handle("/none", NoCache(record))
+	router.Handle("/default", record)
+	router.Handle("/wrapped", unwrapper{TTL(time.Minute)(record)})
+
+	tests := map[string]Policy{
+		"/ttl":     {TTL: time.Minute},
+		"/wrapped": {TTL: time.Minute},
+		"/none":    {Disabled: true},
+		"/default": {},
+	}
+	for path, want := range tests {
+		got = Policy{TTL: -1}
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+		if got != want {
+			t.Errorf("PolicyFor(%s) = %+v, want %+v", path, got, want)
+		}
+	}
+
+	// Outside a router there is no route to declare a policy.
+	if got := PolicyFor(httptest.NewRequest(http.MethodGet, "/", nil)); got != (Policy{}) {
+		t.Errorf("PolicyFor(unrouted) = %+v, want the default", got)
+	}
+}
+
+func TestPurge(t *testing.T) {
+	adapter := newRecordingAdapter(t)
+	var purged int
+	c, err := New(adapter, time.Hour, func(event cache.CacheEvent) {
+		if event.Type == cache.CacheEventPurge {
+			purged++
+		}
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	router := mux.NewRouter()
+	router.PathPrefix("/").HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	})
+	router.Use(c.Middleware)
+	for _, url := range []string{"/bom/bills?b=2&a=1", "/bom/parishes", "/bomx", "/ahcb/states"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, url, nil))
+	}
+
+	if n, err := c.PurgeURL("/bom/bills?a=1&b=2"); err != nil || n != 1 {
+		t.Errorf("PurgeURL() = %d, %v, want 1", n, err)
+	}
+	if n := c.PurgePrefix("/bom/"); n != 1 {
+		t.Errorf("PurgePrefix(/bom/) = %d, want 1", n)
+	}
+	if n := c.PurgeAll(); n != 2 {
+		t.Errorf("PurgeAll() = %d, want 2", n)
+	}
+	if n := c.PurgeAll(); n != 0 {
+		t.Errorf("second PurgeAll() = %d, want 0", n)
+	}
+	if purged != 4 {
+		t.Errorf("purge events = %d, want 4", purged)
+	}
+	if _, err := c.PurgeURL("https://example.com/bom/"); err == nil {
+		t.Error("PurgeURL(absolute URL) error = nil")
+	}
+}
+
+// unwrapper stands in for another package's route declaration.
+type unwrapper struct{ http.Handler }
+
+func (u unwrapper) Unwrap() http.Handler { return u.Handler }
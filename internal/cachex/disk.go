@@ -0,0 +1,316 @@
+package cachex
+
+import (
+	"container/heap"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// diskHeaderSize is the length of the expiration time that precedes each
+// stored response.
+const diskHeaderSize = 8
+
+// urlSuffix ends the names of the files that hold the URL of each response,
+// beside the file that holds the response.
+const urlSuffix = ".url"
+
+// DiskAdapter stores cached responses as files in a directory so that they
+// survive restarts. Each file holds the expiration time followed by the
+// response. An index of expiration times is kept in memory to bound the number
+// of entries without reading the directory on every write; it is ordered by
+// expiration so that evicting an entry does not scan the others. The URL of
+// each response is kept in a second file so that the index survives restarts
+// too.
+type DiskAdapter struct {
+	dir      string
+	capacity int
+
+	mu      sync.Mutex
+	entries map[uint64]*diskEntry
+	queue   diskQueue
+	urls    map[uint64]string
+}
+
+// diskEntry indexes one stored response.
+type diskEntry struct {
+	key        uint64
+	expiration time.Time
+	index      int // Position in the queue
+}
+
+// diskQueue is a heap of entries with the soonest to expire first. Entries
+// that never expire come last.
+type diskQueue []*diskEntry
+
+func (q diskQueue) Len() int { return len(q) }
+
+func (q diskQueue) Less(i, j int) bool {
+	a, b := q[i].expiration, q[j].expiration
+	if a.IsZero() || b.IsZero() {
+		return !a.IsZero() && b.IsZero()
+	}
+	return a.Before(b)
+}
+
+func (q diskQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *diskQueue) Push(x any) {
+	entry := x.(*diskEntry)
+	entry.index = len(*q)
+	*q = append(*q, entry)
+}
+
+func (q *diskQueue) Pop() any {
+	old := *q
+	entry := old[len(old)-1]
+	old[len(old)-1] = nil
+	*q = old[:len(old)-1]
+	return entry
+}
+
+// NewDiskAdapter opens the cache in dir, creating the directory if needed,
+// and indexes the unexpired entries left by a previous run. Expired entries
+// and stray files are removed. capacity is the maximum number of entries.
+func NewDiskAdapter(dir string, capacity int) (*DiskAdapter, error) {
+	if capacity < 1 {
+		return nil, errors.New("disk cache capacity must be at least 1")
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("create cache directory: %w", err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read cache directory: %w", err)
+	}
+
+	a := &DiskAdapter{
+		dir:      dir,
+		capacity: capacity,
+		entries:  make(map[uint64]*diskEntry),
+		urls:     make(map[uint64]string),
+	}
+	now := time.Now()
+	var urlFiles []string
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		if strings.HasSuffix(entry.Name(), urlSuffix) {
+			urlFiles = append(urlFiles, entry.Name())
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		key, err := strconv.ParseUint(entry.Name(), 16, 64)
+		if err != nil {
+			// Temporary files from an interrupted write.
+			_ = os.Remove(path)
+			continue
+		}
+		expiration, err := readExpiration(path)
+		if err != nil || expired(expiration, now) {
+			_ = os.Remove(path)
+			continue
+		}
+		a.add(key, expiration)
+	}
+	for _, name := range urlFiles {
+		path := filepath.Join(dir, name)
+		key, err := strconv.ParseUint(strings.TrimSuffix(name, urlSuffix), 16, 64)
+		if _, ok := a.entries[key]; err != nil || !ok {
+			_ = os.Remove(path)
+			continue
+		}
+		if url, err := os.ReadFile(path); err == nil {
+			a.urls[key] = string(url)
+		}
+	}
+	a.evict()
+	return a, nil
+}
+
+// Get implements cache.Adapter. The file is read without holding the lock, so
+// an entry that is released or evicted meanwhile is a miss.
+func (a *DiskAdapter) Get(key uint64) ([]byte, bool) {
+	a.mu.Lock()
+	entry, ok := a.entries[key]
+	if ok && expired(entry.expiration, time.Now()) {
+		a.remove(key)
+		ok = false
+	}
+	a.mu.Unlock()
+	if !ok {
+		return nil, false
+	}
+
+	data, err := os.ReadFile(a.path(key))
+	if err == nil && len(data) >= diskHeaderSize {
+		return data[diskHeaderSize:], true
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, false
+	}
+	if err == nil {
+		err = errors.New("cached response is truncated")
+	}
+	slog.Warn("error reading cached response", "error", err)
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	// Leave an entry that was replaced while the file was read.
+	if a.entries[key] == entry {
+		a.remove(key)
+	}
+	return nil, false
+}
+
+// Set implements cache.Adapter. The response is written to a temporary file
+// and renamed into place so that readers never see a partial entry.
+func (a *DiskAdapter) Set(key uint64, response []byte, expiration time.Time) {
+	data := make([]byte, diskHeaderSize+len(response))
+	if !expiration.IsZero() {
+		binary.BigEndian.PutUint64(data, uint64(expiration.UnixNano()))
+	}
+	copy(data[diskHeaderSize:], response)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err := a.write(a.path(key), data); err != nil {
+		slog.Warn("error writing cached response", "error", err)
+		return
+	}
+	a.add(key, expiration)
+	a.evict()
+}
+
+// AddURL implements Index.
+func (a *DiskAdapter) AddURL(key uint64, url string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, ok := a.entries[key]; !ok {
+		return
+	}
+	if err := a.write(a.urlPath(key), []byte(url)); err != nil {
+		slog.Warn("error writing cached response URL", "error", err)
+		return
+	}
+	a.urls[key] = url
+}
+
+// RemoveURL implements Index. The URL is removed along with the response, so
+// there is nothing more to do.
+func (a *DiskAdapter) RemoveURL(uint64) {}
+
+// URLs implements Index.
+func (a *DiskAdapter) URLs() map[uint64]string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	urls := make(map[uint64]string, len(a.urls))
+	for key, url := range a.urls {
+		urls[key] = url
+	}
+	return urls
+}
+
+// Touch implements cache.AdapterTouch. Access times are not tracked, so hits
+// do not rewrite the entry.
+func (a *DiskAdapter) Touch(uint64) {}
+
+// Release implements cache.Adapter.
+func (a *DiskAdapter) Release(key uint64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.remove(key)
+}
+
+func (a *DiskAdapter) write(path string, data []byte) error {
+	tmp, err := os.CreateTemp(a.dir, "tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// add indexes an entry, replacing any earlier entry for the key. It is called
+// with a.mu held.
+func (a *DiskAdapter) add(key uint64, expiration time.Time) {
+	if entry, ok := a.entries[key]; ok {
+		heap.Remove(&a.queue, entry.index)
+	}
+	entry := &diskEntry{key: key, expiration: expiration}
+	heap.Push(&a.queue, entry)
+	a.entries[key] = entry
+}
+
+// evict removes the entries closest to expiring until the cache is within
+// its capacity. Entries that never expire go last. It is called with a.mu
+// held.
+func (a *DiskAdapter) evict() {
+	for len(a.entries) > a.capacity {
+		a.remove(a.queue[0].key)
+	}
+}
+
+// remove deletes an entry and its URL. It is called with a.mu held.
+func (a *DiskAdapter) remove(key uint64) {
+	if entry, ok := a.entries[key]; ok {
+		heap.Remove(&a.queue, entry.index)
+		delete(a.entries, key)
+	}
+	delete(a.urls, key)
+	for _, path := range []string{a.path(key), a.urlPath(key)} {
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			slog.Warn("error removing cached response", "error", err)
+		}
+	}
+}
+
+func (a *DiskAdapter) path(key uint64) string {
+	return filepath.Join(a.dir, fmt.Sprintf("%016x", key))
+}
+
+func (a *DiskAdapter) urlPath(key uint64) string {
+	return a.path(key) + urlSuffix
+}
+
+func readExpiration(path string) (time.Time, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer f.Close()
+	var header [diskHeaderSize]byte
+	if _, err := f.Read(header[:]); err != nil {
+		return time.Time{}, err
+	}
+	nanos := binary.BigEndian.Uint64(header[:])
+	if nanos == 0 {
+		return time.Time{}, nil
+	}
+	return time.Unix(0, int64(nanos)), nil
+}
+
+// expired reports whether an entry with the given expiration has expired. A
+// zero expiration never expires.
+func expired(expiration, now time.Time) bool {
+	return !expiration.IsZero() && !expiration.After(now)
+}
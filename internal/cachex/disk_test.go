@@ -0,0 +1,185 @@
+package cachex
+
+import (
+	"os"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDiskAdapterSurvivesReopen(t *testing.T) {
+	dir := t.TempDir()
+	a, err := NewDiskAdapter(dir, 10)
+	if err != nil {
+		t.Fatalf("NewDiskAdapter() error = %v", err)
+	}
+	a.Set(1, []byte("kept"), time.Now().Add(time.Hour))
+	a.Set(2, []byte("forever"), time.Time{})
+	a.Set(3, []byte("expired"), time.Now().Add(-time.Second))
+	a.Set(4, []byte("released"), time.Now().Add(time.Hour))
+	a.Release(4)
+
+	reopened, err := NewDiskAdapter(dir, 10)
+	if err != nil {
+		t.Fatalf("reopen: NewDiskAdapter() error = %v", err)
+	}
+	for key, want := range map[uint64]string{1: "kept", 2: "forever"} {
+		got, ok := reopened.Get(key)
+		if !ok || string(got) != want {
+			t.Errorf("Get(%d) = %q, %t, want %q", key, got, ok, want)
+		}
+	}
+	for _, key := range []uint64{3, 4} {
+		if got, ok := reopened.Get(key); ok {
+			t.Errorf("Get(%d) = %q, want a miss", key, got)
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("cache directory holds %d files, want 2", len(entries))
+	}
+}
+
+func TestDiskAdapterEvictsSoonestToExpire(t *testing.T) {
+	a, err := NewDiskAdapter(t.TempDir(), 2)
+	if err != nil {
+		t.Fatalf("NewDiskAdapter() error = %v", err)
+	}
+	now := time.Now()
+	a.Set(1, []byte("later"), now.Add(2*time.Hour))
+	a.Set(2, []byte("soon"), now.Add(time.Hour))
+	a.Set(3, []byte("latest"), now.Add(3*time.Hour))
+
+	if _, ok := a.Get(2); ok {
+		t.Error("Get(2) hit, want the entry evicted")
+	}
+	for _, key := range []uint64{1, 3} {
+		if _, ok := a.Get(key); !ok {
+			t.Errorf("Get(%d) missed, want a hit", key)
+		}
+	}
+}
+
+func TestDiskAdapterRemovesStrayFiles(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(dir+"/tmp-123", []byte("partial"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewDiskAdapter(dir, 10); err != nil {
+		t.Fatalf("NewDiskAdapter() error = %v", err)
+	}
+	if _, err := os.Stat(dir + "/tmp-123"); !os.IsNotExist(err) {
+		t.Errorf("stray file was kept: %v", err)
+	}
+}
+
+func TestDiskAdapterIndexSurvivesReopen(t *testing.T) {
+	dir := t.TempDir()
+	a, err := NewDiskAdapter(dir, 10)
+	if err != nil {
+		t.Fatalf("NewDiskAdapter() error = %v", err)
+	}
+	a.Set(1, []byte("kept"), time.Time{})
+	a.AddURL(1, "/bom/bills?year=1665")
+	a.Set(2, []byte("released"), time.Time{})
+	a.AddURL(2, "/bom/parishes")
+	a.Release(2)
+	a.AddURL(3, "/never/stored")
+
+	reopened, err := NewDiskAdapter(dir, 10)
+	if err != nil {
+		t.Fatalf("reopen: NewDiskAdapter() error = %v", err)
+	}
+	want := map[uint64]string{1: "/bom/bills?year=1665"}
+	if got := reopened.URLs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("URLs() = %v, want %v", got, want)
+	}
+}
+
+func TestDiskAdapterMissesVanishedFiles(t *testing.T) {
+	a, err := NewDiskAdapter(t.TempDir(), 10)
+	if err != nil {
+		t.Fatalf("NewDiskAdapter() error = %v", err)
+	}
+	a.Set(1, []byte("vanished"), time.Time{})
+	if err := os.Remove(a.path(1)); err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := a.Get(1); ok {
+		t.Errorf("Get(1) = %q, want a miss", got)
+	}
+
+	a.Set(1, []byte("restored"), time.Time{})
+	if got, ok := a.Get(1); !ok || string(got) != "restored" {
+		t.Errorf("Get(1) = %q, %t, want restored", got, ok)
+	}
+}
+
+func TestDiskAdapterReadsConcurrently(t *testing.T) {
+	a, err := NewDiskAdapter(t.TempDir(), 4)
+	if err != nil {
+		t.Fatalf("NewDiskAdapter() error = %v", err)
+	}
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range 50 {
+				key := uint64((i + j) % 6)
+				switch j % 3 {
+				case 0:
+					a.Set(key, []byte("response"), time.Now().Add(time.Hour))
+				case 1:
+					if got, ok := a.Get(key); ok && string(got) != "response" {
+						t.Errorf("Get(%d) = %q, want response", key, got)
+					}
+				case 2:
+					a.Release(key)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestDiskAdapterEvictsInExpirationOrder(t *testing.T) {
+	a, err := NewDiskAdapter(t.TempDir(), 3)
+	if err != nil {
+		t.Fatalf("NewDiskAdapter() error = %v", err)
+	}
+	now := time.Now()
+	a.Set(1, []byte("forever"), time.Time{})
+	a.Set(2, []byte("later"), now.Add(3*time.Hour))
+	a.Set(3, []byte("soon"), now.Add(2*time.Hour))
+	// Replacing an entry moves it in the eviction order.
+	a.Set(2, []byte("sooner"), now.Add(time.Hour))
+	a.Set(4, []byte("latest"), now.Add(4*time.Hour))
+	a.Set(5, []byte("forever too"), time.Time{})
+
+	for _, key := range []uint64{2, 3} {
+		if _, ok := a.Get(key); ok {
+			t.Errorf("Get(%d) hit, want the entry evicted", key)
+		}
+	}
+	for _, key := range []uint64{1, 4, 5} {
+		if _, ok := a.Get(key); !ok {
+			t.Errorf("Get(%d) missed, want a hit", key)
+		}
+	}
+
+	// Once only entries that never expire remain, any of them may go.
+	a.Set(6, []byte("forever three"), time.Time{})
+	a.Set(7, []byte("forever four"), time.Time{})
+	if _, ok := a.Get(4); ok {
+		t.Error("Get(4) hit, want the entry evicted before those that never expire")
+	}
+	if len(a.entries) != 3 || len(a.queue) != 3 {
+		t.Errorf("index holds %d entries and %d queued, want 3", len(a.entries), len(a.queue))
+	}
+}
@@ -0,0 +1,69 @@
+package cachex
+
+import (
+	"sync"
+)
+
+// An Index records the URL of each cached response. Cache keys are hashes,
+// so without it responses could not be found by URL to be purged. Backends
+// that keep responses across restarts implement Index so that the URLs are
+// kept as well; for other backends the Cache indexes URLs in memory.
+type Index interface {
+	// AddURL records that the response stored under key is for url, which is
+	// a path and query string such as /bom/bills?year=1665.
+	AddURL(key uint64, url string)
+	// RemoveURL forgets key.
+	RemoveURL(key uint64)
+	// URLs returns a snapshot of the indexed keys and their URLs. It may
+	// include keys whose responses have since expired or been evicted.
+	URLs() map[uint64]string
+}
+
+// minCompactSize is the size below which a memoryIndex is never compacted.
+const minCompactSize = 1024
+
+// memoryIndex is an Index held in memory. The backend can evict responses
+// without notice, so the index is compacted whenever it doubles in size by
+// dropping the keys that are no longer stored.
+type memoryIndex struct {
+	exists func(key uint64) bool
+
+	mu      sync.Mutex
+	urls    map[uint64]string
+	compact int // Size at which to compact next
+}
+
+func newMemoryIndex(exists func(key uint64) bool) *memoryIndex {
+	return &memoryIndex{exists: exists, urls: make(map[uint64]string), compact: minCompactSize}
+}
+
+func (x *memoryIndex) AddURL(key uint64, url string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.urls[key] = url
+	if len(x.urls) < x.compact {
+		return
+	}
+	for key := range x.urls {
+		if !x.exists(key) {
+			delete(x.urls, key)
+		}
+	}
+	x.compact = max(minCompactSize, 2*len(x.urls))
+}
+
+func (x *memoryIndex) RemoveURL(key uint64) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	delete(x.urls, key)
+}
+
+func (x *memoryIndex) URLs() map[uint64]string {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	urls := make(map[uint64]string, len(x.urls))
+	for key, url := range x.urls {
+		urls[key] = url
+	}
+	return urls
+}
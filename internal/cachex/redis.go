@@ -0,0 +1,176 @@
+package cachex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	cache "github.com/victorspringer/http-cache"
+)
+
+const (
+	// redisKeyPrefix namespaces cached responses so that the server can
+	// share a Redis database with other applications.
+	redisKeyPrefix = "apiary:cache:"
+	// redisIndexKey names the hash that maps cache keys to URLs.
+	redisIndexKey = "apiary:cache-index"
+	// redisPruneEvery is how many URLs are indexed between removals of the
+	// index entries whose responses Redis has expired or evicted.
+	redisPruneEvery = 1024
+	// redisTimeout bounds each cache operation. A slow cache server should
+	// make requests miss, not hang.
+	redisTimeout = 250 * time.Millisecond
+)
+
+// RedisAdapter stores cached responses in a server that speaks the Redis
+// protocol, so that several instances can share one cache. Redis expires
+// entries itself. The index of URLs is a hash in the same database.
+type RedisAdapter struct {
+	client *redis.Client
+	added  atomic.Int64
+}
+
+// NewRedisAdapter connects to the server at url, such as
+// redis://:password@localhost:6379/0, and checks that it answers.
+func NewRedisAdapter(ctx context.Context, url string) (*RedisAdapter, error) {
+	options, err := redis.ParseURL(url)
+	if err != nil {
+		// The parser's message can quote the URL, which may hold a password.
+		return nil, errors.New("invalid Redis URL")
+	}
+	client := redis.NewClient(options)
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("connect to the cache server: %w", err)
+	}
+	return &RedisAdapter{client: client}, nil
+}
+
+// Get implements cache.Adapter. Errors other than a missing key are logged
+// and treated as misses.
+func (a *RedisAdapter) Get(key uint64) ([]byte, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	response, err := a.client.Get(ctx, redisKey(key)).Bytes()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			slog.Warn("error reading cached response", "error", err)
+		}
+		return nil, false
+	}
+	return response, true
+}
+
+// Set implements cache.Adapter.
+func (a *RedisAdapter) Set(key uint64, response []byte, expiration time.Time) {
+	var ttl time.Duration
+	if !expiration.IsZero() {
+		if ttl = time.Until(expiration); ttl <= 0 {
+			return
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	if err := a.client.Set(ctx, redisKey(key), response, ttl).Err(); err != nil {
+		slog.Warn("error writing cached response", "error", err)
+	}
+}
+
+// AddURL implements Index.
+func (a *RedisAdapter) AddURL(key uint64, url string) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	if err := a.client.HSet(ctx, redisIndexKey, cache.KeyAsString(key), url).Err(); err != nil {
+		slog.Warn("error indexing cached response", "error", err)
+		return
+	}
+	if a.added.Add(1)%redisPruneEvery == 0 {
+		go a.prune()
+	}
+}
+
+// RemoveURL implements Index.
+func (a *RedisAdapter) RemoveURL(key uint64) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	if err := a.client.HDel(ctx, redisIndexKey, cache.KeyAsString(key)).Err(); err != nil {
+		slog.Warn("error removing cached response from the index", "error", err)
+	}
+}
+
+// URLs implements Index. It returns nil if the index cannot be read.
+func (a *RedisAdapter) URLs() map[uint64]string {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	fields, err := a.client.HGetAll(ctx, redisIndexKey).Result()
+	if err != nil {
+		slog.Warn("error reading the cache index", "error", err)
+		return nil
+	}
+	urls := make(map[uint64]string, len(fields))
+	for field, url := range fields {
+		if key, err := strconv.ParseUint(field, 36, 64); err == nil {
+			urls[key] = url
+		}
+	}
+	return urls
+}
+
+// prune removes the index entries whose responses no longer exist.
+func (a *RedisAdapter) prune() {
+	urls := a.URLs()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*redisTimeout)
+	defer cancel()
+	keys := make([]uint64, 0, len(urls))
+	exists := make([]*redis.IntCmd, 0, len(urls))
+	_, err := a.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for key := range urls {
+			keys = append(keys, key)
+			exists = append(exists, pipe.Exists(ctx, redisKey(key)))
+		}
+		return nil
+	})
+	if err != nil {
+		slog.Warn("error pruning the cache index", "error", err)
+		return
+	}
+	var gone []string
+	for i, cmd := range exists {
+		if cmd.Val() == 0 {
+			gone = append(gone, cache.KeyAsString(keys[i]))
+		}
+	}
+	if len(gone) == 0 {
+		return
+	}
+	if err := a.client.HDel(ctx, redisIndexKey, gone...).Err(); err != nil {
+		slog.Warn("error pruning the cache index", "error", err)
+	}
+}
+
+// Touch implements cache.AdapterTouch. Access times are not tracked, so hits
+// do not rewrite the entry.
+func (a *RedisAdapter) Touch(uint64) {}
+
+// Release implements cache.Adapter.
+func (a *RedisAdapter) Release(key uint64) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	if err := a.client.Del(ctx, redisKey(key)).Err(); err != nil {
+		slog.Warn("error removing cached response", "error", err)
+	}
+}
+
+// Close closes the connections to the cache server.
+func (a *RedisAdapter) Close() error {
+	return a.client.Close()
+}
+
+func redisKey(key uint64) string {
+	return redisKeyPrefix + cache.KeyAsString(key)
+}
@@ -0,0 +1,90 @@
+package cachex
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func newTestRedisAdapter(t *testing.T) (*RedisAdapter, *miniredis.Miniredis) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	a, err := NewRedisAdapter(context.Background(), "redis://"+server.Addr()+"/0")
+	if err != nil {
+		t.Fatalf("NewRedisAdapter() error = %v", err)
+	}
+	t.Cleanup(func() { a.Close() })
+	return a, server
+}
+
+func TestRedisAdapter(t *testing.T) {
+	a, server := newTestRedisAdapter(t)
+
+	a.Set(1, []byte("response"), time.Now().Add(time.Minute))
+	if got, ok := a.Get(1); !ok || string(got) != "response" {
+		t.Fatalf("Get(1) = %q, %t, want response", got, ok)
+	}
+	keys := server.Keys()
+	if len(keys) != 1 || !strings.HasPrefix(keys[0], redisKeyPrefix) {
+		t.Errorf("stored keys = %v, want one with prefix %s", keys, redisKeyPrefix)
+	}
+
+	// Redis expires entries itself.
+	server.FastForward(2 * time.Minute)
+	if _, ok := a.Get(1); ok {
+		t.Error("Get(1) hit after expiration, want a miss")
+	}
+
+	a.Set(2, []byte("response"), time.Time{})
+	a.Release(2)
+	if _, ok := a.Get(2); ok {
+		t.Error("Get(2) hit after release, want a miss")
+	}
+}
+
+func TestRedisAdapterMissesWhenServerIsDown(t *testing.T) {
+	a, server := newTestRedisAdapter(t)
+	a.Set(1, []byte("response"), time.Time{})
+	server.Close()
+
+	if _, ok := a.Get(1); ok {
+		t.Error("Get(1) hit with the server down, want a miss")
+	}
+}
+
+func TestNewRedisAdapterDoesNotEchoURL(t *testing.T) {
+	_, err := NewRedisAdapter(context.Background(), "redis://:hunter2@localhost:notaport/0")
+	if err == nil || strings.Contains(err.Error(), "hunter2") {
+		t.Fatalf("NewRedisAdapter() error = %v", err)
+	}
+}
+
+func TestRedisAdapterIndex(t *testing.T) {
+	a, server := newTestRedisAdapter(t)
+	a.Set(1, []byte("response"), time.Now().Add(time.Minute))
+	a.AddURL(1, "/bom/bills")
+	a.Set(2, []byte("response"), time.Now().Add(time.Hour))
+	a.AddURL(2, "/bom/parishes")
+
+	want := map[uint64]string{1: "/bom/bills", 2: "/bom/parishes"}
+	if got := a.URLs(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("URLs() = %v, want %v", got, want)
+	}
+
+	// Pruning drops the URLs of responses that Redis has expired.
+	server.FastForward(2 * time.Minute)
+	a.prune()
+	want = map[uint64]string{2: "/bom/parishes"}
+	if got := a.URLs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("URLs() after prune = %v, want %v", got, want)
+	}
+
+	a.RemoveURL(2)
+	if got := a.URLs(); len(got) != 0 {
+		t.Errorf("URLs() after RemoveURL = %v, want none", got)
+	}
+}
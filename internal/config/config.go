@@ -0,0 +1,567 @@
+// Package config loads and validates the server configuration.
+//
+// Settings come from, in increasing order of precedence: built-in defaults, an
+// optional YAML file, APIARY_* environment variables, and command-line flags.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"net"
+	"net/netip"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the complete server configuration.
+type Config struct {
+	Database   Database   `yaml:"database"`
+	HTTP       HTTP       `yaml:"http"`
+	TLS        TLS        `yaml:"tls"`
+	Timeouts   Timeouts   `yaml:"timeouts"`
+	CORS       CORS       `yaml:"cors"`
+	Cache      Cache      `yaml:"cache"`
+	Logging    Logging    `yaml:"logging"`
+	Tracing    Tracing    `yaml:"tracing"`
+	Datasets   Datasets   `yaml:"datasets"`
+	Admin      Admin      `yaml:"admin"`
+	RateLimit  RateLimit  `yaml:"rate_limit"`
+	Auth       Auth       `yaml:"auth"`
+	Pagination Pagination `yaml:"pagination"`
+}
+
+// Database configures the PostgreSQL connection pool.
+type Database struct {
+	URL             string   `yaml:"url"` // Secret: may contain a password
+	MaxConns        int      `yaml:"max_conns"`
+	MinConns        int      `yaml:"min_conns"`
+	MaxConnLifetime Duration `yaml:"max_conn_lifetime"`
+	MaxConnIdleTime Duration `yaml:"max_conn_idle_time"`
+	ConnectTimeout  Duration `yaml:"connect_timeout"` // Includes retries
+	Replicas        []string `yaml:"replicas"`        // Secret: read replica connection strings, which may contain passwords
+}
+
+// HTTP configures the listener and the lifecycle of the server.
+type HTTP struct {
+	Interface       string   `yaml:"interface"`
+	Port            int      `yaml:"port"`
+	ReadTimeout     Duration `yaml:"read_timeout"`
+	WriteTimeout    Duration `yaml:"write_timeout"`
+	IdleTimeout     Duration `yaml:"idle_timeout"`
+	DrainPeriod     Duration `yaml:"drain_period"`     // How long readiness fails before shutdown begins
+	ShutdownTimeout Duration `yaml:"shutdown_timeout"` // How long in-flight requests get to finish
+}
+
+// TLS configures serving HTTPS. The server serves plain HTTP unless both
+// files are set.
+type TLS struct {
+	CertFile     string `yaml:"cert_file"`     // PEM certificate, followed by any intermediates
+	KeyFile      string `yaml:"key_file"`      // PEM private key
+	RedirectPort int    `yaml:"redirect_port"` // Plain HTTP port that redirects to HTTPS; 0 disables
+}
+
+// Enabled reports whether the server serves HTTPS.
+func (t TLS) Enabled() bool {
+	return t.CertFile != "" && t.KeyFile != ""
+}
+
+// Timeouts configures how long requests may run, by route class. Queries get
+// a matching statement_timeout.
+type Timeouts struct {
+	Default Duration            `yaml:"default"` // Routes that declare no class, mostly lookups
+	Bulk    Duration            `yaml:"bulk"`    // Routes that return long lists of records
+	Spatial Duration            `yaml:"spatial"` // Routes that build GeoJSON from PostGIS geometries
+	Routes  map[string]Duration `yaml:"routes"`  // By route template, such as /bom/shapefiles; overrides the class
+}
+
+// CORS configures which web pages may call the API from a browser.
+type CORS struct {
+	AllowedOrigins []string `yaml:"allowed_origins"` // Origins or patterns such as https://*.example.org; * allows any
+	AllowedHeaders []string `yaml:"allowed_headers"` // Request headers beyond the CORS-safelisted ones; * allows any
+	ExposedHeaders []string `yaml:"exposed_headers"` // Response headers scripts may read beyond the safelisted ones
+	MaxAge         Duration `yaml:"max_age"`         // How long browsers may cache a preflight response
+}
+
+// Cache backends.
+const (
+	CacheMemory = "memory" // In process; lost on restart
+	CacheDisk   = "disk"   // Files in Cache.Dir; survives restarts
+	CacheRedis  = "redis"  // A Redis-protocol server at Cache.RedisURL; shared by instances
+)
+
+// Cache configures the response cache.
+type Cache struct {
+	Backend  string   `yaml:"backend"`
+	Capacity int      `yaml:"capacity"` // Maximum number of cached responses; not used by redis
+	TTL      Duration `yaml:"ttl"`      // For routes that do not declare their own
+	Dir      string   `yaml:"dir"`
+	RedisURL string   `yaml:"redis_url"` // Secret: may contain a password
+}
+
+// Log formats.
+const (
+	LogJSON = "json"
+	LogText = "text"
+)
+
+// Logging configures logging.
+type Logging struct {
+	AccessLog bool     `yaml:"access_log"` // Errors and status messages are always logged
+	Format    string   `yaml:"format"`
+	Level     string   `yaml:"level"`      // debug, info, warn, or error
+	SlowQuery Duration `yaml:"slow_query"` // Queries at least this slow are logged as warnings; 0 disables
+}
+
+// SlogLevel returns the configured level. It is info if the level is invalid,
+// which Validate reports.
+func (l Logging) SlogLevel() slog.Level {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(l.Level)); err != nil {
+		return slog.LevelInfo
+	}
+	return level
+}
+
+// Trace exporters.
+const (
+	TraceNone   = "none"   // Tracing is off
+	TraceOTLP   = "otlp"   // OTLP over HTTP to Tracing.Endpoint
+	TraceStdout = "stdout" // Pretty-printed JSON on standard output, for local debugging
+)
+
+// Tracing configures OpenTelemetry tracing.
+type Tracing struct {
+	Exporter    string  `yaml:"exporter"`
+	Endpoint    string  `yaml:"endpoint"`     // OTLP/HTTP traces URL; empty uses the OTEL_EXPORTER_OTLP_* variables
+	SampleRatio float64 `yaml:"sample_ratio"` // Fraction of requests traced when the caller has not decided
+}
+
+// Datasets selects the datasets to serve.
+type Datasets struct {
+	Enabled  []string `yaml:"enabled"`  // Empty means all registered datasets
+	Disabled []string `yaml:"disabled"` // Left out even if otherwise enabled
+}
+
+// Admin configures the administrative API and the listener for operational
+// endpoints.
+type Admin struct {
+	Token     string `yaml:"token"`     // Secret; the admin API is disabled when empty
+	Interface string `yaml:"interface"` // Interface on which the admin listener listens
+	Port      int    `yaml:"port"`      // Port of the admin listener; 0 serves operational endpoints on http.port
+}
+
+// Listens reports whether operational endpoints have their own listener.
+func (a Admin) Listens() bool {
+	return a.Port != 0
+}
+
+// RateLimit configures per-client rate limits. Each route class has its own
+// limit, and clients have a separate allowance for each class.
+type RateLimit struct {
+	Enabled        bool           `yaml:"enabled"`
+	Default        RateLimitClass `yaml:"default"`
+	Bulk           RateLimitClass `yaml:"bulk"`            // Routes that return large results
+	AuthFailures   RateLimitClass `yaml:"auth_failures"`   // Requests with an invalid API key, by address; applies even when not Enabled
+	TrustedProxies []string       `yaml:"trusted_proxies"` // Addresses or CIDR prefixes whose X-Forwarded-For is believed
+	Exempt         []string       `yaml:"exempt"`          // Addresses or CIDR prefixes that are never limited
+}
+
+// RateLimitClass is the limit for one class of routes.
+type RateLimitClass struct {
+	PerMinute int `yaml:"per_minute"` // Sustained requests per minute
+	Burst     int `yaml:"burst"`      // Requests allowed at once after a quiet period
+}
+
+// Auth configures the API keys that unlock restricted datasets.
+type Auth struct {
+	Keys     []APIKey `yaml:"keys"`
+	Database bool     `yaml:"database"` // Also look up keys in the apiary.api_keys table
+}
+
+// APIKey is an API key listed in the configuration file.
+type APIKey struct {
+	Name     string   `yaml:"name"`
+	Hash     string   `yaml:"hash"`     // Hex SHA-256 digest of the key; the key itself is never stored
+	Datasets []string `yaml:"datasets"` // Datasets the key may read; * for all
+}
+
+// Pagination configures the cursors in the links between pages of lists.
+type Pagination struct {
+	CursorSecret string `yaml:"cursor_secret"` // Secret that signs cursors; empty derives one from Database.URL
+}
+
+// minSecretLength is the shortest admin token or cursor secret accepted, so
+// that neither can be guessed.
+const minSecretLength = 32
+
+// Default returns the configuration used when nothing is set.
+func Default() Config {
+	return Config{
+		Database: Database{
+			MaxConns:        max(4, runtime.NumCPU()),
+			MaxConnLifetime: Duration{time.Hour},
+			MaxConnIdleTime: Duration{30 * time.Minute},
+			ConnectTimeout:  Duration{60 * time.Second},
+		},
+		HTTP: HTTP{
+			Interface:       "0.0.0.0",
+			Port:            8090,
+			ReadTimeout:     Duration{15 * time.Second},
+			WriteTimeout:    Duration{15 * time.Second},
+			IdleTimeout:     Duration{60 * time.Second},
+			DrainPeriod:     Duration{5 * time.Second},
+			ShutdownTimeout: Duration{8 * time.Second},
+		},
+		Timeouts: Timeouts{
+			Default: Duration{10 * time.Second},
+			Bulk:    Duration{30 * time.Second},
+			Spatial: Duration{60 * time.Second},
+		},
+		CORS: CORS{
+			AllowedOrigins: []string{"*"},
+			AllowedHeaders: []string{"Authorization", "If-Modified-Since", "If-None-Match", "X-Request-ID"},
+			ExposedHeaders: []string{"ETag", "X-Request-ID", "RateLimit-Limit", "RateLimit-Policy", "RateLimit-Remaining", "RateLimit-Reset", "Retry-After", "Link", "X-Next-Cursor"},
+			MaxAge:         Duration{time.Hour},
+		},
+		Cache: Cache{
+			Backend:  CacheMemory,
+			Capacity: 1_000_000,
+			TTL:      Duration{time.Hour},
+		},
+		Logging: Logging{
+			AccessLog: true,
+			Format:    LogJSON,
+			Level:     "info",
+			SlowQuery: Duration{time.Second},
+		},
+		Tracing: Tracing{
+			Exporter:    TraceNone,
+			SampleRatio: 1,
+		},
+		Admin: Admin{
+			Interface: "127.0.0.1",
+		},
+		RateLimit: RateLimit{
+			Default: RateLimitClass{PerMinute: 300, Burst: 60},
+			Bulk:    RateLimitClass{PerMinute: 30, Burst: 10},
+			// A client that mistypes its key can retry, but guessing keys
+			// costs a database lookup each.
+			AuthFailures: RateLimitClass{PerMinute: 10, Burst: 20},
+		},
+	}
+}
+
+// Address is the host:port at which the server listens.
+func (c Config) Address() string {
+	return net.JoinHostPort(c.HTTP.Interface, strconv.Itoa(c.HTTP.Port))
+}
+
+// RedirectAddress is the host:port at which the server redirects plain HTTP
+// to HTTPS.
+func (c Config) RedirectAddress() string {
+	return net.JoinHostPort(c.HTTP.Interface, strconv.Itoa(c.TLS.RedirectPort))
+}
+
+// AdminAddress is the host:port at which the admin listener listens.
+func (c Config) AdminAddress() string {
+	return net.JoinHostPort(c.Admin.Interface, strconv.Itoa(c.Admin.Port))
+}
+
+// PoolConfig returns the pgxpool configuration for the database settings.
+func (d Database) PoolConfig() (*pgxpool.Config, error) {
+	cfg, err := pgxpool.ParseConfig(d.URL)
+	if err != nil {
+		return nil, err
+	}
+	cfg.MaxConns = int32(d.MaxConns)
+	cfg.MinConns = int32(d.MinConns)
+	cfg.MaxConnLifetime = d.MaxConnLifetime.Duration
+	cfg.MaxConnIdleTime = d.MaxConnIdleTime.Duration
+	return cfg, nil
+}
+
+// ReplicaPoolConfigs returns the pgxpool configurations of the read replicas,
+// sized like the primary's pool.
+func (d Database) ReplicaPoolConfigs() ([]*pgxpool.Config, error) {
+	configs := make([]*pgxpool.Config, len(d.Replicas))
+	for i, replica := range d.Replicas {
+		replicaSettings := d
+		replicaSettings.URL = replica
+		cfg, err := replicaSettings.PoolConfig()
+		if err != nil {
+			return nil, fmt.Errorf("database.replicas[%d]: %w", i, err)
+		}
+		configs[i] = cfg
+	}
+	return configs, nil
+}
+
+// Validate reports every invalid setting, naming each by its key in the
+// configuration file.
+func (c Config) Validate() error {
+	var errs []error
+	check := func(ok bool, key, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+		}
+	}
+
+	if c.Database.URL == "" {
+		errs = append(errs, errors.New("database.url: a PostgreSQL connection string is required; set APIARY_DB or -db"))
+	} else if _, err := pgxpool.ParseConfig(c.Database.URL); err != nil {
+		// The parser's message can quote the connection string, so it is
+		// not repeated here.
+		errs = append(errs, errors.New("database.url: not a valid PostgreSQL connection string"))
+	}
+	for i, replica := range c.Database.Replicas {
+		if _, err := pgxpool.ParseConfig(replica); err != nil {
+			errs = append(errs, fmt.Errorf("database.replicas[%d]: not a valid PostgreSQL connection string", i))
+		}
+	}
+	check(c.Database.MaxConns >= 1 && c.Database.MaxConns <= 1000, "database.max_conns", "must be between 1 and 1000, not %d", c.Database.MaxConns)
+	check(c.Database.MinConns >= 0 && c.Database.MinConns <= c.Database.MaxConns, "database.min_conns", "must be between 0 and database.max_conns, not %d", c.Database.MinConns)
+	check(c.Database.MaxConnLifetime.Duration > 0, "database.max_conn_lifetime", "must be positive")
+	check(c.Database.MaxConnIdleTime.Duration > 0, "database.max_conn_idle_time", "must be positive")
+	check(c.Database.ConnectTimeout.Duration > 0, "database.connect_timeout", "must be positive")
+
+	check(c.HTTP.Port >= 1 && c.HTTP.Port <= 65535, "http.port", "must be between 1 and 65535, not %d", c.HTTP.Port)
+	check(c.HTTP.ReadTimeout.Duration > 0, "http.read_timeout", "must be positive")
+	check(c.HTTP.WriteTimeout.Duration > 0, "http.write_timeout", "must be positive")
+	check(c.HTTP.IdleTimeout.Duration > 0, "http.idle_timeout", "must be positive")
+	check(c.HTTP.DrainPeriod.Duration >= 0, "http.drain_period", "must not be negative")
+	check(c.HTTP.ShutdownTimeout.Duration > 0, "http.shutdown_timeout", "must be positive")
+
+	check(c.TLS.CertFile != "" || c.TLS.KeyFile == "", "tls.cert_file", "is required with tls.key_file")
+	check(c.TLS.KeyFile != "" || c.TLS.CertFile == "", "tls.key_file", "is required with tls.cert_file")
+	if c.TLS.RedirectPort != 0 {
+		check(c.TLS.Enabled(), "tls.redirect_port", "requires tls.cert_file and tls.key_file")
+		check(c.TLS.RedirectPort >= 1 && c.TLS.RedirectPort <= 65535, "tls.redirect_port", "must be between 1 and 65535, not %d", c.TLS.RedirectPort)
+		check(c.TLS.RedirectPort != c.HTTP.Port, "tls.redirect_port", "must differ from http.port")
+	}
+
+	check(c.Timeouts.Default.Duration > 0, "timeouts.default", "must be positive")
+	check(c.Timeouts.Bulk.Duration > 0, "timeouts.bulk", "must be positive")
+	check(c.Timeouts.Spatial.Duration > 0, "timeouts.spatial", "must be positive")
+	for _, template := range slices.Sorted(maps.Keys(c.Timeouts.Routes)) {
+		check(strings.HasPrefix(template, "/"), "timeouts.routes", "%q is not a route template", template)
+		check(c.Timeouts.Routes[template].Duration > 0, "timeouts.routes", "%s must be positive", template)
+	}
+
+	for _, origin := range c.CORS.AllowedOrigins {
+		check(validOrigin(origin), "cors.allowed_origins", "%q is not * or an origin such as https://example.org or https://*.example.org", origin)
+	}
+	for _, list := range []struct {
+		key    string
+		values []string
+	}{{"cors.allowed_headers", c.CORS.AllowedHeaders}, {"cors.exposed_headers", c.CORS.ExposedHeaders}} {
+		for _, value := range list.values {
+			check(headerName.MatchString(value), list.key, "%q is not a header name", value)
+		}
+	}
+	check(c.CORS.MaxAge.Duration >= 0, "cors.max_age", "must not be negative")
+
+	switch c.Cache.Backend {
+	case CacheMemory, CacheDisk, CacheRedis:
+	default:
+		check(false, "cache.backend", "must be %s, %s, or %s, not %q", CacheMemory, CacheDisk, CacheRedis, c.Cache.Backend)
+	}
+	check(c.Cache.Capacity >= 1, "cache.capacity", "must be at least 1, not %d", c.Cache.Capacity)
+	check(c.Cache.TTL.Duration > 0, "cache.ttl", "must be positive")
+	check(c.Cache.Backend != CacheDisk || c.Cache.Dir != "", "cache.dir", "is required for the disk backend; set APIARY_CACHE_DIR or -cache-dir")
+	if c.Cache.Backend == CacheRedis {
+		if c.Cache.RedisURL == "" {
+			errs = append(errs, errors.New("cache.redis_url: is required for the redis backend; set APIARY_CACHE_REDIS_URL or -cache-redis-url"))
+		} else if u, err := url.Parse(c.Cache.RedisURL); err != nil || (u.Scheme != "redis" && u.Scheme != "rediss") {
+			errs = append(errs, errors.New("cache.redis_url: must be a redis:// or rediss:// URL"))
+		}
+	}
+
+	check(c.Logging.Format == LogJSON || c.Logging.Format == LogText, "logging.format", "must be %s or %s, not %q", LogJSON, LogText, c.Logging.Format)
+	var level slog.Level
+	check(level.UnmarshalText([]byte(c.Logging.Level)) == nil, "logging.level", "must be debug, info, warn, or error, not %q", c.Logging.Level)
+	check(c.Logging.SlowQuery.Duration >= 0, "logging.slow_query", "must not be negative")
+
+	switch c.Tracing.Exporter {
+	case TraceNone, TraceOTLP, TraceStdout:
+	default:
+		check(false, "tracing.exporter", "must be %s, %s, or %s, not %q", TraceNone, TraceOTLP, TraceStdout, c.Tracing.Exporter)
+	}
+	if c.Tracing.Endpoint != "" {
+		u, err := url.Parse(c.Tracing.Endpoint)
+		check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "", "tracing.endpoint", "must be an http:// or https:// URL, such as http://localhost:4318/v1/traces")
+	}
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "tracing.sample_ratio", "must be between 0 and 1, not %g", c.Tracing.SampleRatio)
+
+	check(c.Admin.Token == "" || len(c.Admin.Token) >= minSecretLength, "admin.token", "must be at least %d characters", minSecretLength)
+	if c.Admin.Listens() {
+		check(c.Admin.Port >= 1 && c.Admin.Port <= 65535, "admin.port", "must be between 1 and 65535, not %d", c.Admin.Port)
+		check(c.Admin.Port != c.HTTP.Port && c.Admin.Port != c.TLS.RedirectPort, "admin.port", "must differ from http.port and tls.redirect_port")
+		check(c.Admin.Interface != "", "admin.interface", "is required with admin.port")
+	}
+
+	for _, class := range []struct {
+		key   string
+		limit RateLimitClass
+	}{{"rate_limit.default", c.RateLimit.Default}, {"rate_limit.bulk", c.RateLimit.Bulk}, {"rate_limit.auth_failures", c.RateLimit.AuthFailures}} {
+		check(class.limit.PerMinute >= 1, class.key+".per_minute", "must be at least 1, not %d", class.limit.PerMinute)
+		check(class.limit.Burst >= 1, class.key+".burst", "must be at least 1, not %d", class.limit.Burst)
+	}
+	for _, list := range []struct {
+		key    string
+		values []string
+	}{{"rate_limit.trusted_proxies", c.RateLimit.TrustedProxies}, {"rate_limit.exempt", c.RateLimit.Exempt}} {
+		for _, value := range list.values {
+			check(validPrefix(value), list.key, "%q is not an IP address or CIDR prefix", value)
+		}
+	}
+
+	check(c.Pagination.CursorSecret == "" || len(c.Pagination.CursorSecret) >= minSecretLength, "pagination.cursor_secret", "must be at least %d characters", minSecretLength)
+
+	names := make(map[string]bool)
+	for i, key := range c.Auth.Keys {
+		prefix := fmt.Sprintf("auth.keys[%d]", i)
+		check(key.Name != "", prefix+".name", "is required")
+		check(!names[key.Name], prefix+".name", "%q is used by another key", key.Name)
+		names[key.Name] = true
+		check(sha256Hex.MatchString(key.Hash), prefix+".hash", "must be the 64-digit hex SHA-256 digest of the key; see apiary key generate")
+		check(len(key.Datasets) > 0, prefix+".datasets", "must list at least one dataset, or * for all")
+	}
+
+	return errors.Join(errs...)
+}
+
+// sha256Hex matches a hex SHA-256 digest.
+var sha256Hex = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
+
+// headerName matches an HTTP header name.
+var headerName = regexp.MustCompile("^[!#$%&'*+.^_`|~0-9A-Za-z-]+$")
+
+// validOrigin reports whether s is *, or a scheme and host with an optional
+// port, any of which may contain * wildcards.
+func validOrigin(s string) bool {
+	if s == "*" {
+		return true
+	}
+	if _, err := path.Match(s, ""); err != nil {
+		return false
+	}
+	u, err := url.Parse(strings.ReplaceAll(s, "*", "0"))
+	return err == nil && u.Scheme != "" && u.Host != "" && u.User == nil && u.Path == "" && u.RawQuery == "" && u.Fragment == ""
+}
+
+// validPrefix reports whether s is an IP address or a CIDR prefix.
+func validPrefix(s string) bool {
+	if _, err := netip.ParsePrefix(s); err == nil {
+		return true
+	}
+	_, err := netip.ParseAddr(s)
+	return err == nil
+}
+
+// Redacted returns a copy of c with secrets masked, for display.
+func (c Config) Redacted() Config {
+	c.Database.URL = redactConnString(c.Database.URL)
+	c.Cache.RedisURL = redactConnString(c.Cache.RedisURL)
+	c.Database.Replicas = append([]string(nil), c.Database.Replicas...)
+	for i, replica := range c.Database.Replicas {
+		c.Database.Replicas[i] = redactConnString(replica)
+	}
+	if c.Admin.Token != "" {
+		c.Admin.Token = redacted
+	}
+	if c.Pagination.CursorSecret != "" {
+		c.Pagination.CursorSecret = redacted
+	}
+	c.Datasets.Enabled = append([]string(nil), c.Datasets.Enabled...)
+	c.Datasets.Disabled = append([]string(nil), c.Datasets.Disabled...)
+	c.RateLimit.TrustedProxies = append([]string(nil), c.RateLimit.TrustedProxies...)
+	c.RateLimit.Exempt = append([]string(nil), c.RateLimit.Exempt...)
+	c.Auth.Keys = append([]APIKey(nil), c.Auth.Keys...)
+	c.CORS.AllowedOrigins = append([]string(nil), c.CORS.AllowedOrigins...)
+	c.CORS.AllowedHeaders = append([]string(nil), c.CORS.AllowedHeaders...)
+	c.CORS.ExposedHeaders = append([]string(nil), c.CORS.ExposedHeaders...)
+	c.Timeouts.Routes = maps.Clone(c.Timeouts.Routes)
+	return c
+}
+
+const redacted = "REDACTED"
+
+// keywordPassword matches the password in a keyword/value connection string.
+var keywordPassword = regexp.MustCompile(`(password\s*=\s*)('(?:[^'\\]|\\.)*'|\S+)`)
+
+func redactConnString(connString string) string {
+	if u, err := url.Parse(connString); err == nil && u.Scheme != "" {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), redacted)
+		}
+		query := u.Query()
+		if query.Has("password") {
+			query.Set("password", redacted)
+			u.RawQuery = query.Encode()
+		}
+		return u.String()
+	}
+	return keywordPassword.ReplaceAllString(connString, "${1}"+redacted)
+}
+
+// YAML encodes c in the configuration file format.
+func (c Config) YAML() ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(c); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// readFile overlays the settings in a YAML file onto c. Unknown keys are
+// errors so that misspelled settings are not silently ignored.
+func (c *Config) readFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
+
+// Duration is a time.Duration written as a string such as 15s or 1h30m in
+// configuration files, environment variables, and flags.
+type Duration struct {
+	time.Duration
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	duration, err := time.ParseDuration(string(text))
+	if err != nil {
+		return fmt.Errorf("invalid duration %q; use a value such as 15s or 1h30m", text)
+	}
+	d.Duration = duration
+	return nil
+}
@@ -0,0 +1,228 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ConfigFileEnv names the environment variable that points to a configuration
+// file, as an alternative to the -config flag.
+const ConfigFileEnv = "APIARY_CONFIG"
+
+// setting binds a configuration field to a flag and an environment variable.
+type setting struct {
+	flag  string
+	env   string
+	usage string
+	value func(*Config) flag.Value
+}
+
+// settings lists every setting that flags and environment variables can set.
+// Each has a key in the configuration file as well.
+var settings = []setting{
+	{"db", "APIARY_DB", "PostgreSQL connection string", func(c *Config) flag.Value { return (*stringValue)(&c.Database.URL) }},
+	{"db-max-conns", "APIARY_DB_MAX_CONNS", "maximum database connections", func(c *Config) flag.Value { return (*intValue)(&c.Database.MaxConns) }},
+	{"db-min-conns", "APIARY_DB_MIN_CONNS", "database connections kept open when idle", func(c *Config) flag.Value { return (*intValue)(&c.Database.MinConns) }},
+	{"db-max-conn-lifetime", "APIARY_DB_MAX_CONN_LIFETIME", "age at which database connections are replaced", func(c *Config) flag.Value { return &c.Database.MaxConnLifetime }},
+	{"db-max-conn-idle-time", "APIARY_DB_MAX_CONN_IDLE_TIME", "idle time after which database connections are closed", func(c *Config) flag.Value { return &c.Database.MaxConnIdleTime }},
+	{"db-connect-timeout", "APIARY_DB_CONNECT_TIMEOUT", "time allowed to connect to the database at startup, including retries", func(c *Config) flag.Value { return &c.Database.ConnectTimeout }},
+	{"db-replicas", "APIARY_DB_REPLICAS", "comma-separated connection strings of read replicas for dataset queries", func(c *Config) flag.Value { return (*listValue)(&c.Database.Replicas) }},
+	{"interface", "APIARY_INTERFACE", "interface on which the HTTP server listens", func(c *Config) flag.Value { return (*stringValue)(&c.HTTP.Interface) }},
+	{"port", "APIARY_PORT", "HTTP port", func(c *Config) flag.Value { return (*intValue)(&c.HTTP.Port) }},
+	{"read-timeout", "APIARY_READ_TIMEOUT", "time allowed to read a request", func(c *Config) flag.Value { return &c.HTTP.ReadTimeout }},
+	{"write-timeout", "APIARY_WRITE_TIMEOUT", "time allowed to write a response", func(c *Config) flag.Value { return &c.HTTP.WriteTimeout }},
+	{"idle-timeout", "APIARY_IDLE_TIMEOUT", "time an idle keep-alive connection is kept open", func(c *Config) flag.Value { return &c.HTTP.IdleTimeout }},
+	{"drain-period", "APIARY_DRAIN_PERIOD", "how long /readyz fails before shutdown begins", func(c *Config) flag.Value { return &c.HTTP.DrainPeriod }},
+	{"shutdown-timeout", "APIARY_SHUTDOWN_TIMEOUT", "time in-flight requests get to finish during shutdown", func(c *Config) flag.Value { return &c.HTTP.ShutdownTimeout }},
+	{"tls-cert", "APIARY_TLS_CERT", "PEM certificate file; serves HTTPS when set with -tls-key", func(c *Config) flag.Value { return (*stringValue)(&c.TLS.CertFile) }},
+	{"tls-key", "APIARY_TLS_KEY", "PEM private key file for -tls-cert", func(c *Config) flag.Value { return (*stringValue)(&c.TLS.KeyFile) }},
+	{"tls-redirect-port", "APIARY_TLS_REDIRECT_PORT", "plain HTTP port that redirects to HTTPS; 0 disables", func(c *Config) flag.Value { return (*intValue)(&c.TLS.RedirectPort) }},
+	{"timeout", "APIARY_TIMEOUT", "time limit of routes that declare no class, mostly lookups", func(c *Config) flag.Value { return &c.Timeouts.Default }},
+	{"bulk-timeout", "APIARY_BULK_TIMEOUT", "time limit of routes that return long lists", func(c *Config) flag.Value { return &c.Timeouts.Bulk }},
+	{"spatial-timeout", "APIARY_SPATIAL_TIMEOUT", "time limit of routes that build GeoJSON", func(c *Config) flag.Value { return &c.Timeouts.Spatial }},
+	{"cors-origins", "APIARY_CORS_ORIGINS", "comma-separated origins that browsers may call the API from, such as https://*.example.org; * allows any", func(c *Config) flag.Value { return (*listValue)(&c.CORS.AllowedOrigins) }},
+	{"cors-headers", "APIARY_CORS_HEADERS", "comma-separated request headers that cross-origin callers may send", func(c *Config) flag.Value { return (*listValue)(&c.CORS.AllowedHeaders) }},
+	{"cors-expose-headers", "APIARY_CORS_EXPOSE_HEADERS", "comma-separated response headers that cross-origin scripts may read", func(c *Config) flag.Value { return (*listValue)(&c.CORS.ExposedHeaders) }},
+	{"cors-max-age", "APIARY_CORS_MAX_AGE", "how long browsers may cache a preflight response", func(c *Config) flag.Value { return &c.CORS.MaxAge }},
+	{"cache-backend", "APIARY_CACHE_BACKEND", "where responses are cached: memory, disk, or redis", func(c *Config) flag.Value { return (*stringValue)(&c.Cache.Backend) }},
+	{"cache-capacity", "APIARY_CACHE_CAPACITY", "maximum number of cached responses", func(c *Config) flag.Value { return (*intValue)(&c.Cache.Capacity) }},
+	{"cache-ttl", "APIARY_CACHE_TTL", "how long responses are cached when their route does not say", func(c *Config) flag.Value { return &c.Cache.TTL }},
+	{"cache-dir", "APIARY_CACHE_DIR", "directory for the disk cache", func(c *Config) flag.Value { return (*stringValue)(&c.Cache.Dir) }},
+	{"cache-redis-url", "APIARY_CACHE_REDIS_URL", "URL of the Redis server for the redis cache", func(c *Config) flag.Value { return (*stringValue)(&c.Cache.RedisURL) }},
+	{"logging", "APIARY_LOGGING", "write access logs (on or off)", func(c *Config) flag.Value { return (*onOffValue)(&c.Logging.AccessLog) }},
+	{"log-format", "APIARY_LOG_FORMAT", "log format: json or text", func(c *Config) flag.Value { return (*stringValue)(&c.Logging.Format) }},
+	{"log-level", "APIARY_LOG_LEVEL", "lowest level logged: debug, info, warn, or error", func(c *Config) flag.Value { return (*stringValue)(&c.Logging.Level) }},
+	{"slow-query", "APIARY_SLOW_QUERY", "log database queries at least this slow as warnings; 0 disables", func(c *Config) flag.Value { return &c.Logging.SlowQuery }},
+	{"tracing", "APIARY_TRACING", "trace exporter: none, otlp, or stdout", func(c *Config) flag.Value { return (*stringValue)(&c.Tracing.Exporter) }},
+	{"tracing-endpoint", "APIARY_TRACING_ENDPOINT", "OTLP/HTTP traces URL, such as http://localhost:4318/v1/traces", func(c *Config) flag.Value { return (*stringValue)(&c.Tracing.Endpoint) }},
+	{"tracing-sample-ratio", "APIARY_TRACING_SAMPLE_RATIO", "fraction of requests traced, from 0 to 1", func(c *Config) flag.Value { return (*floatValue)(&c.Tracing.SampleRatio) }},
+	{"datasets", "APIARY_DATASETS", "comma-separated datasets to serve; empty serves all", func(c *Config) flag.Value { return (*listValue)(&c.Datasets.Enabled) }},
+	{"disabled-datasets", "APIARY_DISABLED_DATASETS", "comma-separated datasets to leave out", func(c *Config) flag.Value { return (*listValue)(&c.Datasets.Disabled) }},
+	{"rate-limit", "APIARY_RATE_LIMIT", "limit request rates per client (on or off)", func(c *Config) flag.Value { return (*onOffValue)(&c.RateLimit.Enabled) }},
+	{"rate-limit-per-minute", "APIARY_RATE_LIMIT_PER_MINUTE", "requests per minute each client may make to most routes", func(c *Config) flag.Value { return (*intValue)(&c.RateLimit.Default.PerMinute) }},
+	{"rate-limit-burst", "APIARY_RATE_LIMIT_BURST", "requests each client may make at once to most routes", func(c *Config) flag.Value { return (*intValue)(&c.RateLimit.Default.Burst) }},
+	{"rate-limit-bulk-per-minute", "APIARY_RATE_LIMIT_BULK_PER_MINUTE", "requests per minute each client may make to bulk routes", func(c *Config) flag.Value { return (*intValue)(&c.RateLimit.Bulk.PerMinute) }},
+	{"rate-limit-bulk-burst", "APIARY_RATE_LIMIT_BULK_BURST", "requests each client may make at once to bulk routes", func(c *Config) flag.Value { return (*intValue)(&c.RateLimit.Bulk.Burst) }},
+	{"rate-limit-auth-failures-per-minute", "APIARY_RATE_LIMIT_AUTH_FAILURES_PER_MINUTE", "requests with an invalid API key each address may make per minute", func(c *Config) flag.Value { return (*intValue)(&c.RateLimit.AuthFailures.PerMinute) }},
+	{"rate-limit-auth-failures-burst", "APIARY_RATE_LIMIT_AUTH_FAILURES_BURST", "requests with an invalid API key each address may make at once", func(c *Config) flag.Value { return (*intValue)(&c.RateLimit.AuthFailures.Burst) }},
+	{"trusted-proxies", "APIARY_TRUSTED_PROXIES", "comma-separated addresses or CIDR prefixes of proxies whose X-Forwarded-For is believed", func(c *Config) flag.Value { return (*listValue)(&c.RateLimit.TrustedProxies) }},
+	{"rate-limit-exempt", "APIARY_RATE_LIMIT_EXEMPT", "comma-separated addresses or CIDR prefixes of clients that are never limited", func(c *Config) flag.Value { return (*listValue)(&c.RateLimit.Exempt) }},
+	{"auth-database", "APIARY_AUTH_DATABASE", "also look up API keys in the apiary.api_keys table (on or off)", func(c *Config) flag.Value { return (*onOffValue)(&c.Auth.Database) }},
+	{"cursor-secret", "APIARY_CURSOR_SECRET", "secret that signs pagination cursors; set the same one on every instance", func(c *Config) flag.Value { return (*stringValue)(&c.Pagination.CursorSecret) }},
+	{"admin-token", "APIARY_ADMIN_TOKEN", "bearer token for the admin API; empty disables it", func(c *Config) flag.Value { return (*stringValue)(&c.Admin.Token) }},
+	{"admin-interface", "APIARY_ADMIN_INTERFACE", "interface on which the admin listener listens", func(c *Config) flag.Value { return (*stringValue)(&c.Admin.Interface) }},
+	{"admin-port", "APIARY_ADMIN_PORT", "port of the admin listener for probes, metrics, profiles, and the admin API; 0 serves them on the HTTP port", func(c *Config) flag.Value { return (*intValue)(&c.Admin.Port) }},
+}
+
+// newFlagSet returns a flag set whose flags write to c. The -config flag is
+// written to configFile.
+func newFlagSet(name string, c *Config, configFile *string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.StringVar(configFile, "config", "", "path to a YAML configuration file ("+ConfigFileEnv+")")
+	for _, s := range settings {
+		fs.Var(s.value(c), s.flag, fmt.Sprintf("%s (%s)", s.usage, s.env))
+	}
+	return fs
+}
+
+// Load builds the configuration from the defaults, the configuration file,
+// the environment, and the command-line arguments, in that order. It does not
+// validate the result; call Validate. Usage errors are written to output.
+func Load(name string, args []string, lookupEnv func(string) (string, bool), output io.Writer) (Config, error) {
+	// Parse the flags first to find the configuration file, remembering which
+	// flags were given so that they can be applied last.
+	scratch := Default()
+	var configFile string
+	fs := newFlagSet(name, &scratch, &configFile)
+	fs.SetOutput(output)
+	if err := fs.Parse(args); err != nil {
+		return Config{}, err
+	}
+	if fs.NArg() > 0 {
+		return Config{}, fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+	given := make(map[string]string)
+	fs.Visit(func(f *flag.Flag) {
+		given[f.Name] = f.Value.String()
+	})
+	if configFile == "" {
+		configFile, _ = lookupEnv(ConfigFileEnv)
+	}
+
+	c := Default()
+	if configFile != "" {
+		if err := c.readFile(configFile); err != nil {
+			return Config{}, fmt.Errorf("read configuration file %s: %w", configFile, err)
+		}
+	}
+
+	var ignored string
+	overrides := newFlagSet(name, &c, &ignored)
+	for _, s := range settings {
+		value, ok := lookupEnv(s.env)
+		if !ok {
+			continue
+		}
+		if err := overrides.Set(s.flag, value); err != nil {
+			return Config{}, fmt.Errorf("%s: %w", s.env, err)
+		}
+	}
+	for name, value := range given {
+		if name == "config" {
+			continue
+		}
+		if err := overrides.Set(name, value); err != nil {
+			return Config{}, fmt.Errorf("-%s: %w", name, err)
+		}
+	}
+	return c, nil
+}
+
+type stringValue string
+
+func (v *stringValue) String() string     { return string(*v) }
+func (v *stringValue) Set(s string) error { *v = stringValue(s); return nil }
+
+type intValue int
+
+func (v *intValue) String() string { return strconv.Itoa(int(*v)) }
+
+func (v *intValue) Set(s string) error {
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil {
+		return fmt.Errorf("invalid integer %q", s)
+	}
+	*v = intValue(n)
+	return nil
+}
+
+type floatValue float64
+
+func (v *floatValue) String() string { return strconv.FormatFloat(float64(*v), 'g', -1, 64) }
+
+func (v *floatValue) Set(s string) error {
+	f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return fmt.Errorf("invalid number %q", s)
+	}
+	*v = floatValue(f)
+	return nil
+}
+
+// onOffValue is a boolean that also accepts on and off, which APIARY_LOGGING
+// has always used.
+type onOffValue bool
+
+func (v *onOffValue) String() string {
+	if *v {
+		return "on"
+	}
+	return "off"
+}
+
+func (v *onOffValue) Set(s string) error {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "on":
+		*v = true
+	case "off":
+		*v = false
+	default:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("invalid value %q; use on or off", s)
+		}
+		*v = onOffValue(b)
+	}
+	return nil
+}
+
+func (v *onOffValue) IsBoolFlag() bool { return true }
+
+// listValue is a comma-separated list.
+type listValue []string
+
+func (v *listValue) String() string { return strings.Join(*v, ",") }
+
+func (v *listValue) Set(s string) error {
+	*v = splitList(s)
+	return nil
+}
+
+// splitList parses a comma-separated value, ignoring blank entries and
+// surrounding whitespace.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func (d *Duration) Set(s string) error {
+	return d.UnmarshalText([]byte(strings.TrimSpace(s)))
+}
@@ -0,0 +1,196 @@
+// Package cors lets web pages on other origins call the API from a browser. It
+// adds the Access-Control-* headers to responses for allowed origins and
+// answers the preflight OPTIONS requests browsers send before requests with
+// headers such as Authorization.
+package cors
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Any, as an allowed origin, allows every origin. As an allowed header,
+// it allows whatever headers a preflight asks for.
+const Any = "*"
+
+// Options configure a Policy.
+type Options struct {
+	// AllowedOrigins are origins such as https://example.org, or patterns in
+	// which * matches any run of characters but /, such as
+	// https://*.example.org or http://localhost:*.
+	AllowedOrigins []string
+	AllowedHeaders []string      // Request headers beyond the CORS-safelisted ones
+	ExposedHeaders []string      // Response headers that scripts may read beyond the safelisted ones
+	MaxAge         time.Duration // How long browsers may cache a preflight response; 0 leaves it to them
+}
+
+// Policy decides which origins may call the API and what they may send and
+// read.
+type Policy struct {
+	anyOrigin      bool
+	origins        []string // Lower-case patterns
+	anyHeader      bool
+	allowedHeaders string
+	exposedHeaders string
+	maxAge         string
+}
+
+// token matches an HTTP header name.
+var token = regexp.MustCompile("^[!#$%&'*+.^_`|~0-9A-Za-z-]+$")
+
+// New returns a Policy, or an error if an origin pattern or header name is
+// malformed.
+func New(options Options) (*Policy, error) {
+	p := &Policy{}
+	for _, origin := range options.AllowedOrigins {
+		if origin == Any {
+			p.anyOrigin = true
+			continue
+		}
+		if err := validOrigin(origin); err != nil {
+			return nil, err
+		}
+		p.origins = append(p.origins, strings.ToLower(origin))
+	}
+	for _, headers := range [][]string{options.AllowedHeaders, options.ExposedHeaders} {
+		for _, header := range headers {
+			if !token.MatchString(header) {
+				return nil, fmt.Errorf("%q is not a header name", header)
+			}
+		}
+	}
+	p.anyHeader = slices.Contains(options.AllowedHeaders, Any)
+	p.allowedHeaders = strings.Join(options.AllowedHeaders, ", ")
+	p.exposedHeaders = strings.Join(options.ExposedHeaders, ", ")
+	if options.MaxAge > 0 {
+		p.maxAge = strconv.Itoa(int(options.MaxAge.Seconds()))
+	}
+	return p, nil
+}
+
+// validOrigin returns an error if pattern is not a scheme and host with an
+// optional port, any of which may contain * wildcards.
+func validOrigin(pattern string) error {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return fmt.Errorf("%q is not a valid origin pattern", pattern)
+	}
+	u, err := url.Parse(strings.ReplaceAll(pattern, "*", "0"))
+	if err != nil || u.Scheme == "" || u.Host == "" || u.User != nil || u.Path != "" || u.RawQuery != "" || u.Fragment != "" {
+		return fmt.Errorf("%q is not an origin such as https://example.org", pattern)
+	}
+	return nil
+}
+
+// allowOrigin returns the Access-Control-Allow-Origin value for a request
+// from origin, and whether the origin is allowed.
+func (p *Policy) allowOrigin(origin string) (string, bool) {
+	if p.anyOrigin {
+		return Any, true
+	}
+	if origin == "" {
+		return "", false
+	}
+	origin = strings.ToLower(origin)
+	for _, pattern := range p.origins {
+		if ok, _ := path.Match(pattern, origin); ok {
+			return origin, true
+		}
+	}
+	return "", false
+}
+
+// Middleware adds the CORS headers to responses for allowed origins. When
+// only some origins are allowed, responses vary by Origin.
+func (p *Policy) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := w.Header()
+		if !p.anyOrigin {
+			header.Add("Vary", "Origin")
+		}
+		if allow, ok := p.allowOrigin(r.Header.Get("Origin")); ok {
+			header.Set("Access-Control-Allow-Origin", allow)
+			if p.exposedHeaders != "" {
+				header.Set("Access-Control-Expose-Headers", p.exposedHeaders)
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Preflight answers OPTIONS requests to the routes of router, which accept
+// only the methods they were registered with. Use it as the router's
+// MethodNotAllowedHandler, wrapping the handler for other methods; those
+// responses get an Allow header.
+//
+// Every OPTIONS request to a route gets a 204 response listing its methods.
+// A preflight from an allowed origin also gets the methods and headers it
+// may use and how long it may cache the answer; the browser refuses the
+// request if they do not cover it.
+func (p *Policy) Preflight(router *mux.Router, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods := Methods(router, r)
+		if len(methods) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		header := w.Header()
+		header.Set("Allow", strings.Join(append(methods, http.MethodOptions), ", "))
+		if r.Method != http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		header.Add("Vary", "Origin, Access-Control-Request-Method, Access-Control-Request-Headers")
+		allow, ok := p.allowOrigin(r.Header.Get("Origin"))
+		if ok && r.Header.Get("Access-Control-Request-Method") != "" {
+			header.Set("Access-Control-Allow-Origin", allow)
+			header.Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+			allowedHeaders := p.allowedHeaders
+			if p.anyHeader {
+				// A literal * does not cover Authorization, so echo the
+				// request instead.
+				allowedHeaders = r.Header.Get("Access-Control-Request-Headers")
+			}
+			if allowedHeaders != "" {
+				header.Set("Access-Control-Allow-Headers", allowedHeaders)
+			}
+			if p.maxAge != "" {
+				header.Set("Access-Control-Max-Age", p.maxAge)
+			}
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// Methods returns the methods accepted by the routes of router that match
+// r's path, in the order they were registered.
+func Methods(router *mux.Router, r *http.Request) []string {
+	var methods []string
+	probe := r.Clone(r.Context())
+	_ = router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		routeMethods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+		for _, method := range routeMethods {
+			if slices.Contains(methods, method) {
+				continue
+			}
+			probe.Method = method
+			if route.Match(probe, &mux.RouteMatch{}) {
+				methods = append(methods, method)
+			}
+		}
+		return nil
+	})
+	return methods
+}
@@ -0,0 +1,138 @@
+package cors
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestNew(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		options Options
+		want    string
+	}{
+		{"path", Options{AllowedOrigins: []string{"https://example.org/"}}, "not an origin"},
+		{"no scheme", Options{AllowedOrigins: []string{"example.org"}}, "not an origin"},
+		{"bad pattern", Options{AllowedOrigins: []string{"https://[a-"}}, "not a valid origin pattern"},
+		{"header", Options{AllowedHeaders: []string{"X Api Key"}}, "not a header name"},
+		{"exposed header", Options{ExposedHeaders: []string{"ETag:"}}, "not a header name"},
+	} {
+		if _, err := New(tt.options); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: New() error = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	policy, err := New(Options{
+		AllowedOrigins: []string{"https://*.rrchnm.org", "http://localhost:*"},
+		ExposedHeaders: []string{"RateLimit-Remaining", "X-Request-ID"},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	handler := policy.Middleware(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+	for _, tt := range []struct {
+		origin string
+		want   string
+	}{
+		{"https://datascapes.rrchnm.org", "https://datascapes.rrchnm.org"},
+		{"HTTPS://Datascapes.RRCHNM.org", "https://datascapes.rrchnm.org"},
+		{"http://localhost:5173", "http://localhost:5173"},
+		{"https://rrchnm.org", ""},
+		{"https://evil.org/.rrchnm.org", ""},
+		{"https://datascapes.rrchnm.org.evil.org", ""},
+		{"http://datascapes.rrchnm.org", ""},
+		{"", ""},
+	} {
+		request := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tt.origin != "" {
+			request.Header.Set("Origin", tt.origin)
+		}
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+		if got := response.Header().Get("Access-Control-Allow-Origin"); got != tt.want {
+			t.Errorf("origin %q: Access-Control-Allow-Origin = %q, want %q", tt.origin, got, tt.want)
+		}
+		if got := response.Header().Get("Vary"); got != "Origin" {
+			t.Errorf("origin %q: Vary = %q, want Origin", tt.origin, got)
+		}
+		exposed := response.Header().Get("Access-Control-Expose-Headers")
+		if (tt.want != "") != (exposed == "RateLimit-Remaining, X-Request-ID") {
+			t.Errorf("origin %q: Access-Control-Expose-Headers = %q", tt.origin, exposed)
+		}
+	}
+}
+
+func TestMiddlewareAllowsAnyOrigin(t *testing.T) {
+	policy, err := New(Options{AllowedOrigins: []string{Any}})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	response := httptest.NewRecorder()
+	policy.Middleware(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})).
+		ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/", nil))
+	if got := response.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want *", got)
+	}
+	if got := response.Header().Get("Vary"); got != "" {
+		t.Errorf("Vary = %q, want none", got)
+	}
+}
+
+func TestPreflightEchoesAnyHeader(t *testing.T) {
+	policy, err := New(Options{AllowedOrigins: []string{Any}, AllowedHeaders: []string{Any}})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	router := mux.NewRouter()
+	router.Handle("/items/{id:[0-9]+}", http.NotFoundHandler()).Methods(http.MethodGet)
+	handler := policy.Preflight(router, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}))
+
+	request := httptest.NewRequest(http.MethodOptions, "/items/7", nil)
+	request.Header.Set("Origin", "https://example.org")
+	request.Header.Set("Access-Control-Request-Method", "GET")
+	request.Header.Set("Access-Control-Request-Headers", "authorization, x-trace")
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, request)
+	if response.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want %d", response.Code, http.StatusNoContent)
+	}
+	if got := response.Header().Get("Access-Control-Allow-Headers"); got != "authorization, x-trace" {
+		t.Errorf("Access-Control-Allow-Headers = %q", got)
+	}
+	if got := response.Header().Get("Access-Control-Max-Age"); got != "" {
+		t.Errorf("Access-Control-Max-Age = %q, want none", got)
+	}
+}
+
+func TestMethods(t *testing.T) {
+	router := mux.NewRouter()
+	router.Handle("/items", http.NotFoundHandler()).Methods(http.MethodGet, http.MethodHead)
+	router.Handle("/items", http.NotFoundHandler()).Methods(http.MethodPost)
+	router.Handle("/items/{id:[0-9]+}", http.NotFoundHandler()).Methods(http.MethodGet)
+	router.Handle("/any", http.NotFoundHandler())
+
+	for _, tt := range []struct {
+		path string
+		want []string
+	}{
+		{"/items", []string{"GET", "HEAD", "POST"}},
+		{"/items/7", []string{"GET"}},
+		{"/items/seven", nil},
+		{"/any", nil},
+	} {
+		request := httptest.NewRequest(http.MethodOptions, tt.path, nil)
+		if got := Methods(router, request); !slices.Equal(got, tt.want) {
+			t.Errorf("Methods(%s) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
@@ -2,61 +2,60 @@ package ahcb
 
 import (
 	"fmt"
-	"log"
 	"net/http"
-	"strings"
 	"time"
 
+	"github.com/chnm/apiary/internal/httpx"
 	paramx "github.com/chnm/apiary/internal/params"
-	"github.com/gorilla/mux"
 )
 
-// AHCBStatesHandler returns a GeoJSON FeatureCollection containing states from
-// AHCB. The handler will get the county boundaries for a particular date.
-func (h *Handler) AHCBStatesHandler() http.HandlerFunc {
+// The range of dates in the atlas. Requests for dates outside it get the
+// boundaries at the nearest end.
+var (
+	firstStateDate  = time.Date(1783, time.September, 3, 0, 0, 0, 0, time.UTC)
+	firstCountyDate = time.Date(1629, time.March, 4, 0, 0, 0, 0, time.UTC)
+	lastDate        = time.Date(2000, time.December, 31, 0, 0, 0, 0, time.UTC)
+)
 
-	// The minimum and maximum dates are the range of dates for states in AHCB.
-	minDate, _ := time.Parse("2006-01-02", "1783-09-03")
-	maxDate, _ := time.Parse("2006-01-02", "2000-12-31")
+var (
+	stateDateParameter  = paramx.Date("date", dateDescription).InPath().Clamp(firstStateDate, lastDate)
+	countyDateParameter = paramx.Date("date", dateDescription).InPath().Clamp(firstCountyDate, lastDate)
+)
 
-	// Build the GeoJSON in the query itself. This query will be sent to the
-	// database as a prepared statement.
-	query := `
-		SELECT json_build_object(
-			'type','FeatureCollection',
-			'features', json_agg(us_states.feature)
-		)
-		FROM (
-			SELECT json_build_object(
-				'type', 'Feature',
-				'id', id,
-				'geometry', ST_AsGeoJSON(geom_01)::json,
-				'properties', json_build_object(
-					'name', name,
-					'abbr', abbr_name,
-					'area_sqmi', area_sqmi,
-					'terr_type', terr_type)
-			) AS feature
-			FROM ahcb_states
-			WHERE start_date <= $1 AND end_date >= $1
-			) AS us_states;
-		`
+const dateDescription = "Date of the boundaries; dates outside the atlas are clamped to its range"
+
+// The parameters of each handler.
+var (
+	statesParams       = paramx.NewSet(stateDateParameter)
+	countiesParams     = paramx.NewSet(countyDateParameter)
+	countiesByIDParams = paramx.NewSet(
+		countyDateParameter,
+		paramx.String("id", "County IDs, such as mas_essex").InPath().List(),
+	)
+	countiesByStateTerrIDParams = paramx.NewSet(
+		countyDateParameter,
+		paramx.String("state-terr-id", "State or territory IDs, such as nc_state").InPath().List(),
+	)
+	countiesByStateCodeParams = paramx.NewSet(
+		countyDateParameter,
+		paramx.String("state-code", "Two-letter state codes, such as nh").InPath().List(),
+	)
+)
 
+// AHCBStatesHandler returns a GeoJSON FeatureCollection containing states from
+// AHCB. The handler will get the county boundaries for a particular date.
+func (h *Handler) AHCBStatesHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-
-		params := mux.Vars(r)
-		date, err := paramx.DateInRange(params["date"], minDate, maxDate)
+		values, err := statesParams.Parse(r)
 		if err != nil {
-			log.Println(err)
-			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			httpx.BadRequest(w, r, err)
 			return
 		}
+		date := values.Date("date")
 
-		var result string // result will be a string containing GeoJSON
-		err = h.db.QueryRow(r.Context(), query, date).Scan(&result)
+		result, err := h.store.States(r.Context(), date)
 		if err != nil {
-			log.Println(err)
-			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			httpx.InternalServerError(w, r, "error querying AHCB states", err)
 			return
 		}
 
@@ -69,43 +68,16 @@ func (h *Handler) AHCBStatesHandler() http.HandlerFunc {
 // AHCBCountiesHandler returns a GeoJSON FeatureCollection containing counties
 // from AHCB. The handler will get the county boundaries for a particular date.
 func (h *Handler) AHCBCountiesHandler() http.HandlerFunc {
-	minDate, _ := time.Parse("2006-01-02", "1629-03-04")
-	maxDate, _ := time.Parse("2006-01-02", "2000-12-31")
-	query := `
-		SELECT json_build_object(
-			'type','FeatureCollection',
-			'features', json_agg(us_counties.feature)
-		) FROM (
-			SELECT json_build_object(
-				'type', 'Feature',
-				'id', id,
-				'geometry', ST_AsGeoJSON(geom_01)::json,
-				'properties', json_build_object(
-					'name', name,
-					'state_terr', state_terr,
-					'state_terr_id', state_terr_id,
-					'state_code', state_code,
-					'area_sqmi', area_sqmi
-				)
-			) AS feature
-			FROM ahcb_counties
-			WHERE start_date <= $1 AND end_date >= $1
-		) AS us_counties;
-		`
-
 	return func(w http.ResponseWriter, r *http.Request) {
-		params := mux.Vars(r)
-		date, err := paramx.DateInRange(params["date"], minDate, maxDate)
+		values, err := countiesParams.Parse(r)
 		if err != nil {
-			log.Println(err)
-			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			httpx.BadRequest(w, r, err)
 			return
 		}
-		var result string
-		err = h.db.QueryRow(r.Context(), query, date).Scan(&result)
+		date := values.Date("date")
+		result, err := h.store.Counties(r.Context(), date)
 		if err != nil {
-			log.Println(err)
-			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			httpx.InternalServerError(w, r, "error querying AHCB counties", err)
 			return
 		}
 		w.Header().Set("Content-Type", "application/json")
@@ -117,47 +89,17 @@ func (h *Handler) AHCBCountiesHandler() http.HandlerFunc {
 // from AHCB. The handler will get the county boundaries for a particular date and
 // by county ID (or IDs if given a comma-separated string of values).
 func (h *Handler) AHCBCountiesByIDHandler() http.HandlerFunc {
-	minDate, _ := time.Parse("2006-01-02", "1629-03-04")
-	maxDate, _ := time.Parse("2006-01-02", "2000-12-31")
-	query := `
-		SELECT json_build_object(
-			'type','FeatureCollection',
-			'features', json_agg(us_counties.feature)
-		) FROM (
-			SELECT json_build_object(
-				'type', 'Feature',
-				'id', id,
-				'geometry', ST_AsGeoJSON(geom_01)::json,
-				'properties', json_build_object(
-					'name', name,
-					'state_terr', state_terr,
-					'state_terr_id', state_terr_id,
-					'state_code', state_code,
-					'area_sqmi', area_sqmi
-				)
-			) AS feature
-			FROM ahcb_counties
-			WHERE start_date <= $1 AND end_date >= $1
-			AND id = ANY($2)
-		) AS us_counties;
-		`
-
 	return func(w http.ResponseWriter, r *http.Request) {
-		params := mux.Vars(r)
-		date, err := paramx.DateInRange(params["date"], minDate, maxDate)
+		values, err := countiesByIDParams.Parse(r)
 		if err != nil {
-			log.Println(err)
-			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			httpx.BadRequest(w, r, err)
 			return
 		}
-		var result string
-
-		// ids := pq.Array(strings.Split(params["id"], ","))
-		ids := strings.Split(params["id"], ",")
-		err = h.db.QueryRow(r.Context(), query, date, ids).Scan(&result)
+		date := values.Date("date")
+		ids := values.Strings("id")
+		result, err := h.store.CountiesByID(r.Context(), date, ids)
 		if err != nil {
-			log.Println(err)
-			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			httpx.InternalServerError(w, r, "error querying AHCB counties by ID", err)
 			return
 		}
 		w.Header().Set("Content-Type", "application/json")
@@ -169,46 +111,17 @@ func (h *Handler) AHCBCountiesByIDHandler() http.HandlerFunc {
 // counties from AHCB. The handler will get the county boundaries for a particular
 // date and by state/territory ID (or IDs if given a comma-separated string of values).
 func (h *Handler) AHCBCountiesByStateTerrIDHandler() http.HandlerFunc {
-	minDate, _ := time.Parse("2006-01-02", "1629-03-04")
-	maxDate, _ := time.Parse("2006-01-02", "2000-12-31")
-	query := `
-		SELECT json_build_object(
-			'type','FeatureCollection',
-			'features', json_agg(us_counties.feature)
-		) FROM (
-			SELECT json_build_object(
-				'type', 'Feature',
-				'id', id,
-				'geometry', ST_AsGeoJSON(geom_01)::json,
-				'properties', json_build_object(
-					'name', name,
-					'state_terr', state_terr,
-					'state_terr_id', state_terr_id,
-					'state_code', state_code,
-					'area_sqmi', area_sqmi
-				)
-			) AS feature
-			FROM ahcb_counties
-			WHERE start_date <= $1 AND end_date >= $1
-			AND state_terr_id = ANY($2)
-		) AS us_counties;
-		`
-
 	return func(w http.ResponseWriter, r *http.Request) {
-		params := mux.Vars(r)
-		date, err := paramx.DateInRange(params["date"], minDate, maxDate)
+		values, err := countiesByStateTerrIDParams.Parse(r)
 		if err != nil {
-			log.Println(err)
-			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			httpx.BadRequest(w, r, err)
 			return
 		}
-		var result string
-		// stateTerrIds := pq.Array(strings.Split(params["state-terr-id"], ","))
-		stateTerrIds := strings.Split(params["state-terr-id"], ",")
-		err = h.db.QueryRow(r.Context(), query, date, stateTerrIds).Scan(&result)
+		date := values.Date("date")
+		stateTerrIds := values.Strings("state-terr-id")
+		result, err := h.store.CountiesByStateTerrID(r.Context(), date, stateTerrIds)
 		if err != nil {
-			log.Println(err)
-			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			httpx.InternalServerError(w, r, "error querying AHCB counties by state or territory ID", err)
 			return
 		}
 		w.Header().Set("Content-Type", "application/json")
@@ -220,46 +133,17 @@ func (h *Handler) AHCBCountiesByStateTerrIDHandler() http.HandlerFunc {
 // counties from AHCB. The handler will get the county boundaries for a particular
 // date and by state code (or state codes if given a comma-separated string of values).
 func (h *Handler) AHCBCountiesByStateCodeHandler() http.HandlerFunc {
-	minDate, _ := time.Parse("2006-01-02", "1629-03-04")
-	maxDate, _ := time.Parse("2006-01-02", "2000-12-31")
-	query := `
-		SELECT json_build_object(
-			'type','FeatureCollection',
-			'features', json_agg(us_counties.feature)
-		) FROM (
-			SELECT json_build_object(
-				'type', 'Feature',
-				'id', id,
-				'geometry', ST_AsGeoJSON(geom_01)::json,
-				'properties', json_build_object(
-					'name', name,
-					'state_terr', state_terr,
-					'state_terr_id', state_terr_id,
-					'state_code', state_code,
-					'area_sqmi', area_sqmi
-				)
-			) AS feature
-			FROM ahcb_counties
-			WHERE start_date <= $1 AND end_date >= $1
-			AND state_code = ANY($2)
-		) AS us_counties;
-		`
-
 	return func(w http.ResponseWriter, r *http.Request) {
-		params := mux.Vars(r)
-		date, err := paramx.DateInRange(params["date"], minDate, maxDate)
+		values, err := countiesByStateCodeParams.Parse(r)
 		if err != nil {
-			log.Println(err)
-			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			httpx.BadRequest(w, r, err)
 			return
 		}
-		var result string
-		// stateCodes := pq.Array(strings.Split(params["state-code"], ","))
-		stateCodes := strings.Split(params["state-code"], ",")
-		err = h.db.QueryRow(r.Context(), query, date, stateCodes).Scan(&result)
+		date := values.Date("date")
+		stateCodes := values.Strings("state-code")
+		result, err := h.store.CountiesByStateCode(r.Context(), date, stateCodes)
 		if err != nil {
-			log.Println(err)
-			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			httpx.InternalServerError(w, r, "error querying AHCB counties by state code", err)
 			return
 		}
 		w.Header().Set("Content-Type", "application/json")
@@ -2,18 +2,59 @@
 package ahcb
 
 import (
+	"context"
+	"time"
+
+	"github.com/chnm/apiary/db"
+	"github.com/chnm/apiary/internal/cachex"
+	"github.com/chnm/apiary/internal/datasets"
+	"github.com/chnm/apiary/internal/deadline"
+	"github.com/chnm/apiary/internal/httpx"
+	"github.com/chnm/apiary/internal/ratelimit"
 	"github.com/gorilla/mux"
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-type Handler struct{ db *pgxpool.Pool }
+// Store reads the AHCB boundaries. Each method returns a GeoJSON
+// FeatureCollection of the boundaries in effect on date. PostgresStore is the
+// implementation the server uses.
+type Store interface {
+	States(ctx context.Context, date time.Time) (string, error)
+	Counties(ctx context.Context, date time.Time) (string, error)
+	CountiesByID(ctx context.Context, date time.Time, ids []string) (string, error)
+	CountiesByStateTerrID(ctx context.Context, date time.Time, stateTerrIDs []string) (string, error)
+	CountiesByStateCode(ctx context.Context, date time.Time, stateCodes []string) (string, error)
+}
+
+type Handler struct{ store Store }
+
+// New creates a handler that reads from db.
+func New(db db.Querier) *Handler { return NewWithStore(NewPostgresStore(db)) }
 
-func New(db *pgxpool.Pool) *Handler { return &Handler{db: db} }
+// NewWithStore creates a handler that reads from store.
+func NewWithStore(store Store) *Handler { return &Handler{store: store} }
 
 func (h *Handler) RegisterRoutes(router *mux.Router) {
-	router.HandleFunc("/ahcb/counties/{date:[0-9]{4}-[0-9]{2}-[0-9]{2}}/", h.AHCBCountiesHandler()).Methods("GET", "HEAD")
-	router.HandleFunc("/ahcb/counties/{date:[0-9]{4}-[0-9]{2}-[0-9]{2}}/id/{id:[a-z_,]+}/", h.AHCBCountiesByIDHandler()).Methods("GET", "HEAD")
-	router.HandleFunc("/ahcb/counties/{date:[0-9]{4}-[0-9]{2}-[0-9]{2}}/state-code/{state-code:[a-z,]+}/", h.AHCBCountiesByStateCodeHandler()).Methods("GET", "HEAD")
-	router.HandleFunc("/ahcb/counties/{date:[0-9]{4}-[0-9]{2}-[0-9]{2}}/state-terr-id/{state-terr-id:[a-z_,]+}/", h.AHCBCountiesByStateTerrIDHandler()).Methods("GET", "HEAD")
-	router.HandleFunc("/ahcb/states/{date:[0-9]{4}-[0-9]{2}-[0-9]{2}}/", h.AHCBStatesHandler()).Methods("GET", "HEAD")
+	// The boundaries are historical and complete, so they never change.
+	cached := cachex.TTL(cachex.Static)
+	bulk := ratelimit.In(ratelimit.Bulk)
+	spatial := deadline.In(deadline.Spatial)
+	router.Handle("/ahcb/counties/{date:[0-9]{4}-[0-9]{2}-[0-9]{2}}/", cached(bulk(spatial(h.AHCBCountiesHandler())))).Methods("GET", "HEAD")
+	router.Handle("/ahcb/counties/{date:[0-9]{4}-[0-9]{2}-[0-9]{2}}/id/{id:[a-z_,]+}/", cached(bulk(spatial(h.AHCBCountiesByIDHandler())))).Methods("GET", "HEAD")
+	router.Handle("/ahcb/counties/{date:[0-9]{4}-[0-9]{2}-[0-9]{2}}/state-code/{state-code:[a-z,]+}/", cached(bulk(spatial(h.AHCBCountiesByStateCodeHandler())))).Methods("GET", "HEAD")
+	router.Handle("/ahcb/counties/{date:[0-9]{4}-[0-9]{2}-[0-9]{2}}/state-terr-id/{state-terr-id:[a-z_,]+}/", cached(bulk(spatial(h.AHCBCountiesByStateTerrIDHandler())))).Methods("GET", "HEAD")
+	router.Handle("/ahcb/states/{date:[0-9]{4}-[0-9]{2}-[0-9]{2}}/", cached(bulk(spatial(h.AHCBStatesHandler())))).Methods("GET", "HEAD")
 }
+
+// Name returns the identifier used to enable or disable this dataset.
+func (h *Handler) Name() string { return "ahcb" }
+
+// Metadata describes this dataset.
+func (h *Handler) Metadata() datasets.Metadata {
+	return datasets.Metadata{
+		Title:       "Atlas of Historical County Boundaries",
+		Description: "Historical U.S. county and state boundaries by date.",
+	}
+}
+
+// Endpoints returns this dataset's entries for the root endpoint catalog.
+func (h *Handler) Endpoints(baseURL string) []httpx.Endpoint { return Endpoints(baseURL) }
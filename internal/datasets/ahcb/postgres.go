@@ -0,0 +1,164 @@
+package ahcb
+
+import (
+	"context"
+	"time"
+
+	"github.com/chnm/apiary/db"
+)
+
+// The queries build the GeoJSON in the database itself.
+
+const statesQuery = `
+		SELECT json_build_object(
+			'type','FeatureCollection',
+			'features', json_agg(us_states.feature)
+		)
+		FROM (
+			SELECT json_build_object(
+				'type', 'Feature',
+				'id', id,
+				'geometry', ST_AsGeoJSON(geom_01)::json,
+				'properties', json_build_object(
+					'name', name,
+					'abbr', abbr_name,
+					'area_sqmi', area_sqmi,
+					'terr_type', terr_type)
+			) AS feature
+			FROM ahcb_states
+			WHERE start_date <= $1 AND end_date >= $1
+			) AS us_states;
+		`
+
+const countiesQuery = `
+		SELECT json_build_object(
+			'type','FeatureCollection',
+			'features', json_agg(us_counties.feature)
+		) FROM (
+			SELECT json_build_object(
+				'type', 'Feature',
+				'id', id,
+				'geometry', ST_AsGeoJSON(geom_01)::json,
+				'properties', json_build_object(
+					'name', name,
+					'state_terr', state_terr,
+					'state_terr_id', state_terr_id,
+					'state_code', state_code,
+					'area_sqmi', area_sqmi
+				)
+			) AS feature
+			FROM ahcb_counties
+			WHERE start_date <= $1 AND end_date >= $1
+		) AS us_counties;
+		`
+
+const countiesByIDQuery = `
+		SELECT json_build_object(
+			'type','FeatureCollection',
+			'features', json_agg(us_counties.feature)
+		) FROM (
+			SELECT json_build_object(
+				'type', 'Feature',
+				'id', id,
+				'geometry', ST_AsGeoJSON(geom_01)::json,
+				'properties', json_build_object(
+					'name', name,
+					'state_terr', state_terr,
+					'state_terr_id', state_terr_id,
+					'state_code', state_code,
+					'area_sqmi', area_sqmi
+				)
+			) AS feature
+			FROM ahcb_counties
+			WHERE start_date <= $1 AND end_date >= $1
+			AND id = ANY($2)
+		) AS us_counties;
+		`
+
+const countiesByStateTerrIDQuery = `
+		SELECT json_build_object(
+			'type','FeatureCollection',
+			'features', json_agg(us_counties.feature)
+		) FROM (
+			SELECT json_build_object(
+				'type', 'Feature',
+				'id', id,
+				'geometry', ST_AsGeoJSON(geom_01)::json,
+				'properties', json_build_object(
+					'name', name,
+					'state_terr', state_terr,
+					'state_terr_id', state_terr_id,
+					'state_code', state_code,
+					'area_sqmi', area_sqmi
+				)
+			) AS feature
+			FROM ahcb_counties
+			WHERE start_date <= $1 AND end_date >= $1
+			AND state_terr_id = ANY($2)
+		) AS us_counties;
+		`
+
+const countiesByStateCodeQuery = `
+		SELECT json_build_object(
+			'type','FeatureCollection',
+			'features', json_agg(us_counties.feature)
+		) FROM (
+			SELECT json_build_object(
+				'type', 'Feature',
+				'id', id,
+				'geometry', ST_AsGeoJSON(geom_01)::json,
+				'properties', json_build_object(
+					'name', name,
+					'state_terr', state_terr,
+					'state_terr_id', state_terr_id,
+					'state_code', state_code,
+					'area_sqmi', area_sqmi
+				)
+			) AS feature
+			FROM ahcb_counties
+			WHERE start_date <= $1 AND end_date >= $1
+			AND state_code = ANY($2)
+		) AS us_counties;
+		`
+
+// PostgresStore reads the AHCB boundaries from PostgreSQL.
+type PostgresStore struct {
+	db db.Querier
+}
+
+// NewPostgresStore returns a store that reads from db.
+func NewPostgresStore(db db.Querier) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// States implements Store.
+func (s *PostgresStore) States(ctx context.Context, date time.Time) (string, error) {
+	return s.featureCollection(ctx, statesQuery, date)
+}
+
+// Counties implements Store.
+func (s *PostgresStore) Counties(ctx context.Context, date time.Time) (string, error) {
+	return s.featureCollection(ctx, countiesQuery, date)
+}
+
+// CountiesByID implements Store.
+func (s *PostgresStore) CountiesByID(ctx context.Context, date time.Time, ids []string) (string, error) {
+	return s.featureCollection(ctx, countiesByIDQuery, date, ids)
+}
+
+// CountiesByStateTerrID implements Store.
+func (s *PostgresStore) CountiesByStateTerrID(ctx context.Context, date time.Time, stateTerrIDs []string) (string, error) {
+	return s.featureCollection(ctx, countiesByStateTerrIDQuery, date, stateTerrIDs)
+}
+
+// CountiesByStateCode implements Store.
+func (s *PostgresStore) CountiesByStateCode(ctx context.Context, date time.Time, stateCodes []string) (string, error) {
+	return s.featureCollection(ctx, countiesByStateCodeQuery, date, stateCodes)
+}
+
+// featureCollection runs a query that returns a single GeoJSON document.
+func (s *PostgresStore) featureCollection(ctx context.Context, query string, args ...any) (string, error) {
+	var result string
+	err := s.db.QueryRow(ctx, query, args...).Scan(&result)
+	return result, err
+}
@@ -2,6 +2,9 @@ package apb
 
 import (
 	"net/http"
+
+	"github.com/chnm/apiary/internal/httpx"
+	"github.com/chnm/apiary/internal/httpx/pagination"
 )
 
 // BibleBook describes a book of the Bible and which part of the Bible it is in.
@@ -13,39 +16,24 @@ type BibleBook struct {
 
 // APBBibleBooksHandler returns the books of the Bible (in the KJV).
 func (h *Handler) APBBibleBooksHandler() http.HandlerFunc {
-
-	query := `
-	SELECT DISTINCT book, part, book_order
-	FROM apb.scriptures
-	WHERE version = 'KJV'
-	ORDER BY book_order;
-	`
-
 	return func(w http.ResponseWriter, r *http.Request) {
-
-		var result []BibleBook
-		var book BibleBook
-
-		rows, err := h.db.Query(r.Context(), query)
+		if _, err := listParams.Parse(r); err != nil {
+			httpx.BadRequest(w, r, err)
+			return
+		}
+		q, err := pagination.Parse(r, pagination.Lists)
 		if err != nil {
-			internalServerError(w, "error querying Bible books", err)
+			httpx.BadRequest(w, r, err)
 			return
 		}
-		defer rows.Close()
 
-		for rows.Next() {
-			if err := rows.Scan(&book.Book, &book.Part, &book.Order); err != nil {
-				internalServerError(w, "error scanning Bible book", err)
-				return
-			}
-			result = append(result, book)
-		}
-		if err := rows.Err(); err != nil {
-			internalServerError(w, "error iterating Bible books", err)
+		result, err := h.store.BibleBooks(r.Context())
+		if err != nil {
+			internalServerError(w, r, "error querying Bible books", err)
 			return
 		}
 
-		writeJSONResponse(w, result)
+		pagination.WriteJSON(w, r, pagination.Slice(q, result))
 	}
 
 }
@@ -2,6 +2,9 @@ package apb
 
 import (
 	"net/http"
+
+	"github.com/chnm/apiary/internal/httpx"
+	"github.com/chnm/apiary/internal/httpx/pagination"
 )
 
 // BibleSimilarityEdge describes an edge between two parts of the Bible
@@ -14,56 +17,24 @@ type BibleSimilarityEdge struct {
 // APBBibleSimilarityHandler returns the information about the network of
 // similarities within the Bible.
 func (h *Handler) APBBibleSimilarityHandler() http.HandlerFunc {
-
-	query := `
-	SELECT 
-	a_book AS a,
-	b_book AS b,
-	COUNT(*) AS n
-	FROM 
-	(
-	SELECT
-	s1.book AS a_book,
-	s2.book AS b_book
-	FROM apb.scriptures_intraversion_pairs p
-	LEFT JOIN
-	apb.scriptures s1
-	ON p.a = s1.verse_id
-	LEFT JOIN
-	apb.scriptures s2
-	ON p.b = s2.verse_id
-	WHERE 
-	p.version = 'KJV' AND p.score > 0.18
-	) AS pairs
-	GROUP BY a_book, b_book
-	HAVING COUNT(*) >= 5 AND a_book != b_book
-	`
-
 	return func(w http.ResponseWriter, r *http.Request) {
-
-		var edge BibleSimilarityEdge
-		var result []BibleSimilarityEdge
-
-		rows, err := h.db.Query(r.Context(), query)
+		if _, err := listParams.Parse(r); err != nil {
+			httpx.BadRequest(w, r, err)
+			return
+		}
+		q, err := pagination.Parse(r, pagination.Lists)
 		if err != nil {
-			internalServerError(w, "error querying Bible similarities", err)
+			httpx.BadRequest(w, r, err)
 			return
 		}
-		defer rows.Close()
 
-		for rows.Next() {
-			if err := rows.Scan(&edge.A, &edge.B, &edge.N); err != nil {
-				internalServerError(w, "error scanning Bible similarity", err)
-				return
-			}
-			result = append(result, edge)
-		}
-		if err := rows.Err(); err != nil {
-			internalServerError(w, "error iterating Bible similarities", err)
+		result, err := h.store.BibleSimilarity(r.Context())
+		if err != nil {
+			internalServerError(w, r, "error querying Bible similarities", err)
 			return
 		}
 
-		writeJSONResponse(w, result)
+		pagination.WriteJSON(w, r, pagination.Slice(q, result))
 	}
 
 }
@@ -1,19 +1,37 @@
 package apb
 
-import "github.com/chnm/apiary/internal/httpx"
+import (
+	"github.com/chnm/apiary/internal/httpx"
+	"github.com/chnm/apiary/internal/httpx/pagination"
+	paramx "github.com/chnm/apiary/internal/params"
+)
+
+var (
+	refParameter = paramx.String("ref", "Verse reference, such as Luke 18:16").Required()
+
+	// listParams declares the parameters of the handlers that list verses
+	// and books.
+	listParams = paramx.NewSet().Accepting(pagination.Parameters(pagination.Lists)...)
+)
 
 func Endpoints(baseURL string) []httpx.Endpoint {
 	return []httpx.Endpoint{
-		{Name: "APB: Featured verses", URL: baseURL + "/apb/index/featured"},
-		{Name: "APB: Top verses", URL: baseURL + "/apb/index/top"},
-		{Name: "APB: Verses in biblical order", URL: baseURL + "/apb/index/biblical"},
-		{Name: "APB: Verses in chronological order of peak quotations", URL: baseURL + "/apb/index/peaks"},
-		{Name: "APB: All verses in biblical order", URL: baseURL + "/apb/index/all"},
-		{Name: "APB: Verse", URL: baseURL + "/apb/verse?ref=Luke+18:16"},
-		{Name: "APB: Verse trend", URL: baseURL + "/apb/verse-trend?ref=Luke+18:16&corpus=chronam"},
-		{Name: "APB: Verse quotations", URL: baseURL + "/apb/verse-quotations?ref=Luke+18:16"},
-		{Name: "APB: Bible trend", URL: baseURL + "/apb/bible-trend"},
-		{Name: "APB: Bible similarity", URL: baseURL + "/apb/bible-similarity"},
-		{Name: "APB: Books of the Bible", URL: baseURL + "/apb/bible-books"},
+		{Name: "APB: Featured verses", URL: baseURL + "/apb/index/featured", Path: "/apb/index/featured", Parameters: listParams.Parameters(), Response: pagination.Page[APBIndexItem]{}},
+		{Name: "APB: Top verses", URL: baseURL + "/apb/index/top", Path: "/apb/index/top", Parameters: listParams.Parameters(), Response: pagination.Page[APBIndexItem]{}},
+		{Name: "APB: Verses in biblical order", URL: baseURL + "/apb/index/biblical", Path: "/apb/index/biblical", Parameters: listParams.Parameters(), Response: pagination.Page[APBIndexItem]{}},
+		{Name: "APB: Verses in chronological order of peak quotations", URL: baseURL + "/apb/index/peaks", Path: "/apb/index/peaks", Parameters: listParams.Parameters(), Response: pagination.Page[APBIndexItemWithYear]{}},
+		{Name: "APB: All verses in biblical order", URL: baseURL + "/apb/index/all", Path: "/apb/index/all", Parameters: listParams.Parameters(), Response: pagination.Page[APBIndexItemText]{}},
+		{Name: "APB: Verse", URL: baseURL + "/apb/verse?ref=Luke+18:16", Path: "/apb/verse", Parameters: verseParams.Parameters(), Response: Verse{}},
+		{
+			Name:       "APB: Verse trend",
+			URL:        baseURL + "/apb/verse-trend?ref=Luke+18:16&corpus=chronam",
+			Path:       "/apb/verse-trend",
+			Parameters: verseTrendParams.Parameters(),
+			Response:   VerseTrendResponse{},
+		},
+		{Name: "APB: Verse quotations", URL: baseURL + "/apb/verse-quotations?ref=Luke+18:16", Path: "/apb/verse-quotations", Parameters: verseQuotationsParams.Parameters(), Response: pagination.Page[VerseQuotation]{}},
+		{Name: "APB: Bible trend", URL: baseURL + "/apb/bible-trend", Path: "/apb/bible-trend", Parameters: bibleTrendParams.Parameters(), Response: VerseTrendResponse{}},
+		{Name: "APB: Bible similarity", URL: baseURL + "/apb/bible-similarity", Path: "/apb/bible-similarity", Parameters: listParams.Parameters(), Response: pagination.Page[BibleSimilarityEdge]{}},
+		{Name: "APB: Books of the Bible", URL: baseURL + "/apb/bible-books", Path: "/apb/bible-books", Parameters: listParams.Parameters(), Response: pagination.Page[BibleBook]{}},
 	}
 }
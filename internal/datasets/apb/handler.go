@@ -2,33 +2,92 @@
 package apb
 
 import (
+	"context"
+	"errors"
 	"net/http"
 
+	"github.com/chnm/apiary/db"
+	"github.com/chnm/apiary/internal/cachex"
+	"github.com/chnm/apiary/internal/datasets"
 	"github.com/chnm/apiary/internal/httpx"
 	"github.com/gorilla/mux"
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-type Handler struct{ db *pgxpool.Pool }
+// ErrNoVerse is returned by a Store that has no verse with the given
+// reference.
+var ErrNoVerse = errors.New("no such verse")
 
-func New(db *pgxpool.Pool) *Handler { return &Handler{db: db} }
+// Store reads America's Public Bible. PostgresStore is the implementation the
+// server uses.
+type Store interface {
+	// BibleBooks returns the books of the KJV in order.
+	BibleBooks(ctx context.Context) ([]BibleBook, error)
+	// BibleSimilarity returns the edges between books that share similar
+	// verses.
+	BibleSimilarity(ctx context.Context) ([]BibleSimilarityEdge, error)
+	// BibleTrend returns the rate of quotation of the whole Bible in corpus
+	// for each year from minYear to maxYear.
+	BibleTrend(ctx context.Context, corpus string, minYear, maxYear int) ([]VerseTrend, error)
+
+	// FeaturedVerses, BiblicalOrderVerses, TopVerses, ChronologicalVerses, and
+	// AllVerses return the verse indexes.
+	FeaturedVerses(ctx context.Context) ([]APBIndexItem, error)
+	BiblicalOrderVerses(ctx context.Context) ([]APBIndexItem, error)
+	TopVerses(ctx context.Context) ([]APBIndexItem, error)
+	ChronologicalVerses(ctx context.Context) ([]APBIndexItemWithYear, error)
+	AllVerses(ctx context.Context) ([]APBIndexItemText, error)
+
+	// Verse returns the verse with the given reference and the verses
+	// related to it, or ErrNoVerse.
+	Verse(ctx context.Context, ref string) (Verse, error)
+	// VerseQuotations returns the quotations of a verse by date.
+	VerseQuotations(ctx context.Context, ref string) ([]VerseQuotation, error)
+	// VerseTrend returns the rate of quotation of a verse in corpus for each
+	// year from minYear to maxYear.
+	VerseTrend(ctx context.Context, corpus, ref string, minYear, maxYear int) ([]VerseTrend, error)
+}
+
+type Handler struct{ store Store }
+
+// New creates a handler that reads from db.
+func New(db db.Querier) *Handler { return NewWithStore(NewPostgresStore(db)) }
+
+// NewWithStore creates a handler that reads from store.
+func NewWithStore(store Store) *Handler { return &Handler{store: store} }
 
 func (h *Handler) RegisterRoutes(router *mux.Router) {
-	router.HandleFunc("/apb/bible-books", h.APBBibleBooksHandler()).Methods("GET", "HEAD")
-	router.HandleFunc("/apb/bible-similarity", h.APBBibleSimilarityHandler()).Methods("GET", "HEAD")
-	router.HandleFunc("/apb/bible-trend", h.APBBibleTrendHandler()).Methods("GET", "HEAD")
-	router.HandleFunc("/apb/index/featured", h.APBIndexFeaturedHandler()).Methods("GET", "HEAD")
-	router.HandleFunc("/apb/index/top", h.APBIndexTopHandler()).Methods("GET", "HEAD")
-	router.HandleFunc("/apb/index/biblical", h.APBIndexBiblicalOrderHandler()).Methods("GET", "HEAD")
-	router.HandleFunc("/apb/index/peaks", h.APBIndexChronologicalHandler()).Methods("GET", "HEAD")
-	router.HandleFunc("/apb/index/all", h.APBIndexAllHandler()).Methods("GET", "HEAD")
-	router.HandleFunc("/apb/verse", h.APBVerseHandler()).Methods("GET", "HEAD")
-	router.HandleFunc("/apb/verse-quotations", h.APBVerseQuotationsHandler()).Methods("GET", "HEAD")
-	router.HandleFunc("/apb/verse-trend", h.APBVerseTrendHandler()).Methods("GET", "HEAD")
+	cached := cachex.TTL(cachex.Stable)
+	router.Handle("/apb/bible-books", cached(h.APBBibleBooksHandler())).Methods("GET", "HEAD")
+	router.Handle("/apb/bible-similarity", cached(h.APBBibleSimilarityHandler())).Methods("GET", "HEAD")
+	router.Handle("/apb/bible-trend", cached(h.APBBibleTrendHandler())).Methods("GET", "HEAD")
+	router.Handle("/apb/index/featured", cached(h.APBIndexFeaturedHandler())).Methods("GET", "HEAD")
+	router.Handle("/apb/index/top", cached(h.APBIndexTopHandler())).Methods("GET", "HEAD")
+	router.Handle("/apb/index/biblical", cached(h.APBIndexBiblicalOrderHandler())).Methods("GET", "HEAD")
+	router.Handle("/apb/index/peaks", cached(h.APBIndexChronologicalHandler())).Methods("GET", "HEAD")
+	router.Handle("/apb/index/all", cached(h.APBIndexAllHandler())).Methods("GET", "HEAD")
+	router.Handle("/apb/verse", cached(h.APBVerseHandler())).Methods("GET", "HEAD")
+	router.Handle("/apb/verse-quotations", cached(h.APBVerseQuotationsHandler())).Methods("GET", "HEAD")
+	router.Handle("/apb/verse-trend", cached(h.APBVerseTrendHandler())).Methods("GET", "HEAD")
 }
 
-func internalServerError(w http.ResponseWriter, operation string, err error) {
-	httpx.InternalServerError(w, operation, err)
+// Name returns the identifier used to enable or disable this dataset.
+func (h *Handler) Name() string { return "apb" }
+
+// Metadata describes this dataset.
+func (h *Handler) Metadata() datasets.Metadata {
+	return datasets.Metadata{
+		Title:       "America's Public Bible",
+		Description: "Biblical quotations in U.S. newspapers.",
+	}
 }
 
-func writeJSONResponse(w http.ResponseWriter, value any) { httpx.WriteJSON(w, value) }
+// Endpoints returns this dataset's entries for the root endpoint catalog.
+func (h *Handler) Endpoints(baseURL string) []httpx.Endpoint { return Endpoints(baseURL) }
+
+func internalServerError(w http.ResponseWriter, r *http.Request, operation string, err error) {
+	httpx.InternalServerError(w, r, operation, err)
+}
+
+func writeJSONResponse(w http.ResponseWriter, r *http.Request, value any) {
+	httpx.WriteJSON(w, r, value)
+}
@@ -0,0 +1,387 @@
+package apb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/chnm/apiary/db"
+	"github.com/jackc/pgx/v5"
+)
+
+const bibleBooksQuery = `
+	SELECT DISTINCT book, part, book_order
+	FROM apb.scriptures
+	WHERE version = 'KJV'
+	ORDER BY book_order;
+	`
+
+const bibleSimilarityQuery = `
+	SELECT 
+	a_book AS a,
+	b_book AS b,
+	COUNT(*) AS n
+	FROM 
+	(
+	SELECT
+	s1.book AS a_book,
+	s2.book AS b_book
+	FROM apb.scriptures_intraversion_pairs p
+	LEFT JOIN
+	apb.scriptures s1
+	ON p.a = s1.verse_id
+	LEFT JOIN
+	apb.scriptures s2
+	ON p.b = s2.verse_id
+	WHERE 
+	p.version = 'KJV' AND p.score > 0.18
+	) AS pairs
+	GROUP BY a_book, b_book
+	HAVING COUNT(*) >= 5 AND a_book != b_book
+	`
+
+const bibleTrendQuery = `
+	SELECT
+		year,
+		n,
+		SUM(n) OVER (ORDER BY year ROWS BETWEEN 2 PRECEDING AND 2 FOLLOWING) / SUM(wordcount) OVER (ORDER BY year ROWS BETWEEN 2 PRECEDING AND 2 FOLLOWING) * 1000000 AS q_rate_smoothed
+  FROM
+	(SELECT series.year,
+		COALESCE(n, 0) as n,
+		wordcount
+	FROM
+	(SELECT generate_series($2::int, $3::int) AS year) series
+	LEFT JOIN 
+	(SELECT 
+		year, 
+		n, 
+		wordcount
+		FROM apb.rate_quotations_bible
+		WHERE corpus = $1) AS q
+	ON series.year = q.year 
+	ORDER BY series.year) res
+	`
+
+const featuredVersesQuery = `
+	SELECT t.reference_id, s.text, t.n
+	FROM apb.top_verses t
+	LEFT JOIN apb.scriptures s ON t.reference_id = s.reference_id
+	LEFT JOIN apb.verse_cleanup c ON t.reference_id = c.reference_use
+	WHERE s.version = 'KJV' AND c.display = True
+  ORDER BY s.book_order, s.chapter, s.verse;
+	`
+
+const biblicalOrderVersesQuery = `
+	SELECT t.reference_id, s.text, t.n
+	FROM apb.top_verses t
+	LEFT JOIN apb.verse_cleanup c ON t.reference_id = c.reference_id
+	LEFT JOIN apb.scriptures s ON t.reference_id = s.reference_id
+	WHERE t.n > 500 AND c.use = TRUE AND s.version = 'KJV' AND s.part != 'Apocrypha'
+  ORDER BY s.book_order, s.chapter, s.verse;
+	`
+
+const topVersesQuery = `
+	SELECT t.reference_id, s.text, t.n
+	FROM apb.top_verses t
+	LEFT JOIN apb.scriptures s ON t.reference_id = s.reference_id
+	WHERE s.version = 'KJV' AND s.part != 'Apocrypha'
+	ORDER BY t.n DESC
+	LIMIT 100;
+	`
+
+const chronologicalVersesQuery = `
+	SELECT t.reference_id, s.text, t.n, p.year
+	FROM apb.top_verses t
+	LEFT JOIN apb.verse_cleanup c ON t.reference_id = c.reference_id
+	LEFT JOIN apb.scriptures s ON t.reference_id = s.reference_id
+  LEFT JOIN apb.verse_peaks p ON t.reference_id = p.reference_id
+	WHERE t.n > 500 AND c.use = TRUE AND s.version = 'KJV' AND s.part != 'Apocrypha'
+  ORDER BY p.year, t.n DESC;
+	`
+
+const allVersesQuery = `
+	SELECT t.reference_id, s.text
+	FROM apb.top_verses t
+	LEFT JOIN apb.verse_cleanup c ON t.reference_id = c.reference_id
+	LEFT JOIN apb.scriptures s ON t.reference_id = s.reference_id
+	WHERE t.n > 100 AND c.use = TRUE AND s.version = 'KJV' AND s.part != 'Apocrypha'
+  ORDER BY s.book_order, s.chapter, s.verse;
+	`
+
+// verseQuery returns every verse that has not been explicitly disallowed,
+// rather than only verses which have been explicitly allowed.
+const verseQuery = `
+	SELECT v.reference_id, s.text
+	FROM apb.verse_cleanup v
+	LEFT JOIN apb.scriptures s
+		ON v.reference_id=s.reference_id
+	WHERE 
+		v.reference_id = $1 AND
+		(v.use = TRUE OR v.use IS NULL) AND
+		s.version = 'KJV';
+	`
+
+const relatedVersesQuery = `
+	SELECT reference_id
+	FROM apb.verse_cleanup
+	WHERE reference_use = $1 AND reference_id != reference_use
+	`
+
+const verseQuotationsQuery = `
+	SELECT q.reference_id, q.doc_id, q.date::text, q.probability,
+	 	n.title_clean, places.state
+	FROM apb.quotations q
+	LEFT JOIN apb.chronam_pages p ON q.doc_id = p.doc_id
+	LEFT JOIN apb.chronam_newspapers n ON p.lccn = n.lccn
+	LEFT JOIN (SELECT DISTINCT ON (lccn) lccn, state FROM apb.chronam_newspaper_places ORDER BY lccn) places ON p.lccn = places.lccn
+	WHERE reference_id = $1 AND corpus = 'chronam'
+	ORDER BY date;
+	`
+
+const verseTrendQuery = `
+	SELECT 
+	res.year,
+	n,
+	SUM(n) OVER (ORDER BY res.year ROWS BETWEEN 2 PRECEDING AND 2 FOLLOWING) / SUM(wordcount) OVER (ORDER BY res.year ROWS BETWEEN 2 PRECEDING AND 2 FOLLOWING) * 1000000 AS q_rate_smoothed
+	FROM
+		(SELECT series.year,
+			COALESCE(n, 0) as n
+			FROM
+				(SELECT generate_series($3::int, $4::int) AS year) series
+				LEFT JOIN 
+				(SELECT year, n, corpus, reference_id
+				FROM apb.count_quotations_verses 
+				WHERE corpus = $1 AND reference_id = $2) AS q
+				ON series.year = q.year 
+				ORDER BY series.year) res
+				LEFT JOIN 
+			(SELECT year, wordcount FROM apb.wordcounts
+			WHERE corpus = 'chronam') wc
+			ON res.year = wc.year
+	`
+
+// PostgresStore reads America's Public Bible from PostgreSQL.
+type PostgresStore struct {
+	db db.Querier
+}
+
+// NewPostgresStore returns a store that reads from db.
+func NewPostgresStore(db db.Querier) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// BibleBooks implements Store.
+func (s *PostgresStore) BibleBooks(ctx context.Context) ([]BibleBook, error) {
+	rows, err := s.db.Query(ctx, bibleBooksQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []BibleBook
+	var row BibleBook
+	for rows.Next() {
+		if err := rows.Scan(&row.Book, &row.Part, &row.Order); err != nil {
+			return nil, fmt.Errorf("scan Bible book: %w", err)
+		}
+		results = append(results, row)
+	}
+	return results, rows.Err()
+}
+
+// BibleSimilarity implements Store.
+func (s *PostgresStore) BibleSimilarity(ctx context.Context) ([]BibleSimilarityEdge, error) {
+	rows, err := s.db.Query(ctx, bibleSimilarityQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []BibleSimilarityEdge
+	var row BibleSimilarityEdge
+	for rows.Next() {
+		if err := rows.Scan(&row.A, &row.B, &row.N); err != nil {
+			return nil, fmt.Errorf("scan Bible similarity: %w", err)
+		}
+		results = append(results, row)
+	}
+	return results, rows.Err()
+}
+
+// BibleTrend implements Store.
+func (s *PostgresStore) BibleTrend(ctx context.Context, corpus string, minYear, maxYear int) ([]VerseTrend, error) {
+	rows, err := s.db.Query(ctx, bibleTrendQuery, corpus, minYear, maxYear)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := make([]VerseTrend, 0, 87)
+	var row VerseTrend
+	for rows.Next() {
+		if err := rows.Scan(&row.Year, &row.N, &row.QuotationRateSmooth); err != nil {
+			return nil, fmt.Errorf("scan Bible trend: %w", err)
+		}
+		results = append(results, row)
+	}
+	return results, rows.Err()
+}
+
+// FeaturedVerses implements Store.
+func (s *PostgresStore) FeaturedVerses(ctx context.Context) ([]APBIndexItem, error) {
+	rows, err := s.db.Query(ctx, featuredVersesQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []APBIndexItem
+	var row APBIndexItem
+	for rows.Next() {
+		if err := rows.Scan(&row.Reference, &row.Text, &row.Count); err != nil {
+			return nil, fmt.Errorf("scan featured verse index: %w", err)
+		}
+		results = append(results, row)
+	}
+	return results, rows.Err()
+}
+
+// BiblicalOrderVerses implements Store.
+func (s *PostgresStore) BiblicalOrderVerses(ctx context.Context) ([]APBIndexItem, error) {
+	rows, err := s.db.Query(ctx, biblicalOrderVersesQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []APBIndexItem
+	var row APBIndexItem
+	for rows.Next() {
+		if err := rows.Scan(&row.Reference, &row.Text, &row.Count); err != nil {
+			return nil, fmt.Errorf("scan biblical verse index: %w", err)
+		}
+		results = append(results, row)
+	}
+	return results, rows.Err()
+}
+
+// TopVerses implements Store.
+func (s *PostgresStore) TopVerses(ctx context.Context) ([]APBIndexItem, error) {
+	rows, err := s.db.Query(ctx, topVersesQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []APBIndexItem
+	var row APBIndexItem
+	for rows.Next() {
+		if err := rows.Scan(&row.Reference, &row.Text, &row.Count); err != nil {
+			return nil, fmt.Errorf("scan top verse index: %w", err)
+		}
+		results = append(results, row)
+	}
+	return results, rows.Err()
+}
+
+// ChronologicalVerses implements Store.
+func (s *PostgresStore) ChronologicalVerses(ctx context.Context) ([]APBIndexItemWithYear, error) {
+	rows, err := s.db.Query(ctx, chronologicalVersesQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []APBIndexItemWithYear
+	var row APBIndexItemWithYear
+	for rows.Next() {
+		if err := rows.Scan(&row.Reference, &row.Text, &row.Count, &row.Peak); err != nil {
+			return nil, fmt.Errorf("scan chronological verse index: %w", err)
+		}
+		results = append(results, row)
+	}
+	return results, rows.Err()
+}
+
+// AllVerses implements Store.
+func (s *PostgresStore) AllVerses(ctx context.Context) ([]APBIndexItemText, error) {
+	rows, err := s.db.Query(ctx, allVersesQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []APBIndexItemText
+	var row APBIndexItemText
+	for rows.Next() {
+		if err := rows.Scan(&row.Reference, &row.Text); err != nil {
+			return nil, fmt.Errorf("scan complete verse index: %w", err)
+		}
+		results = append(results, row)
+	}
+	return results, rows.Err()
+}
+
+// Verse implements Store.
+func (s *PostgresStore) Verse(ctx context.Context, ref string) (Verse, error) {
+	var result Verse
+	err := s.db.QueryRow(ctx, verseQuery, ref).Scan(&result.Reference, &result.Text)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Verse{}, ErrNoVerse
+	} else if err != nil {
+		return Verse{}, err
+	}
+
+	rows, err := s.db.Query(ctx, relatedVersesQuery, ref)
+	if err != nil {
+		return Verse{}, fmt.Errorf("query related verses: %w", err)
+	}
+	defer rows.Close()
+	result.Related = make([]string, 0)
+	var rel string
+	for rows.Next() {
+		if err := rows.Scan(&rel); err != nil {
+			return Verse{}, fmt.Errorf("scan related verse: %w", err)
+		}
+		result.Related = append(result.Related, rel)
+	}
+	return result, rows.Err()
+}
+
+// VerseQuotations implements Store.
+func (s *PostgresStore) VerseQuotations(ctx context.Context, ref string) ([]VerseQuotation, error) {
+	rows, err := s.db.Query(ctx, verseQuotationsQuery, ref)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := make([]VerseQuotation, 0)
+	var row VerseQuotation
+	for rows.Next() {
+		if err := rows.Scan(&row.Reference, &row.DocID, &row.Date, &row.Probability, &row.Title, &row.State); err != nil {
+			return nil, fmt.Errorf("scan verse quotation: %w", err)
+		}
+		results = append(results, row)
+	}
+	return results, rows.Err()
+}
+
+// VerseTrend implements Store.
+func (s *PostgresStore) VerseTrend(ctx context.Context, corpus, ref string, minYear, maxYear int) ([]VerseTrend, error) {
+	rows, err := s.db.Query(ctx, verseTrendQuery, corpus, ref, minYear, maxYear)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := make([]VerseTrend, 0, 175)
+	var row VerseTrend
+	for rows.Next() {
+		if err := rows.Scan(&row.Year, &row.N, &row.QuotationRateSmooth); err != nil {
+			return nil, fmt.Errorf("scan verse trend: %w", err)
+		}
+		results = append(results, row)
+	}
+	return results, rows.Err()
+}
@@ -2,9 +2,11 @@ package apb
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 
-	"github.com/jackc/pgx/v5"
+	"github.com/chnm/apiary/internal/httpx"
+	paramx "github.com/chnm/apiary/internal/params"
 )
 
 // Verse describes the reference and text of a single Bible verse
@@ -14,66 +16,29 @@ type Verse struct {
 	Related   []string `json:"related"`
 }
 
+// verseParams declares the parameters of APBVerseHandler.
+var verseParams = paramx.NewSet(refParameter)
+
 // APBVerseHandler returns information about a verse, and other verses which are related to it, if any.
 func (h *Handler) APBVerseHandler() http.HandlerFunc {
-
-	// This query currently returns all verses which have not been
-	// explicitly disallowed, rather than returning only verses which
-	// have been explicitly allowed.
-	verseQuery := `
-	SELECT v.reference_id, s.text
-	FROM apb.verse_cleanup v
-	LEFT JOIN apb.scriptures s
-		ON v.reference_id=s.reference_id
-	WHERE 
-		v.reference_id = $1 AND
-		(v.use = TRUE OR v.use IS NULL) AND
-		s.version = 'KJV';
-	`
-
-	relatedVerseQuery := `
-	SELECT reference_id
-	FROM apb.verse_cleanup
-	WHERE reference_use = $1 AND reference_id != reference_use
-	`
-
 	return func(w http.ResponseWriter, r *http.Request) {
-
-		refs := r.URL.Query()["ref"]
-
-		var result Verse
-
-		err := h.db.QueryRow(r.Context(), verseQuery, refs[0]).Scan(&result.Reference, &result.Text)
-		if errors.Is(err, pgx.ErrNoRows) {
-			http.Error(w, "404 Not found.", http.StatusNotFound)
-			return
-		} else if err != nil {
-			internalServerError(w, "error querying verse", err)
+		values, err := verseParams.Parse(r)
+		if err != nil {
+			httpx.BadRequest(w, r, err)
 			return
 		}
+		ref := values.String("ref")
 
-		related := make([]string, 0)
-		rows, err := h.db.Query(r.Context(), relatedVerseQuery, refs[0])
-		if err != nil {
-			internalServerError(w, "error querying related verses", err)
+		result, err := h.store.Verse(r.Context(), ref)
+		if errors.Is(err, ErrNoVerse) {
+			httpx.NotFound(w, r, fmt.Sprintf("no verse with reference %q", ref))
 			return
-		}
-		defer rows.Close()
-		var rel string
-		for rows.Next() {
-			if err := rows.Scan(&rel); err != nil {
-				internalServerError(w, "error scanning related verse", err)
-				return
-			}
-			related = append(related, rel)
-		}
-		if err := rows.Err(); err != nil {
-			internalServerError(w, "error iterating related verses", err)
+		} else if err != nil {
+			internalServerError(w, r, "error querying verse", err)
 			return
 		}
 
-		result.Related = related
-		writeJSONResponse(w, result)
+		writeJSONResponse(w, r, result)
 	}
 
 }
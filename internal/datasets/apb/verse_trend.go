@@ -2,6 +2,9 @@ package apb
 
 import (
 	"net/http"
+
+	"github.com/chnm/apiary/internal/httpx"
+	paramx "github.com/chnm/apiary/internal/params"
 )
 
 // VerseTrend is the rate of quotations in a single year for a single verse in a given corpus. The quotation rate is expressed in quotations per million words; the smoothed rate has the same units, and is a centered three-year rolling average.
@@ -18,53 +21,21 @@ type VerseTrendResponse struct {
 	Trend     []VerseTrend `json:"trend"`
 }
 
+// verseTrendParams declares the parameters of APBVerseTrendHandler.
+var verseTrendParams = paramx.NewSet(
+	refParameter,
+	paramx.Enum("corpus", "Newspaper corpus", "chronam", "ncnp").Default("chronam"),
+)
+
 // APBVerseTrendHandler returns the rates of quotation per year for a verse.
 func (h *Handler) APBVerseTrendHandler() http.HandlerFunc {
-
-	query := `
-	SELECT 
-	res.year,
-	n,
-	SUM(n) OVER (ORDER BY res.year ROWS BETWEEN 2 PRECEDING AND 2 FOLLOWING) / SUM(wordcount) OVER (ORDER BY res.year ROWS BETWEEN 2 PRECEDING AND 2 FOLLOWING) * 1000000 AS q_rate_smoothed
-	FROM
-		(SELECT series.year,
-			COALESCE(n, 0) as n
-			FROM
-				(SELECT generate_series($3::int, $4::int) AS year) series
-				LEFT JOIN 
-				(SELECT year, n, corpus, reference_id
-				FROM apb.count_quotations_verses 
-				WHERE corpus = $1 AND reference_id = $2) AS q
-				ON series.year = q.year 
-				ORDER BY series.year) res
-				LEFT JOIN 
-			(SELECT year, wordcount FROM apb.wordcounts
-			WHERE corpus = 'chronam') wc
-			ON res.year = wc.year
-	`
-
 	return func(w http.ResponseWriter, r *http.Request) {
-
-		// Return a 404 error if we don't get exactly one reference
-		queryRef := r.URL.Query()["ref"]
-		var ref string
-		if len(queryRef) == 1 {
-			ref = queryRef[0]
-		} else {
-			http.Error(w, "400 Bad request. Please provide exactly one reference.", http.StatusBadRequest)
+		values, err := verseTrendParams.Parse(r)
+		if err != nil {
+			httpx.BadRequest(w, r, err)
 			return
 		}
-
-		// Use chronam as the default corpus
-		corpusRef := r.URL.Query()["corpus"]
-		corpus := "chronam"
-		if len(corpusRef) > 0 {
-			corpus = corpusRef[0]
-			if !(corpus == "ncnp" || corpus == "chronam") {
-				http.Error(w, "400 Bad request. Corpus must be 'ncnp' or 'chronam'.", http.StatusBadRequest)
-				return
-			}
-		}
+		ref, corpus := values.String("ref"), values.String("corpus")
 
 		var minYear, maxYear int
 		if corpus == "chronam" {
@@ -76,29 +47,14 @@ func (h *Handler) APBVerseTrendHandler() http.HandlerFunc {
 			maxYear = 1899
 		}
 
-		results := make([]VerseTrend, 0, 175) // Preallocate slice capacity
-		var row VerseTrend
-
-		rows, err := h.db.Query(r.Context(), query, corpus, ref, minYear, maxYear)
+		results, err := h.store.VerseTrend(r.Context(), corpus, ref, minYear, maxYear)
 		if err != nil {
-			internalServerError(w, "error querying verse trend", err)
-			return
-		}
-		defer rows.Close()
-		for rows.Next() {
-			if err := rows.Scan(&row.Year, &row.N, &row.QuotationRateSmooth); err != nil {
-				internalServerError(w, "error scanning verse trend", err)
-				return
-			}
-			results = append(results, row)
-		}
-		if err := rows.Err(); err != nil {
-			internalServerError(w, "error iterating verse trend", err)
+			internalServerError(w, r, "error querying verse trend", err)
 			return
 		}
 
 		wrapper := VerseTrendResponse{Reference: ref, Corpus: corpus, Trend: results}
-		writeJSONResponse(w, wrapper)
+		writeJSONResponse(w, r, wrapper)
 	}
 
 }
@@ -3,30 +3,42 @@ package apb
 import (
 	"net/http"
 	"net/http/httptest"
-	"strings"
 	"testing"
+
+	"github.com/chnm/apiary/internal/httpx"
+	"github.com/chnm/apiary/internal/testsupport"
 )
 
 func TestVerseTrendRejectsInvalidParameters(t *testing.T) {
 	tests := []struct {
-		name string
-		path string
-		body string
+		name      string
+		path      string
+		code      string
+		parameter string
 	}{
 		{
-			name: "missing reference",
-			path: "/apb/verse-trend",
-			body: "400 Bad request. Please provide exactly one reference.",
+			name:      "missing reference",
+			path:      "/apb/verse-trend",
+			code:      httpx.CodeMissingParameter,
+			parameter: "ref",
+		},
+		{
+			name:      "multiple references",
+			path:      "/apb/verse-trend?ref=Gen.1.1&ref=John.1.1",
+			code:      httpx.CodeInvalidParameter,
+			parameter: "ref",
 		},
 		{
-			name: "multiple references",
-			path: "/apb/verse-trend?ref=Gen.1.1&ref=John.1.1",
-			body: "400 Bad request. Please provide exactly one reference.",
+			name:      "invalid corpus",
+			path:      "/apb/verse-trend?ref=Gen.1.1&corpus=unknown",
+			code:      httpx.CodeInvalidParameter,
+			parameter: "corpus",
 		},
 		{
-			name: "invalid corpus",
-			path: "/apb/verse-trend?ref=Gen.1.1&corpus=unknown",
-			body: "400 Bad request. Corpus must be 'ncnp' or 'chronam'.",
+			name:      "unknown parameter",
+			path:      "/apb/verse-trend?ref=Gen.1.1&corpora=ncnp",
+			code:      httpx.CodeInvalidParameter,
+			parameter: "corpora",
 		},
 	}
 
@@ -37,12 +49,7 @@ func TestVerseTrendRejectsInvalidParameters(t *testing.T) {
 
 			New(nil).APBVerseTrendHandler().ServeHTTP(response, request)
 
-			if response.Code != http.StatusBadRequest {
-				t.Fatalf("status = %d, want %d", response.Code, http.StatusBadRequest)
-			}
-			if body := strings.TrimSpace(response.Body.String()); body != tt.body {
-				t.Fatalf("body = %q, want %q", body, tt.body)
-			}
+			testsupport.AssertProblem(t, response, http.StatusBadRequest, tt.code, tt.parameter)
 		})
 	}
 }
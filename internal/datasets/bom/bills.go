@@ -1,14 +1,15 @@
 package bom
 
 import (
-	"encoding/base64"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
-	"strconv"
+	"slices"
 	"strings"
 
-	"github.com/jackc/pgx/v5"
+	"github.com/chnm/apiary/internal/httpx"
+	"github.com/chnm/apiary/internal/httpx/pagination"
+	paramx "github.com/chnm/apiary/internal/params"
 )
 
 // ParishByYear describes a parish's canoncial name, count type, total count, start day,
@@ -32,66 +33,48 @@ type ParishByYear struct {
 	UniqueIdentifier NullString `json:"unique_identifier"`
 	TotalRecords     int        `json:"totalrecords"`
 	Parish           *Parish    `json:"parish,omitempty"`
-}
-
-type PaginatedResponse struct {
-	Data       []ParishByYear `json:"data"`
-	NextCursor *string        `json:"next_cursor,omitempty"`
-	HasMore    bool           `json:"has_more"`
-}
 
-type APIParameters struct {
-	StartYear  int
-	EndYear    int
-	StartWeek  int
-	EndWeek    int
-	Parish     []int
-	BillType   string
-	CountType  string
-	Missing    *bool
-	Illegible  *bool
-	Sort       string
-	Limit      int
-	Offset     int
-	Page       int
-	Cursor     string
-	CursorYear int
-	CursorWeek int
-	CursorName string
+	id int64 // Breaks ties in the default order
 }
 
-type QueryOptions struct {
-	Limit  int
-	Offset int
+// key returns the position of b in the default order.
+func (b ParishByYear) key() any {
+	return billKey{Year: int(b.Year.Int64), Week: b.WeekNumber, Name: b.CanonicalName, ID: b.id}
 }
 
-const defaultBillsSort = "year"
-
-var billsSortExpressions = map[string]string{
-	"year":           "b.year, w.week_number, p.canonical_name",
-	"week_number":    "w.week_number, b.year, p.canonical_name",
-	"canonical_name": "p.canonical_name, b.year, w.week_number",
+type APIParameters struct {
+	StartYear int
+	EndYear   int
+	StartWeek int
+	EndWeek   int
+	Parish    []int
+	BillType  string
+	CountType string
+	Missing   *bool
+	Illegible *bool
+	Sort      string
+	Limit     int      // No limit if zero
+	Offset    int      // Bills to skip; unused with After
+	After     *billKey // Read the bills after this one, in the default order
+	// Stream writes rows as NDJSON while they are read. Streams skip the
+	// total count.
+	Stream bool
 }
 
-// QueryBuilder holds the query string and parameters
-type QueryBuilder struct {
-	Query  string
-	Params []interface{}
+// billKey is the position of a bill in the default order. Cursors carry the
+// key of the last bill of a page, so that the next page can be read from an
+// index rather than by skipping every bill before it.
+type billKey struct {
+	Year int    `json:"y"`
+	Week int    `json:"w"`
+	Name string `json:"n"`
+	ID   int64  `json:"i"` // Orders the bills of a parish in a week
 }
 
-// NewQueryBuilder creates a new query builder
-func NewQueryBuilder() *QueryBuilder {
-	return &QueryBuilder{
-		Params: make([]interface{}, 0),
-	}
-}
+// billsPages bounds the pages of BillsHandler.
+var billsPages = pagination.Records.Streaming()
 
-// AddParam adds a parameter and returns the placeholder ($1, $2, etc.)
-// We do this to prevent injection problems.
-func (qb *QueryBuilder) AddParam(value interface{}) string {
-	qb.Params = append(qb.Params, value)
-	return fmt.Sprintf("$%d", len(qb.Params))
-}
+const defaultBillsSort = "year"
 
 // TotalBills returns to the total number of records in the database.
 type TotalBills struct {
@@ -99,582 +82,167 @@ type TotalBills struct {
 }
 
 // BillsHandler returns the bills for a given range of years. It expects a start year and
-// an end year. It returns a JSON array of ParishByYear objects.
+// an end year. It returns a page of ParishByYear objects.
 // Parish IDs are validated against the bom.parishes table; unknown IDs return 400 Bad Request.
 func (h *Handler) BillsHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Parse and validate query parameters
 		apiParams, err := parseAPIParameters(r)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
-			log.Printf("Error parsing API parameters: %v", err)
+			httpx.BadRequest(w, r, err)
+			return
+		}
+		q, err := pagination.Parse(r, billsPages)
+		if err != nil {
+			httpx.BadRequest(w, r, err)
 			return
 		}
+		apiParams.Stream = httpx.Format(r) == httpx.FormatNDJSON
+		apiParams.Limit, apiParams.Offset = q.Fetch(), q.Offset
+		if apiParams.Stream {
+			// Streams have no next page to look for.
+			apiParams.Limit = q.Limit
+		}
+		// Pages in the default order continue from the key in the cursor.
+		// Other orders have no index to seek, so they skip by offset.
+		keyed := apiParams.Sort == defaultBillsSort
+		if keyed {
+			var key billKey
+			after, err := q.After(&key)
+			if err != nil {
+				httpx.InvalidParameter(w, r, pagination.CursorParameter, "cursor is not one this query returned")
+				return
+			}
+			if after {
+				apiParams.After, apiParams.Offset = &key, 0
+			}
+		}
+		w.Header().Add("Vary", "Accept")
 
 		// Reject parish IDs that don't exist in the database
 		if len(apiParams.Parish) > 0 {
-			invalid, err := h.invalidParishIDs(r.Context(), apiParams.Parish)
+			invalid, err := h.store.InvalidParishIDs(r.Context(), apiParams.Parish)
 			if err != nil {
-				internalServerError(w, "error validating parish IDs", err)
+				internalServerError(w, r, "error validating parish IDs", err)
 				return
 			}
 			if len(invalid) > 0 {
-				http.Error(w, fmt.Sprintf("invalid parish ID(s): %s", intsToString(invalid)), http.StatusBadRequest)
+				httpx.InvalidParameter(w, r, "parish", fmt.Sprintf("invalid parish ID(s): %s", intsToString(invalid)))
 				return
 			}
 		}
 
-		// Build query with parameters
-		qb, err := buildBillsQueryWithParams(apiParams)
-		if err != nil {
-			internalServerError(w, "error building bills query", err)
+		if apiParams.Stream {
+			h.streamBills(w, r, apiParams)
 			return
 		}
 
-		// Execute query with parameters
-		rows, err := h.db.Query(r.Context(), qb.Query, qb.Params...)
+		results := []ParishByYear{}
+		err = h.store.Bills(r.Context(), apiParams, func(bill ParishByYear) error {
+			results = append(results, bill)
+			return nil
+		})
 		if err != nil {
-			internalServerError(w, "error querying bills", err)
+			internalServerError(w, r, "error querying bills", err)
 			return
 		}
-		defer rows.Close()
 
-		// Process results
-		results := []ParishByYear{}
-		for rows.Next() {
-			var result ParishByYear
-			var parish Parish
-			err := rows.Scan(
-				&result.CanonicalName,
-				&result.BillType,
-				&result.CountType,
-				&result.Count,
-				&result.StartDay,
-				&result.StartMonth,
-				&result.EndDay,
-				&result.EndMonth,
-				&result.Year,
-				&result.SplitYear,
-				&result.WeekNumber,
-				&result.WeekID,
-				&result.Missing,
-				&result.Illegible,
-				&result.Source,
-				&result.UniqueIdentifier,
-				&result.TotalRecords,
-				&parish.ParishID,
-				&parish.Name,
-				&parish.CanonicalName,
-				&parish.BillSubunit,
-				&parish.FoundationYear,
-				&parish.Notes,
-			)
-			if err != nil {
-				internalServerError(w, "error scanning bill", err)
-				return
-			}
-			result.Parish = &parish
-			results = append(results, result)
-		}
-
-		if err = rows.Err(); err != nil {
-			internalServerError(w, "error iterating bills", err)
+		if keyed {
+			pagination.WriteTable(w, r, pagination.NewKeyedPage(q, results, ParishByYear.key))
 			return
 		}
-
-		// Create paginated response
-		paginatedResponse := PaginatedResponse{
-			Data:    results,
-			HasMore: len(results) == getEffectiveLimit(apiParams),
-		}
-
-		// Generate next cursor if there are more results
-		if paginatedResponse.HasMore && len(results) > 0 {
-			lastResult := results[len(results)-1]
-			if nextCursor, err := generateCursor(int(lastResult.Year.Int64), int(lastResult.WeekNumber), lastResult.CanonicalName); err == nil {
-				paginatedResponse.NextCursor = &nextCursor
-			}
-		}
-
-		writeJSONResponse(w, paginatedResponse)
+		pagination.WriteTable(w, r, pagination.NewPage(q, results))
 	}
 }
 
-func (p *APIParameters) GetQueryOptions() QueryOptions {
-	if p.Page > 0 {
-		// Default to 25 items per page if using page parameter
-		return QueryOptions{
-			Limit:  25,
-			Offset: (p.Page - 1) * 25,
-		}
+// streamBills writes each bill as a line of NDJSON as soon as it is read.
+func (h *Handler) streamBills(w http.ResponseWriter, r *http.Request, params APIParameters) {
+	stream := httpx.NewNDJSONStream(w, r)
+	err := h.store.Bills(r.Context(), params, func(bill ParishByYear) error {
+		return stream.Write(bill)
+	})
+	if err != nil {
+		stream.Fail("error streaming bills", err)
+		return
 	}
-
-	return QueryOptions{
-		Limit:  p.Limit,
-		Offset: p.Offset,
+	if err := stream.Close(); err != nil {
+		stream.Fail("error streaming bills", err)
 	}
 }
 
-// Helper function to parse and validate query parameters
+// billsParams declares the parameters of BillsHandler.
+var billsParams = paramx.NewSet(
+	startYearParameter,
+	endYearParameter,
+	paramx.Int("start-week", "First week number to include").Between(1, 53),
+	paramx.Int("end-week", "Last week number to include").Between(1, 53),
+	parishParameter,
+	billTypeParameter,
+	countTypeParameter,
+	paramx.Bool("missing", "Restrict results to records that are, or are not, missing"),
+	paramx.Bool("illegible", "Restrict results to records that are, or are not, illegible"),
+	paramx.Enum("sort", "Sort order of the results", "year", "week_number", "canonical_name").Default(defaultBillsSort),
+).Accepting(httpx.StreamFormatParameter).Accepting(pagination.Parameters(billsPages)...)
+
+// parseAPIParameters reads the filters and sort order of a bills request.
 func parseAPIParameters(r *http.Request) (APIParameters, error) {
-	params := APIParameters{
-		StartYear: 1648, // Default values
-		EndYear:   1750,
-		Parish:    []int{},
-		Sort:      defaultBillsSort,
-	}
-
-	// Parse start year
-	if startYear := r.URL.Query().Get("start-year"); startYear != "" {
-		startYearInt, err := strconv.Atoi(startYear)
-		if err != nil {
-			return params, fmt.Errorf("invalid start year: %v", err)
-		}
-		params.StartYear = startYearInt
-	}
-
-	// Parse end year
-	if endYear := r.URL.Query().Get("end-year"); endYear != "" {
-		endYearInt, err := strconv.Atoi(endYear)
-		if err != nil {
-			return params, fmt.Errorf("invalid end year: %v", err)
-		}
-		params.EndYear = endYearInt
-	}
-
-	// Parse start week
-	if startWeek := r.URL.Query().Get("start-week"); startWeek != "" {
-		startWeekInt, err := strconv.Atoi(startWeek)
-		if err != nil {
-			return params, fmt.Errorf("invalid start week: %v", err)
-		}
-		if startWeekInt < 1 || startWeekInt > 53 {
-			return params, fmt.Errorf("start week must be between 1 and 53")
-		}
-		params.StartWeek = startWeekInt
-	}
-
-	// Parse end week
-	if endWeek := r.URL.Query().Get("end-week"); endWeek != "" {
-		endWeekInt, err := strconv.Atoi(endWeek)
-		if err != nil {
-			return params, fmt.Errorf("invalid end week: %v", err)
-		}
-		if endWeekInt < 1 || endWeekInt > 53 {
-			return params, fmt.Errorf("end week must be between 1 and 53")
-		}
-		params.EndWeek = endWeekInt
-	}
-
-	// Parse parish IDs
-	if parish := r.URL.Query().Get("parish"); parish != "" {
-		parishList := strings.Split(parish, ",")
-		for _, p := range parishList {
-			parishInt, err := strconv.Atoi(strings.TrimSpace(p))
-			if err != nil {
-				return params, fmt.Errorf("invalid parish ID: %v", err)
-			}
-			params.Parish = append(params.Parish, parishInt)
-		}
-	}
-
-	// Parse bill type
-	if billType := r.URL.Query().Get("bill-type"); billType != "" {
-		if !IsValidBillType(billType) {
-			return params, fmt.Errorf("invalid bill type: %s", billType)
-		}
-		params.BillType = billType
-	}
-
-	// Parse count type
-	if countType := r.URL.Query().Get("count-type"); countType != "" {
-		if !IsValidCountType(countType) {
-			return params, fmt.Errorf("invalid count type: %s", countType)
-		}
-		params.CountType = countType
-	}
-
-	// Parse missing parameter
-	if missing := r.URL.Query().Get("missing"); missing != "" {
-		missingBool, err := strconv.ParseBool(missing)
-		if err != nil {
-			return params, fmt.Errorf("invalid missing value: %v", err)
-		}
-		params.Missing = &missingBool
-	}
-
-	// Parse illegible parameter
-	if illegible := r.URL.Query().Get("illegible"); illegible != "" {
-		illegibleBool, err := strconv.ParseBool(illegible)
-		if err != nil {
-			return params, fmt.Errorf("invalid illegible value: %v", err)
-		}
-		params.Illegible = &illegibleBool
-	}
-
-	// Parse pagination parameters first
-	if limit := r.URL.Query().Get("limit"); limit != "" {
-		limitInt, err := strconv.Atoi(limit)
-		if err != nil {
-			return params, fmt.Errorf("invalid limit: %v", err)
-		}
-		params.Limit = limitInt
-	}
-
-	if offset := r.URL.Query().Get("offset"); offset != "" {
-		offsetInt, err := strconv.Atoi(offset)
-		if err != nil {
-			return params, fmt.Errorf("invalid offset: %v", err)
-		}
-		params.Offset = offsetInt
-	}
-
-	// Handle page parameter last since it may override limit/offset
-	if page := r.URL.Query().Get("page"); page != "" {
-		pageInt, err := strconv.Atoi(page)
-		if err != nil {
-			return params, fmt.Errorf("invalid page number: %v", err)
-		}
-		params.Page = pageInt
-	}
-
-	// Parse sorting
-	if sort := r.URL.Query().Get("sort"); sort != "" {
-		if _, ok := billsSortExpressions[sort]; !ok {
-			return params, fmt.Errorf("invalid sort: supported values are year, week_number, and canonical_name")
-		}
-		params.Sort = sort
+	values, err := billsParams.Parse(r)
+	if err != nil {
+		return APIParameters{}, err
 	}
-
-	// Parse cursor
-	if cursor := r.URL.Query().Get("cursor"); cursor != "" {
-		params.Cursor = cursor
-		if year, week, name, err := parseCursor(cursor); err == nil {
-			params.CursorYear = year
-			params.CursorWeek = week
-			params.CursorName = name
-		} else {
-			return params, fmt.Errorf("invalid cursor: %v", err)
-		}
+	params := APIParameters{
+		StartYear: values.Int("start-year"),
+		EndYear:   values.Int("end-year"),
+		StartWeek: values.Int("start-week"),
+		EndWeek:   values.Int("end-week"),
+		Parish:    values.Ints("parish"),
+		BillType:  values.String("bill-type"),
+		CountType: values.String("count-type"),
+		Sort:      values.String("sort"),
+	}
+	if values.Has("missing") {
+		missing := values.Bool("missing")
+		params.Missing = &missing
+	}
+	if values.Has("illegible") {
+		illegible := values.Bool("illegible")
+		params.Illegible = &illegible
 	}
-
 	return params, nil
 }
 
-// Helper function to build the SQL query with parameters
-func buildBillsQueryWithParams(params APIParameters) (*QueryBuilder, error) {
-	qb := NewQueryBuilder()
-
-	// For cursor-based pagination, skip the expensive COUNT(*) OVER() calculation
-	// Only calculate total count for first page or legacy pagination
-	var selectClause string
-	if params.Cursor != "" {
-		// Fast cursor query without total count for subsequent pages
-		selectClause = `
-    SELECT
-        p.canonical_name,
-        b.bill_type,
-        b.count_type,
-        b.count,
-        w.start_day,
-        w.start_month,
-        w.end_day,
-        w.end_month,
-        b.year,
-        w.split_year,
-        w.week_number,
-        b.week_id,
-        b.missing,
-        b.illegible,
-        b.source,
-        b.unique_identifier,
-        0 AS totalrecords,
-        p.id,
-        p.parish_name,
-        p.canonical_name AS parish_canonical,
-        p.bills_subunit,
-        p.foundation_year,
-        p.notes`
-	} else {
-		// Include total count for first page and legacy pagination
-		selectClause = `
-    SELECT
-        p.canonical_name,
-        b.bill_type,
-        b.count_type,
-        b.count,
-        w.start_day,
-        w.start_month,
-        w.end_day,
-        w.end_month,
-        b.year,
-        w.split_year,
-        w.week_number,
-        b.week_id,
-        b.missing,
-        b.illegible,
-        b.source,
-        b.unique_identifier,
-        COUNT(*) OVER() AS totalrecords,
-        p.id,
-        p.parish_name,
-        p.canonical_name AS parish_canonical,
-        p.bills_subunit,
-        p.foundation_year,
-        p.notes`
-	}
-
-	baseQuery := selectClause + `
-    FROM
-        bom.bill_of_mortality b
-    JOIN
-        bom.parishes p ON p.id = b.parish_id
-    JOIN
-        bom.year y ON y.year = b.year
-    JOIN
-        bom.week w ON w.joinid = b.week_id
-    WHERE 1=1`
-
-	// Build WHERE clause
-	var conditions []string
-
-	if params.StartYear != 0 {
-		conditions = append(conditions, fmt.Sprintf("b.year >= %s", qb.AddParam(params.StartYear)))
-	}
-
-	if params.EndYear != 0 {
-		conditions = append(conditions, fmt.Sprintf("b.year <= %s", qb.AddParam(params.EndYear)))
-	}
-
-	if params.StartWeek != 0 {
-		conditions = append(conditions, fmt.Sprintf("w.week_number >= %s", qb.AddParam(params.StartWeek)))
-	}
-
-	if params.EndWeek != 0 {
-		conditions = append(conditions, fmt.Sprintf("w.week_number <= %s", qb.AddParam(params.EndWeek)))
-	}
-
-	if len(params.Parish) > 0 {
-		// Convert []int to []interface{} for the parameter
-		parishParams := make([]string, len(params.Parish))
-		for i, p := range params.Parish {
-			parishParams[i] = qb.AddParam(p)
-		}
-		conditions = append(conditions, fmt.Sprintf("b.parish_id IN (%s)", strings.Join(parishParams, ",")))
-	}
-
-	if params.BillType != "" {
-		conditions = append(conditions, fmt.Sprintf("b.bill_type = %s", qb.AddParam(strings.ToLower(params.BillType))))
-	}
-
-	if params.CountType != "" {
-		conditions = append(conditions, fmt.Sprintf("b.count_type = %s", qb.AddParam(strings.ToLower(params.CountType))))
-	}
-
-	if params.Missing != nil {
-		conditions = append(conditions, fmt.Sprintf("b.missing = %s", qb.AddParam(*params.Missing)))
-	}
-
-	if params.Illegible != nil {
-		conditions = append(conditions, fmt.Sprintf("b.illegible = %s", qb.AddParam(*params.Illegible)))
-	}
-
-	// Handle cursor-based pagination
-	if params.Cursor != "" {
-		yearParam := qb.AddParam(params.CursorYear)
-		weekParam := qb.AddParam(params.CursorWeek)
-		nameParam := qb.AddParam(params.CursorName)
-		conditions = append(conditions, fmt.Sprintf("(b.year, w.week_number, p.canonical_name) > (%s, %s, %s)", yearParam, weekParam, nameParam))
-	}
-
-	// Add conditions to query
-	if len(conditions) > 0 {
-		baseQuery += " AND " + strings.Join(conditions, " AND ")
-	}
-
-	// Cursor pagination depends on the same ordering as its year/week/name
-	// continuation tuple. Other pagination modes may select a supported order.
-	sort := params.Sort
-	if sort == "" || params.Cursor != "" {
-		sort = defaultBillsSort
-	}
-	sortExpression, ok := billsSortExpressions[sort]
-	if !ok {
-		return nil, fmt.Errorf("unsupported bills sort %q", sort)
-	}
-	baseQuery += " ORDER BY " + sortExpression + " ASC"
-
-	// Handle pagination - cursor-based is the default and preferred method
-	if params.Cursor != "" {
-		// Cursor pagination (preferred for performance)
-		limit := getEffectiveLimit(params)
-		baseQuery += fmt.Sprintf(" LIMIT %s", qb.AddParam(limit))
-	} else if params.Page > 0 {
-		// Legacy page-based pagination for compatibility
-		limit := 100
-		offset := (params.Page - 1) * limit
-		baseQuery += fmt.Sprintf(" LIMIT %s OFFSET %s", qb.AddParam(limit), qb.AddParam(offset))
-	} else if params.Limit > 0 {
-		// Direct limit/offset pagination
-		baseQuery += fmt.Sprintf(" LIMIT %s", qb.AddParam(params.Limit))
-		if params.Offset > 0 {
-			baseQuery += fmt.Sprintf(" OFFSET %s", qb.AddParam(params.Offset))
-		}
-	} else {
-		// Default to cursor-based pagination
-		defaultLimit := 100
-		baseQuery += fmt.Sprintf(" LIMIT %s", qb.AddParam(defaultLimit))
-	}
-
-	qb.Query = baseQuery
-	return qb, nil
-}
-
 // IsValidBillType checks if the provided bill type is valid
 func IsValidBillType(billType string) bool {
-	validTypes := map[string]bool{
-		"weekly":  true,
-		"general": true,
-		"total":   true,
-	}
-	return validTypes[strings.ToLower(billType)]
+	return slices.ContainsFunc(billTypes, func(valid string) bool { return strings.EqualFold(valid, billType) })
 }
 
 // IsValidCountType checks if the provided count type is valid
 func IsValidCountType(countType string) bool {
-	validTypes := map[string]bool{
-		"buried": true,
-		"plague": true,
-	}
-	return validTypes[strings.ToLower(countType)]
-}
-
-// generateCursor creates a base64-encoded cursor from year, week, and name
-func generateCursor(year, week int, name string) (string, error) {
-	cursorData := fmt.Sprintf("%d|%d|%s", year, week, name)
-	return base64.URLEncoding.EncodeToString([]byte(cursorData)), nil
-}
-
-// parseCursor decodes a base64 cursor back to year, week, and name
-func parseCursor(cursor string) (int, int, string, error) {
-	decoded, err := base64.URLEncoding.DecodeString(cursor)
-	if err != nil {
-		return 0, 0, "", err
-	}
-
-	parts := strings.Split(string(decoded), "|")
-	if len(parts) != 3 {
-		return 0, 0, "", fmt.Errorf("invalid cursor format")
-	}
-
-	year, err := strconv.Atoi(parts[0])
-	if err != nil {
-		return 0, 0, "", fmt.Errorf("invalid year in cursor: %v", err)
-	}
-
-	week, err := strconv.Atoi(parts[1])
-	if err != nil {
-		return 0, 0, "", fmt.Errorf("invalid week in cursor: %v", err)
-	}
-
-	return year, week, parts[2], nil
+	return slices.ContainsFunc(countTypes, func(valid string) bool { return strings.EqualFold(valid, countType) })
 }
 
-// getEffectiveLimit returns the appropriate limit for the query
-func getEffectiveLimit(params APIParameters) int {
-	if params.Cursor != "" {
-		return 100 // Default page size for cursor pagination
-	}
-	if params.Page > 0 {
-		return 100
-	}
-	if params.Limit > 0 {
-		return params.Limit
-	}
-	return 100
-}
+// totalBillsParams declares the parameters of TotalBillsHandler.
+var totalBillsParams = paramx.NewSet(
+	paramx.Enum("type", "Kind of record to count", "weekly", "general", "christenings", "causes").Required(),
+)
 
 // TotalBillsHandler returns the total number of bills in the database.
 // This number is required for pagination in the web application.
 func (h *Handler) TotalBillsHandler() http.HandlerFunc {
-	queryWeekly := `
-	SELECT
-		COUNT(*)
-	FROM
-		bom.bill_of_mortality
-	WHERE 
-		bill_type = 'weekly';
-	`
-
-	queryGeneral := `
-	SELECT
-		COUNT(*)
-	FROM
-		bom.bill_of_mortality
-	WHERE	
-		bill_type = 'general';
-	`
-
-	queryChristenings := `
-	SELECT
-		COUNT(*)
-	FROM	
-		bom.christenings;
-	`
-
-	queryCauses := `
-	SELECT
-		COUNT(*)
-	FROM 
-		bom.causes_of_death;
-	`
-
 	return func(w http.ResponseWriter, r *http.Request) {
-		totalValues := r.URL.Query().Get("type")
-
-		if totalValues == "" {
-			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
-			return
-		}
-
-		results := make([]TotalBills, 0)
-		var row TotalBills
-		var rows pgx.Rows
-		var err error
-
-		switch {
-		case totalValues == "weekly":
-			rows, err = h.db.Query(r.Context(), queryWeekly)
-		case totalValues == "general":
-			rows, err = h.db.Query(r.Context(), queryGeneral)
-		case totalValues == "christenings":
-			rows, err = h.db.Query(r.Context(), queryChristenings)
-		case totalValues == "causes":
-			rows, err = h.db.Query(r.Context(), queryCauses)
-		default:
-			http.Error(w, "Invalid type parameter. Must be 'weekly', 'general', 'christenings', or 'causes'", http.StatusBadRequest)
-			return
-		}
+		values, err := totalBillsParams.Parse(r)
 		if err != nil {
-			internalServerError(w, "error querying bill totals", err)
+			httpx.BadRequest(w, r, err)
 			return
 		}
-		defer rows.Close()
 
-		for rows.Next() {
-			err := rows.Scan(&row.TotalRecords)
-			if err != nil {
-				internalServerError(w, "error scanning bill total", err)
-				return
-			}
-			results = append(results, row)
-		}
-		if err := rows.Err(); err != nil {
-			internalServerError(w, "error iterating bill totals", err)
+		total, err := h.store.TotalBills(r.Context(), values.String("type"))
+		if err != nil {
+			internalServerError(w, r, "error querying bill totals", err)
 			return
 		}
 
-		writeJSONResponse(w, results)
+		writeJSONResponse(w, r, []TotalBills{total})
 	}
 }
 
@@ -700,187 +268,50 @@ type ParishYearlySummary struct {
 	TotalPlague *int   `json:"total_plague"`
 }
 
-func buildYearlyStatsQuery() string {
-	query := `
-  WITH year_range AS (
-        SELECT generate_series(1636, 1754) AS year
-    ),
-    weekly_stats AS (
-        SELECT 
-            b.year as year,
-            COUNT(DISTINCT b.week_id) as weeks_completed,
-            COUNT(*) as rows_count
-        FROM bom.bill_of_mortality b
-        WHERE b.bill_type = 'weekly'
-        GROUP BY b.year
-    )
-    SELECT 
-        yr.year,
-        COALESCE(ws.weeks_completed, 0) as weeks_completed,
-        COALESCE(ws.rows_count, 0) as rows_count,
-        53 as total_count
-    FROM year_range yr
-    LEFT JOIN weekly_stats ws ON yr.year = ws.year
-    ORDER BY yr.year;
-    `
-	return query
-}
-
-func buildWeeklyStatsQuery() string {
-	query := `
-    WITH year_week_range AS (
-        SELECT 
-            y.year,
-            w.number as week_number
-        FROM generate_series(1636, 1754) y(year)
-        CROSS JOIN generate_series(1, 53) w(number)
-    ),
-    weekly_stats AS (
-        SELECT 
-            b.year as year,
-            w.week_number,
-            COUNT(*) as rows_count
-        FROM bom.bill_of_mortality b
-        JOIN bom.week w ON w.joinid = b.week_id
-        WHERE b.bill_type = 'weekly'
-        GROUP BY b.year, w.week_number
-    )
-    SELECT 
-        yr.year,
-        yr.week_number,
-        COALESCE(ws.rows_count, 0) as rows_count
-    FROM year_week_range yr
-    LEFT JOIN weekly_stats ws ON yr.year = ws.year AND yr.week_number = ws.week_number
-    ORDER BY yr.year, yr.week_number;
-    `
-	return query
-}
-
-func buildParishYearlyStatsQuery(parishName string) (*QueryBuilder, error) {
-	qb := NewQueryBuilder()
-
-	query := `
-    SELECT 
-        b.year,
-        p.canonical_name as parish_name,
-        SUM(CASE WHEN b.count_type = 'buried' THEN COALESCE(b.count, 0) ELSE 0 END) as total_buried,
-        NULLIF(SUM(CASE WHEN b.count_type = 'plague' THEN COALESCE(b.count, 0) ELSE 0 END), 0) as total_plague
-    FROM bom.bill_of_mortality b
-    JOIN bom.parishes p ON p.id = b.parish_id
-    WHERE b.bill_type = 'weekly'`
-
-	if parishName != "" {
-		query += fmt.Sprintf(" AND LOWER(p.canonical_name) = LOWER(%s)", qb.AddParam(parishName))
-	}
-
-	query += `
-    GROUP BY b.year, p.canonical_name
-    HAVING SUM(CASE WHEN b.count_type IN ('buried', 'plague') THEN COALESCE(b.count, 0) ELSE 0 END) > 0
-    ORDER BY p.canonical_name, b.year`
-
-	qb.Query = query
-	return qb, nil
-}
+// statisticsParams declares the parameters of StatisticsHandler.
+var statisticsParams = paramx.NewSet(
+	paramx.Enum("type", "Grouping of the statistics; each grouping returns a different row shape", "weekly", "yearly", "parish-yearly").Required(),
+	paramx.String("parish", "Canonical parish name, used with type=parish-yearly"),
+).Accepting(pagination.Parameters(pagination.Lists)...)
 
 func (h *Handler) StatisticsHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		statType := r.URL.Query().Get("type")
-		parishName := r.URL.Query().Get("parish")
-
-		var rows pgx.Rows
-		var err error
-
-		switch statType {
-		case "weekly":
-			query := buildWeeklyStatsQuery()
-			rows, err = h.db.Query(r.Context(), query)
-		case "yearly":
-			query := buildYearlyStatsQuery()
-			rows, err = h.db.Query(r.Context(), query)
-		case "parish-yearly":
-			qb, buildErr := buildParishYearlyStatsQuery(parishName)
-			if buildErr != nil {
-				internalServerError(w, "error building parish-yearly statistics query", buildErr)
-				return
-			}
-			rows, err = h.db.Query(r.Context(), qb.Query, qb.Params...)
-		default:
-			http.Error(w, "Invalid type parameter. Must be 'weekly', 'yearly', or 'parish-yearly'", http.StatusBadRequest)
+		values, err := statisticsParams.Parse(r)
+		if err != nil {
+			httpx.BadRequest(w, r, err)
 			return
 		}
+		q, err := pagination.Parse(r, pagination.Lists)
 		if err != nil {
-			internalServerError(w, "error querying statistics", err)
+			httpx.BadRequest(w, r, err)
 			return
 		}
-		defer rows.Close()
 
-		switch statType {
+		switch values.String("type") {
 		case "weekly":
-			stats := []WeeklySummary{}
-			for rows.Next() {
-				var summary WeeklySummary
-				err := rows.Scan(&summary.Year, &summary.WeekNumber, &summary.RowsCount)
-				if err != nil {
-					internalServerError(w, "error scanning weekly statistics", err)
-					return
-				}
-				stats = append(stats, summary)
-			}
-
-			// Check for errors from iterating over rows
-			if err = rows.Err(); err != nil {
-				internalServerError(w, "error iterating weekly statistics", err)
+			stats, err := h.store.WeeklyStatistics(r.Context())
+			if err != nil {
+				internalServerError(w, r, "error querying weekly statistics", err)
 				return
 			}
-
-			writeJSONResponse(w, stats)
+			pagination.WriteJSON(w, r, pagination.Slice(q, stats))
 
 		case "yearly":
-			stats := []YearlySummary{}
-			for rows.Next() {
-				var summary YearlySummary
-				err := rows.Scan(&summary.Year, &summary.WeeksCompleted,
-					&summary.RowsCount, &summary.TotalCount)
-				if err != nil {
-					internalServerError(w, "error scanning yearly statistics", err)
-					return
-				}
-				stats = append(stats, summary)
-			}
-
-			// Check for errors from iterating over rows
-			if err = rows.Err(); err != nil {
-				internalServerError(w, "error iterating yearly statistics", err)
+			stats, err := h.store.YearlyStatistics(r.Context())
+			if err != nil {
+				internalServerError(w, r, "error querying yearly statistics", err)
 				return
 			}
-
-			writeJSONResponse(w, stats)
+			pagination.WriteJSON(w, r, pagination.Slice(q, stats))
 
 		case "parish-yearly":
-			stats := []ParishYearlySummary{}
-			for rows.Next() {
-				var summary ParishYearlySummary
-				err := rows.Scan(
-					&summary.Year,
-					&summary.ParishName,
-					&summary.TotalBuried,
-					&summary.TotalPlague,
-				)
-				if err != nil {
-					internalServerError(w, "error scanning parish-yearly statistics", err)
-					return
-				}
-				stats = append(stats, summary)
-			}
-
-			// Check for errors from iterating over rows
-			if err = rows.Err(); err != nil {
-				internalServerError(w, "error iterating parish-yearly statistics", err)
+			stats, err := h.store.ParishYearlyStatistics(r.Context(), values.String("parish"))
+			if err != nil {
+				internalServerError(w, r, "error querying parish-yearly statistics", err)
 				return
 			}
-
-			log.Printf("Returning %d parish-yearly summary records", len(stats))
-			writeJSONResponse(w, stats)
+			slog.DebugContext(r.Context(), "returning parish-yearly summary records", "count", len(stats))
+			pagination.WriteJSON(w, r, pagination.Slice(q, stats))
 		}
 	}
 }
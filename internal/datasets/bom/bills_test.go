@@ -1,12 +1,14 @@
 package bom
 
 import (
-	"encoding/base64"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
 	"strings"
 	"testing"
+
+	"github.com/chnm/apiary/internal/httpx"
+	"github.com/chnm/apiary/internal/testsupport"
 )
 
 func TestTotalBillsHandlerRejectsInvalidType(t *testing.T) {
@@ -15,12 +17,7 @@ func TestTotalBillsHandlerRejectsInvalidType(t *testing.T) {
 
 	(&Handler{}).TotalBillsHandler().ServeHTTP(response, request)
 
-	if response.Code != http.StatusBadRequest {
-		t.Fatalf("status = %d, want %d", response.Code, http.StatusBadRequest)
-	}
-	if body := strings.TrimSpace(response.Body.String()); !strings.HasPrefix(body, "Invalid type parameter.") {
-		t.Fatalf("body = %q, want invalid type error", body)
-	}
+	testsupport.AssertProblem(t, response, http.StatusBadRequest, httpx.CodeInvalidParameter, "type")
 }
 
 func TestBillsHandlerRejectsInvalidSort(t *testing.T) {
@@ -32,11 +29,9 @@ func TestBillsHandlerRejectsInvalidSort(t *testing.T) {
 
 	(&Handler{}).BillsHandler().ServeHTTP(response, request)
 
-	if response.Code != http.StatusBadRequest {
-		t.Fatalf("status = %d, want %d", response.Code, http.StatusBadRequest)
-	}
-	if body := strings.TrimSpace(response.Body.String()); body != "invalid sort: supported values are year, week_number, and canonical_name" {
-		t.Fatalf("body = %q, want fixed invalid sort error", body)
+	testsupport.AssertProblem(t, response, http.StatusBadRequest, httpx.CodeInvalidParameter, "sort")
+	if problem := testsupport.DecodeProblem(t, response); problem.Detail != "sort must be year, week_number, or canonical_name" {
+		t.Fatalf("detail = %q, want fixed invalid sort error", problem.Detail)
 	}
 }
 
@@ -57,128 +52,28 @@ func TestParseAPIParametersParish(t *testing.T) {
 		t.Errorf("expected [1 5 151], got %v", params.Parish)
 	}
 
-	// The parser does not range-check IDs; existence is validated against
-	// the database by Handler.invalidParishIDs.
-	r = httptest.NewRequest("GET", "/bom/bills?parish=0,99999", nil)
-	params, err = parseAPIParameters(r)
-	if err != nil {
-		t.Fatalf("unexpected error for out-of-range IDs: %v", err)
-	}
-	if !reflect.DeepEqual(params.Parish, []int{0, 99999}) {
-		t.Errorf("expected [0 99999], got %v", params.Parish)
-	}
-}
-
-func TestAPIParametersGetQueryOptions(t *testing.T) {
-	tests := []struct {
-		name   string
-		params APIParameters
-		want   QueryOptions
-	}{
-		{
-			name:   "direct limit and offset",
-			params: APIParameters{Limit: 50, Offset: 10},
-			want:   QueryOptions{Limit: 50, Offset: 10},
-		},
-		{
-			name:   "page overrides direct pagination",
-			params: APIParameters{Page: 3, Limit: 50, Offset: 10},
-			want:   QueryOptions{Limit: 25, Offset: 50},
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			if got := tt.params.GetQueryOptions(); got != tt.want {
-				t.Fatalf("GetQueryOptions() = %+v, want %+v", got, tt.want)
-			}
-		})
-	}
-}
-
-func TestCursorRoundTrip(t *testing.T) {
-	cursor, err := generateCursor(1665, 12, "All Hallows")
-	if err != nil {
-		t.Fatalf("generateCursor: %v", err)
+	// IDs must be positive, but the parser does not check that they exist;
+	// that is validated against the database by Store.InvalidParishIDs.
+	r = httptest.NewRequest("GET", "/bom/bills?parish=0", nil)
+	if _, err := parseAPIParameters(r); err == nil {
+		t.Error("expected error for parish ID 0, got nil")
 	}
-
-	year, week, name, err := parseCursor(cursor)
+	r = httptest.NewRequest("GET", "/bom/bills?parish=99999", nil)
+	params, err = parseAPIParameters(r)
 	if err != nil {
-		t.Fatalf("parseCursor: %v", err)
-	}
-	if year != 1665 || week != 12 || name != "All Hallows" {
-		t.Fatalf(
-			"parseCursor() = (%d, %d, %q), want (1665, 12, %q)",
-			year,
-			week,
-			name,
-			"All Hallows",
-		)
-	}
-}
-
-func TestParseCursorRejectsMalformedValues(t *testing.T) {
-	tests := []struct {
-		name   string
-		cursor string
-	}{
-		{name: "invalid base64", cursor: "%%%"},
-		{
-			name:   "wrong field count",
-			cursor: base64.URLEncoding.EncodeToString([]byte("1665|12")),
-		},
-		{
-			name:   "invalid year",
-			cursor: base64.URLEncoding.EncodeToString([]byte("year|12|Parish")),
-		},
-		{
-			name:   "invalid week",
-			cursor: base64.URLEncoding.EncodeToString([]byte("1665|week|Parish")),
-		},
+		t.Fatalf("unexpected error for an unknown ID: %v", err)
 	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			if _, _, _, err := parseCursor(tt.cursor); err == nil {
-				t.Fatal("parseCursor returned nil error")
-			}
-		})
-	}
-}
-
-func TestGetEffectiveLimit(t *testing.T) {
-	tests := []struct {
-		name   string
-		params APIParameters
-		want   int
-	}{
-		{name: "default", params: APIParameters{}, want: 100},
-		{name: "direct", params: APIParameters{Limit: 25}, want: 25},
-		{name: "page", params: APIParameters{Page: 2, Limit: 25}, want: 100},
-		{name: "cursor", params: APIParameters{Cursor: "cursor", Limit: 25}, want: 100},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			if got := getEffectiveLimit(tt.params); got != tt.want {
-				t.Fatalf("getEffectiveLimit() = %d, want %d", got, tt.want)
-			}
-		})
+	if !reflect.DeepEqual(params.Parish, []int{99999}) {
+		t.Errorf("expected [99999], got %v", params.Parish)
 	}
 }
 
 func TestParseAPIParameters(t *testing.T) {
-	cursor, err := generateCursor(1665, 12, "All Hallows")
-	if err != nil {
-		t.Fatalf("generate cursor: %v", err)
-	}
-
 	request := httptest.NewRequest(
 		http.MethodGet,
 		"/bom/bills?start-year=1664&end-year=1666&start-week=2&end-week=50"+
 			"&parish=1,2&bill-type=Weekly&count-type=Buried&missing=true"+
-			"&illegible=false&limit=25&offset=5&page=2&sort=canonical_name"+
-			"&cursor="+cursor,
+			"&illegible=false&sort=canonical_name",
 		nil,
 	)
 	params, err := parseAPIParameters(request)
@@ -195,8 +90,9 @@ func TestParseAPIParameters(t *testing.T) {
 	if !reflect.DeepEqual(params.Parish, []int{1, 2}) {
 		t.Fatalf("parishes = %v, want [1 2]", params.Parish)
 	}
-	if params.BillType != "Weekly" || params.CountType != "Buried" {
-		t.Fatalf("types = (%q, %q), want (Weekly, Buried)", params.BillType, params.CountType)
+	// Types match without regard to case and are passed on in lower case.
+	if params.BillType != "weekly" || params.CountType != "buried" {
+		t.Fatalf("types = (%q, %q), want (weekly, buried)", params.BillType, params.CountType)
 	}
 	if params.Missing == nil || !*params.Missing {
 		t.Fatal("missing parameter was not parsed as true")
@@ -204,25 +100,9 @@ func TestParseAPIParameters(t *testing.T) {
 	if params.Illegible == nil || *params.Illegible {
 		t.Fatal("illegible parameter was not parsed as false")
 	}
-	if params.Limit != 25 || params.Offset != 5 || params.Page != 2 {
-		t.Fatalf(
-			"pagination = limit %d offset %d page %d, want 25, 5, 2",
-			params.Limit,
-			params.Offset,
-			params.Page,
-		)
-	}
 	if params.Sort != "canonical_name" {
 		t.Fatalf("sort = %q, want canonical_name", params.Sort)
 	}
-	if params.CursorYear != 1665 || params.CursorWeek != 12 || params.CursorName != "All Hallows" {
-		t.Fatalf(
-			"cursor = (%d, %d, %q), want (1665, 12, All Hallows)",
-			params.CursorYear,
-			params.CursorWeek,
-			params.CursorName,
-		)
-	}
 }
 
 func TestParseAPIParametersSort(t *testing.T) {
@@ -282,10 +162,8 @@ func TestParseAPIParametersRejectsMalformedValues(t *testing.T) {
 		{name: "count type", query: "count-type=unknown"},
 		{name: "missing", query: "missing=sometimes"},
 		{name: "illegible", query: "illegible=sometimes"},
-		{name: "limit", query: "limit=many"},
-		{name: "offset", query: "offset=later"},
-		{name: "page", query: "page=next"},
-		{name: "cursor", query: "cursor=invalid!"},
+		{name: "repeated year", query: "start-year=1664&start-year=1665"},
+		{name: "unknown parameter", query: "start_year=1664"},
 	}
 
 	for _, tt := range tests {
@@ -303,40 +181,48 @@ func TestBuildBillsQueryPaginationModes(t *testing.T) {
 		name          string
 		params        APIParameters
 		queryContains []string
+		queryOmits    []string
 		wantParams    []interface{}
 	}{
 		{
-			name:          "default",
+			name:          "unlimited",
 			params:        APIParameters{},
-			queryContains: []string{"COUNT(*) OVER()", "LIMIT $1"},
-			wantParams:    []interface{}{100},
+			queryContains: []string{"COUNT(*) OVER()"},
+			queryOmits:    []string{"LIMIT", "OFFSET"},
+			wantParams:    []interface{}{},
 		},
 		{
-			name:          "direct",
+			name:          "offset",
 			params:        APIParameters{Limit: 25, Offset: 10},
-			queryContains: []string{"LIMIT $1 OFFSET $2"},
+			queryContains: []string{"COUNT(*) OVER()", "LIMIT $1 OFFSET $2"},
 			wantParams:    []interface{}{25, 10},
 		},
 		{
-			name:          "page",
-			params:        APIParameters{Page: 2},
-			queryContains: []string{"LIMIT $1 OFFSET $2"},
-			wantParams:    []interface{}{100, 100},
-		},
-		{
-			name: "cursor",
+			name: "keyed",
 			params: APIParameters{
-				Cursor:     "cursor",
-				CursorYear: 1665,
-				CursorWeek: 12,
-				CursorName: "All Hallows",
+				Limit: 101,
+				After: &billKey{Year: 1665, Week: 12, Name: "All Hallows", ID: 7},
 			},
 			queryContains: []string{
 				"0 AS totalrecords",
-				"(b.year, w.week_number, p.canonical_name) > ($1, $2, $3)",
-				"LIMIT $4",
+				"(b.year, w.week_number, p.canonical_name, b.id) > ($1, $2, $3, $4)",
+				"LIMIT $5",
 			},
-			wantParams: []interface{}{1665, 12, "All Hallows", 100},
+			queryOmits: []string{"OFFSET"},
+			wantParams: []interface{}{1665, 12, "All Hallows", int64(7), 101},
+		},
+		{
+			name:          "stream",
+			params:        APIParameters{Stream: true},
+			queryContains: []string{"0 AS totalrecords"},
+			queryOmits:    []string{"COUNT(*) OVER()", "LIMIT"},
+			wantParams:    []interface{}{},
+		},
+		{
+			name:          "stream with limit",
+			params:        APIParameters{Stream: true, Limit: 25},
+			queryContains: []string{"0 AS totalrecords", "LIMIT $1"},
+			wantParams:    []interface{}{25},
 		},
 	}
 
@@ -351,6 +237,11 @@ func TestBuildBillsQueryPaginationModes(t *testing.T) {
 					t.Errorf("query does not contain %q", fragment)
 				}
 			}
+			for _, fragment := range tt.queryOmits {
+				if strings.Contains(query.Query, fragment) {
+					t.Errorf("query contains %q", fragment)
+				}
+			}
 			if !reflect.DeepEqual(query.Params, tt.wantParams) {
 				t.Fatalf("params = %#v, want %#v", query.Params, tt.wantParams)
 			}
@@ -368,33 +259,30 @@ func TestBuildBillsQuerySorts(t *testing.T) {
 		{
 			name:   "default",
 			params: APIParameters{},
-			want:   "ORDER BY b.year, w.week_number, p.canonical_name ASC",
+			want:   "ORDER BY b.year, w.week_number, p.canonical_name, b.id ASC",
 		},
 		{
 			name:   "year",
 			params: APIParameters{Sort: "year"},
-			want:   "ORDER BY b.year, w.week_number, p.canonical_name ASC",
+			want:   "ORDER BY b.year, w.week_number, p.canonical_name, b.id ASC",
 		},
 		{
 			name:   "week number",
 			params: APIParameters{Sort: "week_number"},
-			want:   "ORDER BY w.week_number, b.year, p.canonical_name ASC",
+			want:   "ORDER BY w.week_number, b.year, p.canonical_name, b.id ASC",
 		},
 		{
 			name:   "canonical name",
 			params: APIParameters{Sort: "canonical_name"},
-			want:   "ORDER BY p.canonical_name, b.year, w.week_number ASC",
+			want:   "ORDER BY p.canonical_name, b.year, w.week_number, b.id ASC",
 		},
 		{
-			name: "cursor retains continuation order",
+			name: "key retains continuation order",
 			params: APIParameters{
-				Sort:       "canonical_name",
-				Cursor:     "cursor",
-				CursorYear: 1665,
-				CursorWeek: 12,
-				CursorName: "All Hallows",
+				Sort:  "canonical_name",
+				After: &billKey{Year: 1665, Week: 12, Name: "All Hallows", ID: 7},
 			},
-			want: "ORDER BY b.year, w.week_number, p.canonical_name ASC",
+			want: "ORDER BY b.year, w.week_number, p.canonical_name, b.id ASC",
 		},
 		{
 			name:    "rejects unvalidated caller",
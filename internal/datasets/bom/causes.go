@@ -1,22 +1,25 @@
 package bom
 
 import (
-	"fmt"
-	"log"
 	"net/http"
-	"strconv"
-	"strings"
 
-	"github.com/jackc/pgx/v5"
+	"github.com/chnm/apiary/internal/httpx"
+	"github.com/chnm/apiary/internal/httpx/pagination"
+	paramx "github.com/chnm/apiary/internal/params"
 )
 
 type DeathsAPIParameters struct {
 	StartYear int
 	EndYear   int
 	Death     []string
-	Sort      string
+	BillType  string
+	Limit     int // No limit if zero
+	Offset    int
 }
 
+// causesPages bounds the pages of DeathCausesHandler.
+var causesPages = pagination.Records
+
 // DeathCauses returns a list of causes of death with a count of deaths for each
 // cause and related metadata.
 type DeathCauses struct {
@@ -43,264 +46,73 @@ type Causes struct {
 	BillType string `json:"bill_type"`
 }
 
-// DeathCausesHandler returns a JSON array of causes of death. The list of causes
+// causesParams declares the parameters of DeathCausesHandler.
+var causesParams = paramx.NewSet(
+	startYearParameter,
+	endYearParameter,
+	paramx.String("id", "Canonical cause names from /bom/list-deaths").List(),
+	billTypeParameter,
+).Accepting(httpx.FormatParameter).Accepting(pagination.Parameters(causesPages)...)
+
+// DeathCausesHandler returns a page of causes of death. The list of causes
 // depends on whether a user has provided a comma-separated list of causes. If
 // no list is provided, it returns the entire list of causes.
 func (h *Handler) DeathCausesHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		startYear := r.URL.Query().Get("start-year")
-		endYear := r.URL.Query().Get("end-year")
-		causes := r.URL.Query().Get("id")
-		billType := r.URL.Query().Get("bill-type")
-		limit := r.URL.Query().Get("limit")
-		offset := r.URL.Query().Get("offset")
-
-		apiParams := DeathsAPIParameters{
-			StartYear: 1648,
-			EndYear:   1750,
-			Death:     []string{},
-			Sort:      "year, week_number, name",
-		}
-
-		// If a start year is provided, update the API parameters
-		if startYear != "" {
-			startYearInt, err := strconv.Atoi(startYear)
-			if err != nil {
-				http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
-				log.Println("start year is not an integer", err)
-				return
-			}
-
-			apiParams.StartYear = startYearInt
-		}
-
-		// If an end year is provided, update the API parameters
-		if endYear != "" {
-			endYearInt, err := strconv.Atoi(endYear)
-			if err != nil {
-				http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
-				log.Println("end year is not an integer", err)
-				return
-			}
-
-			apiParams.EndYear = endYearInt
-		}
-
-		// if a cause string is provided, update the API parameters
-		if causes != "" {
-			causesList := strings.Split(causes, ",")
-			var causesStr []string
-
-			for _, p := range causesList {
-				causeStr := strings.TrimSpace(p)
-				if causeStr == "" {
-					http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
-					log.Println("cause is an empty string")
-					return
-				}
-				causesStr = append(causesStr, causeStr)
-			}
-
-			apiParams.Death = causesStr
-		}
-
-		// Validate bill type if provided
-		if billType != "" {
-			if !IsValidBillType(billType) {
-				http.Error(w, "Invalid bill type", http.StatusBadRequest)
-				return
-			}
-		}
-
-		query := `
-    SELECT 
-        c.original_name as death,
-				c.name,
-        c.bill_type,
-        c.count, 
-        c.definition,
-        c.definition_source,
-        c.week_id,
-        w.week_number,
-        w.start_day, 
-        w.start_month, 
-        w.end_day, 
-        w.end_month, 
-        y.year,
-        w.split_year,
-        COUNT(*) OVER() AS totalrecords
-    FROM 
-        bom.causes_of_death c
-    JOIN 
-        bom.week w ON w.joinid = c.week_id
-    JOIN
-        bom.year y ON y.year = w.year
-    WHERE 
-        y.year::int >= $1
-        AND y.year::int <= $2
-        AND count IS NOT NULL
-    `
-
-		paramCount := 2
-
-		if len(apiParams.Death) > 0 {
-			paramCount++
-			// Support filtering by canonical names with " & " separator
-			// This allows filtering by "consumption" to match both "consumption"
-			// and "consumption & cough" by splitting on ' & ' and checking for matches
-			query += fmt.Sprintf(` AND EXISTS (
-				SELECT 1
-				FROM unnest($%d::text[]) AS selected_cause
-				WHERE selected_cause = ANY(string_to_array(c.name, ' & '))
-			)`, paramCount)
-		}
-
-		if billType != "" {
-			paramCount++
-			query += fmt.Sprintf(" AND c.bill_type = $%d", paramCount)
-		}
-
-		query += " ORDER BY " + apiParams.Sort
-
-		if limit != "" {
-			limitInt, err := strconv.Atoi(limit)
-			if err != nil {
-				http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
-				log.Println("limit is not an integer", err)
-				return
-			}
-
-			query += " LIMIT " + strconv.Itoa(limitInt)
-		}
-
-		// If an offset is provided, add it to the query
-		if offset != "" {
-			offsetInt, err := strconv.Atoi(offset)
-			if err != nil {
-				http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
-				log.Println("offset is not an integer", err)
-				return
-			}
-
-			query += " OFFSET " + strconv.Itoa(offsetInt)
-		}
-
-		results := make([]DeathCauses, 0)
-		var row DeathCauses
-		var rows pgx.Rows
-		var err error
-
-		// Build parameters slice
-		params := []interface{}{apiParams.StartYear, apiParams.EndYear}
-
-		if len(apiParams.Death) > 0 {
-			params = append(params, apiParams.Death)
+		values, err := causesParams.Parse(r)
+		if err != nil {
+			httpx.BadRequest(w, r, err)
+			return
 		}
-
-		if billType != "" {
-			params = append(params, billType)
+		apiParams := DeathsAPIParameters{
+			StartYear: values.Int("start-year"),
+			EndYear:   values.Int("end-year"),
+			Death:     values.Strings("id"),
+			BillType:  values.String("bill-type"),
 		}
 
-		rows, err = h.db.Query(r.Context(), query, params...)
+		q, err := pagination.Parse(r, causesPages)
 		if err != nil {
-			internalServerError(w, "error querying death causes", err)
+			httpx.BadRequest(w, r, err)
 			return
 		}
+		apiParams.Limit, apiParams.Offset = q.Fetch(), q.Offset
 
-		defer rows.Close()
-		for rows.Next() {
-			err := rows.Scan(
-				&row.Death,
-				&row.Name,
-				&row.BillType,
-				&row.Count,
-				&row.Definition,
-				&row.DefinitionSource,
-				&row.WeekID,
-				&row.WeekNumber,
-				&row.StartDay,
-				&row.StartMonth,
-				&row.EndDay,
-				&row.EndMonth,
-				&row.Year,
-				&row.SplitYear,
-				&row.TotalRecords,
-			)
-			if err != nil {
-				internalServerError(w, "error scanning death cause", err)
-				return
-			}
-			results = append(results, row)
-		}
-		if err := rows.Err(); err != nil {
-			internalServerError(w, "error iterating death causes", err)
+		results, err := h.store.DeathCauses(r.Context(), apiParams)
+		if err != nil {
+			internalServerError(w, r, "error querying death causes", err)
 			return
 		}
 
-		writeJSONResponse(w, results)
+		pagination.WriteTable(w, r, pagination.NewPage(q, results))
 	}
 }
 
-func (h *Handler) ListCausesHandler() http.HandlerFunc {
-	// Query to get a unique list of canonical cause names filtered by bill type
+// listParams declares the parameters of ListCausesHandler and
+// ListChristeningsHandler.
+var listParams = paramx.NewSet(billTypeParameter).Accepting(pagination.Parameters(pagination.Lists)...)
 
+// ListCausesHandler returns the canonical cause names, optionally filtered by
+// bill type.
+func (h *Handler) ListCausesHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		billType := r.URL.Query().Get("bill-type")
-
-		// Validate bill type if provided
-		if billType != "" {
-			if !IsValidBillType(billType) {
-				http.Error(w, "Invalid bill type", http.StatusBadRequest)
-				return
-			}
-		}
-
-		query := `
-		SELECT DISTINCT
-			name,
-			bill_type
-		FROM
-			bom.causes_of_death
-		WHERE
-			name IS NOT NULL
-		`
-
-		// Add bill type filter if provided
-		if billType != "" {
-			query += " AND bill_type = $1"
-		}
-
-		query += " ORDER BY name ASC, bill_type ASC"
-
-		results := make([]Causes, 0)
-		var row Causes
-		var rows pgx.Rows
-		var err error
-
-		// Execute query with or without bill type parameter
-		if billType != "" {
-			rows, err = h.db.Query(r.Context(), query, billType)
-		} else {
-			rows, err = h.db.Query(r.Context(), query)
-		}
-
+		values, err := listParams.Parse(r)
 		if err != nil {
-			internalServerError(w, "error querying cause list", err)
+			httpx.BadRequest(w, r, err)
 			return
 		}
-		defer rows.Close()
-		for rows.Next() {
-			if err := rows.Scan(&row.Name, &row.BillType); err != nil {
-				internalServerError(w, "error scanning cause list", err)
-				return
-			}
-			results = append(results, row)
+		q, err := pagination.Parse(r, pagination.Lists)
+		if err != nil {
+			httpx.BadRequest(w, r, err)
+			return
 		}
-		if err := rows.Err(); err != nil {
-			internalServerError(w, "error iterating cause list", err)
+
+		results, err := h.store.ListCauses(r.Context(), values.String("bill-type"))
+		if err != nil {
+			internalServerError(w, r, "error querying cause list", err)
 			return
 		}
 
-		writeJSONResponse(w, results)
+		pagination.WriteJSON(w, r, pagination.Slice(q, results))
 	}
 }
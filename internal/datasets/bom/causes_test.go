@@ -4,9 +4,10 @@ import (
 	"context"
 	"net/http"
 	"net/http/httptest"
-	"strings"
 	"testing"
 
+	"github.com/chnm/apiary/internal/httpx"
+	"github.com/chnm/apiary/internal/testsupport"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -20,7 +21,7 @@ func TestDeathCausesHandlerQueryErrorReturnsInternalServerError(t *testing.T) {
 	}
 	pool.Close()
 
-	server := &Handler{db: pool}
+	server := New(pool)
 	request := httptest.NewRequest(
 		http.MethodGet,
 		"/bom/causes?start-year=1669&end-year=1754",
@@ -30,11 +31,5 @@ func TestDeathCausesHandlerQueryErrorReturnsInternalServerError(t *testing.T) {
 
 	server.DeathCausesHandler().ServeHTTP(response, request)
 
-	if response.Code != http.StatusInternalServerError {
-		t.Fatalf("status = %d, want %d", response.Code, http.StatusInternalServerError)
-	}
-
-	if body := strings.TrimSpace(response.Body.String()); body != http.StatusText(http.StatusInternalServerError) {
-		t.Fatalf("body = %q, want %q", body, http.StatusText(http.StatusInternalServerError))
-	}
+	testsupport.AssertProblem(t, response, http.StatusInternalServerError, httpx.CodeInternal, "")
 }
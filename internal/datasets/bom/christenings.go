@@ -1,12 +1,11 @@
 package bom
 
 import (
-	"fmt"
 	"net/http"
-	"strconv"
-	"strings"
 
-	"github.com/jackc/pgx/v5"
+	"github.com/chnm/apiary/internal/httpx"
+	"github.com/chnm/apiary/internal/httpx/pagination"
+	paramx "github.com/chnm/apiary/internal/params"
 )
 
 // ChristeningsByYear describes a christening's description, total count, week number,
@@ -25,247 +24,87 @@ type ChristeningsByYear struct {
 	TotalRecords int        `json:"totalrecords"`
 }
 
+// ChristeningsParameters selects the christenings returned by
+// ChristeningsHandler.
+type ChristeningsParameters struct {
+	StartYear int
+	EndYear   int    // Exclusive
+	Locations []int  // Christening location IDs; all if empty
+	BillType  string // All bill types if empty
+	Limit     int
+	Offset    int
+}
+
+// christeningsPages bounds the pages of ChristeningsHandler.
+var christeningsPages = pagination.Records.WithDefaultLimit(25)
+
 // Christenings describes a christening location.
 type Christenings struct {
 	Name     string `json:"name"`
 	BillType string `json:"bill_type"`
 }
 
+// christeningsParams declares the parameters of ChristeningsHandler.
+var christeningsParams = paramx.NewSet(
+	paramx.Int("start-year", "First year to include").Required(),
+	paramx.Int("end-year", "Year after the last year to include").Required(),
+	paramx.Int("id", "Christening location IDs").List(),
+	paramx.Enum("bill-type", "Restrict results to one type of bill", "weekly", "general"),
+).Accepting(pagination.Parameters(christeningsPages)...)
+
 // ChristeningsHandler returns the christenings for a given range of years. It expects a start year and
 // end year as query parameters. Optional query parameters: id (location filter), bill-type (general/weekly filter).
 func (h *Handler) ChristeningsHandler() http.HandlerFunc {
-	queryLocation := `
-	SELECT
-		c.christening,
-		c.count,
-		c.week_number,
-		c.start_day,
-		c.start_month,
-		c.end_day,
-		c.end_month,
-		y.year,
-		c.bill_type,
-		COUNT(*) OVER() AS totalrecords
-	FROM
-		bom.christenings c
-	JOIN
-		bom.year y ON y.year = c.year
-	JOIN
-		bom.christening_locations l ON l.name = c.christening
-	WHERE
-		y.year >= $1::int
-		AND y.year < $2::int
-		AND (
-			$3::int[] IS NULL
-			OR l.id = ANY($3::int[])
-		)
-		AND (
-			$6::text IS NULL
-			OR c.bill_type = $6::text
-		)	
-	ORDER BY
-		year ASC,
-		week_number ASC
-	LIMIT $4
-	OFFSET $5;
-	`
-
-	query := `
-	SELECT
-		c.christening,
-		c.count,
-		c.week_number,
-		c.start_day,
-		c.start_month,
-		c.end_day,
-		c.end_month,
-		y.year,
-		c.bill_type,
-		COUNT(*) OVER() AS totalrecords
-	FROM
-		bom.christenings c
-	JOIN
-		bom.year y ON y.year = c.year
-	WHERE
-		y.year >= $1::int
-		AND y.year < $2::int
-		AND (
-			$5::text IS NULL
-			OR c.bill_type = $5::text
-		)
-	ORDER BY
-		year ASC,
-		week_number ASC
-	LIMIT $3
-	OFFSET $4;
-	`
-
 	return func(w http.ResponseWriter, r *http.Request) {
-		startYear := r.URL.Query().Get("start-year")
-		endYear := r.URL.Query().Get("end-year")
-		location := r.URL.Query().Get("id")
-		billType := r.URL.Query().Get("bill-type")
-		limit := r.URL.Query().Get("limit")
-		offset := r.URL.Query().Get("offset")
-
-		if startYear == "" || endYear == "" {
-			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
-			return
-		}
-
-		startYearInt, err := strconv.Atoi(startYear)
-		if err != nil {
-			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
-			return
-		}
-
-		endYearInt, err := strconv.Atoi(endYear)
+		values, err := christeningsParams.Parse(r)
 		if err != nil {
-			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
-			return
-		}
-
-		if limit == "" {
-			limit = "25"
-		}
-		if offset == "" {
-			offset = "0"
-		}
-
-		// location needs to be a postgres array
-		location = fmt.Sprintf("{%s}", strings.TrimSpace(location))
-
-		// Validate bill_type parameter
-		if billType != "" && billType != "general" && billType != "weekly" {
-			http.Error(w, "bill-type must be 'general' or 'weekly'", http.StatusBadRequest)
+			httpx.BadRequest(w, r, err)
 			return
 		}
 
-		// Convert empty bill_type to nil for SQL query
-		var billTypeParam interface{}
-		if billType == "" {
-			billTypeParam = nil
-		} else {
-			billTypeParam = billType
-		}
-
-		limitInt, err := strconv.Atoi(limit)
+		q, err := pagination.Parse(r, christeningsPages)
 		if err != nil {
-			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			httpx.BadRequest(w, r, err)
 			return
 		}
 
-		offsetInt, err := strconv.Atoi(offset)
+		results, err := h.store.Christenings(r.Context(), ChristeningsParameters{
+			StartYear: values.Int("start-year"),
+			EndYear:   values.Int("end-year"),
+			Locations: values.Ints("id"),
+			BillType:  values.String("bill-type"),
+			Limit:     q.Fetch(),
+			Offset:    q.Offset,
+		})
 		if err != nil {
-			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			internalServerError(w, r, "error querying christenings", err)
 			return
 		}
 
-		results := make([]ChristeningsByYear, 0)
-		var row ChristeningsByYear
-		var rows pgx.Rows
-
-		switch {
-		case location == "{}":
-			rows, err = h.db.Query(r.Context(), query, startYearInt, endYearInt, limitInt, offsetInt, billTypeParam)
-		case location != "{}":
-			rows, err = h.db.Query(r.Context(), queryLocation, startYearInt, endYearInt, location, limitInt, offsetInt, billTypeParam)
-		default:
-			rows, err = h.db.Query(r.Context(), query, startYearInt, endYearInt, limitInt, offsetInt, billTypeParam)
-		}
-
-		if err != nil {
-			internalServerError(w, "error querying christenings", err)
-			return
-		}
-		defer rows.Close()
-		for rows.Next() {
-			err := rows.Scan(
-				&row.Christening,
-				&row.TotalCount,
-				&row.WeekNumber,
-				&row.StartDay,
-				&row.StartMonth,
-				&row.EndDay,
-				&row.EndMonth,
-				&row.Year,
-				&row.BillType,
-				&row.TotalRecords,
-			)
-			if err != nil {
-				internalServerError(w, "error scanning christening", err)
-				return
-			}
-			results = append(results, row)
-		}
-		if err := rows.Err(); err != nil {
-			internalServerError(w, "error iterating christenings", err)
-			return
-		}
-
-		writeJSONResponse(w, results)
+		pagination.WriteJSON(w, r, pagination.NewPage(q, results))
 	}
 }
 
 // ListChristeningsHandler returns a list of unique christening names filtered by bill type.
 func (h *Handler) ListChristeningsHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		billType := r.URL.Query().Get("bill-type")
-
-		// Validate bill type if provided
-		if billType != "" {
-			if !IsValidBillType(billType) {
-				http.Error(w, "Invalid bill type", http.StatusBadRequest)
-				return
-			}
-		}
-
-		query := `
-		SELECT DISTINCT
-			christening,
-			bill_type
-		FROM
-			bom.christenings
-		WHERE
-			christening IS NOT NULL
-		`
-
-		// Add bill type filter if provided
-		if billType != "" {
-			query += " AND bill_type = $1"
-		}
-
-		query += " ORDER BY christening ASC, bill_type ASC"
-
-		results := make([]Christenings, 0)
-		var row Christenings
-		var rows pgx.Rows
-		var err error
-
-		// Execute query with or without bill type parameter
-		if billType != "" {
-			rows, err = h.db.Query(r.Context(), query, billType)
-		} else {
-			rows, err = h.db.Query(r.Context(), query)
-		}
-
+		values, err := listParams.Parse(r)
 		if err != nil {
-			internalServerError(w, "error querying christening list", err)
+			httpx.BadRequest(w, r, err)
 			return
 		}
-		defer rows.Close()
-		for rows.Next() {
-			if err := rows.Scan(&row.Name, &row.BillType); err != nil {
-				internalServerError(w, "error scanning christening list", err)
-				return
-			}
-			results = append(results, row)
+		q, err := pagination.Parse(r, pagination.Lists)
+		if err != nil {
+			httpx.BadRequest(w, r, err)
+			return
 		}
-		if err := rows.Err(); err != nil {
-			internalServerError(w, "error iterating christening list", err)
+
+		results, err := h.store.ListChristenings(r.Context(), values.String("bill-type"))
+		if err != nil {
+			internalServerError(w, r, "error querying christening list", err)
 			return
 		}
 
-		writeJSONResponse(w, results)
+		pagination.WriteJSON(w, r, pagination.Slice(q, results))
 	}
 }
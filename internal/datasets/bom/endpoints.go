@@ -1,13 +1,31 @@
 package bom
 
-import "github.com/chnm/apiary/internal/httpx"
+import (
+	"github.com/chnm/apiary/internal/httpx"
+	"github.com/chnm/apiary/internal/httpx/pagination"
+	paramx "github.com/chnm/apiary/internal/params"
+)
+
+var (
+	billTypes  = []string{"weekly", "general", "total"}
+	countTypes = []string{"buried", "plague"}
+
+	startYearParameter = paramx.Int("start-year", "First year to include").Default(1648)
+	endYearParameter   = paramx.Int("end-year", "Last year to include").Default(1750)
+	billTypeParameter  = paramx.Enum("bill-type", "Restrict results to one type of bill", billTypes...)
+	countTypeParameter = paramx.Enum("count-type", "Restrict results to one type of count", countTypes...)
+	parishParameter    = paramx.Int("parish", "Parish IDs from /bom/parishes").List().Min(1)
+)
 
 // Endpoints returns the BOM entries for the API's root endpoint catalog.
 func Endpoints(baseURL string) []httpx.Endpoint {
 	return []httpx.Endpoint{
 		{
-			Name: "BOM: Total records",
-			URL:  baseURL + "/bom/totalbills?type=weekly",
+			Name:       "BOM: Total records",
+			URL:        baseURL + "/bom/totalbills?type=weekly",
+			Path:       "/bom/totalbills",
+			Parameters: totalBillsParams.Parameters(),
+			Response:   []TotalBills{},
 			Examples: []httpx.ExampleURL{
 				{URL: baseURL + "/bom/totalbills?type=causes", Purpose: "Total records for causes of death"},
 				{URL: baseURL + "/bom/totalbills?type=christenings", Purpose: "Total records for christenings"},
@@ -16,12 +34,17 @@ func Endpoints(baseURL string) []httpx.Endpoint {
 			},
 		},
 		{
-			Name: "BOM: Parishes",
-			URL:  baseURL + "/bom/parishes",
+			Name:       "BOM: Parishes",
+			URL:        baseURL + "/bom/parishes",
+			Path:       "/bom/parishes",
+			Parameters: parishesParams.Parameters(),
+			Response:   pagination.Page[Parish]{},
 		},
 		{
-			Name: "BOM: Completion Statistics",
-			URL:  baseURL + "/bom/statistics",
+			Name:       "BOM: Completion Statistics",
+			URL:        baseURL + "/bom/statistics",
+			Path:       "/bom/statistics",
+			Parameters: statisticsParams.Parameters(),
 			Examples: []httpx.ExampleURL{
 				{URL: baseURL + "/bom/statistics?type=weekly", Purpose: "Group completed bill transcriptions by week"},
 				{URL: baseURL + "/bom/statistics?type=yearly", Purpose: "Group completed bill transcriptions by year"},
@@ -30,8 +53,12 @@ func Endpoints(baseURL string) []httpx.Endpoint {
 			},
 		},
 		{
-			Name: "BOM: Bills data with parish polygons",
-			URL:  baseURL + "/bom/shapefiles",
+			Name:        "BOM: Bills data with parish polygons",
+			URL:         baseURL + "/bom/shapefiles",
+			Path:        "/bom/shapefiles",
+			Parameters:  shapefilesParams.Parameters(),
+			Response:    httpx.FeatureCollection{},
+			ContentType: "application/geo+json",
 			Examples: []httpx.ExampleURL{
 				{URL: baseURL + "/bom/shapefiles?year=1665", Purpose: "Bills data with parish polygons for a specific year"},
 				{URL: baseURL + "/bom/shapefiles?start-year=1664&end-year=1666", Purpose: "Bills data with parish polygons for a range of years"},
@@ -39,42 +66,63 @@ func Endpoints(baseURL string) []httpx.Endpoint {
 			},
 		},
 		{
-			Name: "BOM: Bills of Mortality",
-			URL:  baseURL + "/bom/bills?start-year=1636&end-year=1754",
+			Name:       "BOM: Bills of Mortality",
+			URL:        baseURL + "/bom/bills?start-year=1636&end-year=1754",
+			Path:       "/bom/bills",
+			Parameters: billsParams.Parameters(),
+			Response:   pagination.Page[ParishByYear]{},
+			CSV:        true,
+			StreamRow:  ParishByYear{},
 			Examples: []httpx.ExampleURL{
-				{URL: baseURL + "/bom/bills?start-year=1636&end-year=1754&bill-type=weekly&parish=1,3,17,28&limit=50&offset=0", Purpose: "Weekly bills for a specific parish or set of parishes by ID. Bill type can be: 'weekly' or 'general'."},
-				{URL: baseURL + "/bom/bills?start-year=1636&end-year=1754&count-type=buried&limit=50&offset=0", Purpose: "Bills data for a specific count type (buried or plague). Specific parishes can be provided."},
-				{URL: baseURL + "/bom/bills?start-year=1636&end-year=1754&bill-type=weekly&count-type=buried&limit=50&offset=0", Purpose: "Bill type (weekly or general) and count type (buried or plague) can be specific. Specific parishes can be provided."},
-				{URL: baseURL + "/bom/bills?start-year=1665&end-year=1665&start-week=10&end-week=15&limit=50&offset=0", Purpose: "Filter bills by week number range (1-53). Useful for seasonal analysis or specific time periods within a year."},
-				{URL: baseURL + "/bom/bills?start-year=1665&end-year=1665&start-week=50&bill-type=weekly&count-type=plague&limit=50&offset=0", Purpose: "Combine week number filtering with other parameters. Example shows plague deaths from week 50 onwards in 1665."},
-				{URL: baseURL + "/bom/bills?start-year=1636&end-year=1754&missing=false&illegible=false&limit=50&offset=0", Purpose: "Filter out missing and illegible records. Parameters accept true/false values."},
-				{URL: baseURL + "/bom/bills?start-year=1636&end-year=1754&missing=true&limit=50&offset=0", Purpose: "Show only missing records. Can be combined with other filtering parameters."},
+				{URL: baseURL + "/bom/bills?start-year=1636&end-year=1754&bill-type=weekly&parish=1,3,17,28&limit=50", Purpose: "Weekly bills for a specific parish or set of parishes by ID. Bill type can be: 'weekly' or 'general'."},
+				{URL: baseURL + "/bom/bills?start-year=1636&end-year=1754&count-type=buried&limit=50", Purpose: "Bills data for a specific count type (buried or plague). Specific parishes can be provided."},
+				{URL: baseURL + "/bom/bills?start-year=1636&end-year=1754&bill-type=weekly&count-type=buried&limit=50", Purpose: "Bill type (weekly or general) and count type (buried or plague) can be specific. Specific parishes can be provided."},
+				{URL: baseURL + "/bom/bills?start-year=1665&end-year=1665&start-week=10&end-week=15&limit=50", Purpose: "Filter bills by week number range (1-53). Useful for seasonal analysis or specific time periods within a year."},
+				{URL: baseURL + "/bom/bills?start-year=1665&end-year=1665&start-week=50&bill-type=weekly&count-type=plague&limit=50", Purpose: "Combine week number filtering with other parameters. Example shows plague deaths from week 50 onwards in 1665."},
+				{URL: baseURL + "/bom/bills?start-year=1636&end-year=1754&missing=false&illegible=false&limit=50", Purpose: "Filter out missing and illegible records. Parameters accept true/false values."},
+				{URL: baseURL + "/bom/bills?start-year=1636&end-year=1754&missing=true&limit=50", Purpose: "Show only missing records. Can be combined with other filtering parameters."},
+				{URL: baseURL + "/bom/bills?start-year=1665&end-year=1665&bill-type=weekly&limit=50&format=csv", Purpose: "Bills as CSV for a spreadsheet; the next page is linked in the Link and X-Next-Cursor headers"},
+				{URL: baseURL + "/bom/bills?start-year=1665&end-year=1665&bill-type=weekly&format=ndjson", Purpose: "Stream every matching bill as newline-delimited JSON; no limit applies unless one is given"},
 			},
 		},
 		{
-			Name: "BOM: Causes of Death",
-			URL:  baseURL + "/bom/causes?start-year=1648&end-year=1754&limit=50&offset=0",
+			Name:       "BOM: Causes of Death",
+			URL:        baseURL + "/bom/causes?start-year=1648&end-year=1754&limit=50",
+			Path:       "/bom/causes",
+			Parameters: causesParams.Parameters(),
+			Response:   pagination.Page[DeathCauses]{},
+			CSV:        true,
 			Examples: []httpx.ExampleURL{
 				{URL: baseURL + "/bom/causes", Purpose: "Return all causes of death with bill_type indicating 'weekly' or 'general' bills"},
 				{URL: baseURL + "/bom/causes?start-year=1648&end-year=1754", Purpose: "Causes of death for a specific year range with bill_type parameter"},
 				{URL: baseURL + "/bom/causes?start-year=1648&end-year=1754&bill-type=general&id=aged,drowned", Purpose: "Causes of death for a specific year range and cause IDs with bill_type parameter"},
+				{URL: baseURL + "/bom/causes?start-year=1665&end-year=1666&id=plague&format=csv", Purpose: "Causes of death as CSV for a spreadsheet"},
 			},
 		},
 		{
-			Name: "BOM: Christenings",
-			URL:  baseURL + "/bom/christenings?start-year=1669&end-year=1754&limit=50&offset=0",
+			Name:       "BOM: Christenings",
+			URL:        baseURL + "/bom/christenings?start-year=1669&end-year=1754&limit=50",
+			Path:       "/bom/christenings",
+			Parameters: christeningsParams.Parameters(),
+			Response:   pagination.Page[ChristeningsByYear]{},
 			Examples: []httpx.ExampleURL{
 				{URL: baseURL + "/bom/christenings?start-year=1669&end-year=1754&id=1,3,17,28", Purpose: "Christenings for a specific year range and parish IDs"},
 				{URL: baseURL + "/bom/christenings?start-year=1669&end-year=1754&bill-type=weekly", Purpose: "Christenings for a specific year range from weekly bills"},
 			},
 		},
 		{
-			Name: "BOM: List of unique Causes of Death",
-			URL:  baseURL + "/bom/list-deaths",
+			Name:       "BOM: List of unique Causes of Death",
+			URL:        baseURL + "/bom/list-deaths",
+			Path:       "/bom/list-deaths",
+			Parameters: listParams.Parameters(),
+			Response:   pagination.Page[Causes]{},
 		},
 		{
-			Name: "BOM: List of unique Christening Parishes",
-			URL:  baseURL + "/bom/list-christenings",
+			Name:       "BOM: List of unique Christening Parishes",
+			URL:        baseURL + "/bom/list-christenings",
+			Path:       "/bom/list-christenings",
+			Parameters: listParams.Parameters(),
+			Response:   pagination.Page[Christenings]{},
 		},
 	}
 }
@@ -2,45 +2,111 @@
 package bom
 
 import (
+	"context"
 	"net/http"
+	"time"
 
+	"github.com/chnm/apiary/db"
+	"github.com/chnm/apiary/internal/cachex"
+	"github.com/chnm/apiary/internal/datasets"
+	"github.com/chnm/apiary/internal/deadline"
 	"github.com/chnm/apiary/internal/httpx"
+	"github.com/chnm/apiary/internal/ratelimit"
 	"github.com/gorilla/mux"
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// Store reads the Bills of Mortality. The handlers validate parameters before
+// passing them on. PostgresStore is the implementation the server uses.
+type Store interface {
+	// Bills calls fn with each bill that params selects, in order, and
+	// stops at the first error fn returns. It reads at most params.Limit
+	// bills, after params.After or after skipping params.Offset bills.
+	Bills(ctx context.Context, params APIParameters, fn func(ParishByYear) error) error
+	// TotalBills counts the weekly or general bills, or the christenings or
+	// causes of death, as kind names.
+	TotalBills(ctx context.Context, kind string) (TotalBills, error)
+
+	// WeeklyStatistics, YearlyStatistics, and ParishYearlyStatistics
+	// summarize the weekly bills transcribed so far. The parish summary is
+	// limited to one parish, by canonical name, unless parishName is empty.
+	WeeklyStatistics(ctx context.Context) ([]WeeklySummary, error)
+	YearlyStatistics(ctx context.Context) ([]YearlySummary, error)
+	ParishYearlyStatistics(ctx context.Context, parishName string) ([]ParishYearlySummary, error)
+
+	// Parishes returns every parish by canonical name.
+	Parishes(ctx context.Context) ([]Parish, error)
+	// InvalidParishIDs returns the members of ids that are not parishes, in
+	// request order and without duplicates, or nil if all of them are.
+	InvalidParishIDs(ctx context.Context, ids []int) ([]int, error)
+
+	DeathCauses(ctx context.Context, params DeathsAPIParameters) ([]DeathCauses, error)
+	Christenings(ctx context.Context, params ChristeningsParameters) ([]ChristeningsByYear, error)
+	// ListCauses and ListChristenings return the distinct names, limited to
+	// one bill type unless billType is empty.
+	ListCauses(ctx context.Context, billType string) ([]Causes, error)
+	ListChristenings(ctx context.Context, billType string) ([]Christenings, error)
+
+	// Shapefiles returns a GeoJSON FeatureCollection of the parishes that
+	// params selects, with the totals of the bills it selects.
+	Shapefiles(ctx context.Context, params ShapefileParameters) (string, error)
+}
+
 // Handler owns the dependencies and HTTP handlers for the BOM dataset.
 type Handler struct {
-	db *pgxpool.Pool
+	store Store
 }
 
-// New creates a BOM dataset handler.
-func New(db *pgxpool.Pool) *Handler {
-	return &Handler{db: db}
+// New creates a BOM dataset handler that reads from db.
+func New(db db.Querier) *Handler {
+	return NewWithStore(NewPostgresStore(db))
+}
+
+// NewWithStore creates a BOM dataset handler that reads from store.
+func NewWithStore(store Store) *Handler {
+	return &Handler{store: store}
 }
 
 // RegisterRoutes registers all BOM routes.
 func (h *Handler) RegisterRoutes(router *mux.Router) {
-	router.HandleFunc("/bom/parishes", h.ParishesHandler()).Methods("GET", "HEAD")
-	router.HandleFunc("/bom/totalbills", h.TotalBillsHandler()).Methods("GET", "HEAD")
-	router.HandleFunc("/bom/statistics", h.StatisticsHandler()).Methods("GET", "HEAD")
-	router.HandleFunc("/bom/bills", h.BillsHandler()).Methods("GET", "HEAD")
-	router.HandleFunc("/bom/shapefiles", h.BillsShapefilesHandler()).Methods("GET", "HEAD")
-	router.HandleFunc("/bom/christenings", h.ChristeningsHandler()).Methods("GET", "HEAD")
-	router.HandleFunc("/bom/causes", h.DeathCausesHandler()).Methods("GET", "HEAD")
-	router.HandleFunc("/bom/list-deaths", h.ListCausesHandler()).Methods("GET", "HEAD")
-	router.HandleFunc("/bom/list-christenings", h.ListChristeningsHandler()).Methods("GET", "HEAD")
+	// Transcription is ongoing, so new bills arrive regularly.
+	cached := cachex.TTL(time.Hour)
+	bulk := ratelimit.In(ratelimit.Bulk)
+	long := deadline.In(deadline.Bulk)
+	spatial := deadline.In(deadline.Spatial)
+	router.Handle("/bom/parishes", cached(h.ParishesHandler())).Methods("GET", "HEAD")
+	router.Handle("/bom/totalbills", cached(h.TotalBillsHandler())).Methods("GET", "HEAD")
+	router.Handle("/bom/statistics", cached(h.StatisticsHandler())).Methods("GET", "HEAD")
+	router.Handle("/bom/bills", cached(bulk(long(deadline.Streaming(h.BillsHandler()))))).Methods("GET", "HEAD")
+	router.Handle("/bom/shapefiles", cached(bulk(spatial(h.BillsShapefilesHandler())))).Methods("GET", "HEAD")
+	router.Handle("/bom/christenings", cached(h.ChristeningsHandler())).Methods("GET", "HEAD")
+	router.Handle("/bom/causes", cached(h.DeathCausesHandler())).Methods("GET", "HEAD")
+	router.Handle("/bom/list-deaths", cached(h.ListCausesHandler())).Methods("GET", "HEAD")
+	router.Handle("/bom/list-christenings", cached(h.ListChristeningsHandler())).Methods("GET", "HEAD")
+}
+
+// Name returns the identifier used to enable or disable this dataset.
+func (h *Handler) Name() string { return "bom" }
+
+// Metadata describes this dataset.
+func (h *Handler) Metadata() datasets.Metadata {
+	return datasets.Metadata{
+		Title:       "Bills of Mortality",
+		Description: "Weekly and general bills of mortality for London, 1636-1754.",
+	}
 }
 
+// Endpoints returns this dataset's entries for the root endpoint catalog.
+func (h *Handler) Endpoints(baseURL string) []httpx.Endpoint { return Endpoints(baseURL) }
+
 type NullInt64 = httpx.NullInt64
 type NullString = httpx.NullString
 
-func internalServerError(w http.ResponseWriter, operation string, err error) {
-	httpx.InternalServerError(w, operation, err)
+func internalServerError(w http.ResponseWriter, r *http.Request, operation string, err error) {
+	httpx.InternalServerError(w, r, operation, err)
 }
 
-func writeJSONResponse(w http.ResponseWriter, value any) {
-	httpx.WriteJSON(w, value)
+func writeJSONResponse(w http.ResponseWriter, r *http.Request, value any) {
+	httpx.WriteJSON(w, r, value)
 }
 
 func intsToString(ids []int) string {
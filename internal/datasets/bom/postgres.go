@@ -0,0 +1,960 @@
+package bom
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/chnm/apiary/db"
+	"github.com/jackc/pgx/v5"
+)
+
+// billsSortExpressions end with b.id so that every order is total, which
+// paging relies on.
+var billsSortExpressions = map[string]string{
+	"year":           "b.year, w.week_number, p.canonical_name, b.id",
+	"week_number":    "w.week_number, b.year, p.canonical_name, b.id",
+	"canonical_name": "p.canonical_name, b.year, w.week_number, b.id",
+}
+
+// QueryBuilder holds the query string and parameters
+type QueryBuilder struct {
+	Query  string
+	Params []interface{}
+}
+
+// NewQueryBuilder creates a new query builder
+func NewQueryBuilder() *QueryBuilder {
+	return &QueryBuilder{
+		Params: make([]interface{}, 0),
+	}
+}
+
+// AddParam adds a parameter and returns the placeholder ($1, $2, etc.)
+// We do this to prevent injection problems.
+func (qb *QueryBuilder) AddParam(value interface{}) string {
+	qb.Params = append(qb.Params, value)
+	return fmt.Sprintf("$%d", len(qb.Params))
+}
+
+// Helper function to build the SQL query with parameters
+func buildBillsQueryWithParams(params APIParameters) (*QueryBuilder, error) {
+	qb := NewQueryBuilder()
+
+	// For keyed pages and streams, skip the expensive COUNT(*) OVER()
+	// calculation, which must read every row before returning the first.
+	// Only calculate total count for first page or offset pagination
+	var selectClause string
+	if params.After != nil || params.Stream {
+		// Fast keyed query without total count for subsequent pages
+		selectClause = `
+    SELECT
+        p.canonical_name,
+        b.bill_type,
+        b.count_type,
+        b.count,
+        w.start_day,
+        w.start_month,
+        w.end_day,
+        w.end_month,
+        b.year,
+        w.split_year,
+        w.week_number,
+        b.week_id,
+        b.missing,
+        b.illegible,
+        b.source,
+        b.unique_identifier,
+        0 AS totalrecords,
+        p.id,
+        p.parish_name,
+        p.canonical_name AS parish_canonical,
+        p.bills_subunit,
+        p.foundation_year,
+        p.notes,
+        b.id`
+	} else {
+		// Include total count for first page and offset pagination
+		selectClause = `
+    SELECT
+        p.canonical_name,
+        b.bill_type,
+        b.count_type,
+        b.count,
+        w.start_day,
+        w.start_month,
+        w.end_day,
+        w.end_month,
+        b.year,
+        w.split_year,
+        w.week_number,
+        b.week_id,
+        b.missing,
+        b.illegible,
+        b.source,
+        b.unique_identifier,
+        COUNT(*) OVER() AS totalrecords,
+        p.id,
+        p.parish_name,
+        p.canonical_name AS parish_canonical,
+        p.bills_subunit,
+        p.foundation_year,
+        p.notes,
+        b.id`
+	}
+
+	baseQuery := selectClause + `
+    FROM
+        bom.bill_of_mortality b
+    JOIN
+        bom.parishes p ON p.id = b.parish_id
+    JOIN
+        bom.year y ON y.year = b.year
+    JOIN
+        bom.week w ON w.joinid = b.week_id
+    WHERE 1=1`
+
+	// Build WHERE clause
+	var conditions []string
+
+	if params.StartYear != 0 {
+		conditions = append(conditions, fmt.Sprintf("b.year >= %s", qb.AddParam(params.StartYear)))
+	}
+
+	if params.EndYear != 0 {
+		conditions = append(conditions, fmt.Sprintf("b.year <= %s", qb.AddParam(params.EndYear)))
+	}
+
+	if params.StartWeek != 0 {
+		conditions = append(conditions, fmt.Sprintf("w.week_number >= %s", qb.AddParam(params.StartWeek)))
+	}
+
+	if params.EndWeek != 0 {
+		conditions = append(conditions, fmt.Sprintf("w.week_number <= %s", qb.AddParam(params.EndWeek)))
+	}
+
+	if len(params.Parish) > 0 {
+		// Convert []int to []interface{} for the parameter
+		parishParams := make([]string, len(params.Parish))
+		for i, p := range params.Parish {
+			parishParams[i] = qb.AddParam(p)
+		}
+		conditions = append(conditions, fmt.Sprintf("b.parish_id IN (%s)", strings.Join(parishParams, ",")))
+	}
+
+	if params.BillType != "" {
+		conditions = append(conditions, fmt.Sprintf("b.bill_type = %s", qb.AddParam(strings.ToLower(params.BillType))))
+	}
+
+	if params.CountType != "" {
+		conditions = append(conditions, fmt.Sprintf("b.count_type = %s", qb.AddParam(strings.ToLower(params.CountType))))
+	}
+
+	if params.Missing != nil {
+		conditions = append(conditions, fmt.Sprintf("b.missing = %s", qb.AddParam(*params.Missing)))
+	}
+
+	if params.Illegible != nil {
+		conditions = append(conditions, fmt.Sprintf("b.illegible = %s", qb.AddParam(*params.Illegible)))
+	}
+
+	// Keyed pages continue after the last bill of the previous page
+	if params.After != nil {
+		yearParam := qb.AddParam(params.After.Year)
+		weekParam := qb.AddParam(params.After.Week)
+		nameParam := qb.AddParam(params.After.Name)
+		idParam := qb.AddParam(params.After.ID)
+		conditions = append(conditions, fmt.Sprintf("(b.year, w.week_number, p.canonical_name, b.id) > (%s, %s, %s, %s)", yearParam, weekParam, nameParam, idParam))
+	}
+
+	// Add conditions to query
+	if len(conditions) > 0 {
+		baseQuery += " AND " + strings.Join(conditions, " AND ")
+	}
+
+	// Keyed pages depend on the same ordering as their year/week/name/id
+	// key. Other pages may select a supported order.
+	sort := params.Sort
+	if sort == "" || params.After != nil {
+		sort = defaultBillsSort
+	}
+	sortExpression, ok := billsSortExpressions[sort]
+	if !ok {
+		return nil, fmt.Errorf("unsupported bills sort %q", sort)
+	}
+	baseQuery += " ORDER BY " + sortExpression + " ASC"
+
+	if params.Limit > 0 {
+		baseQuery += fmt.Sprintf(" LIMIT %s", qb.AddParam(params.Limit))
+	}
+	if params.Offset > 0 {
+		baseQuery += fmt.Sprintf(" OFFSET %s", qb.AddParam(params.Offset))
+	}
+
+	qb.Query = baseQuery
+	return qb, nil
+}
+
+// scanBill scans a row of the bills query.
+func scanBill(rows pgx.Rows) (ParishByYear, error) {
+	var result ParishByYear
+	var parish Parish
+	err := rows.Scan(
+		&result.CanonicalName,
+		&result.BillType,
+		&result.CountType,
+		&result.Count,
+		&result.StartDay,
+		&result.StartMonth,
+		&result.EndDay,
+		&result.EndMonth,
+		&result.Year,
+		&result.SplitYear,
+		&result.WeekNumber,
+		&result.WeekID,
+		&result.Missing,
+		&result.Illegible,
+		&result.Source,
+		&result.UniqueIdentifier,
+		&result.TotalRecords,
+		&parish.ParishID,
+		&parish.Name,
+		&parish.CanonicalName,
+		&parish.BillSubunit,
+		&parish.FoundationYear,
+		&parish.Notes,
+		&result.id,
+	)
+	result.Parish = &parish
+	return result, err
+}
+
+// totalBillsQueries count the rows behind each type accepted by
+// TotalBillsHandler.
+var totalBillsQueries = map[string]string{
+	"weekly": `
+	SELECT
+		COUNT(*)
+	FROM
+		bom.bill_of_mortality
+	WHERE 
+		bill_type = 'weekly';
+	`,
+	"general": `
+	SELECT
+		COUNT(*)
+	FROM
+		bom.bill_of_mortality
+	WHERE	
+		bill_type = 'general';
+	`,
+	"christenings": `
+	SELECT
+		COUNT(*)
+	FROM	
+		bom.christenings;
+	`,
+	"causes": `
+	SELECT
+		COUNT(*)
+	FROM 
+		bom.causes_of_death;
+	`,
+}
+
+func buildYearlyStatsQuery() string {
+	query := `
+  WITH year_range AS (
+        SELECT generate_series(1636, 1754) AS year
+    ),
+    weekly_stats AS (
+        SELECT 
+            b.year as year,
+            COUNT(DISTINCT b.week_id) as weeks_completed,
+            COUNT(*) as rows_count
+        FROM bom.bill_of_mortality b
+        WHERE b.bill_type = 'weekly'
+        GROUP BY b.year
+    )
+    SELECT 
+        yr.year,
+        COALESCE(ws.weeks_completed, 0) as weeks_completed,
+        COALESCE(ws.rows_count, 0) as rows_count,
+        53 as total_count
+    FROM year_range yr
+    LEFT JOIN weekly_stats ws ON yr.year = ws.year
+    ORDER BY yr.year;
+    `
+	return query
+}
+
+func buildWeeklyStatsQuery() string {
+	query := `
+    WITH year_week_range AS (
+        SELECT 
+            y.year,
+            w.number as week_number
+        FROM generate_series(1636, 1754) y(year)
+        CROSS JOIN generate_series(1, 53) w(number)
+    ),
+    weekly_stats AS (
+        SELECT 
+            b.year as year,
+            w.week_number,
+            COUNT(*) as rows_count
+        FROM bom.bill_of_mortality b
+        JOIN bom.week w ON w.joinid = b.week_id
+        WHERE b.bill_type = 'weekly'
+        GROUP BY b.year, w.week_number
+    )
+    SELECT 
+        yr.year,
+        yr.week_number,
+        COALESCE(ws.rows_count, 0) as rows_count
+    FROM year_week_range yr
+    LEFT JOIN weekly_stats ws ON yr.year = ws.year AND yr.week_number = ws.week_number
+    ORDER BY yr.year, yr.week_number;
+    `
+	return query
+}
+
+func buildParishYearlyStatsQuery(parishName string) (*QueryBuilder, error) {
+	qb := NewQueryBuilder()
+
+	query := `
+    SELECT 
+        b.year,
+        p.canonical_name as parish_name,
+        SUM(CASE WHEN b.count_type = 'buried' THEN COALESCE(b.count, 0) ELSE 0 END) as total_buried,
+        NULLIF(SUM(CASE WHEN b.count_type = 'plague' THEN COALESCE(b.count, 0) ELSE 0 END), 0) as total_plague
+    FROM bom.bill_of_mortality b
+    JOIN bom.parishes p ON p.id = b.parish_id
+    WHERE b.bill_type = 'weekly'`
+
+	if parishName != "" {
+		query += fmt.Sprintf(" AND LOWER(p.canonical_name) = LOWER(%s)", qb.AddParam(parishName))
+	}
+
+	query += `
+    GROUP BY b.year, p.canonical_name
+    HAVING SUM(CASE WHEN b.count_type IN ('buried', 'plague') THEN COALESCE(b.count, 0) ELSE 0 END) > 0
+    ORDER BY p.canonical_name, b.year`
+
+	qb.Query = query
+	return qb, nil
+}
+
+const parishesQuery = `
+	SELECT id, parish_name, canonical_name, bills_subunit, foundation_year, notes
+	FROM bom.parishes
+	ORDER BY canonical_name;
+	`
+
+const parishIDsQuery = `SELECT id FROM bom.parishes WHERE id = ANY($1);`
+
+const deathCausesQuery = `
+    SELECT 
+        c.original_name as death,
+				c.name,
+        c.bill_type,
+        c.count, 
+        c.definition,
+        c.definition_source,
+        c.week_id,
+        w.week_number,
+        w.start_day, 
+        w.start_month, 
+        w.end_day, 
+        w.end_month, 
+        y.year,
+        w.split_year,
+        COUNT(*) OVER() AS totalrecords
+    FROM 
+        bom.causes_of_death c
+    JOIN 
+        bom.week w ON w.joinid = c.week_id
+    JOIN
+        bom.year y ON y.year = w.year
+    WHERE 
+        y.year::int >= $1
+        AND y.year::int <= $2
+        AND count IS NOT NULL
+    `
+
+// listCausesQuery is completed with an optional bill type filter and an
+// ORDER BY clause.
+const listCausesQuery = `
+		SELECT DISTINCT
+			name,
+			bill_type
+		FROM
+			bom.causes_of_death
+		WHERE
+			name IS NOT NULL
+		`
+
+const christeningsQuery = `
+	SELECT
+		c.christening,
+		c.count,
+		c.week_number,
+		c.start_day,
+		c.start_month,
+		c.end_day,
+		c.end_month,
+		y.year,
+		c.bill_type,
+		COUNT(*) OVER() AS totalrecords
+	FROM
+		bom.christenings c
+	JOIN
+		bom.year y ON y.year = c.year
+	WHERE
+		y.year >= $1::int
+		AND y.year < $2::int
+		AND (
+			$5::text IS NULL
+			OR c.bill_type = $5::text
+		)
+	ORDER BY
+		year ASC,
+		week_number ASC,
+		c.id ASC
+	LIMIT $3
+	OFFSET $4;
+	`
+
+const christeningsByLocationQuery = `
+	SELECT
+		c.christening,
+		c.count,
+		c.week_number,
+		c.start_day,
+		c.start_month,
+		c.end_day,
+		c.end_month,
+		y.year,
+		c.bill_type,
+		COUNT(*) OVER() AS totalrecords
+	FROM
+		bom.christenings c
+	JOIN
+		bom.year y ON y.year = c.year
+	JOIN
+		bom.christening_locations l ON l.name = c.christening
+	WHERE
+		y.year >= $1::int
+		AND y.year < $2::int
+		AND (
+			$3::int[] IS NULL
+			OR l.id = ANY($3::int[])
+		)
+		AND (
+			$6::text IS NULL
+			OR c.bill_type = $6::text
+		)	
+	ORDER BY
+		year ASC,
+		week_number ASC,
+		c.id ASC
+	LIMIT $4
+	OFFSET $5;
+	`
+
+// listChristeningsQuery is completed like listCausesQuery.
+const listChristeningsQuery = `
+		SELECT DISTINCT
+			christening,
+			bill_type
+		FROM
+			bom.christenings
+		WHERE
+			christening IS NOT NULL
+		`
+
+// Base query with materialized CTE and spatial index hints for performance
+const shapefilesQuery = `
+    WITH filtered_bills AS MATERIALIZED (
+        SELECT 
+            b.parish_id,
+            b.count_type,
+            b.count,
+            b.year
+        FROM 
+            bom.bill_of_mortality b
+        WHERE 1=1
+        -- Dynamic bill filters will be added here
+    ),
+    unique_parishes AS (
+        SELECT DISTINCT ON (civ_par, start_yr, ST_AsText(geom_01))
+            id,
+            par,
+            civ_par,
+            dbn_par,
+            omeka_par,
+            subunit,
+            city_cnty,
+            start_yr,
+            sp_total,
+            sp_per,
+            geom_01
+        FROM bom.parishes_shp
+        WHERE 1=1
+        -- Dynamic parish filters will be added here
+    ),
+    parish_data AS (
+        SELECT
+            unique_parishes.id,
+            unique_parishes.par,
+            unique_parishes.civ_par,
+            unique_parishes.dbn_par,
+            unique_parishes.omeka_par,
+            unique_parishes.subunit,
+            unique_parishes.city_cnty,
+            unique_parishes.start_yr,
+            unique_parishes.sp_total,
+            unique_parishes.sp_per,
+            COALESCE(SUM(CASE WHEN fb.count_type = 'buried' THEN fb.count ELSE 0 END), 0) as total_buried,
+            COALESCE(SUM(CASE WHEN fb.count_type = 'plague' THEN fb.count ELSE 0 END), 0) as total_plague,
+            COUNT(fb.parish_id) as bill_count,
+            unique_parishes.geom_01
+        FROM
+            unique_parishes
+				LEFT JOIN
+      		bom.parishes p ON LOWER(REPLACE(REPLACE(p.canonical_name, '-', ' '), '.', '')) = LOWER(REPLACE(REPLACE(unique_parishes.civ_par, '-', ' '), '.', ''))
+				LEFT JOIN
+      		filtered_bills fb ON fb.parish_id = p.id
+        WHERE 1=1
+        -- Dynamic parish filters will be added here
+        GROUP BY
+            unique_parishes.id, unique_parishes.par, unique_parishes.civ_par, unique_parishes.dbn_par,
+            unique_parishes.omeka_par, unique_parishes.subunit, unique_parishes.city_cnty,
+            unique_parishes.start_yr, unique_parishes.sp_total, unique_parishes.sp_per, unique_parishes.geom_01
+    )
+    SELECT json_build_object(
+        'type', 'FeatureCollection',
+        'features', COALESCE(json_agg(features.feature), '[]'::json)
+    )
+    FROM (
+        SELECT json_build_object(
+            'type', 'Feature',
+            'id', id,
+            'properties', json_build_object(
+                'par', par,
+                'civ_par', civ_par,
+                'dbn_par', dbn_par,
+                'omeka_par', omeka_par,
+                'subunit', subunit,
+                'city_cnty', city_cnty,
+                'start_yr', start_yr,
+                'sp_total', sp_total,
+                'sp_per', sp_per,
+                'total_buried', total_buried,
+                'total_plague', total_plague,
+                'bill_count', bill_count
+            ),
+            'geometry', ST_AsGeoJSON(
+                ST_Transform(
+                    ST_SetSRID(geom_01, 27700), 
+                    4326
+                ), 
+                6
+            )::json
+        ) AS feature
+        FROM parish_data
+    ) AS features;
+    `
+
+// buildSeparateFilters constructs parameterized SQL filters for bills and
+// parishes from the shapefile parameters.
+func buildSeparateFilters(filter ShapefileParameters) (string, string, []any) {
+	var billFilters []string
+	var parishFilters []string
+	var params []any
+
+	addParam := func(value any) string {
+		params = append(params, value)
+		return fmt.Sprintf("$%d", len(params))
+	}
+
+	// Note: Year filters only apply to bills, not parish geometries
+	// Parish geometries are filtered by other attributes (subunit, city_cnty, parish ID)
+	if filter.Year != 0 {
+		billFilters = append(billFilters, fmt.Sprintf("AND b.year = %s", addParam(filter.Year)))
+	} else {
+		if filter.StartYear != 0 {
+			billFilters = append(billFilters, fmt.Sprintf("AND b.year >= %s", addParam(filter.StartYear)))
+		}
+		if filter.EndYear != 0 {
+			billFilters = append(billFilters, fmt.Sprintf("AND b.year <= %s", addParam(filter.EndYear)))
+		}
+	}
+
+	// Parish-specific filters
+	if filter.Subunit != "" {
+		parishFilters = append(parishFilters, fmt.Sprintf("AND parishes_shp.subunit = %s", addParam(filter.Subunit)))
+	}
+
+	if filter.CityCounty != "" {
+		parishFilters = append(parishFilters, fmt.Sprintf("AND parishes_shp.city_cnty = %s", addParam(filter.CityCounty)))
+	}
+
+	// Bills-specific filters
+	if filter.BillType != "" {
+		billFilters = append(billFilters, fmt.Sprintf("AND b.bill_type = %s", addParam(filter.BillType)))
+	}
+
+	if filter.CountType != "" {
+		billFilters = append(billFilters, fmt.Sprintf("AND b.count_type = %s", addParam(filter.CountType)))
+	}
+
+	// Add parish filter to both queries to ensure they're properly joined
+	if len(filter.Parish) > 0 {
+		parishIDsParam := addParam(filter.Parish)
+		parishFilters = append(parishFilters, fmt.Sprintf("AND parishes_shp.id = ANY(%s)", parishIDsParam))
+		billFilters = append(billFilters, fmt.Sprintf("AND b.parish_id = ANY(%s)", parishIDsParam))
+	}
+
+	return strings.Join(billFilters, " "), strings.Join(parishFilters, " "), params
+}
+
+// PostgresStore reads the Bills of Mortality from PostgreSQL.
+type PostgresStore struct {
+	db db.Querier
+}
+
+// NewPostgresStore returns a store that reads from db.
+func NewPostgresStore(db db.Querier) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// Bills implements Store.
+func (s *PostgresStore) Bills(ctx context.Context, params APIParameters, fn func(ParishByYear) error) error {
+	qb, err := buildBillsQueryWithParams(params)
+	if err != nil {
+		return fmt.Errorf("build bills query: %w", err)
+	}
+	rows, err := s.db.Query(ctx, qb.Query, qb.Params...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		bill, err := scanBill(rows)
+		if err != nil {
+			return fmt.Errorf("scan bill: %w", err)
+		}
+		if err := fn(bill); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// TotalBills implements Store.
+func (s *PostgresStore) TotalBills(ctx context.Context, kind string) (TotalBills, error) {
+	query, ok := totalBillsQueries[kind]
+	if !ok {
+		return TotalBills{}, fmt.Errorf("no bill totals of type %q", kind)
+	}
+	var total TotalBills
+	err := s.db.QueryRow(ctx, query).Scan(&total.TotalRecords)
+	return total, err
+}
+
+// WeeklyStatistics implements Store.
+func (s *PostgresStore) WeeklyStatistics(ctx context.Context) ([]WeeklySummary, error) {
+	rows, err := s.db.Query(ctx, buildWeeklyStatsQuery())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := []WeeklySummary{}
+	for rows.Next() {
+		var summary WeeklySummary
+		if err := rows.Scan(&summary.Year, &summary.WeekNumber, &summary.RowsCount); err != nil {
+			return nil, fmt.Errorf("scan weekly statistics: %w", err)
+		}
+		stats = append(stats, summary)
+	}
+	return stats, rows.Err()
+}
+
+// YearlyStatistics implements Store.
+func (s *PostgresStore) YearlyStatistics(ctx context.Context) ([]YearlySummary, error) {
+	rows, err := s.db.Query(ctx, buildYearlyStatsQuery())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := []YearlySummary{}
+	for rows.Next() {
+		var summary YearlySummary
+		if err := rows.Scan(&summary.Year, &summary.WeeksCompleted,
+			&summary.RowsCount, &summary.TotalCount); err != nil {
+			return nil, fmt.Errorf("scan yearly statistics: %w", err)
+		}
+		stats = append(stats, summary)
+	}
+	return stats, rows.Err()
+}
+
+// ParishYearlyStatistics implements Store.
+func (s *PostgresStore) ParishYearlyStatistics(ctx context.Context, parishName string) ([]ParishYearlySummary, error) {
+	qb, err := buildParishYearlyStatsQuery(parishName)
+	if err != nil {
+		return nil, fmt.Errorf("build parish-yearly statistics query: %w", err)
+	}
+	rows, err := s.db.Query(ctx, qb.Query, qb.Params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := []ParishYearlySummary{}
+	for rows.Next() {
+		var summary ParishYearlySummary
+		if err := rows.Scan(
+			&summary.Year,
+			&summary.ParishName,
+			&summary.TotalBuried,
+			&summary.TotalPlague,
+		); err != nil {
+			return nil, fmt.Errorf("scan parish-yearly statistics: %w", err)
+		}
+		stats = append(stats, summary)
+	}
+	return stats, rows.Err()
+}
+
+// Parishes implements Store.
+func (s *PostgresStore) Parishes(ctx context.Context) ([]Parish, error) {
+	rows, err := s.db.Query(ctx, parishesQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := make([]Parish, 0)
+	var row Parish
+	for rows.Next() {
+		if err := rows.Scan(
+			&row.ParishID,
+			&row.Name,
+			&row.CanonicalName,
+			&row.BillSubunit,
+			&row.FoundationYear,
+			&row.Notes,
+		); err != nil {
+			return nil, fmt.Errorf("scan parish: %w", err)
+		}
+		results = append(results, row)
+	}
+	return results, rows.Err()
+}
+
+// InvalidParishIDs implements Store.
+func (s *PostgresStore) InvalidParishIDs(ctx context.Context, ids []int) ([]int, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	rows, err := s.db.Query(ctx, parishIDsQuery, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	found := make(map[int]bool, len(ids))
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		found[id] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return missingIDs(ids, found), nil
+}
+
+// DeathCauses implements Store.
+func (s *PostgresStore) DeathCauses(ctx context.Context, params DeathsAPIParameters) ([]DeathCauses, error) {
+	query := deathCausesQuery
+	args := []any{params.StartYear, params.EndYear}
+
+	if len(params.Death) > 0 {
+		args = append(args, params.Death)
+		// Support filtering by canonical names with " & " separator
+		// This allows filtering by "consumption" to match both "consumption"
+		// and "consumption & cough" by splitting on ' & ' and checking for matches
+		query += fmt.Sprintf(` AND EXISTS (
+				SELECT 1
+				FROM unnest($%d::text[]) AS selected_cause
+				WHERE selected_cause = ANY(string_to_array(c.name, ' & '))
+			)`, len(args))
+	}
+
+	if params.BillType != "" {
+		args = append(args, params.BillType)
+		query += fmt.Sprintf(" AND c.bill_type = $%d", len(args))
+	}
+
+	query += " ORDER BY year, week_number, name, c.id"
+
+	if params.Limit != 0 {
+		query += " LIMIT " + strconv.Itoa(params.Limit)
+	}
+	if params.Offset != 0 {
+		query += " OFFSET " + strconv.Itoa(params.Offset)
+	}
+
+	rows, err := s.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := make([]DeathCauses, 0)
+	var row DeathCauses
+	for rows.Next() {
+		err := rows.Scan(
+			&row.Death,
+			&row.Name,
+			&row.BillType,
+			&row.Count,
+			&row.Definition,
+			&row.DefinitionSource,
+			&row.WeekID,
+			&row.WeekNumber,
+			&row.StartDay,
+			&row.StartMonth,
+			&row.EndDay,
+			&row.EndMonth,
+			&row.Year,
+			&row.SplitYear,
+			&row.TotalRecords,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("scan death cause: %w", err)
+		}
+		results = append(results, row)
+	}
+	return results, rows.Err()
+}
+
+// ListCauses implements Store.
+func (s *PostgresStore) ListCauses(ctx context.Context, billType string) ([]Causes, error) {
+	query := listCausesQuery
+	var args []any
+	if billType != "" {
+		query += " AND bill_type = $1"
+		args = append(args, billType)
+	}
+	query += " ORDER BY name ASC, bill_type ASC"
+
+	rows, err := s.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := make([]Causes, 0)
+	var row Causes
+	for rows.Next() {
+		if err := rows.Scan(&row.Name, &row.BillType); err != nil {
+			return nil, fmt.Errorf("scan cause list: %w", err)
+		}
+		results = append(results, row)
+	}
+	return results, rows.Err()
+}
+
+// Christenings implements Store.
+func (s *PostgresStore) Christenings(ctx context.Context, params ChristeningsParameters) ([]ChristeningsByYear, error) {
+	// Convert empty bill_type to nil for SQL query
+	var billType any
+	if params.BillType != "" {
+		billType = params.BillType
+	}
+
+	var rows pgx.Rows
+	var err error
+	if len(params.Locations) == 0 {
+		rows, err = s.db.Query(ctx, christeningsQuery, params.StartYear, params.EndYear, params.Limit, params.Offset, billType)
+	} else {
+		rows, err = s.db.Query(ctx, christeningsByLocationQuery, params.StartYear, params.EndYear, params.Locations, params.Limit, params.Offset, billType)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := make([]ChristeningsByYear, 0)
+	var row ChristeningsByYear
+	for rows.Next() {
+		err := rows.Scan(
+			&row.Christening,
+			&row.TotalCount,
+			&row.WeekNumber,
+			&row.StartDay,
+			&row.StartMonth,
+			&row.EndDay,
+			&row.EndMonth,
+			&row.Year,
+			&row.BillType,
+			&row.TotalRecords,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("scan christening: %w", err)
+		}
+		results = append(results, row)
+	}
+	return results, rows.Err()
+}
+
+// ListChristenings implements Store.
+func (s *PostgresStore) ListChristenings(ctx context.Context, billType string) ([]Christenings, error) {
+	query := listChristeningsQuery
+	var args []any
+	if billType != "" {
+		query += " AND bill_type = $1"
+		args = append(args, billType)
+	}
+	query += " ORDER BY christening ASC, bill_type ASC"
+
+	rows, err := s.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := make([]Christenings, 0)
+	var row Christenings
+	for rows.Next() {
+		if err := rows.Scan(&row.Name, &row.BillType); err != nil {
+			return nil, fmt.Errorf("scan christening list: %w", err)
+		}
+		results = append(results, row)
+	}
+	return results, rows.Err()
+}
+
+// Shapefiles implements Store.
+func (s *PostgresStore) Shapefiles(ctx context.Context, params ShapefileParameters) (string, error) {
+	billFilters, parishFilters, args := buildSeparateFilters(params)
+
+	// Apply the filters to their respective sections
+	query := strings.Replace(shapefilesQuery, "-- Dynamic bill filters will be added here", billFilters, 1)
+	query = strings.Replace(query, "-- Dynamic parish filters will be added here", parishFilters, 1)
+
+	var result string
+	err := s.db.QueryRow(ctx, query, args...).Scan(&result)
+	return result, err
+}
@@ -1,6 +1,9 @@
 package bom
 
 import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"reflect"
 	"strings"
 	"testing"
@@ -9,12 +12,19 @@ import (
 func TestBuildSeparateFiltersParameterizesValues(t *testing.T) {
 	injection := "City' OR 1=1 --"
 
-	billFilters, parishFilters, params, err := buildSeparateFilters(
-		"1665", "", "", injection, "London", "Weekly", "Buried", "1, 2",
-	)
+	query := url.Values{
+		"year":       {"1665"},
+		"subunit":    {injection},
+		"city_cnty":  {"London"},
+		"bill-type":  {"Weekly"},
+		"count-type": {"Buried"},
+		"parish":     {"1, 2"},
+	}
+	params, err := parseShapefileParameters(httptest.NewRequest(http.MethodGet, "/bom/shapefiles?"+query.Encode(), nil))
 	if err != nil {
-		t.Fatalf("buildSeparateFilters returned an unexpected error: %v", err)
+		t.Fatalf("parseShapefileParameters returned an unexpected error: %v", err)
 	}
+	billFilters, parishFilters, args := buildSeparateFilters(params)
 
 	wantBillFilters := "AND b.year = $1 AND b.bill_type = $4 AND b.count_type = $5 AND b.parish_id = ANY($6)"
 	if billFilters != wantBillFilters {
@@ -30,39 +40,32 @@ func TestBuildSeparateFiltersParameterizesValues(t *testing.T) {
 		t.Fatal("user input was interpolated into SQL filters")
 	}
 
-	wantParams := []any{1665, injection, "London", "weekly", "buried", []int{1, 2}}
-	if !reflect.DeepEqual(params, wantParams) {
-		t.Errorf("params = %#v, want %#v", params, wantParams)
+	wantArgs := []any{1665, injection, "London", "weekly", "buried", []int{1, 2}}
+	if !reflect.DeepEqual(args, wantArgs) {
+		t.Errorf("args = %#v, want %#v", args, wantArgs)
 	}
 }
 
-func TestBuildSeparateFiltersRejectsMalformedValues(t *testing.T) {
+func TestParseShapefileParametersRejectsMalformedValues(t *testing.T) {
 	tests := []struct {
-		name      string
-		year      string
-		startYear string
-		endYear   string
-		billType  string
-		countType string
-		parish    string
+		name  string
+		query string
 	}{
-		{name: "year", year: "abc"},
-		{name: "start year", startYear: "abc"},
-		{name: "end year", endYear: "abc"},
-		{name: "bill type", billType: "invalid"},
-		{name: "count type", countType: "invalid"},
-		{name: "parish", parish: "abc"},
-		{name: "empty parish", parish: "1,,2"},
-		{name: "non-positive parish", parish: "0"},
+		{name: "year", query: "year=abc"},
+		{name: "start year", query: "start-year=abc"},
+		{name: "end year", query: "end-year=abc"},
+		{name: "bill type", query: "bill-type=invalid"},
+		{name: "count type", query: "count-type=invalid"},
+		{name: "parish", query: "parish=abc"},
+		{name: "empty parish", query: "parish=1,,2"},
+		{name: "non-positive parish", query: "parish=0"},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			_, _, _, err := buildSeparateFilters(
-				tt.year, tt.startYear, tt.endYear, "", "", tt.billType, tt.countType, tt.parish,
-			)
-			if err == nil {
-				t.Fatal("buildSeparateFilters returned nil error")
+			request := httptest.NewRequest(http.MethodGet, "/bom/shapefiles?"+tt.query, nil)
+			if _, err := parseShapefileParameters(request); err == nil {
+				t.Fatal("parseShapefileParameters returned nil error")
 			}
 		})
 	}
@@ -0,0 +1,220 @@
+package bom
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/chnm/apiary/internal/httpx"
+	"github.com/chnm/apiary/internal/httpx/pagination"
+	"github.com/chnm/apiary/internal/testsupport"
+)
+
+// fakeStore serves bills from memory. Methods the tests do not use panic
+// through the nil embedded Store.
+type fakeStore struct {
+	Store
+	bills         []ParishByYear
+	billsErr      error
+	invalidParish []int
+	params        APIParameters // The parameters of the last Bills call
+}
+
+func (s *fakeStore) Bills(_ context.Context, params APIParameters, fn func(ParishByYear) error) error {
+	s.params = params
+	if s.billsErr != nil {
+		return s.billsErr
+	}
+	for _, bill := range s.bills {
+		if err := fn(bill); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *fakeStore) InvalidParishIDs(_ context.Context, ids []int) ([]int, error) {
+	return s.invalidParish, nil
+}
+
+func fakeBills(n int) []ParishByYear {
+	bills := make([]ParishByYear, n)
+	for i := range bills {
+		bills[i] = ParishByYear{
+			CanonicalName: "All Hallows",
+			Year:          NullInt64{NullInt64: sql.NullInt64{Int64: 1665, Valid: true}},
+			WeekNumber:    i + 1,
+		}
+	}
+	return bills
+}
+
+func TestBillsHandlerPaginates(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		bills    int // Rows the store returns, which is one more than the limit when more follow
+		wantData int
+		wantMore bool
+	}{
+		{
+			name:     "full page",
+			path:     "/bom/bills?start-year=1665&end-year=1665&limit=3",
+			bills:    4,
+			wantData: 3,
+			wantMore: true,
+		},
+		{
+			name:     "last page",
+			path:     "/bom/bills?start-year=1665&end-year=1665&limit=3",
+			bills:    2,
+			wantData: 2,
+		},
+		{
+			name: "no results",
+			path: "/bom/bills?start-year=1665&end-year=1665",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fakeStore{bills: fakeBills(tt.bills)}
+			response := httptest.NewRecorder()
+
+			NewWithStore(store).BillsHandler().ServeHTTP(response, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if response.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200: %s", response.Code, response.Body)
+			}
+			var page pagination.Page[ParishByYear]
+			if err := json.Unmarshal(response.Body.Bytes(), &page); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if len(page.Data) != tt.wantData || page.HasMore != tt.wantMore {
+				t.Fatalf("page = %d bills, has_more %t; want %d, %t", len(page.Data), page.HasMore, tt.wantData, tt.wantMore)
+			}
+			if (page.NextCursor != "") != tt.wantMore || (page.Next != "") != tt.wantMore {
+				t.Fatalf("next = %q, next_cursor = %q; want them only if more follow", page.Next, page.NextCursor)
+			}
+		})
+	}
+}
+
+func TestBillsHandlerFollowsNextLinks(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		wantAfter  *billKey
+		wantOffset int
+	}{
+		{
+			name:      "default order continues from the last bill",
+			path:      "/bom/bills?start-year=1665&end-year=1665&limit=3",
+			wantAfter: &billKey{Year: 1665, Week: 3, Name: "All Hallows"},
+		},
+		{
+			name:       "other orders skip the first page",
+			path:       "/bom/bills?start-year=1665&end-year=1665&limit=3&sort=week_number",
+			wantOffset: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewWithStore(&fakeStore{bills: fakeBills(4)}).BillsHandler()
+			response := httptest.NewRecorder()
+			handler.ServeHTTP(response, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			var page pagination.Page[ParishByYear]
+			if err := json.Unmarshal(response.Body.Bytes(), &page); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+
+			store := &fakeStore{}
+			response = httptest.NewRecorder()
+			NewWithStore(store).BillsHandler().ServeHTTP(response, httptest.NewRequest(http.MethodGet, page.Next, nil))
+
+			if response.Code != http.StatusOK {
+				t.Fatalf("next page status = %d, want 200: %s", response.Code, response.Body)
+			}
+			if !reflect.DeepEqual(store.params.After, tt.wantAfter) || store.params.Offset != tt.wantOffset || store.params.Limit != 4 {
+				t.Fatalf("next page parameters = after %+v, offset %d, limit %d; want after %+v, offset %d, limit 4",
+					store.params.After, store.params.Offset, store.params.Limit, tt.wantAfter, tt.wantOffset)
+			}
+		})
+	}
+}
+
+func TestBillsHandlerRejectsInvalidPaging(t *testing.T) {
+	for _, tt := range []struct{ query, parameter string }{
+		{"limit=many", "limit"},
+		{"limit=0", "limit"},
+		{"offset=later", "offset"},
+		{"page=next", "page"},
+		{"cursor=invalid!", "cursor"},
+	} {
+		t.Run(tt.query, func(t *testing.T) {
+			response := httptest.NewRecorder()
+
+			NewWithStore(&fakeStore{}).BillsHandler().ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/bom/bills?"+tt.query, nil))
+
+			testsupport.AssertProblem(t, response, http.StatusBadRequest, httpx.CodeInvalidParameter, tt.parameter)
+		})
+	}
+}
+
+func TestBillsHandlerRejectsUnknownParishes(t *testing.T) {
+	store := &fakeStore{invalidParish: []int{999}}
+	response := httptest.NewRecorder()
+
+	NewWithStore(store).BillsHandler().ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/bom/bills?start-year=1665&end-year=1665&parish=1,999", nil))
+
+	testsupport.AssertProblem(t, response, http.StatusBadRequest, httpx.CodeInvalidParameter, "parish")
+}
+
+func TestBillsHandlerStreamsNDJSON(t *testing.T) {
+	store := &fakeStore{bills: fakeBills(3)}
+	response := httptest.NewRecorder()
+
+	NewWithStore(store).BillsHandler().ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/bom/bills?start-year=1665&end-year=1665&format=ndjson", nil))
+
+	if response.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", response.Code, response.Body)
+	}
+	if !store.params.Stream || store.params.Limit != 0 {
+		t.Fatalf("store parameters = %+v, want an unlimited stream", store.params)
+	}
+	lines := 0
+	scanner := bufio.NewScanner(response.Body)
+	for scanner.Scan() {
+		var bill ParishByYear
+		if err := json.Unmarshal(scanner.Bytes(), &bill); err != nil {
+			t.Fatalf("decode line %d: %v", lines+1, err)
+		}
+		lines++
+		if bill.WeekNumber != lines {
+			t.Fatalf("line %d week = %d, want %d", lines, bill.WeekNumber, lines)
+		}
+	}
+	if lines != 3 {
+		t.Fatalf("streamed %d bills, want 3", lines)
+	}
+}
+
+func TestBillsHandlerStoreErrorReturnsProblem(t *testing.T) {
+	for _, format := range []string{"json", "ndjson"} {
+		t.Run(format, func(t *testing.T) {
+			store := &fakeStore{billsErr: errors.New("connection refused")}
+			response := httptest.NewRecorder()
+
+			NewWithStore(store).BillsHandler().ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/bom/bills?start-year=1665&end-year=1665&format="+format, nil))
+
+			testsupport.AssertProblem(t, response, http.StatusInternalServerError, httpx.CodeInternal, "")
+		})
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"regexp"
+	"slices"
 	"testing"
 
 	"github.com/chnm/apiary/internal/datasets/ahcb"
@@ -45,16 +47,43 @@ func TestDatasetCatalogsMatchRegisteredRoutes(t *testing.T) {
 			}
 			router := mux.NewRouter()
 			tt.register(router)
+			templates := routeTemplates(t, router)
 			for _, endpoint := range tt.endpoints {
+				if !slices.Contains(templates, endpoint.Path) {
+					t.Errorf("catalog path %q for %q is not a registered route template", endpoint.Path, endpoint.Name)
+				}
 				assertCatalogRoute(t, router, endpoint.URL)
+				assertCatalogQuery(t, endpoint, endpoint.URL)
 				for _, example := range endpoint.Examples {
 					assertCatalogRoute(t, router, example.URL)
+					assertCatalogQuery(t, endpoint, example.URL)
 				}
 			}
 		})
 	}
 }
 
+var routeVariablePattern = regexp.MustCompile(`\{([^{}:]+):(?:[^{}]|\{[^{}]*\})*\}`)
+
+// routeTemplates returns the router's path templates in OpenAPI form, with
+// variable patterns such as {id:[0-9]+} reduced to {id}.
+func routeTemplates(t *testing.T, router *mux.Router) []string {
+	t.Helper()
+	var templates []string
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		template, err := route.GetPathTemplate()
+		if err != nil {
+			return err
+		}
+		templates = append(templates, routeVariablePattern.ReplaceAllString(template, "{$1}"))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk routes: %v", err)
+	}
+	return templates
+}
+
 func assertCatalogRoute(t *testing.T, router *mux.Router, endpointURL string) {
 	t.Helper()
 	parsed, err := url.Parse(endpointURL)
@@ -66,3 +95,21 @@ func assertCatalogRoute(t *testing.T, router *mux.Router, endpointURL string) {
 		t.Errorf("catalog URL %q does not match a registered route", endpointURL)
 	}
 }
+
+// assertCatalogQuery checks that a catalog URL gives only the query
+// parameters that its endpoint declares, since handlers reject the rest.
+func assertCatalogQuery(t *testing.T, endpoint httpx.Endpoint, endpointURL string) {
+	t.Helper()
+	parsed, err := url.Parse(endpointURL)
+	if err != nil {
+		t.Fatalf("parse endpoint URL %q: %v", endpointURL, err)
+	}
+	for name := range parsed.Query() {
+		declared := slices.ContainsFunc(endpoint.Parameters, func(p httpx.Parameter) bool {
+			return p.Name == name && p.In == httpx.InQuery
+		})
+		if !declared {
+			t.Errorf("catalog URL %q gives %s, which %q does not declare", endpointURL, name, endpoint.Name)
+		}
+	}
+}
@@ -1,10 +1,23 @@
 package catholic
 
-import "github.com/chnm/apiary/internal/httpx"
+import (
+	"github.com/chnm/apiary/internal/httpx"
+	"github.com/chnm/apiary/internal/httpx/pagination"
+)
 
 func Endpoints(baseURL string) []httpx.Endpoint {
 	return []httpx.Endpoint{
-		{Name: "Roman Catholic Dioceses in North America", URL: baseURL + "/catholic-dioceses/"},
-		{Name: "Roman Catholic Dioceses in North America: number established per decade", URL: baseURL + "/catholic-dioceses/per-decade/"},
+		{
+			Name:       "Roman Catholic Dioceses in North America",
+			URL:        baseURL + "/catholic-dioceses/",
+			Path:       "/catholic-dioceses/",
+			Parameters: diocesesParams.Parameters(),
+			Response:   pagination.Page[CatholicDiocese]{},
+			CSV:        true,
+			Examples: []httpx.ExampleURL{
+				{URL: baseURL + "/catholic-dioceses/?format=csv", Purpose: "Dioceses as CSV for a spreadsheet"},
+			},
+		},
+		{Name: "Roman Catholic Dioceses in North America: number established per decade", URL: baseURL + "/catholic-dioceses/per-decade/", Path: "/catholic-dioceses/per-decade/", Parameters: perDecadeParams.Parameters(), Response: pagination.Page[CatholicDiocesesPerDecade]{}},
 	}
 }
@@ -2,18 +2,51 @@
 package catholic
 
 import (
+	"context"
+
+	"github.com/chnm/apiary/db"
+	"github.com/chnm/apiary/internal/cachex"
+	"github.com/chnm/apiary/internal/datasets"
 	"github.com/chnm/apiary/internal/httpx"
 	"github.com/gorilla/mux"
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-type Handler struct{ db *pgxpool.Pool }
+// Store reads the Catholic dioceses. PostgresStore is the implementation the
+// server uses.
+type Store interface {
+	// Dioceses returns every diocese in the order they were erected.
+	Dioceses(ctx context.Context) ([]CatholicDiocese, error)
+	// DiocesesPerDecade returns how many dioceses were erected in each decade
+	// from 1500 to 2020, including decades with none.
+	DiocesesPerDecade(ctx context.Context) ([]CatholicDiocesesPerDecade, error)
+}
+
+type Handler struct{ store Store }
+
+// New creates a handler that reads from db.
+func New(db db.Querier) *Handler { return NewWithStore(NewPostgresStore(db)) }
 
-func New(db *pgxpool.Pool) *Handler { return &Handler{db: db} }
+// NewWithStore creates a handler that reads from store.
+func NewWithStore(store Store) *Handler { return &Handler{store: store} }
 
 func (h *Handler) RegisterRoutes(router *mux.Router) {
-	router.HandleFunc("/catholic-dioceses/", h.CatholicDiocesesHandler()).Methods("GET", "HEAD")
-	router.HandleFunc("/catholic-dioceses/per-decade/", h.CatholicDiocesesPerDecadeHandler()).Methods("GET", "HEAD")
+	cached := cachex.TTL(cachex.Stable)
+	router.Handle("/catholic-dioceses/", cached(h.CatholicDiocesesHandler())).Methods("GET", "HEAD")
+	router.Handle("/catholic-dioceses/per-decade/", cached(h.CatholicDiocesesPerDecadeHandler())).Methods("GET", "HEAD")
 }
 
+// Name returns the identifier used to enable or disable this dataset.
+func (h *Handler) Name() string { return "catholic" }
+
+// Metadata describes this dataset.
+func (h *Handler) Metadata() datasets.Metadata {
+	return datasets.Metadata{
+		Title:       "Roman Catholic Dioceses in North America",
+		Description: "Catholic dioceses with their dates of establishment and locations.",
+	}
+}
+
+// Endpoints returns this dataset's entries for the root endpoint catalog.
+func (h *Handler) Endpoints(baseURL string) []httpx.Endpoint { return Endpoints(baseURL) }
+
 type NullInt64 = httpx.NullInt64
@@ -0,0 +1,87 @@
+package catholic
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/chnm/apiary/db"
+)
+
+const diocesesQuery = `
+	SELECT city, state, country, rite, 
+		date_part( 'year', date_erected) as year_erected,
+		date_part('year', date_metropolitan) as year_metropolitan,
+		date_part('year', date_destroyed) as year_destroyed,
+		ST_X(geometry) as lon, ST_Y(geometry) as lat
+	FROM catholic_dioceses
+	ORDER BY date_erected;
+	`
+
+// This query counts the number of dioceses established per decade. But it
+// also generates a series of decades from 1500 to 2020 so that there are no
+// gaps between decades in the result.
+const diocesesPerDecadeQuery = `
+	SELECT 
+		series.decade,
+		coalesce(n, 0) AS n
+	FROM 
+		(SELECT generate_series(1500, 2020, 10) AS decade) AS series
+	LEFT JOIN 
+		(SELECT 
+			floor(date_part( 'year', date_erected)/10)*10 AS decade,
+			count(*) AS n
+		FROM catholic_dioceses
+		GROUP BY decade) counts 
+	ON series.decade  = counts.decade
+	ORDER BY series.decade;
+	`
+
+// PostgresStore reads the Catholic dioceses from PostgreSQL.
+type PostgresStore struct {
+	db db.Querier
+}
+
+// NewPostgresStore returns a store that reads from db.
+func NewPostgresStore(db db.Querier) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// Dioceses implements Store.
+func (s *PostgresStore) Dioceses(ctx context.Context) ([]CatholicDiocese, error) {
+	rows, err := s.db.Query(ctx, diocesesQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := make([]CatholicDiocese, 0)
+	for rows.Next() {
+		var row CatholicDiocese
+		if err := rows.Scan(&row.City, &row.State, &row.Country, &row.Rite,
+			&row.YearErected, &row.YearMetropolitan, &row.YearDestroyed,
+			&row.Lon, &row.Lat); err != nil {
+			return nil, fmt.Errorf("scan Catholic diocese: %w", err)
+		}
+		results = append(results, row)
+	}
+	return results, rows.Err()
+}
+
+// DiocesesPerDecade implements Store.
+func (s *PostgresStore) DiocesesPerDecade(ctx context.Context) ([]CatholicDiocesesPerDecade, error) {
+	rows, err := s.db.Query(ctx, diocesesPerDecadeQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := make([]CatholicDiocesesPerDecade, 0, 52) // Preallocate slice capacity
+	for rows.Next() {
+		var row CatholicDiocesesPerDecade
+		if err := rows.Scan(&row.Decade, &row.Count); err != nil {
+			return nil, fmt.Errorf("scan Catholic dioceses per decade: %w", err)
+		}
+		results = append(results, row)
+	}
+	return results, rows.Err()
+}
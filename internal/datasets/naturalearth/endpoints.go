@@ -4,8 +4,11 @@ import "github.com/chnm/apiary/internal/httpx"
 
 func Endpoints(baseURL string) []httpx.Endpoint {
 	return []httpx.Endpoint{{
-		Name: "Countries from Natural Earth",
-		URL:  baseURL + "/ne/globe?location=Europe",
+		Name:       "Countries from Natural Earth",
+		URL:        baseURL + "/ne/globe?location=Europe",
+		Path:       "/ne/globe",
+		Parameters: globeParams.Parameters(),
+		Response:   httpx.FeatureCollection{},
 		Examples: []httpx.ExampleURL{
 			{URL: baseURL + "/ne/globe", Purpose: "All available polygons for all countries"},
 			{URL: baseURL + "/ne/globe?location=Europe", Purpose: "All available polygons for Europe"},
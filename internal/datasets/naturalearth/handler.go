@@ -2,14 +2,51 @@
 package naturalearth
 
 import (
+	"context"
+
+	"github.com/chnm/apiary/db"
+	"github.com/chnm/apiary/internal/cachex"
+	"github.com/chnm/apiary/internal/datasets"
+	"github.com/chnm/apiary/internal/deadline"
+	"github.com/chnm/apiary/internal/httpx"
+	"github.com/chnm/apiary/internal/ratelimit"
 	"github.com/gorilla/mux"
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-type Handler struct{ db *pgxpool.Pool }
+// Store reads the Natural Earth countries. PostgresStore is the
+// implementation the server uses.
+type Store interface {
+	// Countries returns a GeoJSON FeatureCollection of the countries on the
+	// given continents, or of every country if continents is empty.
+	Countries(ctx context.Context, continents []string) (string, error)
+}
+
+type Handler struct{ store Store }
+
+// New creates a handler that reads from db.
+func New(db db.Querier) *Handler { return NewWithStore(NewPostgresStore(db)) }
 
-func New(db *pgxpool.Pool) *Handler { return &Handler{db: db} }
+// NewWithStore creates a handler that reads from store.
+func NewWithStore(store Store) *Handler { return &Handler{store: store} }
 
 func (h *Handler) RegisterRoutes(router *mux.Router) {
-	router.HandleFunc("/ne/globe", h.NaturalEarthHandler()).Methods("GET", "HEAD")
+	// The boundaries are a fixed release of Natural Earth.
+	cached := cachex.TTL(cachex.Static)
+	bulk := ratelimit.In(ratelimit.Bulk)
+	spatial := deadline.In(deadline.Spatial)
+	router.Handle("/ne/globe", cached(bulk(spatial(h.NaturalEarthHandler())))).Methods("GET", "HEAD")
 }
+
+// Name returns the identifier used to enable or disable this dataset.
+func (h *Handler) Name() string { return "naturalearth" }
+
+// Metadata describes this dataset.
+func (h *Handler) Metadata() datasets.Metadata {
+	return datasets.Metadata{
+		Title:       "Natural Earth",
+		Description: "Country boundaries from Natural Earth.",
+	}
+}
+
+// Endpoints returns this dataset's entries for the root endpoint catalog.
+func (h *Handler) Endpoints(baseURL string) []httpx.Endpoint { return Endpoints(baseURL) }
@@ -0,0 +1,63 @@
+package naturalearth
+
+import (
+	"context"
+
+	"github.com/chnm/apiary/db"
+)
+
+const countriesQuery = `
+		SELECT json_build_object(
+			'type','FeatureCollection',
+			'features', json_agg(countries.feature)
+		)
+		FROM (
+			SELECT json_build_object(
+				'type', 'Feature',
+				'id', adm0_a3,
+				'properties', json_build_object(
+					'name', name),
+			  'geometry', ST_AsGeoJSON(geom_50m, 6)::json
+			) AS feature
+			FROM naturalearth.countries
+			) AS countries;
+		`
+
+const countriesByContinentQuery = `
+			SELECT json_build_object(
+				'type','FeatureCollection',
+				'features', json_agg(countries.feature)
+			)
+			FROM (
+				SELECT json_build_object(
+					'type', 'Feature',
+					'id', adm0_a3,
+					'properties', json_build_object(
+						'name', name),
+						'geometry', ST_AsGeoJSON(geom_50m, 6)::json
+				) AS feature
+				FROM naturalearth.countries
+				WHERE continent = ANY($1) AND geom_50m IS NOT NULL
+			) AS countries;
+		`
+
+// PostgresStore reads the Natural Earth countries from PostgreSQL.
+type PostgresStore struct {
+	db db.Querier
+}
+
+// NewPostgresStore returns a store that reads from db.
+func NewPostgresStore(db db.Querier) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// Countries implements Store.
+func (s *PostgresStore) Countries(ctx context.Context, continents []string) (string, error) {
+	var result string
+	if len(continents) == 0 {
+		err := s.db.QueryRow(ctx, countriesQuery).Scan(&result)
+		return result, err
+	}
+	err := s.db.QueryRow(ctx, countriesByContinentQuery, continents).Scan(&result)
+	return result, err
+}
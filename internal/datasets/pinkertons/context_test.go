@@ -5,10 +5,11 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
-	"strings"
 	"testing"
 	"time"
 
+	"github.com/chnm/apiary/internal/httpx"
+	"github.com/chnm/apiary/internal/testsupport"
 	"github.com/gorilla/mux"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -141,7 +142,7 @@ func TestPinkertonsHandlersPropagateRequestCancellation(t *testing.T) {
 					panicValue = recover()
 					handlerDone <- panicValue
 				}()
-				tt.handler(&Handler{db: pool}).ServeHTTP(response, request)
+				tt.handler(New(pool)).ServeHTTP(response, request)
 			}()
 
 			assertPinkertonsDatabaseContext(t, observedContext)
@@ -156,12 +157,7 @@ func TestPinkertonsHandlersPropagateRequestCancellation(t *testing.T) {
 				t.Fatal("handler did not stop after request cancellation")
 			}
 
-			if response.Code != http.StatusInternalServerError {
-				t.Fatalf("status = %d, want %d", response.Code, http.StatusInternalServerError)
-			}
-			if body := strings.TrimSpace(response.Body.String()); body != http.StatusText(http.StatusInternalServerError) {
-				t.Fatalf("body = %q, want %q", body, http.StatusText(http.StatusInternalServerError))
-			}
+			testsupport.AssertProblem(t, response, http.StatusInternalServerError, httpx.CodeInternal, "")
 		})
 	}
 }
@@ -174,7 +170,7 @@ func TestActivityLocationsPropagatesRequestCancellation(t *testing.T) {
 
 	queryDone := make(chan error, 1)
 	go func() {
-		_, err := (&Handler{db: pool}).activityLocations(request.Context(), 1)
+		_, err := NewPostgresStore(pool).activityLocations(request.Context(), 1)
 		queryDone <- err
 	}()
 
@@ -199,7 +195,7 @@ func TestBulkActivityLocationsPropagatesRequestCancellation(t *testing.T) {
 
 	queryDone := make(chan error, 1)
 	go func() {
-		_, err := (&Handler{db: pool}).activityLocationsByActivityIDs(
+		_, err := NewPostgresStore(pool).activityLocationsByActivityIDs(
 			request.Context(),
 			[]int{1, 2},
 		)
@@ -1,24 +1,40 @@
 package pinkertons
 
-import "github.com/chnm/apiary/internal/httpx"
+import (
+	"github.com/chnm/apiary/internal/httpx"
+	"github.com/chnm/apiary/internal/httpx/pagination"
+)
 
 func Endpoints(baseURL string) []httpx.Endpoint {
 	return []httpx.Endpoint{
 		{
-			Name: "Pinkertons: Activities (first 500 by default)",
-			URL:  baseURL + "/pinkertons/activities",
+			Name:       "Pinkertons: Activities (first 500 by default)",
+			URL:        baseURL + "/pinkertons/activities",
+			Path:       "/pinkertons/activities",
+			Parameters: activitiesParams.Parameters(),
+			Response:   pagination.Page[Activity]{},
+			CSV:        true,
+			StreamRow:  Activity{},
 			Examples: []httpx.ExampleURL{
 				{URL: baseURL + "/pinkertons/activities?limit=10", Purpose: "First 10 activities with location coordinates"},
-				{URL: baseURL + "/pinkertons/activities?limit=10&offset=10", Purpose: "Next 10 activities with location coordinates"},
+				{URL: baseURL + "/pinkertons/activities?limit=10&page=2", Purpose: "Next 10 activities with location coordinates"},
 				{URL: baseURL + "/pinkertons/activities?operative=John+Doe", Purpose: "Follow a specific operative"},
 				{URL: baseURL + "/pinkertons/activities?subject=Jane+Smith", Purpose: "Activities related to a specific subject"},
 				{URL: baseURL + "/pinkertons/activities?start_date=1900-01-01&end_date=1900-12-31", Purpose: "Activities within a date range"},
 				{URL: baseURL + "/pinkertons/activities?limit=50&start_date=1900-01-01", Purpose: "First 50 activities from 1900 onwards"},
+				{URL: baseURL + "/pinkertons/activities?limit=50&format=csv", Purpose: "Activities as CSV, with locations flattened into columns"},
+				{URL: baseURL + "/pinkertons/activities?start_date=1900-01-01&format=ndjson", Purpose: "Stream every matching activity as newline-delimited JSON; no limit applies unless one is given"},
 			},
 		},
-		{Name: "Pinkertons: Activity by ID with locations", URL: baseURL + "/pinkertons/activities/1"},
-		{Name: "Pinkertons: All locations with coordinates", URL: baseURL + "/pinkertons/locations"},
-		{Name: "Pinkertons: List of unique operatives", URL: baseURL + "/pinkertons/operatives"},
-		{Name: "Pinkertons: List of unique subjects", URL: baseURL + "/pinkertons/subjects"},
+		{
+			Name:       "Pinkertons: Activity by ID with locations",
+			URL:        baseURL + "/pinkertons/activities/1",
+			Path:       "/pinkertons/activities/{id}",
+			Parameters: activityParams.Parameters(),
+			Response:   Activity{},
+		},
+		{Name: "Pinkertons: All locations with coordinates", URL: baseURL + "/pinkertons/locations", Path: "/pinkertons/locations", Parameters: listParams.Parameters(), Response: pagination.Page[Location]{}},
+		{Name: "Pinkertons: List of unique operatives", URL: baseURL + "/pinkertons/operatives", Path: "/pinkertons/operatives", Parameters: listParams.Parameters(), Response: pagination.Page[string]{}},
+		{Name: "Pinkertons: List of unique subjects", URL: baseURL + "/pinkertons/subjects", Path: "/pinkertons/subjects", Parameters: listParams.Parameters(), Response: pagination.Page[string]{}},
 	}
 }
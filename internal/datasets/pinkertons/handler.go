@@ -2,22 +2,76 @@
 package pinkertons
 
 import (
+	"context"
+	"errors"
+
+	"github.com/chnm/apiary/db"
+	"github.com/chnm/apiary/internal/auth"
+	"github.com/chnm/apiary/internal/cachex"
+	"github.com/chnm/apiary/internal/datasets"
+	"github.com/chnm/apiary/internal/deadline"
 	"github.com/chnm/apiary/internal/httpx"
+	"github.com/chnm/apiary/internal/ratelimit"
 	"github.com/gorilla/mux"
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-type Handler struct{ db *pgxpool.Pool }
+// ErrNoActivity is returned by a Store that has no activity with the given
+// ID.
+var ErrNoActivity = errors.New("no such activity")
+
+// Store reads the Pinkerton reports. PostgresStore is the implementation the
+// server uses.
+type Store interface {
+	// Activities calls fn with each activity that matches params, with its
+	// locations, ordered by date, time, and ID. It stops at the first error
+	// fn returns.
+	Activities(ctx context.Context, params ActivitiesParameters, fn func(Activity) error) error
+	// Activity returns the activity with the given ID and its locations, or
+	// ErrNoActivity.
+	Activity(ctx context.Context, id int) (Activity, error)
+	// Locations returns every location, ordered by locality and name.
+	Locations(ctx context.Context) ([]Location, error)
+	// Operatives and Subjects return the distinct operatives and subjects
+	// named in the activities, in order.
+	Operatives(ctx context.Context) ([]string, error)
+	Subjects(ctx context.Context) ([]string, error)
+}
+
+type Handler struct{ store Store }
+
+// New creates a handler that reads from db.
+func New(db db.Querier) *Handler { return NewWithStore(NewPostgresStore(db)) }
 
-func New(db *pgxpool.Pool) *Handler { return &Handler{db: db} }
+// NewWithStore creates a handler that reads from store.
+func NewWithStore(store Store) *Handler { return &Handler{store: store} }
 
 func (h *Handler) RegisterRoutes(router *mux.Router) {
-	router.HandleFunc("/pinkertons/activities", h.ActivitiesHandler()).Methods("GET", "HEAD")
-	router.HandleFunc("/pinkertons/activities/{id:[0-9]+}", h.ActivityByIDHandler()).Methods("GET", "HEAD")
-	router.HandleFunc("/pinkertons/locations", h.LocationsHandler()).Methods("GET", "HEAD")
-	router.HandleFunc("/pinkertons/operatives", h.OperativesHandler()).Methods("GET", "HEAD")
-	router.HandleFunc("/pinkertons/subjects", h.SubjectsHandler()).Methods("GET", "HEAD")
+	// The data is being prepared for publication, so every route requires an
+	// API key whose scope includes this dataset.
+	restricted := auth.Restricted(h.Name())
+	cached := cachex.TTL(cachex.Stable)
+	bulk := ratelimit.In(ratelimit.Bulk)
+	long := deadline.In(deadline.Bulk)
+	router.Handle("/pinkertons/activities", restricted(cached(bulk(long(deadline.Streaming(h.ActivitiesHandler())))))).Methods("GET", "HEAD")
+	router.Handle("/pinkertons/activities/{id:[0-9]+}", restricted(cached(h.ActivityByIDHandler()))).Methods("GET", "HEAD")
+	router.Handle("/pinkertons/locations", restricted(cached(h.LocationsHandler()))).Methods("GET", "HEAD")
+	router.Handle("/pinkertons/operatives", restricted(cached(h.OperativesHandler()))).Methods("GET", "HEAD")
+	router.Handle("/pinkertons/subjects", restricted(cached(h.SubjectsHandler()))).Methods("GET", "HEAD")
 }
 
+// Name returns the identifier used to enable or disable this dataset.
+func (h *Handler) Name() string { return "pinkertons" }
+
+// Metadata describes this dataset.
+func (h *Handler) Metadata() datasets.Metadata {
+	return datasets.Metadata{
+		Title:       "Pinkerton surveillance data",
+		Description: "Activities, locations, operatives, and subjects from Pinkerton detective reports.",
+	}
+}
+
+// Endpoints returns this dataset's entries for the root endpoint catalog.
+func (h *Handler) Endpoints(baseURL string) []httpx.Endpoint { return Endpoints(baseURL) }
+
 type NullInt64 = httpx.NullInt64
 type NullString = httpx.NullString
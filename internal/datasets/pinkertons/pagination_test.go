@@ -3,45 +3,57 @@ package pinkertons
 import (
 	"net/http"
 	"net/http/httptest"
-	"strings"
 	"testing"
+
+	"github.com/chnm/apiary/internal/httpx"
+	"github.com/chnm/apiary/internal/testsupport"
 )
 
 func TestActivitiesRejectInvalidPaginationParameters(t *testing.T) {
 	tests := []struct {
-		name string
-		path string
-		body string
+		name      string
+		path      string
+		parameter string
 	}{
 		{
-			name: "zero limit",
-			path: "/pinkertons/activities?limit=0",
-			body: "Invalid limit parameter",
+			name:      "zero limit",
+			path:      "/pinkertons/activities?limit=0",
+			parameter: "limit",
+		},
+		{
+			name:      "non-numeric limit",
+			path:      "/pinkertons/activities?limit=ten",
+			parameter: "limit",
+		},
+		{
+			name:      "negative offset",
+			path:      "/pinkertons/activities?offset=-1",
+			parameter: "offset",
 		},
 		{
-			name: "non-numeric limit",
-			path: "/pinkertons/activities?limit=ten",
-			body: "Invalid limit parameter",
+			name:      "non-numeric offset",
+			path:      "/pinkertons/activities?offset=ten",
+			parameter: "offset",
 		},
 		{
-			name: "negative offset",
-			path: "/pinkertons/activities?offset=-1",
-			body: "Invalid offset parameter",
+			name:      "zero location ID",
+			path:      "/pinkertons/activities?location_id=0",
+			parameter: "location_id",
 		},
 		{
-			name: "non-numeric offset",
-			path: "/pinkertons/activities?offset=ten",
-			body: "Invalid offset parameter",
+			name:      "non-numeric location ID",
+			path:      "/pinkertons/activities?location_id=unknown",
+			parameter: "location_id",
 		},
 		{
-			name: "zero location ID",
-			path: "/pinkertons/activities?location_id=0",
-			body: "Invalid location_id parameter",
+			name:      "malformed start date",
+			path:      "/pinkertons/activities?start_date=1877-13-01",
+			parameter: "start_date",
 		},
 		{
-			name: "non-numeric location ID",
-			path: "/pinkertons/activities?location_id=unknown",
-			body: "Invalid location_id parameter",
+			name:      "unknown parameter",
+			path:      "/pinkertons/activities?start-date=1877-01-01",
+			parameter: "start-date",
 		},
 	}
 
@@ -52,16 +64,7 @@ func TestActivitiesRejectInvalidPaginationParameters(t *testing.T) {
 
 			(&Handler{}).ActivitiesHandler().ServeHTTP(response, request)
 
-			if response.Code != http.StatusBadRequest {
-				t.Fatalf(
-					"status = %d, want %d",
-					response.Code,
-					http.StatusBadRequest,
-				)
-			}
-			if body := strings.TrimSpace(response.Body.String()); body != tt.body {
-				t.Fatalf("body = %q, want %q", body, tt.body)
-			}
+			testsupport.AssertProblem(t, response, http.StatusBadRequest, httpx.CodeInvalidParameter, tt.parameter)
 		})
 	}
 }
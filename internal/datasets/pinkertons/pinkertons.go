@@ -1,16 +1,16 @@
 package pinkertons
 
 import (
-	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
 	"net/http"
 	"strconv"
+	"time"
 
-	"github.com/gorilla/mux"
-	"github.com/jackc/pgx/v5"
+	"github.com/chnm/apiary/internal/httpx"
+	"github.com/chnm/apiary/internal/httpx/pagination"
+	paramx "github.com/chnm/apiary/internal/params"
 )
 
 // Activity represents a detective activity from the database
@@ -47,107 +47,22 @@ type Location struct {
 	Longitude            NullFloat64 `json:"longitude"`
 }
 
-const activityLocationsQuery = `
-SELECT
-	l.id, l.locality, l.street_address, l.location_name,
-	l.location_type, l.specific_location_type, l.location_notes, l.visits, l.latitude, l.longitude
-FROM detectives.locations l
-INNER JOIN detectives.activity_locations al ON l.id = al.location_id
-WHERE al.activity_id = $1;
-`
-
-const activityLocationsByActivityIDsQuery = `
-SELECT
-	al.activity_id,
-	l.id, l.locality, l.street_address, l.location_name,
-	l.location_type, l.specific_location_type, l.location_notes, l.visits, l.latitude, l.longitude
-FROM detectives.locations l
-INNER JOIN detectives.activity_locations al ON l.id = al.location_id
-WHERE al.activity_id = ANY($1);
-`
-
-const defaultActivitiesLimit = 500
-
-func (h *Handler) activityLocations(ctx context.Context, activityID int) ([]Location, error) {
-	locations := make([]Location, 0)
-	rows, err := h.db.Query(ctx, activityLocationsQuery, activityID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var location Location
-		if err := rows.Scan(
-			&location.ID,
-			&location.Locality,
-			&location.StreetAddress,
-			&location.LocationName,
-			&location.LocationType,
-			&location.SpecificLocationType,
-			&location.LocationNotes,
-			&location.Visits,
-			&location.Latitude,
-			&location.Longitude,
-		); err != nil {
-			return nil, err
-		}
-		locations = append(locations, location)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-
-	return locations, nil
+// ActivitiesParameters selects the activities returned by ActivitiesHandler.
+// Empty filters match every activity.
+type ActivitiesParameters struct {
+	Operative  string
+	Subject    string
+	StartDate  string // YYYY-MM-DD
+	EndDate    string // YYYY-MM-DD
+	LocationID int
+	Limit      int // No limit if zero
+	Offset     int
 }
 
-func (h *Handler) activityLocationsByActivityIDs(
-	ctx context.Context,
-	activityIDs []int,
-) (map[int][]Location, error) {
-	locationsByActivityID := make(map[int][]Location, len(activityIDs))
-	for _, activityID := range activityIDs {
-		locationsByActivityID[activityID] = make([]Location, 0)
-	}
-	if len(activityIDs) == 0 {
-		return locationsByActivityID, nil
-	}
-
-	rows, err := h.db.Query(ctx, activityLocationsByActivityIDsQuery, activityIDs)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var activityID int
-		var location Location
-		if err := rows.Scan(
-			&activityID,
-			&location.ID,
-			&location.Locality,
-			&location.StreetAddress,
-			&location.LocationName,
-			&location.LocationType,
-			&location.SpecificLocationType,
-			&location.LocationNotes,
-			&location.Visits,
-			&location.Latitude,
-			&location.Longitude,
-		); err != nil {
-			return nil, err
-		}
-		locationsByActivityID[activityID] = append(
-			locationsByActivityID[activityID],
-			location,
-		)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
+const defaultActivitiesLimit = 500
 
-	return locationsByActivityID, nil
-}
+// activitiesPages bounds the pages of ActivitiesHandler.
+var activitiesPages = pagination.Records.WithDefaultLimit(defaultActivitiesLimit).Streaming()
 
 // NullFloat64 handles nullable float64 values for JSON marshaling
 type NullFloat64 struct {
@@ -214,6 +129,15 @@ func (v *NullFloat64) Scan(value interface{}) error {
 	return nil
 }
 
+// activitiesParams declares the parameters of ActivitiesHandler.
+var activitiesParams = paramx.NewSet(
+	paramx.String("operative", "Operative name, as listed by /pinkertons/operatives"),
+	paramx.String("subject", "Subject name, as listed by /pinkertons/subjects"),
+	paramx.Date("start_date", "Earliest activity date to include"),
+	paramx.Date("end_date", "Latest activity date to include"),
+	paramx.Int("location_id", "Only activities at this location").Min(1),
+).Accepting(httpx.StreamFormatParameter).Accepting(pagination.Parameters(activitiesPages)...)
+
 // ActivitiesHandler returns detective activities with location data and optional filtering.
 // Query parameters:
 //   - operative: filter by operative name
@@ -221,363 +145,173 @@ func (v *NullFloat64) Scan(value interface{}) error {
 //   - start_date: filter by start date (YYYY-MM-DD)
 //   - end_date: filter by end date (YYYY-MM-DD)
 //   - location_id: filter by location ID
-//   - limit: maximum number of results to return (default: 500)
-//   - offset: number of ordered results to skip (default: 0)
+//   - limit, offset, page, and cursor: the page to return, as
+//     pagination.Parse reads them (default: the first 500, or every
+//     activity when streaming)
+//   - format: json (default), csv, or ndjson to stream one activity per line
 func (h *Handler) ActivitiesHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		operative := r.URL.Query().Get("operative")
-		subject := r.URL.Query().Get("subject")
-		startDate := r.URL.Query().Get("start_date")
-		endDate := r.URL.Query().Get("end_date")
-		locationIDStr := r.URL.Query().Get("location_id")
-		limitStr := r.URL.Query().Get("limit")
-		offsetStr := r.URL.Query().Get("offset")
-
-		// Build query dynamically based on parameters
-		baseQuery := `
-		SELECT
-			a.id, a.source, a.operative, a.date, a.time, a.duration,
-			a.activity, a.mode, a.activity_notes, a.subject, a.information,
-			a.information_type, a.edited, a.edit_type, a.investigation
-		FROM detectives.activities a
-		WHERE 1=1
-		`
-
-		// Add filters
-		args := make([]interface{}, 0)
-		argCount := 1
-
-		if operative != "" {
-			baseQuery += fmt.Sprintf(" AND a.operative = $%d", argCount)
-			args = append(args, operative)
-			argCount++
-		}
+		stream := httpx.Format(r) == httpx.FormatNDJSON
 
-		if subject != "" {
-			baseQuery += fmt.Sprintf(" AND a.subject = $%d", argCount)
-			args = append(args, subject)
-			argCount++
+		values, err := activitiesParams.Parse(r)
+		if err != nil {
+			httpx.BadRequest(w, r, err)
+			return
 		}
-
-		if startDate != "" {
-			baseQuery += fmt.Sprintf(" AND a.date >= $%d", argCount)
-			args = append(args, startDate)
-			argCount++
+		params := ActivitiesParameters{
+			Operative:  values.String("operative"),
+			Subject:    values.String("subject"),
+			LocationID: values.Int("location_id"),
 		}
-
-		if endDate != "" {
-			baseQuery += fmt.Sprintf(" AND a.date <= $%d", argCount)
-			args = append(args, endDate)
-			argCount++
+		if values.Has("start_date") {
+			params.StartDate = values.Date("start_date").Format(time.DateOnly)
 		}
-
-		if locationIDStr != "" {
-			locationID, err := strconv.Atoi(locationIDStr)
-			if err != nil || locationID <= 0 {
-				http.Error(w, "Invalid location_id parameter", http.StatusBadRequest)
-				return
-			}
-			baseQuery += fmt.Sprintf(" AND a.id IN (SELECT activity_id FROM detectives.activity_locations WHERE location_id = $%d)", argCount)
-			args = append(args, locationID)
-			argCount++
+		if values.Has("end_date") {
+			params.EndDate = values.Date("end_date").Format(time.DateOnly)
 		}
 
-		baseQuery += " ORDER BY a.date, a.time, a.id"
-
-		limit := defaultActivitiesLimit
-		if limitStr != "" {
-			var err error
-			limit, err = strconv.Atoi(limitStr)
-			if err != nil || limit <= 0 {
-				http.Error(w, "Invalid limit parameter", http.StatusBadRequest)
-				return
-			}
-		}
-		baseQuery += fmt.Sprintf(" LIMIT $%d", argCount)
-		args = append(args, limit)
-		argCount++
-
-		// Add offset if specified
-		if offsetStr != "" {
-			offset, err := strconv.Atoi(offsetStr)
-			if err != nil || offset < 0 {
-				http.Error(w, "Invalid offset parameter", http.StatusBadRequest)
-				return
-			}
-			baseQuery += fmt.Sprintf(" OFFSET $%d", argCount)
-			args = append(args, offset)
-		}
-
-		baseQuery += ";"
-
-		results := make([]Activity, 0)
-
-		rows, err := h.db.Query(r.Context(), baseQuery, args...)
+		q, err := pagination.Parse(r, activitiesPages)
 		if err != nil {
-			log.Println("Error querying activities:", err)
-			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			httpx.BadRequest(w, r, err)
 			return
 		}
-		defer rows.Close()
-
-		for rows.Next() {
-			var row Activity
-			err := rows.Scan(
-				&row.ID, &row.Source, &row.Operative, &row.Date, &row.Time,
-				&row.Duration, &row.Activity, &row.Mode, &row.ActivityNotes,
-				&row.Subject, &row.Information, &row.InformationType,
-				&row.Edited, &row.EditType, &row.Investigation,
-			)
-			if err != nil {
-				log.Println("Error scanning activity row:", err)
-				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
-				return
-			}
-			results = append(results, row)
-		}
+		params.Limit, params.Offset = q.Fetch(), q.Offset
 
-		if err = rows.Err(); err != nil {
-			log.Println("Error iterating activities:", err)
-			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		if stream {
+			// Streams have no next page to look for.
+			params.Limit = q.Limit
+			h.streamActivities(w, r, params)
 			return
 		}
 
-		activityIDs := make([]int, len(results))
-		for i := range results {
-			activityIDs[i] = results[i].ID
-		}
-
-		locationsByActivityID, err := h.activityLocationsByActivityIDs(
-			r.Context(),
-			activityIDs,
-		)
+		results := make([]Activity, 0)
+		err = h.store.Activities(r.Context(), params, func(activity Activity) error {
+			results = append(results, activity)
+			return nil
+		})
 		if err != nil {
-			log.Println("Error querying activity locations:", err)
-			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			httpx.InternalServerError(w, r, "error querying activities", err)
 			return
 		}
-		for i := range results {
-			results[i].Locations = locationsByActivityID[results[i].ID]
-		}
 
-		response, err := json.Marshal(results)
-		if err != nil {
-			log.Println("Error marshaling JSON:", err)
-			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
-			return
-		}
+		pagination.WriteTable(w, r, pagination.NewPage(q, results))
+	}
+}
 
-		w.Header().Set("Content-Type", "application/json")
-		fmt.Fprint(w, string(response))
+// streamActivities writes activities as NDJSON as soon as they are read.
+func (h *Handler) streamActivities(w http.ResponseWriter, r *http.Request, params ActivitiesParameters) {
+	w.Header().Add("Vary", "Accept")
+	stream := httpx.NewNDJSONStream(w, r)
+	err := h.store.Activities(r.Context(), params, func(activity Activity) error {
+		return stream.Write(activity)
+	})
+	if err != nil {
+		stream.Fail("error streaming activities", err)
+		return
+	}
+	if err := stream.Close(); err != nil {
+		stream.Fail("error streaming activities", err)
 	}
 }
 
+// activityParams declares the parameters of ActivityByIDHandler.
+var activityParams = paramx.NewSet(
+	paramx.Int("id", "Activity ID").InPath().Min(1),
+)
+
+// listParams declares the parameters of the handlers that list locations,
+// operatives, and subjects.
+var listParams = paramx.NewSet().Accepting(pagination.Parameters(pagination.Lists)...)
+
 // ActivityByIDHandler returns a single activity with its locations
 func (h *Handler) ActivityByIDHandler() http.HandlerFunc {
-	activityQuery := `
-	SELECT
-		a.id, a.source, a.operative, a.date, a.time, a.duration,
-		a.activity, a.mode, a.activity_notes, a.subject, a.information,
-		a.information_type, a.edited, a.edit_type, a.investigation
-	FROM detectives.activities a
-	WHERE a.id = $1;
-	`
-
 	return func(w http.ResponseWriter, r *http.Request) {
-		vars := mux.Vars(r)
-		idStr := vars["id"]
-		id, err := strconv.Atoi(idStr)
+		values, err := activityParams.Parse(r)
 		if err != nil {
-			http.Error(w, "Invalid activity ID", http.StatusBadRequest)
+			httpx.BadRequest(w, r, err)
 			return
 		}
+		id := values.Int("id")
 
-		var activity Activity
-
-		// Get activity
-		err = h.db.QueryRow(r.Context(), activityQuery, id).Scan(
-			&activity.ID, &activity.Source, &activity.Operative, &activity.Date,
-			&activity.Time, &activity.Duration, &activity.Activity, &activity.Mode,
-			&activity.ActivityNotes, &activity.Subject, &activity.Information,
-			&activity.InformationType, &activity.Edited, &activity.EditType, &activity.Investigation,
-		)
-		if errors.Is(err, pgx.ErrNoRows) {
-			http.Error(w, "Activity not found", http.StatusNotFound)
+		activity, err := h.store.Activity(r.Context(), id)
+		if errors.Is(err, ErrNoActivity) {
+			httpx.NotFound(w, r, fmt.Sprintf("no activity with id %d", id))
 			return
 		}
 		if err != nil {
-			log.Println("Error querying activity:", err)
-			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
-			return
-		}
-
-		// Get locations for this activity
-		activity.Locations, err = h.activityLocations(r.Context(), id)
-		if err != nil {
-			log.Println("Error querying locations:", err)
-			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
-			return
-		}
-
-		response, err := json.Marshal(activity)
-		if err != nil {
-			log.Println("Error marshaling JSON:", err)
-			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			httpx.InternalServerError(w, r, "error querying activity", err)
 			return
 		}
 
-		w.Header().Set("Content-Type", "application/json")
-		fmt.Fprint(w, string(response))
+		httpx.WriteJSON(w, r, activity)
 	}
 }
 
 // LocationsHandler returns all locations with coordinates
 func (h *Handler) LocationsHandler() http.HandlerFunc {
-	query := `
-	SELECT
-		l.id, l.locality, l.street_address, l.location_name,
-		l.location_type, l.specific_location_type, l.location_notes, l.visits, l.latitude, l.longitude
-	FROM detectives.locations l
-	ORDER BY l.locality, l.location_name;
-	`
-
 	return func(w http.ResponseWriter, r *http.Request) {
-		results := make([]Location, 0)
-
-		rows, err := h.db.Query(r.Context(), query)
-		if err != nil {
-			log.Println("Error querying locations:", err)
-			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		if _, err := listParams.Parse(r); err != nil {
+			httpx.BadRequest(w, r, err)
 			return
 		}
-		defer rows.Close()
-
-		for rows.Next() {
-			var row Location
-			err := rows.Scan(
-				&row.ID, &row.Locality, &row.StreetAddress, &row.LocationName,
-				&row.LocationType, &row.SpecificLocationType, &row.LocationNotes, &row.Visits, &row.Latitude, &row.Longitude,
-			)
-			if err != nil {
-				log.Println("Error scanning location row:", err)
-				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
-				return
-			}
-			results = append(results, row)
-		}
-
-		if err = rows.Err(); err != nil {
-			log.Println("Error iterating locations:", err)
-			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		q, err := pagination.Parse(r, pagination.Lists)
+		if err != nil {
+			httpx.BadRequest(w, r, err)
 			return
 		}
 
-		response, err := json.Marshal(results)
+		results, err := h.store.Locations(r.Context())
 		if err != nil {
-			log.Println("Error marshaling JSON:", err)
-			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			httpx.InternalServerError(w, r, "error querying locations", err)
 			return
 		}
 
-		w.Header().Set("Content-Type", "application/json")
-		fmt.Fprint(w, string(response))
+		pagination.WriteJSON(w, r, pagination.Slice(q, results))
 	}
 }
 
 // OperativesHandler returns a list of unique operatives
 func (h *Handler) OperativesHandler() http.HandlerFunc {
-	query := `
-	SELECT DISTINCT operative
-	FROM detectives.activities
-	WHERE operative IS NOT NULL
-	ORDER BY operative;
-	`
-
 	return func(w http.ResponseWriter, r *http.Request) {
-		results := make([]string, 0)
-
-		rows, err := h.db.Query(r.Context(), query)
-		if err != nil {
-			log.Println("Error querying operatives:", err)
-			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		if _, err := listParams.Parse(r); err != nil {
+			httpx.BadRequest(w, r, err)
 			return
 		}
-		defer rows.Close()
-
-		for rows.Next() {
-			var operative string
-			err := rows.Scan(&operative)
-			if err != nil {
-				log.Println("Error scanning operative:", err)
-				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
-				return
-			}
-			results = append(results, operative)
-		}
-
-		if err = rows.Err(); err != nil {
-			log.Println("Error iterating operatives:", err)
-			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		q, err := pagination.Parse(r, pagination.Lists)
+		if err != nil {
+			httpx.BadRequest(w, r, err)
 			return
 		}
 
-		response, err := json.Marshal(results)
+		results, err := h.store.Operatives(r.Context())
 		if err != nil {
-			log.Println("Error marshaling JSON:", err)
-			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			httpx.InternalServerError(w, r, "error querying operatives", err)
 			return
 		}
 
-		w.Header().Set("Content-Type", "application/json")
-		fmt.Fprint(w, string(response))
+		pagination.WriteJSON(w, r, pagination.Slice(q, results))
 	}
 }
 
 // SubjectsHandler returns a list of unique subjects
 func (h *Handler) SubjectsHandler() http.HandlerFunc {
-	query := `
-	SELECT DISTINCT subject
-	FROM detectives.activities
-	WHERE subject IS NOT NULL
-	ORDER BY subject;
-	`
-
 	return func(w http.ResponseWriter, r *http.Request) {
-		results := make([]string, 0)
-
-		rows, err := h.db.Query(r.Context(), query)
-		if err != nil {
-			log.Println("Error querying subjects:", err)
-			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		if _, err := listParams.Parse(r); err != nil {
+			httpx.BadRequest(w, r, err)
 			return
 		}
-		defer rows.Close()
-
-		for rows.Next() {
-			var subject string
-			err := rows.Scan(&subject)
-			if err != nil {
-				log.Println("Error scanning subject:", err)
-				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
-				return
-			}
-			results = append(results, subject)
-		}
-
-		if err = rows.Err(); err != nil {
-			log.Println("Error iterating subjects:", err)
-			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		q, err := pagination.Parse(r, pagination.Lists)
+		if err != nil {
+			httpx.BadRequest(w, r, err)
 			return
 		}
 
-		response, err := json.Marshal(results)
+		results, err := h.store.Subjects(r.Context())
 		if err != nil {
-			log.Println("Error marshaling JSON:", err)
-			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			httpx.InternalServerError(w, r, "error querying subjects", err)
 			return
 		}
 
-		w.Header().Set("Content-Type", "application/json")
-		fmt.Fprint(w, string(response))
+		pagination.WriteJSON(w, r, pagination.Slice(q, results))
 	}
 }
@@ -0,0 +1,313 @@
+package pinkertons
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/chnm/apiary/db"
+	"github.com/jackc/pgx/v5"
+)
+
+const activityColumns = `
+	a.id, a.source, a.operative, a.date, a.time, a.duration,
+	a.activity, a.mode, a.activity_notes, a.subject, a.information,
+	a.information_type, a.edited, a.edit_type, a.investigation
+`
+
+const activityQuery = `
+SELECT` + activityColumns + `
+FROM detectives.activities a
+WHERE a.id = $1;
+`
+
+const activityLocationsQuery = `
+SELECT
+	l.id, l.locality, l.street_address, l.location_name,
+	l.location_type, l.specific_location_type, l.location_notes, l.visits, l.latitude, l.longitude
+FROM detectives.locations l
+INNER JOIN detectives.activity_locations al ON l.id = al.location_id
+WHERE al.activity_id = $1;
+`
+
+const activityLocationsByActivityIDsQuery = `
+SELECT
+	al.activity_id,
+	l.id, l.locality, l.street_address, l.location_name,
+	l.location_type, l.specific_location_type, l.location_notes, l.visits, l.latitude, l.longitude
+FROM detectives.locations l
+INNER JOIN detectives.activity_locations al ON l.id = al.location_id
+WHERE al.activity_id = ANY($1);
+`
+
+const locationsQuery = `
+SELECT
+	l.id, l.locality, l.street_address, l.location_name,
+	l.location_type, l.specific_location_type, l.location_notes, l.visits, l.latitude, l.longitude
+FROM detectives.locations l
+ORDER BY l.locality, l.location_name;
+`
+
+const operativesQuery = `
+SELECT DISTINCT operative
+FROM detectives.activities
+WHERE operative IS NOT NULL
+ORDER BY operative;
+`
+
+const subjectsQuery = `
+SELECT DISTINCT subject
+FROM detectives.activities
+WHERE subject IS NOT NULL
+ORDER BY subject;
+`
+
+// activityBatchSize is how many activities share a locations query.
+const activityBatchSize = 100
+
+// PostgresStore reads the Pinkerton reports from PostgreSQL.
+type PostgresStore struct {
+	db db.Querier
+}
+
+// NewPostgresStore returns a store that reads from db.
+func NewPostgresStore(db db.Querier) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// buildActivitiesQuery returns the query for the activities params selects,
+// and its arguments.
+func buildActivitiesQuery(params ActivitiesParameters) (string, []any) {
+	query := `
+	SELECT` + activityColumns + `
+	FROM detectives.activities a
+	WHERE 1=1
+	`
+	var args []any
+	add := func(condition string, value any) {
+		args = append(args, value)
+		query += fmt.Sprintf(condition, len(args))
+	}
+
+	if params.Operative != "" {
+		add(" AND a.operative = $%d", params.Operative)
+	}
+	if params.Subject != "" {
+		add(" AND a.subject = $%d", params.Subject)
+	}
+	if params.StartDate != "" {
+		add(" AND a.date >= $%d", params.StartDate)
+	}
+	if params.EndDate != "" {
+		add(" AND a.date <= $%d", params.EndDate)
+	}
+	if params.LocationID != 0 {
+		add(" AND a.id IN (SELECT activity_id FROM detectives.activity_locations WHERE location_id = $%d)", params.LocationID)
+	}
+
+	query += " ORDER BY a.date, a.time, a.id"
+	if params.Limit > 0 {
+		add(" LIMIT $%d", params.Limit)
+	}
+	if params.Offset > 0 {
+		add(" OFFSET $%d", params.Offset)
+	}
+	return query + ";", args
+}
+
+func scanActivity(row pgx.Row) (Activity, error) {
+	var activity Activity
+	err := row.Scan(
+		&activity.ID, &activity.Source, &activity.Operative, &activity.Date, &activity.Time,
+		&activity.Duration, &activity.Activity, &activity.Mode, &activity.ActivityNotes,
+		&activity.Subject, &activity.Information, &activity.InformationType,
+		&activity.Edited, &activity.EditType, &activity.Investigation,
+	)
+	return activity, err
+}
+
+func scanLocation(row pgx.Row, prefix ...any) (Location, error) {
+	var location Location
+	err := row.Scan(append(prefix,
+		&location.ID,
+		&location.Locality,
+		&location.StreetAddress,
+		&location.LocationName,
+		&location.LocationType,
+		&location.SpecificLocationType,
+		&location.LocationNotes,
+		&location.Visits,
+		&location.Latitude,
+		&location.Longitude,
+	)...)
+	return location, err
+}
+
+// Activities implements Store. The locations of each batch of activities are
+// looked up together before the batch is passed to fn.
+func (s *PostgresStore) Activities(ctx context.Context, params ActivitiesParameters, fn func(Activity) error) error {
+	query, args := buildActivitiesQuery(params)
+	rows, err := s.db.Query(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	batch := make([]Activity, 0, activityBatchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		activityIDs := make([]int, len(batch))
+		for i := range batch {
+			activityIDs[i] = batch[i].ID
+		}
+		locationsByActivityID, err := s.activityLocationsByActivityIDs(ctx, activityIDs)
+		if err != nil {
+			return fmt.Errorf("query activity locations: %w", err)
+		}
+		for i := range batch {
+			batch[i].Locations = locationsByActivityID[batch[i].ID]
+			if err := fn(batch[i]); err != nil {
+				return err
+			}
+		}
+		batch = batch[:0]
+		return nil
+	}
+
+	for rows.Next() {
+		activity, err := scanActivity(rows)
+		if err != nil {
+			return fmt.Errorf("scan activity: %w", err)
+		}
+		batch = append(batch, activity)
+		if len(batch) == activityBatchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return flush()
+}
+
+// Activity implements Store.
+func (s *PostgresStore) Activity(ctx context.Context, id int) (Activity, error) {
+	activity, err := scanActivity(s.db.QueryRow(ctx, activityQuery, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Activity{}, ErrNoActivity
+	}
+	if err != nil {
+		return Activity{}, err
+	}
+	activity.Locations, err = s.activityLocations(ctx, id)
+	if err != nil {
+		return Activity{}, fmt.Errorf("query activity locations: %w", err)
+	}
+	return activity, nil
+}
+
+func (s *PostgresStore) activityLocations(ctx context.Context, activityID int) ([]Location, error) {
+	rows, err := s.db.Query(ctx, activityLocationsQuery, activityID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	locations := make([]Location, 0)
+	for rows.Next() {
+		location, err := scanLocation(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan location: %w", err)
+		}
+		locations = append(locations, location)
+	}
+	return locations, rows.Err()
+}
+
+func (s *PostgresStore) activityLocationsByActivityIDs(
+	ctx context.Context,
+	activityIDs []int,
+) (map[int][]Location, error) {
+	locationsByActivityID := make(map[int][]Location, len(activityIDs))
+	for _, activityID := range activityIDs {
+		locationsByActivityID[activityID] = make([]Location, 0)
+	}
+	if len(activityIDs) == 0 {
+		return locationsByActivityID, nil
+	}
+
+	rows, err := s.db.Query(ctx, activityLocationsByActivityIDsQuery, activityIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var activityID int
+		location, err := scanLocation(rows, &activityID)
+		if err != nil {
+			return nil, fmt.Errorf("scan location: %w", err)
+		}
+		locationsByActivityID[activityID] = append(
+			locationsByActivityID[activityID],
+			location,
+		)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return locationsByActivityID, nil
+}
+
+// Locations implements Store.
+func (s *PostgresStore) Locations(ctx context.Context) ([]Location, error) {
+	rows, err := s.db.Query(ctx, locationsQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := make([]Location, 0)
+	for rows.Next() {
+		location, err := scanLocation(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan location: %w", err)
+		}
+		results = append(results, location)
+	}
+	return results, rows.Err()
+}
+
+// Operatives implements Store.
+func (s *PostgresStore) Operatives(ctx context.Context) ([]string, error) {
+	return s.names(ctx, operativesQuery, "operative")
+}
+
+// Subjects implements Store.
+func (s *PostgresStore) Subjects(ctx context.Context) ([]string, error) {
+	return s.names(ctx, subjectsQuery, "subject")
+}
+
+// names returns the single text column that query selects.
+func (s *PostgresStore) names(ctx context.Context, query, column string) ([]string, error) {
+	rows, err := s.db.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := make([]string, 0)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("scan %s: %w", column, err)
+		}
+		results = append(results, name)
+	}
+	return results, rows.Err()
+}
@@ -0,0 +1,186 @@
+package pinkertons
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/chnm/apiary/internal/httpx"
+	"github.com/chnm/apiary/internal/httpx/pagination"
+	"github.com/chnm/apiary/internal/testsupport"
+	"github.com/gorilla/mux"
+)
+
+// fakeStore serves activities from memory. Methods the tests do not use
+// panic through the nil embedded Store.
+type fakeStore struct {
+	Store
+	activities []Activity
+	params     ActivitiesParameters // The parameters of the last Activities call
+}
+
+func (s *fakeStore) Activities(_ context.Context, params ActivitiesParameters, fn func(Activity) error) error {
+	s.params = params
+	for _, activity := range s.activities {
+		if err := fn(activity); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *fakeStore) Activity(_ context.Context, id int) (Activity, error) {
+	for _, activity := range s.activities {
+		if activity.ID == id {
+			return activity, nil
+		}
+	}
+	return Activity{}, ErrNoActivity
+}
+
+var fakeActivities = []Activity{
+	{ID: 1, Locations: []Location{{ID: 10}, {ID: 11}}},
+	{ID: 2, Locations: []Location{}},
+}
+
+// Pages read one activity more than their limit, to tell whether another
+// page follows.
+func TestActivitiesHandlerPassesParameters(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		want ActivitiesParameters
+	}{
+		{
+			name: "defaults",
+			path: "/pinkertons/activities",
+			want: ActivitiesParameters{Limit: defaultActivitiesLimit + 1},
+		},
+		{
+			name: "filters",
+			path: "/pinkertons/activities?operative=Smith&subject=Jones&start_date=1877-01-01&end_date=1877-12-31&location_id=4&limit=10&offset=20",
+			want: ActivitiesParameters{
+				Operative:  "Smith",
+				Subject:    "Jones",
+				StartDate:  "1877-01-01",
+				EndDate:    "1877-12-31",
+				LocationID: 4,
+				Limit:      11,
+				Offset:     20,
+			},
+		},
+		{
+			name: "unlimited stream",
+			path: "/pinkertons/activities?format=ndjson",
+			want: ActivitiesParameters{},
+		},
+		{
+			name: "limited stream",
+			path: "/pinkertons/activities?format=ndjson&limit=5",
+			want: ActivitiesParameters{Limit: 5},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fakeStore{}
+			response := httptest.NewRecorder()
+
+			NewWithStore(store).ActivitiesHandler().ServeHTTP(response, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if response.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200: %s", response.Code, response.Body)
+			}
+			if store.params != tt.want {
+				t.Fatalf("store parameters = %+v, want %+v", store.params, tt.want)
+			}
+		})
+	}
+}
+
+func TestActivitiesHandlerWritesLocations(t *testing.T) {
+	response := httptest.NewRecorder()
+
+	NewWithStore(&fakeStore{activities: fakeActivities}).ActivitiesHandler().ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/pinkertons/activities", nil))
+
+	var page pagination.Page[Activity]
+	if err := json.Unmarshal(response.Body.Bytes(), &page); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if !reflect.DeepEqual(page.Data, []Activity{{ID: 1, Locations: []Location{{ID: 10}, {ID: 11}}}, {ID: 2}}) {
+		t.Fatalf("activities = %+v, want the store's activities and locations", page.Data)
+	}
+}
+
+func TestActivitiesHandlerLinksNextPage(t *testing.T) {
+	response := httptest.NewRecorder()
+
+	NewWithStore(&fakeStore{activities: fakeActivities}).ActivitiesHandler().ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/pinkertons/activities?subject=Jones&limit=1", nil))
+
+	var page pagination.Page[Activity]
+	if err := json.Unmarshal(response.Body.Bytes(), &page); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(page.Data) != 1 || !page.HasMore || page.Next == "" {
+		t.Fatalf("page = %+v, want one activity and a next link", page)
+	}
+	if link := response.Header().Get("Link"); link != "<"+page.Next+`>; rel="next"` {
+		t.Fatalf("Link = %q, want the next link", link)
+	}
+
+	store := &fakeStore{}
+	NewWithStore(store).ActivitiesHandler().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, page.Next, nil))
+	if want := (ActivitiesParameters{Subject: "Jones", Limit: 2, Offset: 1}); store.params != want {
+		t.Fatalf("next page parameters = %+v, want %+v", store.params, want)
+	}
+}
+
+func TestActivitiesHandlerStreamsNDJSON(t *testing.T) {
+	response := httptest.NewRecorder()
+
+	NewWithStore(&fakeStore{activities: fakeActivities}).ActivitiesHandler().ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/pinkertons/activities?format=ndjson", nil))
+
+	if got := response.Header().Get("Content-Type"); got != httpx.NDJSONContentType {
+		t.Fatalf("Content-Type = %q, want %q", got, httpx.NDJSONContentType)
+	}
+	lines := strings.Split(strings.TrimSpace(response.Body.String()), "\n")
+	if len(lines) != len(fakeActivities) {
+		t.Fatalf("streamed %d lines, want %d", len(lines), len(fakeActivities))
+	}
+	if !strings.Contains(lines[0], `"locations":[{"id":10,`) {
+		t.Fatalf("first line = %s, want its locations", lines[0])
+	}
+}
+
+func TestActivityByIDHandlerReturnsNotFound(t *testing.T) {
+	request := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/pinkertons/activities/3", nil), map[string]string{"id": "3"})
+	response := httptest.NewRecorder()
+
+	NewWithStore(&fakeStore{activities: fakeActivities}).ActivityByIDHandler().ServeHTTP(response, request)
+
+	testsupport.AssertProblem(t, response, http.StatusNotFound, httpx.CodeNotFound, "")
+}
+
+func TestBuildActivitiesQueryNumbersArguments(t *testing.T) {
+	query, args := buildActivitiesQuery(ActivitiesParameters{
+		Subject:    "Jones",
+		LocationID: 4,
+		Limit:      10,
+	})
+
+	for _, want := range []string{"a.subject = $1", "location_id = $2", "LIMIT $3"} {
+		if !strings.Contains(query, want) {
+			t.Errorf("query does not contain %q:\n%s", want, query)
+		}
+	}
+	if strings.Contains(query, "OFFSET") {
+		t.Errorf("query has an OFFSET without one being requested:\n%s", query)
+	}
+	if want := []any{"Jones", 4, 10}; !reflect.DeepEqual(args, want) {
+		t.Errorf("args = %v, want %v", args, want)
+	}
+}
@@ -1,9 +1,15 @@
 package pinkertons
 
 import (
+	"database/sql"
+	"encoding/csv"
 	"encoding/json"
 	"math"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+
+	"github.com/chnm/apiary/internal/httpx"
 )
 
 func TestNullFloat64JSON(t *testing.T) {
@@ -84,3 +90,40 @@ func TestNullFloat64Scan(t *testing.T) {
 		}
 	}
 }
+
+func TestActivitiesCSVFlattensLocations(t *testing.T) {
+	activities := []Activity{{
+		ID:        7,
+		Operative: NullString{NullString: sql.NullString{String: "J. McParland", Valid: true}},
+		Locations: []Location{
+			{ID: 1, Latitude: NullFloat64{Float64: 41.88, Valid: true}},
+			{ID: 2},
+		},
+	}}
+	response := httptest.NewRecorder()
+
+	httpx.WriteCSV(response, httptest.NewRequest(http.MethodGet, "/pinkertons/activities?format=csv", nil), activities)
+
+	records, err := csv.NewReader(response.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("read CSV: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("records = %d, want header and one activity", len(records))
+	}
+	row := make(map[string]string)
+	for i, column := range records[0] {
+		row[column] = records[1][i]
+	}
+	for column, want := range map[string]string{
+		"id":                 "7",
+		"operative":          "J. McParland",
+		"source":             "",
+		"locations.id":       "1; 2",
+		"locations.latitude": "41.88; ",
+	} {
+		if got, ok := row[column]; !ok || got != want {
+			t.Errorf("%s = %q (present %v), want %q", column, got, ok, want)
+		}
+	}
+}
@@ -1,11 +1,32 @@
 package popplaces
 
-import "github.com/chnm/apiary/internal/httpx"
+import (
+	"github.com/chnm/apiary/internal/httpx"
+	"github.com/chnm/apiary/internal/httpx/pagination"
+)
 
 func Endpoints(baseURL string) []httpx.Endpoint {
 	return []httpx.Endpoint{
-		{Name: "Populated places: A list of counties in a state", URL: baseURL + "/pop-places/state/ma/county/"},
-		{Name: "Populated places: A list of places in a county", URL: baseURL + "/pop-places/county/cas_ventura/place/"},
-		{Name: "Populated places: Information about a populated place", URL: baseURL + "/pop-places/place/611119/"},
+		{
+			Name:       "Populated places: A list of counties in a state",
+			URL:        baseURL + "/pop-places/state/ma/county/",
+			Path:       "/pop-places/state/{state}/county/",
+			Parameters: countiesParams.Parameters(),
+			Response:   pagination.Page[PlaceCounty]{},
+		},
+		{
+			Name:       "Populated places: A list of places in a county",
+			URL:        baseURL + "/pop-places/county/cas_ventura/place/",
+			Path:       "/pop-places/county/{county}/place/",
+			Parameters: placesParams.Parameters(),
+			Response:   pagination.Page[Place]{},
+		},
+		{
+			Name:       "Populated places: Information about a populated place",
+			URL:        baseURL + "/pop-places/place/611119/",
+			Path:       "/pop-places/place/{place}/",
+			Parameters: placeParams.Parameters(),
+			Response:   PlaceDetails{},
+		},
 	}
 }
@@ -2,16 +2,57 @@
 package popplaces
 
 import (
+	"context"
+	"errors"
+
+	"github.com/chnm/apiary/db"
+	"github.com/chnm/apiary/internal/cachex"
+	"github.com/chnm/apiary/internal/datasets"
+	"github.com/chnm/apiary/internal/httpx"
 	"github.com/gorilla/mux"
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-type Handler struct{ db *pgxpool.Pool }
+// ErrNoPlace is returned by a Store that has no place with the given ID.
+var ErrNoPlace = errors.New("no such place")
+
+// Store reads the populated places. PostgresStore is the implementation the
+// server uses.
+type Store interface {
+	// CountiesInState returns the counties in a state, given by its upper-case
+	// postal code, ordered by name.
+	CountiesInState(ctx context.Context, state string) ([]PlaceCounty, error)
+	// PlacesInCounty returns the places in a county, given by its AHCB ID,
+	// ordered by name.
+	PlacesInCounty(ctx context.Context, countyAHCB string) ([]Place, error)
+	// Place returns the place with the given ID, or ErrNoPlace.
+	Place(ctx context.Context, placeID int) (PlaceDetails, error)
+}
+
+type Handler struct{ store Store }
+
+// New creates a handler that reads from db.
+func New(db db.Querier) *Handler { return NewWithStore(NewPostgresStore(db)) }
 
-func New(db *pgxpool.Pool) *Handler { return &Handler{db: db} }
+// NewWithStore creates a handler that reads from store.
+func NewWithStore(store Store) *Handler { return &Handler{store: store} }
 
 func (h *Handler) RegisterRoutes(router *mux.Router) {
-	router.HandleFunc("/pop-places/county/{county:[a-z_,]+}/place/", h.PlacesInCounty()).Methods("GET", "HEAD")
-	router.HandleFunc("/pop-places/place/{place}/", h.Place()).Methods("GET", "HEAD")
-	router.HandleFunc("/pop-places/state/{state:[a-z]{2}}/county/", h.CountiesInState()).Methods("GET", "HEAD")
+	cached := cachex.TTL(cachex.Stable)
+	router.Handle("/pop-places/county/{county:[a-z_,]+}/place/", cached(h.PlacesInCounty())).Methods("GET", "HEAD")
+	router.Handle("/pop-places/place/{place}/", cached(h.Place())).Methods("GET", "HEAD")
+	router.Handle("/pop-places/state/{state:[a-z]{2}}/county/", cached(h.CountiesInState())).Methods("GET", "HEAD")
 }
+
+// Name returns the identifier used to enable or disable this dataset.
+func (h *Handler) Name() string { return "popplaces" }
+
+// Metadata describes this dataset.
+func (h *Handler) Metadata() datasets.Metadata {
+	return datasets.Metadata{
+		Title:       "Populated places",
+		Description: "U.S. populated places circa 1926 with AHCB county identifiers.",
+	}
+}
+
+// Endpoints returns this dataset's entries for the root endpoint catalog.
+func (h *Handler) Endpoints(baseURL string) []httpx.Endpoint { return Endpoints(baseURL) }
@@ -4,13 +4,13 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
-	"strconv"
 	"strings"
 
-	"github.com/gorilla/mux"
-	"github.com/jackc/pgx/v5"
+	"github.com/chnm/apiary/internal/httpx"
+	"github.com/chnm/apiary/internal/httpx/pagination"
+	paramx "github.com/chnm/apiary/internal/params"
 )
 
 // This file creates a series of endpoints to return all possible names for
@@ -41,156 +41,101 @@ type PlaceDetails struct {
 	State      string  `json:"state"`
 }
 
+// The parameters of each handler.
+var (
+	countiesParams = paramx.NewSet(
+		paramx.String("state", "Two-letter state code, such as ma").InPath(),
+	).Accepting(pagination.Parameters(pagination.Lists)...)
+	placesParams = paramx.NewSet(
+		paramx.String("county", "AHCB county ID, such as cas_ventura").InPath(),
+	).Accepting(pagination.Parameters(pagination.Lists)...)
+	placeParams = paramx.NewSet(
+		paramx.Int("place", "Place ID").InPath(),
+	)
+)
+
 // CountiesInState returns a list of all the counties in a state, with
 // IDs from AHCB.
 func (h *Handler) CountiesInState() http.HandlerFunc {
-
-	query := `
-		SELECT DISTINCT county_ahcb, county
-		FROM relcensus.popplaces_1926
-		WHERE state = $1
-		ORDER BY county;
-		`
-
 	return func(w http.ResponseWriter, r *http.Request) {
-
-		state := mux.Vars(r)["state"]
-		state = strings.ToUpper(state)
-
-		results := make([]PlaceCounty, 0)
-
-		rows, err := h.db.Query(r.Context(), query, state)
+		values, err := countiesParams.Parse(r)
 		if err != nil {
-			log.Printf("query populated-place counties: %v", err)
-			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			httpx.BadRequest(w, r, err)
 			return
 		}
-		defer rows.Close()
-
-		for rows.Next() {
-			var row PlaceCounty
-			if err := rows.Scan(&row.CountyAHCB, &row.County); err != nil {
-				log.Printf("scan populated-place county: %v", err)
-				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
-				return
-			}
-			results = append(results, row)
-		}
-		if err := rows.Err(); err != nil {
-			log.Printf("iterate populated-place counties: %v", err)
-			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		state := strings.ToUpper(values.String("state"))
+
+		q, err := pagination.Parse(r, pagination.Lists)
+		if err != nil {
+			httpx.BadRequest(w, r, err)
 			return
 		}
 
-		response, err := json.Marshal(results)
+		results, err := h.store.CountiesInState(r.Context(), state)
 		if err != nil {
-			log.Printf("marshal populated-place counties: %v", err)
-			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			httpx.InternalServerError(w, r, "query populated-place counties", err)
 			return
 		}
 
-		w.Header().Set("Content-Type", "application/json")
-		if _, err := w.Write(response); err != nil {
-			log.Printf("write populated-place counties response: %v", err)
-		}
+		pagination.WriteJSON(w, r, pagination.Slice(q, results))
 	}
 }
 
 // PlacesInCounty returns a list of all the populated places in a county.
 func (h *Handler) PlacesInCounty() http.HandlerFunc {
-
-	query := `
-		SELECT place_id, place, lat, lon
-		FROM relcensus.popplaces_1926
-		WHERE county_ahcb = $1
-		ORDER BY place;
-		`
-
 	return func(w http.ResponseWriter, r *http.Request) {
-
-		county := mux.Vars(r)["county"]
-		county = strings.ToLower(county)
-
-		results := make([]Place, 0)
-
-		rows, err := h.db.Query(r.Context(), query, county)
+		values, err := placesParams.Parse(r)
 		if err != nil {
-			log.Printf("query populated places: %v", err)
-			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			httpx.BadRequest(w, r, err)
 			return
 		}
-		defer rows.Close()
-
-		for rows.Next() {
-			var row Place
-			if err := rows.Scan(&row.PlaceID, &row.Place, &row.Lat, &row.Lon); err != nil {
-				log.Printf("scan populated place: %v", err)
-				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
-				return
-			}
-			results = append(results, row)
-		}
-		if err := rows.Err(); err != nil {
-			log.Printf("iterate populated places: %v", err)
-			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		county := strings.ToLower(values.String("county"))
+
+		q, err := pagination.Parse(r, pagination.Lists)
+		if err != nil {
+			httpx.BadRequest(w, r, err)
 			return
 		}
 
-		response, err := json.Marshal(results)
+		results, err := h.store.PlacesInCounty(r.Context(), county)
 		if err != nil {
-			log.Printf("marshal populated places: %v", err)
-			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			httpx.InternalServerError(w, r, "query populated places", err)
 			return
 		}
 
-		w.Header().Set("Content-Type", "application/json")
-		if _, err := w.Write(response); err != nil {
-			log.Printf("write populated places response: %v", err)
-		}
+		pagination.WriteJSON(w, r, pagination.Slice(q, results))
 	}
 }
 
 // Place returns the details about a populated place.
 func (h *Handler) Place() http.HandlerFunc {
-
-	query := `
-		SELECT place_id, place, lat, lon, county, county_ahcb, state
-		FROM relcensus.popplaces_1926
-		WHERE place_id = $1
-		`
-
 	return func(w http.ResponseWriter, r *http.Request) {
-
-		placeID, err := strconv.Atoi(mux.Vars(r)["place"])
+		values, err := placeParams.Parse(r)
 		if err != nil {
-			http.Error(w, "Bad request: place ID must be an integer", http.StatusBadRequest)
+			httpx.BadRequest(w, r, err)
 			return
 		}
+		placeID := values.Int("place")
 
-		var result PlaceDetails
-
-		err = h.db.QueryRow(r.Context(), query, placeID).Scan(&result.PlaceID, &result.Place,
-			&result.Lat, &result.Lon, &result.County, &result.CountyAHCB, &result.State)
+		result, err := h.store.Place(r.Context(), placeID)
+		if errors.Is(err, ErrNoPlace) {
+			httpx.NotFound(w, r, fmt.Sprintf("no place with id %v", placeID))
+			return
+		}
 		if err != nil {
-			if errors.Is(err, pgx.ErrNoRows) {
-				http.Error(w, fmt.Sprintf("Not found: No place with id %v.", placeID), http.StatusNotFound)
-				return
-			}
-			log.Printf("query populated-place details: %v", err)
-			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			httpx.InternalServerError(w, r, "query populated-place details", err)
 			return
 		}
 
 		response, err := json.Marshal(result)
 		if err != nil {
-			log.Printf("marshal populated-place details: %v", err)
-			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			httpx.InternalServerError(w, r, "marshal populated-place details", err)
 			return
 		}
 
 		w.Header().Set("Content-Type", "application/json")
 		if _, err := w.Write(response); err != nil {
-			log.Printf("write populated-place details response: %v", err)
+			slog.WarnContext(r.Context(), "write populated-place details response", "error", err)
 		}
 	}
 }
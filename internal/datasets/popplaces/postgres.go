@@ -0,0 +1,89 @@
+package popplaces
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/chnm/apiary/db"
+	"github.com/jackc/pgx/v5"
+)
+
+const countiesInStateQuery = `
+		SELECT DISTINCT county_ahcb, county
+		FROM relcensus.popplaces_1926
+		WHERE state = $1
+		ORDER BY county;
+		`
+
+const placesInCountyQuery = `
+		SELECT place_id, place, lat, lon
+		FROM relcensus.popplaces_1926
+		WHERE county_ahcb = $1
+		ORDER BY place;
+		`
+
+const placeQuery = `
+		SELECT place_id, place, lat, lon, county, county_ahcb, state
+		FROM relcensus.popplaces_1926
+		WHERE place_id = $1
+		`
+
+// PostgresStore reads the populated places from PostgreSQL.
+type PostgresStore struct {
+	db db.Querier
+}
+
+// NewPostgresStore returns a store that reads from db.
+func NewPostgresStore(db db.Querier) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// CountiesInState implements Store.
+func (s *PostgresStore) CountiesInState(ctx context.Context, state string) ([]PlaceCounty, error) {
+	rows, err := s.db.Query(ctx, countiesInStateQuery, state)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := make([]PlaceCounty, 0)
+	for rows.Next() {
+		var row PlaceCounty
+		if err := rows.Scan(&row.CountyAHCB, &row.County); err != nil {
+			return nil, fmt.Errorf("scan populated-place county: %w", err)
+		}
+		results = append(results, row)
+	}
+	return results, rows.Err()
+}
+
+// PlacesInCounty implements Store.
+func (s *PostgresStore) PlacesInCounty(ctx context.Context, countyAHCB string) ([]Place, error) {
+	rows, err := s.db.Query(ctx, placesInCountyQuery, countyAHCB)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := make([]Place, 0)
+	for rows.Next() {
+		var row Place
+		if err := rows.Scan(&row.PlaceID, &row.Place, &row.Lat, &row.Lon); err != nil {
+			return nil, fmt.Errorf("scan populated place: %w", err)
+		}
+		results = append(results, row)
+	}
+	return results, rows.Err()
+}
+
+// Place implements Store.
+func (s *PostgresStore) Place(ctx context.Context, placeID int) (PlaceDetails, error) {
+	var result PlaceDetails
+	err := s.db.QueryRow(ctx, placeQuery, placeID).Scan(&result.PlaceID, &result.Place,
+		&result.Lat, &result.Lon, &result.County, &result.CountyAHCB, &result.State)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return PlaceDetails{}, ErrNoPlace
+	}
+	return result, err
+}
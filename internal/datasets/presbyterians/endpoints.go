@@ -1,7 +1,22 @@
 package presbyterians
 
-import "github.com/chnm/apiary/internal/httpx"
+import (
+	"github.com/chnm/apiary/internal/httpx"
+	"github.com/chnm/apiary/internal/httpx/pagination"
+)
 
 func Endpoints(baseURL string) []httpx.Endpoint {
-	return []httpx.Endpoint{{Name: "Presbyterian statistics, 1826-1926", URL: baseURL + "/presbyterians/"}}
+	return []httpx.Endpoint{
+		{
+			Name:       "Presbyterian statistics, 1826-1926",
+			URL:        baseURL + "/presbyterians/",
+			Path:       "/presbyterians/",
+			Parameters: statisticsParams.Parameters(),
+			Response:   pagination.Page[PresbyteriansByYear]{},
+			CSV:        true,
+			Examples: []httpx.ExampleURL{
+				{URL: baseURL + "/presbyterians/?format=csv", Purpose: "Presbyterian statistics as CSV for a spreadsheet"},
+			},
+		},
+	}
 }
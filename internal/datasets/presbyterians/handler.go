@@ -2,14 +2,45 @@
 package presbyterians
 
 import (
+	"context"
+
+	"github.com/chnm/apiary/db"
+	"github.com/chnm/apiary/internal/cachex"
+	"github.com/chnm/apiary/internal/datasets"
+	"github.com/chnm/apiary/internal/httpx"
 	"github.com/gorilla/mux"
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-type Handler struct{ db *pgxpool.Pool }
+// Store reads the Presbyterian statistics. PostgresStore is the
+// implementation the server uses.
+type Store interface {
+	// Statistics returns the members and churches in each year, oldest first.
+	Statistics(ctx context.Context) ([]PresbyteriansByYear, error)
+}
+
+type Handler struct{ store Store }
+
+// New creates a handler that reads from db.
+func New(db db.Querier) *Handler { return NewWithStore(NewPostgresStore(db)) }
 
-func New(db *pgxpool.Pool) *Handler { return &Handler{db: db} }
+// NewWithStore creates a handler that reads from store.
+func NewWithStore(store Store) *Handler { return &Handler{store: store} }
 
 func (h *Handler) RegisterRoutes(router *mux.Router) {
-	router.HandleFunc("/presbyterians/", h.PresbyteriansHandler()).Methods("GET", "HEAD")
+	cached := cachex.TTL(cachex.Stable)
+	router.Handle("/presbyterians/", cached(h.PresbyteriansHandler())).Methods("GET", "HEAD")
 }
+
+// Name returns the identifier used to enable or disable this dataset.
+func (h *Handler) Name() string { return "presbyterians" }
+
+// Metadata describes this dataset.
+func (h *Handler) Metadata() datasets.Metadata {
+	return datasets.Metadata{
+		Title:       "Presbyterian statistics",
+		Description: "Presbyterian membership and churches, 1826-1926.",
+	}
+}
+
+// Endpoints returns this dataset's entries for the root endpoint catalog.
+func (h *Handler) Endpoints(baseURL string) []httpx.Endpoint { return Endpoints(baseURL) }
@@ -0,0 +1,48 @@
+package presbyterians
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/chnm/apiary/db"
+)
+
+const statisticsQuery = `
+	SELECT 
+		year, 
+		SUM(members) as members, 
+		SUM(churches) as churches
+	FROM presbyterians_weber 
+	WHERE members IS NOT NULL 
+	GROUP BY year 
+	ORDER BY year;
+	`
+
+// PostgresStore reads the Presbyterian statistics from PostgreSQL.
+type PostgresStore struct {
+	db db.Querier
+}
+
+// NewPostgresStore returns a store that reads from db.
+func NewPostgresStore(db db.Querier) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// Statistics implements Store.
+func (s *PostgresStore) Statistics(ctx context.Context) ([]PresbyteriansByYear, error) {
+	rows, err := s.db.Query(ctx, statisticsQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := make([]PresbyteriansByYear, 0)
+	for rows.Next() {
+		var row PresbyteriansByYear
+		if err := rows.Scan(&row.Year, &row.Members, &row.Churches); err != nil {
+			return nil, fmt.Errorf("scan Presbyterian statistics: %w", err)
+		}
+		results = append(results, row)
+	}
+	return results, rows.Err()
+}
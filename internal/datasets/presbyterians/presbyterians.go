@@ -1,9 +1,11 @@
 package presbyterians
 
 import (
-	"encoding/json"
-	"log"
 	"net/http"
+
+	"github.com/chnm/apiary/internal/httpx"
+	"github.com/chnm/apiary/internal/httpx/pagination"
+	paramx "github.com/chnm/apiary/internal/params"
 )
 
 // PresbyteriansByYear holds aggregate data on Presbyterian membership and churches.
@@ -13,56 +15,26 @@ type PresbyteriansByYear struct {
 	Churches int `json:"churches"`
 }
 
+// statisticsParams declares the parameters of PresbyteriansHandler.
+var statisticsParams = paramx.NewSet().Accepting(httpx.FormatParameter).Accepting(pagination.Parameters(pagination.Lists)...)
+
 // PresbyteriansHandler returns the aggregate data on Presbyterian memberhsip and churches.
 func (h *Handler) PresbyteriansHandler() http.HandlerFunc {
-
-	query := `
-	SELECT 
-		year, 
-		SUM(members) as members, 
-		SUM(churches) as churches
-	FROM presbyterians_weber 
-	WHERE members IS NOT NULL 
-	GROUP BY year 
-	ORDER BY year;
-	`
-
 	return func(w http.ResponseWriter, r *http.Request) {
-		results := make([]PresbyteriansByYear, 0)
-
-		rows, err := h.db.Query(r.Context(), query)
-		if err != nil {
-			log.Printf("query Presbyterian statistics: %v", err)
-			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		if _, err := statisticsParams.Parse(r); err != nil {
+			httpx.BadRequest(w, r, err)
 			return
 		}
-		defer rows.Close()
-
-		for rows.Next() {
-			var row PresbyteriansByYear
-			if err := rows.Scan(&row.Year, &row.Members, &row.Churches); err != nil {
-				log.Printf("scan Presbyterian statistics: %v", err)
-				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
-				return
-			}
-			results = append(results, row)
-		}
-		if err := rows.Err(); err != nil {
-			log.Printf("iterate Presbyterian statistics: %v", err)
-			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		q, err := pagination.Parse(r, pagination.Lists)
+		if err != nil {
+			httpx.BadRequest(w, r, err)
 			return
 		}
-
-		response, err := json.Marshal(results)
+		results, err := h.store.Statistics(r.Context())
 		if err != nil {
-			log.Printf("marshal Presbyterian statistics: %v", err)
-			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			httpx.InternalServerError(w, r, "query Presbyterian statistics", err)
 			return
 		}
-
-		w.Header().Set("Content-Type", "application/json")
-		if _, err := w.Write(response); err != nil {
-			log.Printf("write Presbyterian statistics response: %v", err)
-		}
+		pagination.WriteTable(w, r, pagination.Slice(q, results))
 	}
 }
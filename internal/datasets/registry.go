@@ -0,0 +1,124 @@
+// Package datasets defines the contract that dataset packages implement and
+// the registry the server walks to mount their routes and catalogs.
+package datasets
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/chnm/apiary/internal/httpx"
+	"github.com/gorilla/mux"
+)
+
+// Metadata describes a dataset for operators and API consumers.
+type Metadata struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+}
+
+// Dataset is implemented by each package under internal/datasets. The name is
+// the stable identifier used in configuration to enable or disable a dataset.
+type Dataset interface {
+	Name() string
+	Metadata() Metadata
+	RegisterRoutes(*mux.Router)
+	Endpoints(baseURL string) []httpx.Endpoint
+}
+
+// Registry is an ordered set of datasets with unique names. Routes and
+// catalog entries are produced in registration order.
+type Registry struct {
+	datasets []Dataset
+}
+
+// NewRegistry returns a registry containing datasets. It panics if two
+// datasets share a name, since that is a programming error.
+func NewRegistry(datasets ...Dataset) *Registry {
+	r := &Registry{}
+	for _, d := range datasets {
+		r.Register(d)
+	}
+	return r
+}
+
+// Register adds a dataset to the registry. It panics if a dataset with the
+// same name is already registered.
+func (r *Registry) Register(d Dataset) {
+	if r.Lookup(d.Name()) != nil {
+		panic(fmt.Sprintf("datasets: dataset %q registered twice", d.Name()))
+	}
+	r.datasets = append(r.datasets, d)
+}
+
+// Lookup returns the dataset with the given name, or nil.
+func (r *Registry) Lookup(name string) Dataset {
+	for _, d := range r.datasets {
+		if d.Name() == name {
+			return d
+		}
+	}
+	return nil
+}
+
+// Datasets returns the registered datasets in registration order.
+func (r *Registry) Datasets() []Dataset {
+	return slices.Clone(r.datasets)
+}
+
+// Names returns the names of the registered datasets in registration order.
+func (r *Registry) Names() []string {
+	names := make([]string, len(r.datasets))
+	for i, d := range r.datasets {
+		names[i] = d.Name()
+	}
+	return names
+}
+
+// Select returns a registry restricted by configuration. An empty enabled
+// list means every dataset is enabled; names in disabled are then removed.
+// Unknown names are reported as an error so that typos in configuration do
+// not silently serve the wrong set of datasets.
+func (r *Registry) Select(enabled, disabled []string) (*Registry, error) {
+	var unknown []string
+	for _, name := range slices.Concat(enabled, disabled) {
+		if r.Lookup(name) == nil {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf(
+			"unknown dataset(s) %s; available datasets are %s",
+			strings.Join(unknown, ", "),
+			strings.Join(r.Names(), ", "),
+		)
+	}
+
+	selected := &Registry{}
+	for _, d := range r.datasets {
+		if len(enabled) > 0 && !slices.Contains(enabled, d.Name()) {
+			continue
+		}
+		if slices.Contains(disabled, d.Name()) {
+			continue
+		}
+		selected.datasets = append(selected.datasets, d)
+	}
+	return selected, nil
+}
+
+// RegisterRoutes registers the routes of every dataset on router.
+func (r *Registry) RegisterRoutes(router *mux.Router) {
+	for _, d := range r.datasets {
+		d.RegisterRoutes(router)
+	}
+}
+
+// Endpoints returns the catalog entries of every dataset.
+func (r *Registry) Endpoints(baseURL string) []httpx.Endpoint {
+	var endpoints []httpx.Endpoint
+	for _, d := range r.datasets {
+		endpoints = append(endpoints, d.Endpoints(baseURL)...)
+	}
+	return endpoints
+}
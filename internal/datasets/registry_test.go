@@ -0,0 +1,97 @@
+package datasets_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/chnm/apiary/internal/datasets"
+	"github.com/chnm/apiary/internal/httpx"
+	"github.com/gorilla/mux"
+)
+
+type fakeDataset struct {
+	name string
+}
+
+func (d fakeDataset) Name() string                      { return d.name }
+func (d fakeDataset) Metadata() datasets.Metadata       { return datasets.Metadata{Title: d.name} }
+func (d fakeDataset) RegisterRoutes(router *mux.Router) { router.Handle("/"+d.name, nil) }
+func (d fakeDataset) Endpoints(baseURL string) []httpx.Endpoint {
+	return []httpx.Endpoint{{Name: d.name, URL: baseURL + "/" + d.name}}
+}
+
+func newFakeRegistry() *datasets.Registry {
+	return datasets.NewRegistry(fakeDataset{"a"}, fakeDataset{"b"}, fakeDataset{"c"})
+}
+
+func TestRegistrySelect(t *testing.T) {
+	tests := []struct {
+		name     string
+		enabled  []string
+		disabled []string
+		want     []string
+	}{
+		{name: "all by default", want: []string{"a", "b", "c"}},
+		{name: "enabled subset", enabled: []string{"c", "a"}, want: []string{"a", "c"}},
+		{name: "disabled", disabled: []string{"b"}, want: []string{"a", "c"}},
+		{name: "disabled wins", enabled: []string{"a", "b"}, disabled: []string{"b"}, want: []string{"a"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selected, err := newFakeRegistry().Select(tt.enabled, tt.disabled)
+			if err != nil {
+				t.Fatalf("Select() error = %v", err)
+			}
+			if got := selected.Names(); !slices.Equal(got, tt.want) {
+				t.Fatalf("Names() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegistrySelectRejectsUnknownDatasets(t *testing.T) {
+	_, err := newFakeRegistry().Select([]string{"a", "typo"}, []string{"other"})
+	if err == nil {
+		t.Fatal("Select() accepted unknown dataset names")
+	}
+	for _, want := range []string{"typo", "other", "a, b, c"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+}
+
+func TestRegistryRejectsDuplicateNames(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("registering a duplicate dataset did not panic")
+		}
+	}()
+	datasets.NewRegistry(fakeDataset{"a"}, fakeDataset{"a"})
+}
+
+func TestRegistryRoutesAndEndpoints(t *testing.T) {
+	registry, err := newFakeRegistry().Select(nil, []string{"b"})
+	if err != nil {
+		t.Fatalf("Select() error = %v", err)
+	}
+
+	router := mux.NewRouter()
+	registry.RegisterRoutes(router)
+	var templates []string
+	_ = router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		template, _ := route.GetPathTemplate()
+		templates = append(templates, template)
+		return nil
+	})
+	if want := []string{"/a", "/c"}; !slices.Equal(templates, want) {
+		t.Fatalf("routes = %v, want %v", templates, want)
+	}
+
+	endpoints := registry.Endpoints("https://data.example")
+	if len(endpoints) != 2 || endpoints[1].URL != "https://data.example/c" {
+		t.Fatalf("Endpoints() = %+v", endpoints)
+	}
+}
@@ -1,9 +1,11 @@
 package relcensus
 
 import (
-	"encoding/json"
-	"log"
 	"net/http"
+
+	"github.com/chnm/apiary/internal/httpx"
+	"github.com/chnm/apiary/internal/httpx/pagination"
+	paramx "github.com/chnm/apiary/internal/params"
 )
 
 // DenominationFamily describes a group of denominations. There can be different
@@ -21,115 +23,58 @@ type Denomination struct {
 	FamilyRelec    string     `json:"family_relec"`
 }
 
-// RelCensusDenominationFamiliesHandler returns
+// RelCensusDenominationFamiliesHandler returns a page of the denomination
+// families.
 func (h *Handler) RelCensusDenominationFamiliesHandler() http.HandlerFunc {
-
-	query := `
-	SELECT DISTINCT family_relec 
-	FROM relcensus.denominations 
-	WHERE year = 1926
-	ORDER BY family_relec;
-	`
-
 	return func(w http.ResponseWriter, r *http.Request) {
-		results := make([]DenominationFamily, 0)
-
-		rows, err := h.db.Query(r.Context(), query)
-		if err != nil {
-			log.Printf("query Religious Census denomination families: %v", err)
-			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		if _, err := listParams.Parse(r); err != nil {
+			httpx.BadRequest(w, r, err)
 			return
 		}
-		defer rows.Close()
-
-		for rows.Next() {
-			var row DenominationFamily
-			if err := rows.Scan(&row.Name); err != nil {
-				log.Printf("scan Religious Census denomination family: %v", err)
-				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
-				return
-			}
-			results = append(results, row)
-		}
-		if err := rows.Err(); err != nil {
-			log.Printf("iterate Religious Census denomination families: %v", err)
-			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		q, err := pagination.Parse(r, pagination.Lists)
+		if err != nil {
+			httpx.BadRequest(w, r, err)
 			return
 		}
 
-		container := struct {
-			FamilyRelec []DenominationFamily `json:"family_relec"`
-		}{
-			FamilyRelec: results,
-		}
-
-		response, err := json.Marshal(container)
+		results, err := h.store.DenominationFamilies(r.Context())
 		if err != nil {
-			log.Printf("marshal Religious Census denomination families: %v", err)
-			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			httpx.InternalServerError(w, r, "query Religious Census denomination families", err)
 			return
 		}
 
-		w.Header().Set("Content-Type", "application/json")
-		if _, err := w.Write(response); err != nil {
-			log.Printf("write Religious Census denomination families response: %v", err)
-		}
+		pagination.WriteJSON(w, r, pagination.Slice(q, results))
 	}
 }
 
+// denominationsParams declares the parameters of
+// RelCensusDenominationsHandler.
+var denominationsParams = paramx.NewSet(
+	paramx.String("family_relec", "Only denominations in this family, as listed by /relcensus/denomination-families"),
+).Accepting(pagination.Parameters(pagination.Lists)...)
+
 // RelCensusDenominationsHandler returns the denominations that are available.
 // Optionally, it can be filtered to get just the denominations in a particular family.
 func (h *Handler) RelCensusDenominationsHandler() http.HandlerFunc {
-	query := `
-	SELECT denomination_id, name, short_name, family_census, family_relec
-	FROM relcensus.denominations
-	WHERE ($1::text = '' OR family_relec = $1::text) AND year = 1926;
-	`
-
 	return func(w http.ResponseWriter, r *http.Request) {
-		familyRelec := r.URL.Query().Get("family_relec")
-		results := make([]Denomination, 0)
-
-		rows, err := h.db.Query(r.Context(), query, familyRelec)
+		values, err := denominationsParams.Parse(r)
 		if err != nil {
-			log.Printf("query Religious Census denominations: %v", err)
-			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			httpx.BadRequest(w, r, err)
 			return
 		}
-		defer rows.Close()
-
-		for rows.Next() {
-			var row Denomination
-			if err := rows.Scan(
-				&row.DenominationID,
-				&row.Name,
-				&row.ShortName,
-				&row.FamilyCensus,
-				&row.FamilyRelec,
-			); err != nil {
-				log.Printf("scan Religious Census denomination: %v", err)
-				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
-				return
-			}
-			results = append(results, row)
-		}
-		if err := rows.Err(); err != nil {
-			log.Printf("iterate Religious Census denominations: %v", err)
-			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		familyRelec := values.String("family_relec")
+		q, err := pagination.Parse(r, pagination.Lists)
+		if err != nil {
+			httpx.BadRequest(w, r, err)
 			return
 		}
 
-		response, err := json.Marshal(results)
+		results, err := h.store.Denominations(r.Context(), familyRelec)
 		if err != nil {
-			log.Printf("marshal Religious Census denominations: %v", err)
-			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			httpx.InternalServerError(w, r, "query Religious Census denominations", err)
 			return
 		}
 
-		w.Header().Set("Content-Type", "application/json")
-		if _, err := w.Write(response); err != nil {
-			log.Printf("write Religious Census denominations response: %v", err)
-		}
+		pagination.WriteJSON(w, r, pagination.Slice(q, results))
 	}
-
 }
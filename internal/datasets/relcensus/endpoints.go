@@ -1,19 +1,38 @@
 package relcensus
 
-import "github.com/chnm/apiary/internal/httpx"
+import (
+	"github.com/chnm/apiary/internal/httpx"
+	"github.com/chnm/apiary/internal/httpx/pagination"
+	paramx "github.com/chnm/apiary/internal/params"
+)
+
+// listParams declares the parameters of the handlers that list denomination
+// families and cities.
+var listParams = paramx.NewSet().Accepting(pagination.Parameters(pagination.Lists)...)
 
 func Endpoints(baseURL string) []httpx.Endpoint {
 	return []httpx.Endpoint{
-		{Name: "Religious Bodies Census denomination families", URL: baseURL + "/relcensus/denomination-families"},
-		{Name: "Religious Bodies Census denominations", URL: baseURL + "/relcensus/denominations"},
-		{Name: "Religious Bodies list of all cities", URL: baseURL + "/relcensus/cities"},
+		{Name: "Religious Bodies Census denomination families", URL: baseURL + "/relcensus/denomination-families", Path: "/relcensus/denomination-families", Parameters: listParams.Parameters(), Response: pagination.Page[DenominationFamily]{}},
+		{
+			Name:       "Religious Bodies Census denominations",
+			URL:        baseURL + "/relcensus/denominations",
+			Path:       "/relcensus/denominations",
+			Parameters: denominationsParams.Parameters(),
+			Response:   pagination.Page[Denomination]{},
+		},
+		{Name: "Religious Bodies list of all cities", URL: baseURL + "/relcensus/cities", Path: "/relcensus/cities", Parameters: listParams.Parameters(), Response: pagination.Page[LocationInfo]{}},
 		{
-			Name: "Religious Bodies Census membership data for a denomination in a city in a year",
-			URL:  baseURL + "/relcensus/city-membership?year=1926&denomination=Protestant+Episcopal+Church",
+			Name:       "Religious Bodies Census membership data for a denomination in a city in a year",
+			URL:        baseURL + "/relcensus/city-membership?year=1926&denomination=Protestant+Episcopal+Church",
+			Path:       "/relcensus/city-membership",
+			Parameters: cityMembershipParams.Parameters(),
+			Response:   pagination.Page[CityMembership]{},
+			CSV:        true,
 			Examples: []httpx.ExampleURL{
 				{URL: baseURL + "/relcensus/city-membership?year=1926&denomination=Church+of+God+in+Christ", Purpose: "Membership data for a specific denomination in each city"},
 				{URL: baseURL + "/relcensus/city-membership?year=1926&denominationFamily=Pentecostal", Purpose: "Membership data aggregated for a denomination family in each city"},
 				{URL: baseURL + "/relcensus/city-membership?year=1926", Purpose: "Membership data aggregated for all denominations in each city"},
+				{URL: baseURL + "/relcensus/city-membership?year=1926&format=csv", Purpose: "Membership data as CSV for a spreadsheet"},
 			},
 		},
 	}
@@ -2,21 +2,60 @@
 package relcensus
 
 import (
+	"context"
+
+	"github.com/chnm/apiary/db"
+	"github.com/chnm/apiary/internal/cachex"
+	"github.com/chnm/apiary/internal/datasets"
 	"github.com/chnm/apiary/internal/httpx"
 	"github.com/gorilla/mux"
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-type Handler struct{ db *pgxpool.Pool }
+// Store reads the Census of Religious Bodies. PostgresStore is the
+// implementation the server uses.
+type Store interface {
+	// CityMembership returns the membership in each city in year, ordered by
+	// state and city. At most one of denomination and denominationFamily is
+	// set; if neither is, every denomination is counted together.
+	CityMembership(ctx context.Context, year int, denomination, denominationFamily string) ([]CityMembership, error)
+	// Locations returns the populated places of 1926.
+	Locations(ctx context.Context) ([]LocationInfo, error)
+	// DenominationFamilies returns the denomination families of 1926.
+	DenominationFamilies(ctx context.Context) ([]DenominationFamily, error)
+	// Denominations returns the denominations of 1926, only those in
+	// familyRelec unless it is empty.
+	Denominations(ctx context.Context, familyRelec string) ([]Denomination, error)
+}
+
+type Handler struct{ store Store }
+
+// New creates a handler that reads from db.
+func New(db db.Querier) *Handler { return NewWithStore(NewPostgresStore(db)) }
 
-func New(db *pgxpool.Pool) *Handler { return &Handler{db: db} }
+// NewWithStore creates a handler that reads from store.
+func NewWithStore(store Store) *Handler { return &Handler{store: store} }
 
 func (h *Handler) RegisterRoutes(router *mux.Router) {
-	router.HandleFunc("/relcensus/denomination-families", h.RelCensusDenominationFamiliesHandler()).Methods("GET", "HEAD")
-	router.HandleFunc("/relcensus/denominations", h.RelCensusDenominationsHandler()).Methods("GET", "HEAD")
-	router.HandleFunc("/relcensus/city-membership", h.RelCensusCityMembershipHandler()).Methods("GET", "HEAD")
-	router.HandleFunc("/relcensus/cities", h.RelCensusLocationsHandler()).Methods("GET", "HEAD")
+	cached := cachex.TTL(cachex.Stable)
+	router.Handle("/relcensus/denomination-families", cached(h.RelCensusDenominationFamiliesHandler())).Methods("GET", "HEAD")
+	router.Handle("/relcensus/denominations", cached(h.RelCensusDenominationsHandler())).Methods("GET", "HEAD")
+	router.Handle("/relcensus/city-membership", cached(h.RelCensusCityMembershipHandler())).Methods("GET", "HEAD")
+	router.Handle("/relcensus/cities", cached(h.RelCensusLocationsHandler())).Methods("GET", "HEAD")
 }
 
+// Name returns the identifier used to enable or disable this dataset.
+func (h *Handler) Name() string { return "relcensus" }
+
+// Metadata describes this dataset.
+func (h *Handler) Metadata() datasets.Metadata {
+	return datasets.Metadata{
+		Title:       "Census of Religious Bodies",
+		Description: "Denominations and city membership from the U.S. Census of Religious Bodies.",
+	}
+}
+
+// Endpoints returns this dataset's entries for the root endpoint catalog.
+func (h *Handler) Endpoints(baseURL string) []httpx.Endpoint { return Endpoints(baseURL) }
+
 type NullInt64 = httpx.NullInt64
 type NullString = httpx.NullString
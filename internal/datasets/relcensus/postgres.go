@@ -0,0 +1,215 @@
+package relcensus
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/chnm/apiary/db"
+	"github.com/jackc/pgx/v5"
+)
+
+const cityMembershipByDenominationQuery = `
+		SELECT m.year, m.denomination, 
+		c.city, c.state,
+		1::integer AS denominations,
+		m.churches, m.members_total,
+		p.pop_est_1926,
+		ST_X(c.geometry) AS lon, ST_Y(c.geometry) AS lat
+		FROM relcensus.membership_city m
+		LEFT JOIN relcensus.cities_25K c ON m.city = c.city AND m.state = c.state
+		LEFT JOIN relcensus.popplaces_1926 p ON c.place_id = p.place_id
+		WHERE year = $1 AND denomination = $2
+		ORDER BY state, city;
+	`
+
+const cityMembershipByFamilyQuery = `
+	SELECT 
+	d.year,
+	d.family_relec,
+	c.city, c.state, 
+	d.denominations, 
+	d.churches, 
+	d.members_total, 
+	p.pop_est_1926,
+	ST_X(c.geometry) AS lon, ST_Y(c.geometry) AS lat
+	FROM
+	(
+	SELECT 
+	m.year, 
+	d.family_relec, 
+	m.city, m.state,
+	count(m.denomination) AS denominations, 
+	sum(m.churches) AS churches, 
+	sum(m.members_total) AS members_total
+	FROM relcensus.membership_city m
+	LEFT JOIN relcensus.denominations d ON m.denomination = d.name
+	WHERE m.year = $1 AND d.family_relec = $2 AND m.churches IS NOT NULL
+	GROUP BY m.year, d.family_relec, m.city, m.state
+	) d
+	LEFT JOIN relcensus.cities_25k c ON d.city = c.city AND d.state = c.state
+	LEFT JOIN relcensus.popplaces_1926 p ON c.place_id = p.place_id
+	ORDER BY c.state, c.city;
+	`
+
+const cityMembershipQuery = `
+	SELECT 
+	d.year,
+	'All denominations' AS group,
+	c.city, c.state, 
+	d.denominations, 
+	d.churches, 
+	d.members_total, 
+	p.pop_est_1926,
+	ST_X(c.geometry) AS lon, ST_Y(c.geometry) AS lat
+	FROM
+	(
+	SELECT 
+		m.year, 
+		m.city, m.state,
+		count(m.denomination) AS denominations, 
+		sum(m.churches) AS churches, 
+		sum(m.members_total) AS members_total
+	FROM relcensus.membership_city m
+	WHERE m.year = $1 
+	GROUP BY m.year, m.city, m.state
+	) d
+	LEFT JOIN relcensus.cities_25k c ON d.city = c.city AND d.state = c.state
+	LEFT JOIN relcensus.popplaces_1926 p ON c.place_id = p.place_id
+	ORDER BY c.state, c.city;
+	`
+
+const locationsQuery = `
+		SELECT DISTINCT place_id, place, county, state, county_ahcb, map_name, lat, lon
+		FROM relcensus.popplaces_1926
+		ORDER BY state, county, place;
+	`
+
+const denominationFamiliesQuery = `
+	SELECT DISTINCT family_relec 
+	FROM relcensus.denominations 
+	WHERE year = 1926
+	ORDER BY family_relec;
+	`
+
+const denominationsQuery = `
+	SELECT denomination_id, name, short_name, family_census, family_relec
+	FROM relcensus.denominations
+	WHERE ($1::text = '' OR family_relec = $1::text) AND year = 1926;
+	`
+
+// PostgresStore reads the Census of Religious Bodies from PostgreSQL.
+type PostgresStore struct {
+	db db.Querier
+}
+
+// NewPostgresStore returns a store that reads from db.
+func NewPostgresStore(db db.Querier) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// CityMembership implements Store.
+func (s *PostgresStore) CityMembership(ctx context.Context, year int, denomination, denominationFamily string) ([]CityMembership, error) {
+	var rows pgx.Rows
+	var err error
+	switch {
+	case denomination != "":
+		rows, err = s.db.Query(ctx, cityMembershipByDenominationQuery, year, denomination)
+	case denominationFamily != "":
+		rows, err = s.db.Query(ctx, cityMembershipByFamilyQuery, year, denominationFamily)
+	default:
+		rows, err = s.db.Query(ctx, cityMembershipQuery, year)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := make([]CityMembership, 0)
+	for rows.Next() {
+		var row CityMembership
+		if err := rows.Scan(
+			&row.Year,
+			&row.Group,
+			&row.City, &row.State,
+			&row.Denominations, &row.Churches, &row.Members,
+			&row.Population1926,
+			&row.Lon, &row.Lat,
+		); err != nil {
+			return nil, fmt.Errorf("scan Religious Census city membership: %w", err)
+		}
+		results = append(results, row)
+	}
+	return results, rows.Err()
+}
+
+// Locations implements Store.
+func (s *PostgresStore) Locations(ctx context.Context) ([]LocationInfo, error) {
+	rows, err := s.db.Query(ctx, locationsQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := make([]LocationInfo, 0)
+	for rows.Next() {
+		var row LocationInfo
+		if err := rows.Scan(
+			&row.PlaceID,
+			&row.City,
+			&row.County,
+			&row.State,
+			&row.CountyAHCB,
+			&row.MapName,
+			&row.Lat,
+			&row.Lon,
+		); err != nil {
+			return nil, fmt.Errorf("scan Religious Census location: %w", err)
+		}
+		results = append(results, row)
+	}
+	return results, rows.Err()
+}
+
+// DenominationFamilies implements Store.
+func (s *PostgresStore) DenominationFamilies(ctx context.Context) ([]DenominationFamily, error) {
+	rows, err := s.db.Query(ctx, denominationFamiliesQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := make([]DenominationFamily, 0)
+	for rows.Next() {
+		var row DenominationFamily
+		if err := rows.Scan(&row.Name); err != nil {
+			return nil, fmt.Errorf("scan Religious Census denomination family: %w", err)
+		}
+		results = append(results, row)
+	}
+	return results, rows.Err()
+}
+
+// Denominations implements Store.
+func (s *PostgresStore) Denominations(ctx context.Context, familyRelec string) ([]Denomination, error) {
+	rows, err := s.db.Query(ctx, denominationsQuery, familyRelec)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := make([]Denomination, 0)
+	for rows.Next() {
+		var row Denomination
+		if err := rows.Scan(
+			&row.DenominationID,
+			&row.Name,
+			&row.ShortName,
+			&row.FamilyCensus,
+			&row.FamilyRelec,
+		); err != nil {
+			return nil, fmt.Errorf("scan Religious Census denomination: %w", err)
+		}
+		results = append(results, row)
+	}
+	return results, rows.Err()
+}
@@ -0,0 +1,220 @@
+// Package deadline gives each route a time limit. The request context, and so
+// every query made with it, is canceled when the limit passes, and the
+// database is told to stop queries that run longer than it. NDJSON streams
+// from routes that declare them are exempt, since an export is expected to
+// outlast any limit on the whole response; the stream bounds each flush
+// instead.
+package deadline
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/chnm/apiary/db"
+	"github.com/chnm/apiary/internal/httpx"
+	"github.com/gorilla/mux"
+)
+
+// Class names a group of routes that share a time limit.
+type Class string
+
+// Route classes. Routes that declare none are in Default.
+const (
+	Default Class = "default"
+	// Bulk routes return long lists of records.
+	Bulk Class = "bulk"
+	// Spatial routes build GeoJSON from PostGIS geometries, which can take
+	// far longer than reading records.
+	Spatial Class = "spatial"
+)
+
+// writeMargin is the time allowed after a route's deadline to write the
+// error response or the end of a stream.
+const writeMargin = 5 * time.Second
+
+// Options configure a Policy.
+type Options struct {
+	Classes      map[Class]time.Duration
+	Routes       map[string]time.Duration // By route template; overrides the class
+	WriteTimeout time.Duration            // The server's; extended for routes that need longer
+}
+
+// Policy holds the time limit of every route.
+type Policy struct {
+	classes      map[Class]time.Duration
+	routes       map[string]time.Duration
+	writeTimeout time.Duration
+}
+
+// New returns a Policy. There must be a positive limit for Default and for
+// every class that routes declare.
+func New(options Options) (*Policy, error) {
+	if _, ok := options.Classes[Default]; !ok {
+		return nil, fmt.Errorf("no deadline for the %s route class", Default)
+	}
+	for class, timeout := range options.Classes {
+		if timeout <= 0 {
+			return nil, fmt.Errorf("the deadline for the %s route class must be positive", class)
+		}
+	}
+	for template, timeout := range options.Routes {
+		if timeout <= 0 {
+			return nil, fmt.Errorf("the deadline for %s must be positive", template)
+		}
+	}
+	return &Policy{
+		classes:      options.Classes,
+		routes:       options.Routes,
+		writeTimeout: options.WriteTimeout,
+	}, nil
+}
+
+// classHandler carries a route's class alongside its handler so that the
+// middleware can find it from the matched route.
+type classHandler struct {
+	http.Handler
+	class Class
+}
+
+// Unwrap implements httpx.Declaration.
+func (h classHandler) Unwrap() http.Handler { return h.Handler }
+
+// In returns a function that declares that the handlers it wraps belong to
+// class, for use when registering routes:
+//
+//	spatial := deadline.In(deadline.Spatial)
+//	router.Handle("/bom/shapefiles", cached(spatial(h.BillsShapefilesHandler())))
+func In(class Class) func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return classHandler{Handler: h, class: class}
+	}
+}
+
+// streamHandler marks a route whose handler can stream NDJSON.
+type streamHandler struct {
+	http.Handler
+}
+
+// Unwrap implements httpx.Declaration.
+func (h streamHandler) Unwrap() http.Handler { return h.Handler }
+
+// Streaming declares that h streams its response when a request asks for
+// NDJSON. Such requests get no time limit, so that a whole-dataset export is
+// not canceled partway through its body:
+//
+//	router.Handle("/bom/bills", cached(bulk(long(deadline.Streaming(h.BillsHandler())))))
+func Streaming(h http.Handler) http.Handler {
+	return streamHandler{Handler: h}
+}
+
+// RouteClass returns the class declared by route's handler.
+func RouteClass(route *mux.Route) Class {
+	if c, ok := httpx.RouteDeclaration[classHandler](route); ok {
+		return c.class
+	}
+	return Default
+}
+
+// RouteStreams reports whether route's handler is declared Streaming.
+func RouteStreams(route *mux.Route) bool {
+	_, ok := httpx.RouteDeclaration[streamHandler](route)
+	return ok
+}
+
+// For returns the time limit of route: its own if one is configured,
+// otherwise its class's.
+func (p *Policy) For(route *mux.Route) time.Duration {
+	if template, err := route.GetPathTemplate(); err == nil {
+		if timeout, ok := p.routes[template]; ok {
+			return timeout
+		}
+	}
+	if timeout, ok := p.classes[RouteClass(route)]; ok {
+		return timeout
+	}
+	return p.classes[Default]
+}
+
+// Check returns an error if a route template in the policy matches no route
+// of router, which is most likely a typo in the configuration.
+func (p *Policy) Check(router *mux.Router) error {
+	templates := make(map[string]bool)
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		if template, err := route.GetPathTemplate(); err == nil {
+			templates[template] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for template := range p.routes {
+		if !templates[template] {
+			return fmt.Errorf("no route matches %s", template)
+		}
+	}
+	return nil
+}
+
+// Middleware gives each request the time limit of its route: the request
+// context is canceled when it passes, queries run with a matching statement
+// timeout, and the connection's write deadline is extended if the server's
+// write timeout would cut the response off first. NDJSON requests to
+// Streaming routes are only canceled if the limit passes before the response
+// starts; after that, the stream sets a write deadline for each flush, and
+// queries have no statement timeout. It must run inside the router so that
+// it can find the route.
+func (p *Policy) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		timeout := p.classes[Default]
+		route := mux.CurrentRoute(r)
+		if route != nil {
+			timeout = p.For(route)
+		}
+		if p.writeTimeout > 0 && timeout+writeMargin > p.writeTimeout {
+			// Writers that cannot set deadlines keep the server's.
+			_ = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(timeout + writeMargin))
+		}
+		if route != nil && RouteStreams(route) && httpx.Format(r) == httpx.FormatNDJSON {
+			ctx, cancel := context.WithCancel(r.Context())
+			defer cancel()
+			stream := &streamWriter{ResponseWriter: w, timer: time.AfterFunc(timeout, cancel)}
+			defer stream.timer.Stop()
+			next.ServeHTTP(stream, r.WithContext(ctx))
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		ctx = db.WithStatementTimeout(ctx, timeout)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// streamWriter cancels a stream whose response has not started by the time
+// its timer fires.
+type streamWriter struct {
+	http.ResponseWriter
+	timer *time.Timer
+}
+
+func (w *streamWriter) WriteHeader(status int) {
+	w.timer.Stop()
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *streamWriter) Write(b []byte) (int, error) {
+	w.timer.Stop()
+	return w.ResponseWriter.Write(b)
+}
+
+// FlushError sends what the stream has written so far to the client.
+func (w *streamWriter) FlushError() error {
+	w.timer.Stop()
+	return http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap lets http.ResponseController set deadlines on the underlying writer.
+func (w *streamWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
@@ -0,0 +1,161 @@
+package deadline
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chnm/apiary/db"
+	"github.com/chnm/apiary/internal/cachex"
+	"github.com/chnm/apiary/internal/httpx"
+	"github.com/gorilla/mux"
+)
+
+func newTestPolicy(t *testing.T, routes map[string]time.Duration) *Policy {
+	t.Helper()
+	policy, err := New(Options{
+		Classes: map[Class]time.Duration{
+			Default: 10 * time.Second,
+			Bulk:    30 * time.Second,
+			Spatial: time.Minute,
+		},
+		Routes:       routes,
+		WriteTimeout: 15 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return policy
+}
+
+// newTestRouter returns a router whose handlers write the time left before
+// their context's deadline and the statement timeout of their queries.
+func newTestRouter(policy *Policy) *mux.Router {
+	report := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		deadline, ok := r.Context().Deadline()
+		if !ok {
+			http.Error(w, "no deadline", http.StatusInternalServerError)
+			return
+		}
+		left := time.Until(deadline).Round(time.Second)
+		_, _ = w.Write([]byte(left.String() + " " + db.StatementTimeoutFromContext(r.Context()).String()))
+	})
+	router := mux.NewRouter()
+	router.Handle("/lookup", report)
+	router.Handle("/bills", In(Bulk)(report))
+	router.Handle("/shapefiles", cachex.TTL(time.Hour)(In(Spatial)(report)))
+	router.Handle("/globe", In(Spatial)(report))
+	router.Use(policy.Middleware)
+	return router
+}
+
+func TestMiddleware(t *testing.T) {
+	router := newTestRouter(newTestPolicy(t, map[string]time.Duration{"/globe": 2 * time.Minute}))
+
+	tests := []struct{ path, want string }{
+		{"/lookup", "10s 10s"},
+		{"/bills", "30s 30s"},
+		{"/shapefiles", "1m0s 1m0s"},
+		{"/globe", "2m0s 2m0s"},
+	}
+	for _, tt := range tests {
+		response := httptest.NewRecorder()
+		router.ServeHTTP(response, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if got := response.Body.String(); got != tt.want {
+			t.Errorf("%s: deadline and statement timeout = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		options Options
+		want    string
+	}{
+		{name: "no default", options: Options{Classes: map[Class]time.Duration{Bulk: time.Second}}, want: "no deadline for the default"},
+		{name: "zero class", options: Options{Classes: map[Class]time.Duration{Default: time.Second, Spatial: 0}}, want: "spatial route class must be positive"},
+		{name: "zero route", options: Options{Classes: map[Class]time.Duration{Default: time.Second}, Routes: map[string]time.Duration{"/globe": 0}}, want: "/globe must be positive"},
+	}
+	for _, tt := range tests {
+		if _, err := New(tt.options); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: New() error = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	policy := newTestPolicy(t, map[string]time.Duration{"/globe": time.Minute})
+	if err := policy.Check(newTestRouter(policy)); err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+
+	policy = newTestPolicy(t, map[string]time.Duration{"/glob": time.Minute})
+	if err := policy.Check(newTestRouter(policy)); err == nil || !strings.Contains(err.Error(), "/glob") {
+		t.Fatalf("Check() error = %v, want one naming /glob", err)
+	}
+}
+
+func TestMiddlewareLetsStreamsOutlastTheirClass(t *testing.T) {
+	policy, err := New(Options{Classes: map[Class]time.Duration{Default: time.Second, Bulk: 20 * time.Millisecond}})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	// export writes a row every 10ms for five times the class's limit,
+	// stopping early if its context is canceled.
+	export := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		stream := httpx.NewNDJSONStream(w, r)
+		for i := range 10 {
+			select {
+			case <-r.Context().Done():
+				stream.Fail("export rows", r.Context().Err())
+				return
+			case <-time.After(10 * time.Millisecond):
+			}
+			if err := stream.Write(i); err != nil {
+				stream.Fail("export rows", err)
+				return
+			}
+		}
+		if err := stream.Close(); err != nil {
+			stream.Fail("export rows", err)
+		}
+	})
+	router := mux.NewRouter()
+	router.Handle("/bills", In(Bulk)(Streaming(export)))
+	router.Handle("/shapefiles", In(Bulk)(export))
+	router.Use(policy.Middleware)
+
+	response := httptest.NewRecorder()
+	router.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/bills?format=ndjson", nil))
+	if lines := strings.Count(response.Body.String(), "\n"); lines != 10 {
+		t.Fatalf("streamed %d rows, want all 10: %q", lines, response.Body)
+	}
+
+	// A stream must still start within the limit.
+	stalled := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+			http.Error(w, "timed out", http.StatusGatewayTimeout)
+		case <-time.After(time.Second):
+			_, _ = w.Write([]byte("{}\n"))
+		}
+	})
+	router.Handle("/stalled", In(Bulk)(Streaming(stalled)))
+	response = httptest.NewRecorder()
+	router.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/stalled?format=ndjson", nil))
+	if response.Code != http.StatusGatewayTimeout {
+		t.Fatalf("stalled stream status = %d, want it canceled at the limit", response.Code)
+	}
+
+	// Routes that do not declare streams keep their limit, whatever the
+	// request asks for.
+	defer func() {
+		if recovered := recover(); recovered != http.ErrAbortHandler {
+			t.Fatalf("stream from an undeclared route panicked with %v, want it aborted at the limit", recovered)
+		}
+	}()
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/shapefiles?format=ndjson", nil))
+}
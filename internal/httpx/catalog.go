@@ -7,8 +7,104 @@ type ExampleURL struct {
 }
 
 // Endpoint describes an API endpoint and provides sample requests.
+//
+// Path, Parameters, Response, ContentType, CSV, and StreamRow are not part of the root
+// catalog; they describe the endpoint for the generated OpenAPI document.
 type Endpoint struct {
 	Name     string       `json:"name"`
 	URL      string       `json:"path"`
 	Examples []ExampleURL `json:"examples,omitempty"`
+
+	// Path is the route template in OpenAPI form, such as
+	// /pinkertons/activities/{id}.
+	Path string `json:"-"`
+	// Parameters lists the path and query parameters the handler reads.
+	Parameters []Parameter `json:"-"`
+	// Response is a value whose type describes the successful response body,
+	// such as []Parish{}. A nil Response is documented as an arbitrary value.
+	Response any `json:"-"`
+	// ContentType of the successful response. It defaults to application/json.
+	ContentType string `json:"-"`
+	// CSV means the endpoint also returns its rows as CSV, through WriteTable
+	// or WriteCSV. Such endpoints should declare FormatParameter.
+	CSV bool `json:"-"`
+	// StreamRow is a value of the type of each line the endpoint streams as
+	// NDJSON, or nil if it does not stream. Such endpoints should declare
+	// StreamFormatParameter.
+	StreamRow any `json:"-"`
+}
+
+// FormatParameter is the query parameter that selects CSV output on endpoints
+// that support it. Clients may send Accept: text/csv instead.
+var FormatParameter = Parameter{
+	Name:        "format",
+	In:          InQuery,
+	Type:        TypeString,
+	Default:     FormatJSON,
+	Enum:        []any{FormatJSON, FormatCSV},
+	Description: "Response format; csv flattens each record into a row",
+}
+
+// StreamFormatParameter is FormatParameter for endpoints that can also stream
+// their rows as NDJSON. Clients may send Accept: application/x-ndjson instead.
+var StreamFormatParameter = Parameter{
+	Name:        "format",
+	In:          InQuery,
+	Type:        TypeString,
+	Default:     FormatJSON,
+	Enum:        []any{FormatJSON, FormatCSV, FormatNDJSON},
+	Description: "Response format; csv flattens each record into a row, and ndjson streams one JSON record per line",
+}
+
+// Parameter locations.
+const (
+	InQuery = "query"
+	InPath  = "path"
+)
+
+// Parameter types. These are the JSON Schema primitive types.
+const (
+	TypeString  = "string"
+	TypeInteger = "integer"
+	TypeNumber  = "number"
+	TypeBoolean = "boolean"
+)
+
+// Parameter describes a path or query parameter accepted by an endpoint.
+type Parameter struct {
+	Name        string
+	In          string // InQuery or InPath
+	Description string
+	Type        string // one of the Type constants
+	Format      string // optional JSON Schema format, such as "date"
+	Required    bool
+	Default     any
+	Enum        []any
+	Minimum     *int
+	Maximum     *int
+	// List means the value is a comma-separated list, as in ?parish=1,3,17.
+	List bool
+	// Repeated means the parameter may be given more than once, as in
+	// ?location=Europe&location=Asia.
+	Repeated bool
+}
+
+// Bound returns a pointer to n for use as a Parameter's Minimum or Maximum.
+func Bound(n int) *int {
+	return &n
+}
+
+// FeatureCollection documents the shape of GeoJSON responses, which datasets
+// build in SQL and write without decoding.
+type FeatureCollection struct {
+	Type     string    `json:"type"`
+	Features []Feature `json:"features"`
+}
+
+// Feature is a GeoJSON feature within a FeatureCollection.
+type Feature struct {
+	Type       string         `json:"type"`
+	ID         any            `json:"id,omitempty"`
+	Properties map[string]any `json:"properties"`
+	Geometry   map[string]any `json:"geometry"`
 }
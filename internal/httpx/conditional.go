@@ -0,0 +1,169 @@
+package httpx
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Conditional adds a strong ETag, computed from the body, to successful GET
+// and HEAD responses, and answers conditional requests whose validators still
+// match with 304 Not Modified. It hashes the bytes as they will be sent, so it
+// must wrap any compression middleware: each content coding of a response is a
+// different representation and gets its own ETag. Streamed responses and
+// bodies larger than maxETagBody are passed through without an ETag, since
+// the body is not known until the stream ends and holding a large one in
+// memory to hash it costs more than revalidation saves.
+func Conditional(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if (r.Method != http.MethodGet && r.Method != http.MethodHead) || Format(r) == FormatNDJSON {
+			next.ServeHTTP(w, r)
+			return
+		}
+		cw := &conditionalWriter{ResponseWriter: w, ctx: r.Context()}
+		next.ServeHTTP(cw, r)
+		cw.finish(r)
+	})
+}
+
+// maxETagBody is the size of the largest body that Conditional holds to
+// compute an ETag.
+const maxETagBody = 1 << 20
+
+// conditionalWriter holds a response until it is complete so that its ETag
+// can be computed. If the handler flushes, or the body grows past
+// maxETagBody, the response is sent as it is written instead.
+type conditionalWriter struct {
+	http.ResponseWriter
+	ctx       context.Context // Of the request, for logging
+	status    int
+	body      bytes.Buffer
+	streaming bool
+}
+
+func (w *conditionalWriter) WriteHeader(status int) {
+	if w.streaming {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *conditionalWriter) Write(b []byte) (int, error) {
+	if w.streaming {
+		return w.ResponseWriter.Write(b)
+	}
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if w.body.Len()+len(b) > maxETagBody {
+		w.streaming = true
+		w.send()
+		return w.ResponseWriter.Write(b)
+	}
+	return w.body.Write(b)
+}
+
+// Flush sends what has been written so far and passes the rest of the
+// response through.
+func (w *conditionalWriter) Flush() {
+	if !w.streaming {
+		w.streaming = true
+		w.send()
+	}
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (w *conditionalWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// finish validates the complete response and sends it, or a 304 in its place.
+func (w *conditionalWriter) finish(r *http.Request) {
+	if w.streaming {
+		return
+	}
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if w.status != http.StatusOK {
+		w.send()
+		return
+	}
+
+	header := w.Header()
+	if header.Get("ETag") == "" {
+		sum := sha256.Sum256(w.body.Bytes())
+		header.Set("ETag", `"`+hex.EncodeToString(sum[:16])+`"`)
+	}
+	if !notModified(r, header) {
+		w.send()
+		return
+	}
+	// As in http.ServeContent, drop the headers that describe a body.
+	header.Del("Content-Type")
+	header.Del("Content-Length")
+	header.Del("Content-Encoding")
+	w.ResponseWriter.WriteHeader(http.StatusNotModified)
+}
+
+func (w *conditionalWriter) send() {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	if _, err := w.ResponseWriter.Write(w.body.Bytes()); err != nil {
+		slog.WarnContext(w.ctx, "error writing response", "error", err)
+	}
+	w.body.Reset()
+}
+
+// notModified evaluates If-None-Match, or If-Modified-Since when there is no
+// If-None-Match, against the validators of a response (RFC 9110, section 13.2.2).
+func notModified(r *http.Request, header http.Header) bool {
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		return etagMatches(ifNoneMatch, header.Get("ETag"))
+	}
+	ifModifiedSince, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	lastModified, err := http.ParseTime(header.Get("Last-Modified"))
+	if err != nil {
+		return false
+	}
+	return !lastModified.After(ifModifiedSince)
+}
+
+// etagMatches reports whether an If-None-Match list matches etag, using the
+// weak comparison that If-None-Match calls for.
+func etagMatches(list, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(list, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// LastModified sets Last-Modified to the time a response is generated.
+// Handlers that know when their data last changed may overwrite it. It is
+// only meaningful for responses that are stored and served again unchanged,
+// such as those of the response cache, which applies it to the responses it
+// stores so that hits keep their original time.
+func LastModified(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+		next.ServeHTTP(w, r)
+	})
+}
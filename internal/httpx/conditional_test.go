@@ -0,0 +1,119 @@
+package httpx
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestConditional(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		handler    http.HandlerFunc
+		wantStatus int
+		wantETag   string // "" for none, "*" for any
+	}{
+		{
+			name:   "success",
+			method: http.MethodGet, target: "/",
+			handler:    func(w http.ResponseWriter, _ *http.Request) { _, _ = w.Write([]byte("ok")) },
+			wantStatus: http.StatusOK, wantETag: "*",
+		},
+		{
+			name:   "head",
+			method: http.MethodHead, target: "/",
+			handler:    func(w http.ResponseWriter, _ *http.Request) { _, _ = w.Write([]byte("ok")) },
+			wantStatus: http.StatusOK, wantETag: "*",
+		},
+		{
+			name:   "handler ETag is kept",
+			method: http.MethodGet, target: "/",
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("ETag", `"v1"`)
+				_, _ = w.Write([]byte("ok"))
+			},
+			wantStatus: http.StatusOK, wantETag: `"v1"`,
+		},
+		{
+			name:   "error",
+			method: http.MethodGet, target: "/",
+			handler:    func(w http.ResponseWriter, _ *http.Request) { http.Error(w, "bad", http.StatusBadRequest) },
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "post",
+			method: http.MethodPost, target: "/",
+			handler:    func(w http.ResponseWriter, _ *http.Request) { _, _ = w.Write([]byte("ok")) },
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:   "stream",
+			method: http.MethodGet, target: "/?format=ndjson",
+			handler:    func(w http.ResponseWriter, _ *http.Request) { _, _ = w.Write([]byte("{}\n")) },
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:   "large",
+			method: http.MethodGet, target: "/",
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = w.Write(bytes.Repeat([]byte("x"), maxETagBody))
+				_, _ = w.Write([]byte("x"))
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:   "flushed",
+			method: http.MethodGet, target: "/",
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = w.Write([]byte("first"))
+				w.(http.Flusher).Flush()
+				_, _ = w.Write([]byte("second"))
+			},
+			wantStatus: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := httptest.NewRecorder()
+			Conditional(tt.handler).ServeHTTP(response, httptest.NewRequest(tt.method, tt.target, nil))
+			if response.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", response.Code, tt.wantStatus)
+			}
+			etag := response.Header().Get("ETag")
+			switch {
+			case tt.wantETag == "*" && etag == "":
+				t.Error("response has no ETag")
+			case tt.wantETag != "*" && etag != tt.wantETag:
+				t.Errorf("ETag = %q, want %q", etag, tt.wantETag)
+			}
+			if tt.name == "flushed" && response.Body.String() != "firstsecond" {
+				t.Errorf("body = %q, want firstsecond", response.Body)
+			}
+			if tt.name == "large" && response.Body.Len() != maxETagBody+1 {
+				t.Errorf("body has %d bytes, want %d", response.Body.Len(), maxETagBody+1)
+			}
+		})
+	}
+}
+
+func TestETagMatches(t *testing.T) {
+	tests := []struct {
+		list, etag string
+		want       bool
+	}{
+		{`"a"`, `"a"`, true},
+		{`"b", "a"`, `"a"`, true},
+		{`W/"a"`, `"a"`, true},
+		{`*`, `"a"`, true},
+		{`"b"`, `"a"`, false},
+		{`"a`, `"a"`, false},
+	}
+	for _, tt := range tests {
+		if got := etagMatches(tt.list, tt.etag); got != tt.want {
+			t.Errorf("etagMatches(%q, %q) = %t, want %t", tt.list, tt.etag, got, tt.want)
+		}
+	}
+}
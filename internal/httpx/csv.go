@@ -0,0 +1,289 @@
+package httpx
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net/http"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Response formats that a client can request.
+const (
+	FormatJSON   = "json"
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+)
+
+// CSVContentType is the media type of CSV responses.
+const CSVContentType = "text/csv; charset=utf-8"
+
+// csvListSeparator joins the values of a list field within a single cell.
+const csvListSeparator = "; "
+
+// formatMediaTypes maps the media types a client may name in its Accept
+// header to response formats.
+var formatMediaTypes = map[string]string{
+	"application/json":     FormatJSON,
+	"text/csv":             FormatCSV,
+	"application/x-ndjson": FormatNDJSON,
+	"application/ndjson":   FormatNDJSON,
+}
+
+// Format returns the response format the client asked for. The format query
+// parameter takes precedence over the Accept header; anything other than an
+// explicit request for CSV or NDJSON gets JSON. Endpoints that do not support
+// the requested format respond with JSON.
+func Format(r *http.Request) string {
+	switch format := strings.ToLower(r.URL.Query().Get("format")); format {
+	case FormatJSON, FormatCSV, FormatNDJSON:
+		return format
+	}
+	return acceptedFormat(r.Header.Get("Accept"))
+}
+
+// acceptedFormat picks the format whose media type an Accept header prefers.
+// Wildcards do not select a format, so browsers and generic clients keep
+// getting JSON, and JSON wins ties.
+func acceptedFormat(accept string) string {
+	qualities := make(map[string]float64)
+	for _, mediaRange := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(mediaRange))
+		if err != nil {
+			continue
+		}
+		format, ok := formatMediaTypes[mediaType]
+		if !ok {
+			continue
+		}
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+		qualities[format] = max(qualities[format], quality)
+	}
+
+	best, bestQuality := FormatJSON, qualities[FormatJSON]
+	for _, format := range []string{FormatCSV, FormatNDJSON} {
+		if quality := qualities[format]; quality > 0 && quality > bestQuality {
+			best, bestQuality = format, quality
+		}
+	}
+	return best
+}
+
+// WriteTable writes rows, a slice of structs, as CSV when the client asked for
+// CSV and as JSON otherwise.
+func WriteTable(w http.ResponseWriter, r *http.Request, rows any) {
+	w.Header().Add("Vary", "Accept")
+	if Format(r) == FormatCSV {
+		WriteCSV(w, r, rows)
+		return
+	}
+	WriteJSON(w, r, rows)
+}
+
+// WriteCSV writes rows, a slice of structs, as CSV with a header row. Columns
+// are named after the fields' JSON names. Nested structs are flattened into
+// dotted columns such as parish.name, lists are joined within a cell, and
+// null values become empty cells. The body is encoded before headers are
+// written so that encoding errors can still produce an error response.
+func WriteCSV(w http.ResponseWriter, r *http.Request, rows any) {
+	body, err := encodeCSV(rows)
+	if err != nil {
+		InternalServerError(w, r, "error encoding CSV response", err)
+		return
+	}
+
+	name := path.Base(strings.TrimSuffix(r.URL.Path, "/"))
+	if name == "." || name == "/" {
+		name = "apiary"
+	}
+	w.Header().Set("Content-Type", CSVContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", name+".csv"))
+	if _, err := w.Write(body); err != nil {
+		slog.WarnContext(r.Context(), "error writing CSV response", "error", err)
+	}
+}
+
+// csvColumn is a leaf value reached from a row by following field indexes.
+type csvColumn struct {
+	name string
+	path [][]int
+}
+
+func encodeCSV(rows any) ([]byte, error) {
+	value := reflect.ValueOf(rows)
+	if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+		return nil, fmt.Errorf("CSV rows must be a slice, not %T", rows)
+	}
+	rowType := value.Type().Elem()
+	if indirect(rowType).Kind() != reflect.Struct || isCSVLeaf(indirect(rowType)) {
+		return nil, fmt.Errorf("CSV rows must be structs, not %s", rowType)
+	}
+
+	columns := csvColumns(indirect(rowType), "", nil)
+	header := make([]string, len(columns))
+	for i, column := range columns {
+		header[i] = column.name
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	if err := writer.Write(header); err != nil {
+		return nil, err
+	}
+	record := make([]string, len(columns))
+	for i := range value.Len() {
+		for j, column := range columns {
+			cells, err := csvCells(value.Index(i), column.path)
+			if err != nil {
+				return nil, fmt.Errorf("column %s: %w", column.name, err)
+			}
+			record[j] = strings.Join(cells, csvListSeparator)
+		}
+		if err := writer.Write(record); err != nil {
+			return nil, err
+		}
+	}
+	writer.Flush()
+	return buf.Bytes(), writer.Error()
+}
+
+// csvColumns lists the leaf columns of a struct type, following the
+// encoding/json rules for field names, skipped fields, and embedding.
+func csvColumns(t reflect.Type, prefix string, parent [][]int) []csvColumn {
+	var columns []csvColumn
+	for i := range t.NumField() {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		fieldPath := append(append([][]int(nil), parent...), field.Index)
+		fieldType := indirect(field.Type)
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct && !isCSVLeaf(fieldType) {
+			columns = append(columns, csvColumns(fieldType, prefix, fieldPath)...)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		name = prefix + name
+
+		// Lists of structs are flattened like single structs, with the values
+		// of every element joined in each cell.
+		elemType := fieldType
+		if fieldType.Kind() == reflect.Slice && fieldType.Elem().Kind() != reflect.Uint8 {
+			elemType = indirect(fieldType.Elem())
+		}
+		if elemType.Kind() == reflect.Struct && !isCSVLeaf(elemType) {
+			columns = append(columns, csvColumns(elemType, name+".", fieldPath)...)
+			continue
+		}
+		columns = append(columns, csvColumn{name: name, path: fieldPath})
+	}
+	return columns
+}
+
+// csvCells follows path from v and formats the values it reaches. A nil
+// pointer yields no values, and a slice yields the values of each element.
+func csvCells(v reflect.Value, path [][]int) ([]string, error) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8 && !isCSVLeaf(v.Type()) {
+		var cells []string
+		for i := range v.Len() {
+			elemCells, err := csvCells(v.Index(i), path)
+			if err != nil {
+				return nil, err
+			}
+			cells = append(cells, elemCells...)
+		}
+		return cells, nil
+	}
+	if len(path) > 0 {
+		return csvCells(v.FieldByIndex(path[0]), path[1:])
+	}
+	cell, err := csvCell(v)
+	if err != nil {
+		return nil, err
+	}
+	return []string{cell}, nil
+}
+
+// csvCell formats a leaf value. Types with their own JSON encoding, such as
+// NullInt64, are formatted from it, so that JSON null becomes an empty cell.
+func csvCell(v reflect.Value) (string, error) {
+	if isCSVLeaf(v.Type()) {
+		encoded, err := json.Marshal(v.Interface())
+		if err != nil {
+			return "", err
+		}
+		return jsonCell(encoded)
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits()), nil
+	default:
+		encoded, err := json.Marshal(v.Interface())
+		if err != nil {
+			return "", err
+		}
+		return jsonCell(encoded)
+	}
+}
+
+// jsonCell turns an encoded JSON value into cell text: null is empty, strings
+// are unquoted, and anything else is kept as JSON.
+func jsonCell(encoded []byte) (string, error) {
+	switch {
+	case string(encoded) == "null":
+		return "", nil
+	case len(encoded) > 0 && encoded[0] == '"':
+		var s string
+		err := json.Unmarshal(encoded, &s)
+		return s, err
+	default:
+		return string(encoded), nil
+	}
+}
+
+var jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+
+// isCSVLeaf reports whether t controls its own JSON encoding and so should be
+// formatted as a single cell rather than flattened.
+func isCSVLeaf(t reflect.Type) bool {
+	return t.Implements(jsonMarshalerType)
+}
+
+func indirect(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
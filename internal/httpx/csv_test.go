@@ -0,0 +1,150 @@
+package httpx
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		accept string
+		want   string
+	}{
+		{name: "default", want: FormatJSON},
+		{name: "query", query: "?format=csv", want: FormatCSV},
+		{name: "query overrides Accept", query: "?format=json", accept: "text/csv", want: FormatJSON},
+		{name: "Accept", accept: "text/csv", want: FormatCSV},
+		{name: "Accept with charset", accept: "text/csv; charset=utf-8", want: FormatCSV},
+		{name: "JSON preferred", accept: "text/csv;q=0.5, application/json", want: FormatJSON},
+		{name: "CSV preferred", accept: "application/json;q=0.5, text/csv", want: FormatCSV},
+		{name: "CSV refused", accept: "text/csv;q=0", want: FormatJSON},
+		{name: "browser", accept: "text/html,application/xhtml+xml,*/*;q=0.8", want: FormatJSON},
+		{name: "NDJSON query", query: "?format=ndjson", want: FormatNDJSON},
+		{name: "NDJSON Accept", accept: "application/x-ndjson", want: FormatNDJSON},
+		{name: "NDJSON registered type", accept: "application/ndjson", want: FormatNDJSON},
+		{name: "NDJSON over CSV", accept: "text/csv;q=0.5, application/x-ndjson", want: FormatNDJSON},
+		{name: "unknown format", query: "?format=xlsx", want: FormatJSON},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/rows"+tt.query, nil)
+			if tt.accept != "" {
+				request.Header.Set("Accept", tt.accept)
+			}
+			if got := Format(request); got != tt.want {
+				t.Fatalf("Format() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+type csvParish struct {
+	ID   int        `json:"id"`
+	Name NullString `json:"name"`
+}
+
+type csvLocation struct {
+	Locality NullString `json:"locality"`
+	Visits   NullInt64  `json:"visits"`
+}
+
+type csvRow struct {
+	Name      string        `json:"name"`
+	Count     NullInt64     `json:"count"`
+	Missing   *bool         `json:"missing"`
+	Ratio     float64       `json:"ratio"`
+	Parish    *csvParish    `json:"parish,omitempty"`
+	Locations []csvLocation `json:"locations,omitempty"`
+	Tags      []string      `json:"tags"`
+	Internal  string        `json:"-"`
+	hidden    string
+}
+
+func TestWriteCSV(t *testing.T) {
+	missing := true
+	rows := []csvRow{
+		{
+			Name:    "St Mary, \"Aldermanbury\"",
+			Count:   NullInt64{sql.NullInt64{Int64: 12, Valid: true}},
+			Missing: &missing,
+			Ratio:   0.25,
+			Parish:  &csvParish{ID: 3, Name: NullString{sql.NullString{String: "Aldgate", Valid: true}}},
+			Locations: []csvLocation{
+				{Locality: NullString{sql.NullString{String: "Chicago", Valid: true}}, Visits: NullInt64{sql.NullInt64{Int64: 2, Valid: true}}},
+				{Locality: NullString{sql.NullString{String: "Denver", Valid: true}}},
+			},
+			Tags: []string{"a", "b"},
+		},
+		{Name: "Unknown", Internal: "secret", hidden: "secret"},
+	}
+	request := httptest.NewRequest(http.MethodGet, "/bom/bills/?format=csv", nil)
+	response := httptest.NewRecorder()
+
+	WriteCSV(response, request, rows)
+
+	if response.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", response.Code, http.StatusOK)
+	}
+	if got := response.Header().Get("Content-Type"); got != CSVContentType {
+		t.Fatalf("Content-Type = %q, want %q", got, CSVContentType)
+	}
+	if got := response.Header().Get("Content-Disposition"); got != `inline; filename="bills.csv"` {
+		t.Fatalf("Content-Disposition = %q", got)
+	}
+	want := strings.Join([]string{
+		"name,count,missing,ratio,parish.id,parish.name,locations.locality,locations.visits,tags",
+		`"St Mary, ""Aldermanbury""",12,true,0.25,3,Aldgate,Chicago; Denver,2; ,a; b`,
+		"Unknown,,,0,,,,,",
+		"",
+	}, "\n")
+	if got := response.Body.String(); got != want {
+		t.Fatalf("body =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestWriteCSVEmptyRowsHasHeader(t *testing.T) {
+	response := httptest.NewRecorder()
+
+	WriteCSV(response, httptest.NewRequest(http.MethodGet, "/rows", nil), []csvParish{})
+
+	if got := response.Body.String(); got != "id,name\n" {
+		t.Fatalf("body = %q, want header only", got)
+	}
+}
+
+func TestWriteCSVRejectsNonTabularValues(t *testing.T) {
+	for _, value := range []any{csvParish{}, []string{"a"}, []NullInt64{}} {
+		response := httptest.NewRecorder()
+
+		WriteCSV(response, httptest.NewRequest(http.MethodGet, "/rows", nil), value)
+
+		if response.Code != http.StatusInternalServerError {
+			t.Fatalf("%T: status = %d, want %d", value, response.Code, http.StatusInternalServerError)
+		}
+	}
+}
+
+func TestWriteTable(t *testing.T) {
+	rows := []csvParish{{ID: 1}}
+
+	response := httptest.NewRecorder()
+	WriteTable(response, httptest.NewRequest(http.MethodGet, "/rows", nil), rows)
+	if got := response.Body.String(); got != `[{"id":1,"name":null}]` {
+		t.Fatalf("JSON body = %q", got)
+	}
+
+	response = httptest.NewRecorder()
+	WriteTable(response, httptest.NewRequest(http.MethodGet, "/rows?format=csv", nil), rows)
+	if got := response.Body.String(); got != "id,name\n1,\n" {
+		t.Fatalf("CSV body = %q", got)
+	}
+	if got := response.Header().Get("Vary"); got != "Accept" {
+		t.Fatalf("Vary = %q, want Accept", got)
+	}
+}
@@ -0,0 +1,108 @@
+package httpx
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// NDJSONContentType is the media type of streamed responses: one JSON value
+// per line.
+const NDJSONContentType = "application/x-ndjson"
+
+const (
+	// ndjsonFlushRows is how many rows are buffered before they are flushed
+	// to the client.
+	ndjsonFlushRows = 100
+	// ndjsonWriteTimeout bounds each flush rather than the whole response,
+	// so a long export can outlast the server's WriteTimeout as long as the
+	// client keeps reading.
+	ndjsonWriteTimeout = 15 * time.Second
+)
+
+// NDJSONStream writes rows to the client as they are produced instead of
+// collecting them first. Writes block while the client is not reading, which
+// in turn stops the caller from reading more rows from the database, and a
+// client that goes away cancels the request context that the query runs on.
+type NDJSONStream struct {
+	w          http.ResponseWriter
+	r          *http.Request
+	controller *http.ResponseController
+	rows       int
+	started    bool
+}
+
+// NewNDJSONStream prepares w for a streamed response. Nothing is written until
+// the first row or Close, so errors before then can still be reported with a
+// problem response.
+func NewNDJSONStream(w http.ResponseWriter, r *http.Request) *NDJSONStream {
+	return &NDJSONStream{w: w, r: r, controller: http.NewResponseController(w)}
+}
+
+// Write encodes row as a line of JSON. An error means the row could not be
+// encoded or the client stopped reading; the caller should stop producing
+// rows and pass the error to Fail.
+func (s *NDJSONStream) Write(row any) error {
+	line, err := json.Marshal(row)
+	if err != nil {
+		return err
+	}
+	s.start()
+	if _, err := s.w.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	s.rows++
+	if s.rows%ndjsonFlushRows == 0 {
+		return s.flush()
+	}
+	return nil
+}
+
+// Close flushes any buffered rows. A stream without rows is an empty 200
+// response.
+func (s *NDJSONStream) Close() error {
+	s.start()
+	return s.flush()
+}
+
+// Fail reports an error that stopped the stream. Before any row is written
+// this is an ordinary problem response. Afterwards the status has been sent,
+// so the connection is aborted instead, which clients see as a truncated
+// response rather than a complete one.
+func (s *NDJSONStream) Fail(operation string, err error) {
+	if !s.started {
+		InternalServerError(s.w, s.r, operation, err)
+		return
+	}
+	slog.ErrorContext(s.r.Context(), operation, "error", err, "rows", s.rows)
+	panic(http.ErrAbortHandler)
+}
+
+func (s *NDJSONStream) start() {
+	if s.started {
+		return
+	}
+	s.started = true
+	s.w.Header().Set("Content-Type", NDJSONContentType)
+	s.w.Header().Set("X-Content-Type-Options", "nosniff")
+	s.extendDeadline()
+	s.w.WriteHeader(http.StatusOK)
+}
+
+func (s *NDJSONStream) flush() error {
+	if err := s.controller.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	s.extendDeadline()
+	return nil
+}
+
+// extendDeadline gives the next batch of rows a fresh write deadline. Writers
+// that cannot set deadlines, such as test recorders, are left alone.
+func (s *NDJSONStream) extendDeadline() {
+	if err := s.controller.SetWriteDeadline(time.Now().Add(ndjsonWriteTimeout)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		slog.WarnContext(s.r.Context(), "error extending write deadline for streamed response", "error", err)
+	}
+}
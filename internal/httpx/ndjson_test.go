@@ -0,0 +1,99 @@
+package httpx
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNDJSONStreamWritesLines(t *testing.T) {
+	response := httptest.NewRecorder()
+	stream := NewNDJSONStream(response, httptest.NewRequest(http.MethodGet, "/rows?format=ndjson", nil))
+
+	for _, row := range []csvParish{{ID: 1}, {ID: 2}} {
+		if err := stream.Write(row); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+	if err := stream.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	if got := response.Header().Get("Content-Type"); got != NDJSONContentType {
+		t.Fatalf("Content-Type = %q, want %q", got, NDJSONContentType)
+	}
+	if got, want := response.Body.String(), "{\"id\":1,\"name\":null}\n{\"id\":2,\"name\":null}\n"; got != want {
+		t.Fatalf("body = %q, want %q", got, want)
+	}
+	if !response.Flushed {
+		t.Fatal("Close() did not flush the response")
+	}
+}
+
+func TestNDJSONStreamFlushesInBatches(t *testing.T) {
+	response := httptest.NewRecorder()
+	stream := NewNDJSONStream(response, httptest.NewRequest(http.MethodGet, "/rows", nil))
+
+	for i := range ndjsonFlushRows - 1 {
+		if err := stream.Write(i); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+	if response.Flushed {
+		t.Fatal("flushed before a full batch was written")
+	}
+	if err := stream.Write(ndjsonFlushRows); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if !response.Flushed {
+		t.Fatal("did not flush after a full batch was written")
+	}
+}
+
+func TestNDJSONStreamEmpty(t *testing.T) {
+	response := httptest.NewRecorder()
+	stream := NewNDJSONStream(response, httptest.NewRequest(http.MethodGet, "/rows", nil))
+
+	if err := stream.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if response.Code != http.StatusOK || response.Body.Len() != 0 {
+		t.Fatalf("status = %d, body = %q; want empty 200", response.Code, response.Body.String())
+	}
+	if got := response.Header().Get("Content-Type"); got != NDJSONContentType {
+		t.Fatalf("Content-Type = %q, want %q", got, NDJSONContentType)
+	}
+}
+
+func TestNDJSONStreamFailBeforeFirstRow(t *testing.T) {
+	response := httptest.NewRecorder()
+	stream := NewNDJSONStream(response, httptest.NewRequest(http.MethodGet, "/rows", nil))
+
+	stream.Fail("error scanning row", errors.New("boom"))
+
+	if response.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want %d", response.Code, http.StatusInternalServerError)
+	}
+	if got := response.Header().Get("Content-Type"); got != ProblemContentType {
+		t.Fatalf("Content-Type = %q, want %q", got, ProblemContentType)
+	}
+}
+
+func TestNDJSONStreamFailAfterFirstRowAborts(t *testing.T) {
+	response := httptest.NewRecorder()
+	stream := NewNDJSONStream(response, httptest.NewRequest(http.MethodGet, "/rows", nil))
+	if err := stream.Write(1); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	defer func() {
+		if err := recover(); err != http.ErrAbortHandler {
+			t.Fatalf("recover() = %v, want http.ErrAbortHandler", err)
+		}
+		if response.Code != http.StatusOK {
+			t.Fatalf("status = %d, want the %d already sent", response.Code, http.StatusOK)
+		}
+	}()
+	stream.Fail("error scanning row", errors.New("boom"))
+}
@@ -0,0 +1,326 @@
+// Package pagination pages through the lists that endpoints return.
+//
+// Every list endpoint reads the same parameters: limit, and one of offset,
+// page, or cursor. Parse turns them into a Request, the endpoint reads the
+// rows it names, and NewPage or Slice wraps those rows in a Page: the
+// standard response envelope, which links to the pages before and after it.
+// WriteJSON and WriteTable write the page with the same links in an RFC 8288
+// Link header, and the next page's cursor in an X-Next-Cursor header.
+//
+// The links carry cursors, which record the position of a page and the
+// filters of the query it belongs to. They are signed, so that clients can
+// neither forge them nor use them with another query.
+package pagination
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/chnm/apiary/internal/httpx"
+)
+
+// The parameters that select a page.
+const (
+	LimitParameter  = "limit"
+	OffsetParameter = "offset"
+	PageParameter   = "page"
+	CursorParameter = "cursor"
+)
+
+// maxOffset bounds offsets, including those that pages work out to, so that
+// they cannot overflow.
+const maxOffset = math.MaxInt32
+
+// Options bound the pages of an endpoint.
+type Options struct {
+	DefaultLimit int  // Rows in a page when the request sets no limit; 0 for the whole list
+	MaxLimit     int  // Most rows a request may ask for
+	Streams      bool // The endpoint streams NDJSON, which is unlimited unless the request sets a limit
+}
+
+var (
+	// Records is for endpoints that return long lists of records.
+	Records = Options{DefaultLimit: 100, MaxLimit: 5000}
+	// Lists is for short lists that clients usually want whole, such as
+	// the values of a filter. They are paged only on request.
+	Lists = Options{MaxLimit: 5000}
+)
+
+// WithDefaultLimit returns o with a different default page size.
+func (o Options) WithDefaultLimit(limit int) Options {
+	o.DefaultLimit = limit
+	return o
+}
+
+// Streaming returns o for an endpoint that streams NDJSON.
+func (o Options) Streaming() Options {
+	o.Streams = true
+	return o
+}
+
+// Request is the page that a request asks for.
+type Request struct {
+	Limit  int // Rows in the page; 0 for every row from Offset on
+	Offset int // Rows before the page
+
+	after  json.RawMessage // Key of the row before the page, if the cursor has one
+	path   string
+	query  url.Values // The request's query without the paging parameters
+	limit  string     // The limit parameter, kept in links
+	signer *Signer
+}
+
+// Parse reads the page that r asks for. The error is an
+// *httpx.ParameterError. Streams are meant for exporting everything that
+// matches, so they are only limited on request.
+func Parse(r *http.Request, options Options) (Request, error) {
+	values := r.URL.Query()
+	q := Request{
+		Limit:  options.DefaultLimit,
+		path:   r.URL.Path,
+		query:  withoutPaging(values),
+		limit:  values.Get(LimitParameter),
+		signer: signerFor(r),
+	}
+	if options.Streams && httpx.Format(r) == httpx.FormatNDJSON {
+		q.Limit = 0
+	}
+
+	if q.limit != "" {
+		limit, err := strconv.Atoi(q.limit)
+		if err != nil || limit < 1 || limit > options.MaxLimit {
+			return Request{}, httpx.InvalidParameterf(LimitParameter, "limit must be an integer from 1 to %d", options.MaxLimit)
+		}
+		q.Limit = limit
+	}
+
+	offset, page, cursor := values.Get(OffsetParameter), values.Get(PageParameter), values.Get(CursorParameter)
+	switch {
+	case cursor != "" && (offset != "" || page != ""):
+		return Request{}, httpx.InvalidParameterf(CursorParameter, "cursor cannot be combined with offset or page")
+	case offset != "" && page != "":
+		return Request{}, httpx.InvalidParameterf(PageParameter, "page cannot be combined with offset")
+	}
+
+	if offset != "" {
+		n, err := strconv.Atoi(offset)
+		if err != nil || n < 0 || n > maxOffset {
+			return Request{}, httpx.InvalidParameterf(OffsetParameter, "offset must be an integer from 0 to %d", maxOffset)
+		}
+		q.Offset = n
+	}
+
+	if page != "" {
+		if q.Limit == 0 {
+			// A page of a list that is otherwise whole is as long as
+			// allowed.
+			q.Limit = options.MaxLimit
+		}
+		n, err := strconv.Atoi(page)
+		if err != nil || n < 1 || n-1 > maxOffset/q.Limit {
+			return Request{}, httpx.InvalidParameterf(PageParameter, "page must be an integer from 1 to %d", maxOffset/q.Limit+1)
+		}
+		q.Offset = (n - 1) * q.Limit
+	}
+
+	if cursor != "" {
+		c, err := q.signer.decode(cursor)
+		if err != nil || c.Query != queryHash(q.path, q.query) {
+			return Request{}, httpx.InvalidParameterf(CursorParameter, "cursor is not one this query returned")
+		}
+		q.Offset = c.Offset
+		q.after = c.After
+	}
+
+	return q, nil
+}
+
+// withoutPaging returns a copy of values without the parameters that select
+// a page.
+func withoutPaging(values url.Values) url.Values {
+	filters := make(url.Values, len(values))
+	for name, value := range values {
+		switch name {
+		case LimitParameter, OffsetParameter, PageParameter, CursorParameter:
+		default:
+			filters[name] = value
+		}
+	}
+	return filters
+}
+
+// Fetch is how many rows to read for the page: one more than Limit, so that
+// NewPage can tell whether another page follows. It is 0 for no limit.
+func (q Request) Fetch() int {
+	if q.Limit == 0 {
+		return 0
+	}
+	return q.Limit + 1
+}
+
+// After reports whether the request continues from the key of a row, as
+// returned by the key function given to NewKeyedPage, and decodes the key
+// into key. Queries should read the rows after the key rather than skipping
+// Offset rows.
+func (q Request) After(key any) (bool, error) {
+	if len(q.after) == 0 {
+		return false, nil
+	}
+	if err := json.Unmarshal(q.after, key); err != nil {
+		return false, fmt.Errorf("decode cursor key: %w", err)
+	}
+	return true, nil
+}
+
+// Page is a page of a list and the links to its neighbors. Next and Prev are
+// relative to the server, and empty at either end of the list.
+type Page[T any] struct {
+	Data       []T    `json:"data"`
+	HasMore    bool   `json:"has_more"`
+	Next       string `json:"next,omitempty"`
+	Prev       string `json:"prev,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"` // The cursor in Next
+}
+
+// NewPage returns the page of rows, which are up to Fetch rows read for q.
+func NewPage[T any](q Request, rows []T) Page[T] {
+	return NewKeyedPage(q, rows, nil)
+}
+
+// NewKeyedPage is NewPage for lists that are read from the key of the last
+// row of the previous page rather than by offset. The key must marshal to
+// JSON, and the next page's Request.After returns it.
+func NewKeyedPage[T any](q Request, rows []T, key func(T) any) Page[T] {
+	page := Page[T]{Data: rows}
+	if page.Data == nil {
+		page.Data = []T{}
+	}
+	if q.Limit > 0 && len(page.Data) > q.Limit {
+		page.Data = page.Data[:q.Limit]
+		page.HasMore = true
+	}
+
+	if page.HasMore {
+		next := cursor{Query: queryHash(q.path, q.query), Offset: q.Offset + len(page.Data)}
+		if key != nil {
+			// The key is a value the endpoint built from its own row, so
+			// it marshals.
+			next.After, _ = json.Marshal(key(page.Data[len(page.Data)-1]))
+		}
+		page.NextCursor = q.signer.encode(next)
+		page.Next = q.link(page.NextCursor)
+	}
+	if q.Offset > 0 {
+		// Earlier pages are always found by offset, since keys only lead
+		// forward.
+		prev := ""
+		if offset := q.Offset - q.Limit; q.Limit > 0 && offset > 0 {
+			prev = q.signer.encode(cursor{Query: queryHash(q.path, q.query), Offset: offset})
+		}
+		page.Prev = q.link(prev)
+	}
+	return page
+}
+
+// Slice returns the page of list, which holds every row.
+func Slice[T any](q Request, list []T) Page[T] {
+	if q.Offset >= len(list) {
+		return NewPage(q, list[len(list):])
+	}
+	list = list[q.Offset:]
+	if fetch := q.Fetch(); fetch > 0 && len(list) > fetch {
+		list = list[:fetch]
+	}
+	return NewPage(q, list)
+}
+
+// link returns the path and query of the page at cursor, or of the first page
+// if cursor is empty.
+func (q Request) link(cursor string) string {
+	values := make(url.Values, len(q.query)+2)
+	for name, value := range q.query {
+		values[name] = value
+	}
+	if q.limit != "" {
+		values.Set(LimitParameter, q.limit)
+	}
+	if cursor != "" {
+		values.Set(CursorParameter, cursor)
+	}
+	if len(values) == 0 {
+		return q.path
+	}
+	return q.path + "?" + values.Encode()
+}
+
+// NextCursorHeader carries the next page's cursor, for clients of formats
+// such as CSV that have no room for the envelope.
+const NextCursorHeader = "X-Next-Cursor"
+
+// SetLinks sets the Link header to the pages before and after page, and the
+// NextCursorHeader.
+func SetLinks[T any](w http.ResponseWriter, page Page[T]) {
+	if page.NextCursor != "" {
+		w.Header().Set(NextCursorHeader, page.NextCursor)
+	}
+	var links []string
+	if page.Next != "" {
+		links = append(links, fmt.Sprintf(`<%s>; rel="next"`, page.Next))
+	}
+	if page.Prev != "" {
+		links = append(links, fmt.Sprintf(`<%s>; rel="prev"`, page.Prev))
+	}
+	if len(links) > 0 {
+		w.Header().Set("Link", strings.Join(links, ", "))
+	}
+}
+
+// WriteJSON writes page as JSON, with the headers that SetLinks sets.
+func WriteJSON[T any](w http.ResponseWriter, r *http.Request, page Page[T]) {
+	SetLinks(w, page)
+	httpx.WriteJSON(w, r, page)
+}
+
+// WriteTable writes page as JSON, or its rows as CSV when the client asked for
+// CSV, with the headers that SetLinks sets in either case.
+func WriteTable[T any](w http.ResponseWriter, r *http.Request, page Page[T]) {
+	SetLinks(w, page)
+	w.Header().Add("Vary", "Accept")
+	if httpx.Format(r) == httpx.FormatCSV {
+		httpx.WriteCSV(w, r, page.Data)
+		return
+	}
+	httpx.WriteJSON(w, r, page)
+}
+
+// Parameters describes the paging parameters for the endpoint catalog.
+func Parameters(options Options) []httpx.Parameter {
+	limit := httpx.Parameter{
+		Name:        LimitParameter,
+		In:          httpx.InQuery,
+		Type:        httpx.TypeInteger,
+		Minimum:     httpx.Bound(1),
+		Maximum:     httpx.Bound(options.MaxLimit),
+		Description: "Most rows to return",
+	}
+	switch {
+	case options.DefaultLimit == 0:
+		limit.Description = "Most rows to return; the whole list by default"
+	case options.Streams:
+		limit.Default = options.DefaultLimit
+		limit.Description = "Most rows to return; streams are unlimited by default"
+	default:
+		limit.Default = options.DefaultLimit
+	}
+	return []httpx.Parameter{
+		limit,
+		{Name: OffsetParameter, In: httpx.InQuery, Type: httpx.TypeInteger, Default: 0, Minimum: httpx.Bound(0), Maximum: httpx.Bound(maxOffset), Description: "Rows to skip; cannot be combined with page or cursor"},
+		{Name: PageParameter, In: httpx.InQuery, Type: httpx.TypeInteger, Minimum: httpx.Bound(1), Description: "Page of limit rows, counting from 1; cannot be combined with offset or cursor"},
+		{Name: CursorParameter, In: httpx.InQuery, Type: httpx.TypeString, Description: "Opaque position from the next and prev links or next_cursor of a previous response, for the same query"},
+	}
+}
@@ -0,0 +1,266 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/chnm/apiary/internal/httpx"
+)
+
+var testOptions = Options{DefaultLimit: 10, MaxLimit: 50}
+
+func parse(t *testing.T, target string, options Options) Request {
+	t.Helper()
+	q, err := Parse(httptest.NewRequest(http.MethodGet, target, nil), options)
+	if err != nil {
+		t.Fatalf("Parse(%s): %v", target, err)
+	}
+	return q
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name       string
+		target     string
+		options    Options
+		wantLimit  int
+		wantOffset int
+	}{
+		{name: "defaults", target: "/rows", options: testOptions, wantLimit: 10},
+		{name: "limit and offset", target: "/rows?limit=5&offset=20", options: testOptions, wantLimit: 5, wantOffset: 20},
+		{name: "page", target: "/rows?limit=5&page=3", options: testOptions, wantLimit: 5, wantOffset: 10},
+		{name: "page of the default size", target: "/rows?page=2", options: testOptions, wantLimit: 10, wantOffset: 10},
+		{name: "whole list", target: "/rows", options: Lists},
+		{name: "page of a whole list", target: "/rows?page=2", options: Lists, wantLimit: Lists.MaxLimit, wantOffset: Lists.MaxLimit},
+		{name: "unlimited stream", target: "/rows?format=ndjson", options: testOptions.Streaming()},
+		{name: "limited stream", target: "/rows?format=ndjson&limit=3", options: testOptions.Streaming(), wantLimit: 3},
+		{name: "ndjson from an endpoint that does not stream", target: "/rows?format=ndjson", options: testOptions, wantLimit: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := parse(t, tt.target, tt.options)
+			if q.Limit != tt.wantLimit || q.Offset != tt.wantOffset {
+				t.Fatalf("limit %d, offset %d; want %d, %d", q.Limit, q.Offset, tt.wantLimit, tt.wantOffset)
+			}
+		})
+	}
+}
+
+func TestParseRejectsInvalidParameters(t *testing.T) {
+	tests := []struct {
+		target    string
+		parameter string
+	}{
+		{target: "/rows?limit=0", parameter: LimitParameter},
+		{target: "/rows?limit=51", parameter: LimitParameter},
+		{target: "/rows?limit=ten", parameter: LimitParameter},
+		{target: "/rows?offset=-1", parameter: OffsetParameter},
+		{target: "/rows?offset=ten", parameter: OffsetParameter},
+		{target: "/rows?offset=99999999999", parameter: OffsetParameter},
+		{target: "/rows?page=0", parameter: PageParameter},
+		{target: "/rows?page=999999999999", parameter: PageParameter},
+		{target: "/rows?page=2&offset=10", parameter: PageParameter},
+		{target: "/rows?cursor=abc&offset=10", parameter: CursorParameter},
+		{target: "/rows?cursor=abc&page=2", parameter: CursorParameter},
+		{target: "/rows?cursor=not-a-cursor", parameter: CursorParameter},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			_, err := Parse(httptest.NewRequest(http.MethodGet, tt.target, nil), testOptions)
+			var parameterErr *httpx.ParameterError
+			if !errors.As(err, &parameterErr) || parameterErr.Parameter != tt.parameter {
+				t.Fatalf("Parse() error = %v, want a %s parameter error", err, tt.parameter)
+			}
+		})
+	}
+}
+
+// follow parses the request that link makes.
+func follow(t *testing.T, link string, options Options) Request {
+	t.Helper()
+	if link == "" {
+		t.Fatal("link is empty")
+	}
+	return parse(t, link, options)
+}
+
+func TestPagesLinkToTheirNeighbors(t *testing.T) {
+	rows := []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}
+	options := Options{DefaultLimit: 5, MaxLimit: 50}
+
+	first := Slice(parse(t, "/rows?year=1665", options), rows)
+	if !reflect.DeepEqual(first.Data, []int{0, 1, 2, 3, 4}) || !first.HasMore || first.Prev != "" {
+		t.Fatalf("first page = %+v", first)
+	}
+	next, err := url.Parse(first.Next)
+	if err != nil {
+		t.Fatalf("parse next link: %v", err)
+	}
+	if next.Path != "/rows" || next.Query().Get("year") != "1665" || next.Query().Get(CursorParameter) != first.NextCursor {
+		t.Fatalf("next link = %s, want /rows with the filter and next_cursor", first.Next)
+	}
+
+	second := Slice(follow(t, first.Next, options), rows)
+	if !reflect.DeepEqual(second.Data, []int{5, 6, 7, 8, 9}) || !second.HasMore {
+		t.Fatalf("second page = %+v", second)
+	}
+	if second.Prev != "/rows?year=1665" {
+		t.Fatalf("second page prev = %q, want the first page", second.Prev)
+	}
+
+	last := Slice(follow(t, second.Next, options), rows)
+	if !reflect.DeepEqual(last.Data, []int{10, 11}) || last.HasMore || last.Next != "" || last.NextCursor != "" {
+		t.Fatalf("last page = %+v", last)
+	}
+	if back := Slice(follow(t, last.Prev, options), rows); !reflect.DeepEqual(back.Data, second.Data) {
+		t.Fatalf("last page prev = %v, want the second page", back.Data)
+	}
+}
+
+func TestLinksKeepTheClientsLimit(t *testing.T) {
+	page := Slice(parse(t, "/rows?limit=2&offset=2", testOptions), []int{0, 1, 2, 3, 4, 5})
+	for _, link := range []string{page.Next, page.Prev} {
+		u, err := url.Parse(link)
+		if err != nil {
+			t.Fatalf("parse link %q: %v", link, err)
+		}
+		if got := u.Query().Get(LimitParameter); got != "2" {
+			t.Errorf("link %s has limit %q, want 2", link, got)
+		}
+		if u.Query().Has(OffsetParameter) {
+			t.Errorf("link %s keeps the offset parameter", link)
+		}
+	}
+}
+
+func TestKeyedPages(t *testing.T) {
+	type row struct{ Year, Week int }
+	type key struct{ Year, Week int }
+	q := parse(t, "/rows?limit=2", testOptions)
+	if after, err := q.After(&key{}); after || err != nil {
+		t.Fatalf("first page After() = %t, %v; want false", after, err)
+	}
+
+	page := NewKeyedPage(q, []row{{1665, 1}, {1665, 2}, {1665, 3}}, func(r row) any { return key(r) })
+
+	next := follow(t, page.Next, testOptions)
+	var after key
+	if ok, err := next.After(&after); !ok || err != nil || after != (key{1665, 2}) {
+		t.Fatalf("next page After() = %t, %+v, %v; want the key of the last row", ok, after, err)
+	}
+	if next.Offset != 2 {
+		t.Fatalf("next page offset = %d, want 2", next.Offset)
+	}
+}
+
+func TestCursorsBelongToTheirQuery(t *testing.T) {
+	page := Slice(parse(t, "/rows?year=1665&format=csv", testOptions), make([]int, 20))
+	cursor := url.QueryEscape(page.NextCursor)
+
+	// The format does not change which rows the query selects.
+	parse(t, "/rows?year=1665&cursor="+cursor, testOptions)
+
+	for _, target := range []string{
+		"/rows?year=1666&cursor=" + cursor,
+		"/rows?cursor=" + cursor,
+		"/other?year=1665&cursor=" + cursor,
+	} {
+		if _, err := Parse(httptest.NewRequest(http.MethodGet, target, nil), testOptions); err == nil {
+			t.Errorf("Parse(%s) accepted a cursor from another query", target)
+		}
+	}
+}
+
+func TestCursorsAreSigned(t *testing.T) {
+	signer := NewSigner("a secret long enough to sign cursors with")
+	value := signer.encode(cursor{Query: "q", Offset: 100})
+
+	if c, err := signer.decode(value); err != nil || c.Offset != 100 {
+		t.Fatalf("decode() = %+v, %v; want offset 100", c, err)
+	}
+	if _, err := NewSigner("another secret").decode(value); err == nil {
+		t.Fatal("a cursor decoded with another key")
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		t.Fatalf("decode cursor: %v", err)
+	}
+	payload, _ := json.Marshal(cursor{Query: "q", Offset: 1000})
+	forged := append(payload, raw[len(raw)-macSize:]...)
+	if _, err := signer.decode(base64.RawURLEncoding.EncodeToString(forged)); err == nil {
+		t.Fatal("a cursor with a changed offset decoded")
+	}
+}
+
+func TestSignerMiddleware(t *testing.T) {
+	signer := NewSigner("a secret long enough to sign cursors with")
+	var page Page[int]
+	handler := signer.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q, err := Parse(r, testOptions)
+		if err != nil {
+			t.Fatalf("Parse: %v", err)
+		}
+		page = Slice(q, make([]int, 20))
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/rows", nil))
+
+	if _, err := signer.decode(page.NextCursor); err != nil {
+		t.Fatalf("cursor was not signed by the middleware's signer: %v", err)
+	}
+}
+
+func TestWriteTable(t *testing.T) {
+	type row struct {
+		ID int `json:"id"`
+	}
+	q := parse(t, "/rows?limit=1&offset=1", testOptions)
+	page := Slice(q, []row{{1}, {2}, {3}})
+
+	response := httptest.NewRecorder()
+	WriteTable(response, httptest.NewRequest(http.MethodGet, "/rows?limit=1&offset=1", nil), page)
+	var body Page[row]
+	if err := json.Unmarshal(response.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode JSON: %v", err)
+	}
+	if !reflect.DeepEqual(body, page) {
+		t.Fatalf("JSON = %+v, want %+v", body, page)
+	}
+	link := response.Header().Get("Link")
+	if !strings.Contains(link, "<"+page.Next+`>; rel="next"`) || !strings.Contains(link, "<"+page.Prev+`>; rel="prev"`) {
+		t.Fatalf("Link = %q, want next and prev links", link)
+	}
+
+	response = httptest.NewRecorder()
+	WriteTable(response, httptest.NewRequest(http.MethodGet, "/rows?limit=1&offset=1&format=csv", nil), page)
+	if got := response.Body.String(); got != "id\n2\n" {
+		t.Fatalf("CSV = %q, want the page's rows", got)
+	}
+	if response.Header().Get("Link") != link {
+		t.Fatalf("CSV Link = %q, want %q", response.Header().Get("Link"), link)
+	}
+	if got := response.Header().Get(NextCursorHeader); got != page.NextCursor {
+		t.Fatalf("%s = %q, want %q", NextCursorHeader, got, page.NextCursor)
+	}
+}
+
+func TestEmptyPage(t *testing.T) {
+	response := httptest.NewRecorder()
+	WriteJSON(response, httptest.NewRequest(http.MethodGet, "/rows", nil), Slice(parse(t, "/rows", testOptions), []int(nil)))
+
+	if got := strings.TrimSpace(response.Body.String()); got != `{"data":[],"has_more":false}` {
+		t.Fatalf("body = %s, want an empty page", got)
+	}
+	if link, cursor := response.Header().Get("Link"), response.Header().Get(NextCursorHeader); link != "" || cursor != "" {
+		t.Fatalf("Link = %q and %s = %q, want neither", link, NextCursorHeader, cursor)
+	}
+}
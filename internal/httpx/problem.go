@@ -0,0 +1,208 @@
+package httpx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+)
+
+// ProblemContentType is the media type of error responses (RFC 7807).
+const ProblemContentType = "application/problem+json"
+
+// Stable error codes. Clients may rely on these values; add new codes rather
+// than changing the meaning of existing ones.
+const (
+	CodeInvalidParameter = "invalid_parameter"
+	CodeMissingParameter = "missing_parameter"
+	CodeNotFound         = "not_found"
+	CodeRouteNotFound    = "route_not_found"
+	CodeMethodNotAllowed = "method_not_allowed"
+	CodeTimeout          = "timeout"
+	CodeInternal         = "internal_error"
+	CodeUnavailable      = "unavailable"
+	CodeUnauthorized     = "unauthorized"
+	CodeForbidden        = "forbidden"
+	CodeRateLimited      = "rate_limited"
+)
+
+// Problem is an RFC 7807 problem details object. Code, Parameter, and
+// RequestID are extension members.
+type Problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Instance  string `json:"instance,omitempty"`
+	Code      string `json:"code"`
+	Parameter string `json:"parameter,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// WriteProblem writes p as an application/problem+json response. The type,
+// title, instance, and request ID are filled in when they are empty.
+func WriteProblem(w http.ResponseWriter, r *http.Request, p Problem) {
+	if p.Type == "" {
+		p.Type = "urn:apiary:problem:" + p.Code
+	}
+	if p.Title == "" {
+		p.Title = http.StatusText(p.Status)
+	}
+	if p.Instance == "" {
+		p.Instance = r.URL.Path
+	}
+	if p.RequestID == "" {
+		p.RequestID = RequestIDFromContext(r.Context())
+	}
+
+	response, err := json.Marshal(p)
+	if err != nil {
+		// A Problem only holds strings and an integer, so this cannot
+		// happen; fall back to a plain response rather than recursing.
+		slog.ErrorContext(r.Context(), "error marshaling problem response", "error", err)
+		http.Error(w, http.StatusText(p.Status), p.Status)
+		return
+	}
+	w.Header().Del("Content-Length")
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(p.Status)
+	if _, err := w.Write(response); err != nil {
+		slog.WarnContext(r.Context(), "error writing problem response", "error", err)
+	}
+}
+
+// InvalidParameter reports a malformed or out-of-range parameter value.
+func InvalidParameter(w http.ResponseWriter, r *http.Request, parameter, detail string) {
+	WriteProblem(w, r, Problem{
+		Status:    http.StatusBadRequest,
+		Code:      CodeInvalidParameter,
+		Parameter: parameter,
+		Detail:    detail,
+	})
+}
+
+// MissingParameter reports a required parameter that was not supplied.
+func MissingParameter(w http.ResponseWriter, r *http.Request, parameter string) {
+	WriteProblem(w, r, Problem{
+		Status:    http.StatusBadRequest,
+		Code:      CodeMissingParameter,
+		Parameter: parameter,
+		Detail:    fmt.Sprintf("the %s parameter is required", parameter),
+	})
+}
+
+// NotFound reports that the requested record does not exist.
+func NotFound(w http.ResponseWriter, r *http.Request, detail string) {
+	WriteProblem(w, r, Problem{
+		Status: http.StatusNotFound,
+		Code:   CodeNotFound,
+		Detail: detail,
+	})
+}
+
+// Unauthorized reports a request without valid credentials and asks for a
+// bearer token.
+func Unauthorized(w http.ResponseWriter, r *http.Request, detail string) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="apiary"`)
+	WriteProblem(w, r, Problem{
+		Status: http.StatusUnauthorized,
+		Code:   CodeUnauthorized,
+		Detail: detail,
+	})
+}
+
+// InternalServerError logs an internal error and returns a generic response.
+// The operation and error are logged with the request's context, and so with
+// its request ID, but never sent to the client. Errors caused by the request
+// running out of time are reported with GatewayTimeout instead.
+func InternalServerError(w http.ResponseWriter, r *http.Request, operation string, err error) {
+	if TimedOut(r, err) {
+		slog.WarnContext(r.Context(), operation, "error", err, "timed_out", true)
+		GatewayTimeout(w, r)
+		return
+	}
+	slog.ErrorContext(r.Context(), operation, "error", err)
+	WriteProblem(w, r, Problem{
+		Status: http.StatusInternalServerError,
+		Code:   CodeInternal,
+	})
+}
+
+// queryCanceled is the SQLSTATE of a query stopped by statement_timeout or a
+// cancel request.
+const queryCanceled = "57014"
+
+// TimedOut reports whether err was caused by the request's deadline passing
+// or by the database stopping a query that ran too long.
+func TimedOut(r *http.Request, err error) bool {
+	if errors.Is(r.Context().Err(), context.DeadlineExceeded) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var sqlErr interface{ SQLState() string }
+	return errors.As(err, &sqlErr) && sqlErr.SQLState() == queryCanceled
+}
+
+// GatewayTimeout reports that the request ran out of time before the database
+// answered.
+func GatewayTimeout(w http.ResponseWriter, r *http.Request) {
+	WriteProblem(w, r, Problem{
+		Status: http.StatusGatewayTimeout,
+		Code:   CodeTimeout,
+		Detail: "the request took longer than this endpoint allows; try again with narrower filters or a smaller limit",
+	})
+}
+
+// ParameterError describes an invalid request parameter. Parsers return it so
+// that handlers can report which parameter was at fault.
+type ParameterError struct {
+	Parameter string
+	Detail    string
+	Missing   bool
+}
+
+func (e *ParameterError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Parameter, e.Detail)
+}
+
+// InvalidParameterf returns a ParameterError for parameter with a formatted
+// detail message.
+func InvalidParameterf(parameter, format string, args ...any) error {
+	return &ParameterError{Parameter: parameter, Detail: fmt.Sprintf(format, args...)}
+}
+
+// MissingParameterError returns a ParameterError for a required parameter that
+// was not supplied.
+func MissingParameterError(parameter string) error {
+	return &ParameterError{
+		Parameter: parameter,
+		Detail:    fmt.Sprintf("the %s parameter is required", parameter),
+		Missing:   true,
+	}
+}
+
+// BadRequest writes a 400 response for err. A ParameterError is reported with
+// its parameter name; any other error is reported as an invalid request.
+func BadRequest(w http.ResponseWriter, r *http.Request, err error) {
+	var parameterErr *ParameterError
+	if !errors.As(err, &parameterErr) {
+		WriteProblem(w, r, Problem{
+			Status: http.StatusBadRequest,
+			Code:   CodeInvalidParameter,
+			Detail: err.Error(),
+		})
+		return
+	}
+	code := CodeInvalidParameter
+	if parameterErr.Missing {
+		code = CodeMissingParameter
+	}
+	WriteProblem(w, r, Problem{
+		Status:    http.StatusBadRequest,
+		Code:      code,
+		Parameter: parameterErr.Parameter,
+		Detail:    parameterErr.Detail,
+	})
+}
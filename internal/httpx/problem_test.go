@@ -0,0 +1,129 @@
+package httpx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func decodeProblem(t *testing.T, response *httptest.ResponseRecorder) Problem {
+	t.Helper()
+	if contentType := response.Header().Get("Content-Type"); contentType != ProblemContentType {
+		t.Fatalf("Content-Type = %q, want %q", contentType, ProblemContentType)
+	}
+	var problem Problem
+	if err := json.Unmarshal(response.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("decode problem: %v", err)
+	}
+	return problem
+}
+
+func TestWriteProblemFillsDefaults(t *testing.T) {
+	request := httptest.NewRequest(http.MethodGet, "/bom/bills?start-year=x", nil)
+	request = request.WithContext(ContextWithRequestID(request.Context(), "req-1"))
+	response := httptest.NewRecorder()
+
+	InvalidParameter(response, request, "start-year", "start-year must be an integer")
+
+	if response.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", response.Code, http.StatusBadRequest)
+	}
+	want := Problem{
+		Type:      "urn:apiary:problem:invalid_parameter",
+		Title:     "Bad Request",
+		Status:    http.StatusBadRequest,
+		Detail:    "start-year must be an integer",
+		Instance:  "/bom/bills",
+		Code:      CodeInvalidParameter,
+		Parameter: "start-year",
+		RequestID: "req-1",
+	}
+	if got := decodeProblem(t, response); got != want {
+		t.Fatalf("problem = %+v, want %+v", got, want)
+	}
+}
+
+func TestInternalServerErrorHidesCause(t *testing.T) {
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	response := httptest.NewRecorder()
+
+	InternalServerError(response, request, "query things", errors.New("password authentication failed"))
+
+	problem := decodeProblem(t, response)
+	if problem.Status != http.StatusInternalServerError || problem.Code != CodeInternal {
+		t.Fatalf("problem = %+v", problem)
+	}
+	if problem.Detail != "" {
+		t.Fatalf("detail = %q, want no detail", problem.Detail)
+	}
+}
+
+// sqlError is an error with a SQLSTATE, like pgconn.PgError.
+type sqlError struct{ code string }
+
+func (e sqlError) Error() string    { return "ERROR: canceling statement (SQLSTATE " + e.code + ")" }
+func (e sqlError) SQLState() string { return e.code }
+
+func TestInternalServerErrorReportsTimeouts(t *testing.T) {
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		err     error
+		timeout bool
+	}{
+		{name: "request deadline", ctx: expired, err: errors.New("conn closed"), timeout: true},
+		{name: "statement timeout", ctx: context.Background(), err: fmt.Errorf("query: %w", sqlError{queryCanceled}), timeout: true},
+		{name: "wrapped deadline", ctx: context.Background(), err: fmt.Errorf("scan: %w", context.DeadlineExceeded), timeout: true},
+		{name: "other SQL error", ctx: context.Background(), err: sqlError{"42P01"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/bom/shapefiles", nil).WithContext(tt.ctx)
+			response := httptest.NewRecorder()
+
+			InternalServerError(response, request, "query shapefiles", tt.err)
+
+			problem := decodeProblem(t, response)
+			switch {
+			case tt.timeout && (problem.Status != http.StatusGatewayTimeout || problem.Code != CodeTimeout):
+				t.Fatalf("problem = %+v, want a 504 timeout", problem)
+			case !tt.timeout && problem.Status != http.StatusInternalServerError:
+				t.Fatalf("problem = %+v, want a 500", problem)
+			}
+		})
+	}
+}
+
+func TestBadRequest(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		code      string
+		parameter string
+	}{
+		{"invalid", InvalidParameterf("limit", "limit must be at least %d", 1), CodeInvalidParameter, "limit"},
+		{"missing", MissingParameterError("year"), CodeMissingParameter, "year"},
+		{"wrapped", errors.Join(errors.New("context"), MissingParameterError("year")), CodeMissingParameter, "year"},
+		{"other", errors.New("bad request"), CodeInvalidParameter, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := httptest.NewRecorder()
+			BadRequest(response, httptest.NewRequest(http.MethodGet, "/", nil), tt.err)
+
+			problem := decodeProblem(t, response)
+			if problem.Status != http.StatusBadRequest || problem.Code != tt.code || problem.Parameter != tt.parameter {
+				t.Fatalf("problem = %+v, want code %q and parameter %q", problem, tt.code, tt.parameter)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package httpx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name     string
+		incoming string
+		keep     bool
+	}{
+		{name: "generated", incoming: "", keep: false},
+		{name: "propagated", incoming: "abc-123_x.y:z", keep: true},
+		{name: "invalid characters", incoming: "bad id\n", keep: false},
+		{name: "too long", incoming: strings.Repeat("a", maxRequestIDLength+1), keep: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var seen string
+			handler := RequestID(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				seen = RequestIDFromContext(r.Context())
+			}))
+			request := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.incoming != "" {
+				request.Header.Set(RequestIDHeader, tt.incoming)
+			}
+			response := httptest.NewRecorder()
+
+			handler.ServeHTTP(response, request)
+
+			header := response.Header().Get(RequestIDHeader)
+			if seen == "" || header != seen {
+				t.Fatalf("context ID = %q, header = %q", seen, header)
+			}
+			if tt.keep != (seen == tt.incoming) {
+				t.Fatalf("request ID = %q, incoming %q, want kept = %v", seen, tt.incoming, tt.keep)
+			}
+		})
+	}
+}
@@ -2,30 +2,24 @@ package httpx
 
 import (
 	"encoding/json"
-	"log"
+	"log/slog"
 	"net/http"
 	"strconv"
 	"strings"
 )
 
-// InternalServerError logs an internal error and returns a generic response.
-func InternalServerError(w http.ResponseWriter, operation string, err error) {
-	log.Printf("%s: %v", operation, err)
-	http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
-}
-
 // WriteJSON marshals value before writing headers so encoding errors can still
 // produce a generic error response.
-func WriteJSON(w http.ResponseWriter, value any) {
+func WriteJSON(w http.ResponseWriter, r *http.Request, value any) {
 	response, err := json.Marshal(value)
 	if err != nil {
-		InternalServerError(w, "error marshaling JSON response", err)
+		InternalServerError(w, r, "error marshaling JSON response", err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if _, err := w.Write(response); err != nil {
-		log.Printf("error writing JSON response: %v", err)
+		slog.WarnContext(r.Context(), "error writing JSON response", "error", err)
 	}
 }
 
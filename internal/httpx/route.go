@@ -0,0 +1,38 @@
+package httpx
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// Declaration is a handler that wraps a route's handler to declare a setting
+// of the route, such as its cache policy or time limit, for middleware to
+// find. Declarations from different packages stack in any order, since each
+// can be unwrapped to reach the ones beneath it.
+type Declaration interface {
+	http.Handler
+	Unwrap() http.Handler
+}
+
+// RouteDeclaration returns the first handler of type T among route's handler
+// and the handlers that its Declarations wrap, and false if there is none or
+// route is nil.
+func RouteDeclaration[T http.Handler](route *mux.Route) (T, bool) {
+	var h http.Handler
+	if route != nil {
+		h = route.GetHandler()
+	}
+	for h != nil {
+		if found, ok := h.(T); ok {
+			return found, true
+		}
+		declaration, ok := h.(Declaration)
+		if !ok {
+			break
+		}
+		h = declaration.Unwrap()
+	}
+	var zero T
+	return zero, false
+}
@@ -0,0 +1,38 @@
+package httpx
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+type testDeclaration struct {
+	http.Handler
+	name string
+}
+
+func (h testDeclaration) Unwrap() http.Handler { return h.Handler }
+
+type otherDeclaration struct{ http.Handler }
+
+func (h otherDeclaration) Unwrap() http.Handler { return h.Handler }
+
+func TestRouteDeclaration(t *testing.T) {
+	handler := http.NotFoundHandler()
+	router := mux.NewRouter()
+	outer := router.Handle("/outer", testDeclaration{Handler: otherDeclaration{handler}, name: "outer"})
+	inner := router.Handle("/inner", otherDeclaration{testDeclaration{Handler: handler, name: "inner"}})
+	// A handler that cannot be unwrapped hides the declarations beneath it.
+	hidden := router.Handle("/hidden", http.StripPrefix("/", testDeclaration{Handler: handler, name: "hidden"}))
+
+	for _, tt := range []struct {
+		route *mux.Route
+		want  string
+	}{{outer, "outer"}, {inner, "inner"}, {hidden, ""}, {nil, ""}} {
+		found, ok := RouteDeclaration[testDeclaration](tt.route)
+		if found.name != tt.want || ok != (tt.want != "") {
+			t.Errorf("RouteDeclaration() = %q, %t, want %q", found.name, ok, tt.want)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync"
 	"time"
 )
 
@@ -14,27 +15,56 @@ type Server interface {
 	Shutdown(context.Context) error
 }
 
-// Run starts server and blocks until it exits or ctx is canceled. On
-// cancellation, Run waits for graceful shutdown and for the serving goroutine
-// to finish before returning.
+// Drainer is implemented by servers that need to stop taking new work before
+// they shut down, such as by failing readiness checks so that a load balancer
+// stops routing to them. Drain returns once the server is ready to shut down.
+type Drainer interface {
+	Drain()
+}
+
+// Run starts the servers together and blocks until ctx is canceled or any of
+// them stops. On cancellation, Run drains the servers that are Drainers, then
+// shuts all of them down gracefully. If a server stops by itself, such as when
+// its listener fails, the others are shut down gracefully without draining.
+// Either way Run waits for every serving goroutine to finish before
+// returning, and returns the errors of the servers and of shutting them down.
+// The shutdown timeout does not include the time spent draining.
 func Run(
 	ctx context.Context,
-	server Server,
 	shutdownTimeout time.Duration,
+	servers ...Server,
 ) error {
 	if shutdownTimeout <= 0 {
 		return errors.New("shutdown timeout must be positive")
 	}
+	if len(servers) == 0 {
+		return errors.New("no servers to run")
+	}
 
-	runErr := make(chan error, 1)
-	go func() {
-		runErr <- server.Run()
-	}()
+	type result struct {
+		server int
+		err    error
+	}
+	results := make(chan result, len(servers))
+	for i, server := range servers {
+		go func() {
+			results <- result{server: i, err: server.Run()}
+		}()
+	}
 
+	var errs []error
+	stopped := make([]bool, len(servers))
+	running := len(servers)
 	select {
-	case err := <-runErr:
-		return err
+	case r := <-results:
+		errs = append(errs, r.err)
+		stopped[r.server] = true
+		running--
 	case <-ctx.Done():
+		drain(servers)
+	}
+	if running == 0 {
+		return errors.Join(errs...)
 	}
 
 	shutdownCtx, cancel := context.WithTimeout(
@@ -43,17 +73,50 @@ func Run(
 	)
 	defer cancel()
 
-	if err := server.Shutdown(shutdownCtx); err != nil {
-		return fmt.Errorf("gracefully shut down server: %w", err)
+	shutdownErrs := make([]error, len(servers))
+	var wg sync.WaitGroup
+	for i, server := range servers {
+		if stopped[i] {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := server.Shutdown(shutdownCtx); err != nil {
+				shutdownErrs[i] = fmt.Errorf("gracefully shut down server: %w", err)
+			}
+		}()
 	}
+	wg.Wait()
+	errs = append(errs, shutdownErrs...)
 
-	select {
-	case err := <-runErr:
-		return err
-	case <-shutdownCtx.Done():
-		return fmt.Errorf(
-			"wait for server to stop: %w",
-			shutdownCtx.Err(),
-		)
+	for ; running > 0; running-- {
+		select {
+		case r := <-results:
+			errs = append(errs, r.err)
+		case <-shutdownCtx.Done():
+			errs = append(errs, fmt.Errorf(
+				"wait for server to stop: %w",
+				shutdownCtx.Err(),
+			))
+			return errors.Join(errs...)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// drain drains the servers that are Drainers at the same time, so that their
+// drain periods overlap, and returns once all are done.
+func drain(servers []Server) {
+	var wg sync.WaitGroup
+	for _, server := range servers {
+		if drainer, ok := server.(Drainer); ok {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				drainer.Drain()
+			}()
+		}
 	}
+	wg.Wait()
 }
@@ -43,7 +43,7 @@ func TestRunShutsDownAfterImmediateCancellation(t *testing.T) {
 	cancel()
 	server := newFakeServer()
 
-	if err := Run(ctx, server, time.Second); err != nil {
+	if err := Run(ctx, time.Second, server); err != nil {
 		t.Fatalf("Run returned an error: %v", err)
 	}
 	if !server.shutdownCalled.Load() {
@@ -57,7 +57,7 @@ func TestRunReturnsServerErrorWithoutShutdown(t *testing.T) {
 	server.runErr = wantErr
 	close(server.runFinished)
 
-	err := Run(context.Background(), server, time.Second)
+	err := Run(context.Background(), time.Second, server)
 	if !errors.Is(err, wantErr) {
 		t.Fatalf("Run error = %v, want %v", err, wantErr)
 	}
@@ -78,7 +78,7 @@ func TestRunWaitsForShutdownAndServingGoroutine(t *testing.T) {
 
 	result := make(chan error, 1)
 	go func() {
-		result <- Run(ctx, server, time.Second)
+		result <- Run(ctx, time.Second, server)
 	}()
 
 	<-server.runStarted
@@ -106,7 +106,7 @@ func TestRunBoundsGracefulShutdown(t *testing.T) {
 	}
 
 	cancel()
-	err := Run(ctx, server, 20*time.Millisecond)
+	err := Run(ctx, 20*time.Millisecond, server)
 	if !errors.Is(err, context.DeadlineExceeded) {
 		t.Fatalf(
 			"Run error = %v, want context.DeadlineExceeded",
@@ -115,9 +115,76 @@ func TestRunBoundsGracefulShutdown(t *testing.T) {
 	}
 }
 
+type drainingServer struct {
+	*fakeServer
+	drainedBeforeShutdown atomic.Bool
+}
+
+func (server *drainingServer) Drain() {
+	server.drainedBeforeShutdown.Store(!server.shutdownCalled.Load())
+}
+
+func TestRunDrainsBeforeShutdown(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	server := &drainingServer{fakeServer: newFakeServer()}
+
+	if err := Run(ctx, time.Second, server); err != nil {
+		t.Fatalf("Run returned an error: %v", err)
+	}
+	if !server.drainedBeforeShutdown.Load() {
+		t.Fatal("Drain was not called before Shutdown")
+	}
+}
+
 func TestRunRejectsNonPositiveShutdownTimeout(t *testing.T) {
-	err := Run(context.Background(), newFakeServer(), 0)
+	err := Run(context.Background(), 0, newFakeServer())
 	if err == nil {
 		t.Fatal("Run returned nil for a non-positive shutdown timeout")
 	}
 }
+
+func TestRunShutsDownEveryServer(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	api := &drainingServer{fakeServer: newFakeServer()}
+	admin := newFakeServer()
+
+	if err := Run(ctx, time.Second, api, admin); err != nil {
+		t.Fatalf("Run returned an error: %v", err)
+	}
+	if !api.drainedBeforeShutdown.Load() {
+		t.Fatal("Drain was not called before Shutdown")
+	}
+	if !api.shutdownCalled.Load() || !admin.shutdownCalled.Load() {
+		t.Fatal("Shutdown was not called on every server")
+	}
+}
+
+func TestRunShutsDownOthersWhenOneFails(t *testing.T) {
+	wantErr := errors.New("admin listener failed")
+	api := &drainingServer{fakeServer: newFakeServer()}
+	admin := newFakeServer()
+	admin.runErr = wantErr
+	close(admin.runFinished)
+
+	err := Run(context.Background(), time.Second, api, admin)
+	if !errors.Is(err, wantErr) {
+		t.Fatalf("Run error = %v, want %v", err, wantErr)
+	}
+	if !api.shutdownCalled.Load() {
+		t.Fatal("the server that was still running was not shut down")
+	}
+	if api.drainedBeforeShutdown.Load() {
+		t.Fatal("Drain was called although the process was not asked to stop")
+	}
+	if admin.shutdownCalled.Load() {
+		t.Fatal("Shutdown was called after the server had already stopped")
+	}
+}
+
+func TestRunRejectsNoServers(t *testing.T) {
+	if err := Run(context.Background(), time.Second); err == nil {
+		t.Fatal("Run returned nil without servers")
+	}
+}
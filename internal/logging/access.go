@@ -0,0 +1,105 @@
+package logging
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/chnm/apiary/internal/httpx"
+	"github.com/felixge/httpsnoop"
+	"github.com/gorilla/mux"
+	cache "github.com/victorspringer/http-cache"
+)
+
+// unmatchedRoute is the route of requests that did not match any route.
+const unmatchedRoute = "unmatched"
+
+// Middleware adds a Request to the context of each request, so that the
+// lines logged while serving it name its route, and the response cache and
+// database can add to it. If accessLog is set, it then logs a line with the
+// status, size, duration, cache outcome, and database time of the response.
+// It must run inside the router so that it can find the route, and outside
+// the response cache.
+func Middleware(accessLog bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			req := &Request{ID: httpx.RequestIDFromContext(r.Context()), Route: unmatchedRoute}
+			if current := mux.CurrentRoute(r); current != nil {
+				if template, err := current.GetPathTemplate(); err == nil {
+					req.Route = template
+				}
+			}
+			r = r.WithContext(WithRequest(r.Context(), req))
+			if !accessLog {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			start := time.Now()
+			status, bytes := 0, int64(0)
+			w = httpsnoop.Wrap(w, httpsnoop.Hooks{
+				WriteHeader: func(next httpsnoop.WriteHeaderFunc) httpsnoop.WriteHeaderFunc {
+					return func(code int) {
+						if status == 0 {
+							status = code
+						}
+						next(code)
+					}
+				},
+				Write: func(next httpsnoop.WriteFunc) httpsnoop.WriteFunc {
+					return func(b []byte) (int, error) {
+						if status == 0 {
+							status = http.StatusOK
+						}
+						n, err := next(b)
+						bytes += int64(n)
+						return n, err
+					}
+				},
+			})
+			// Log in a deferred call so that streams aborted with a panic
+			// are logged too.
+			aborted := true
+			defer func() {
+				if status == 0 {
+					status = http.StatusOK
+				}
+				queries, queryTime := req.Queries()
+				attrs := []slog.Attr{
+					slog.String("method", r.Method),
+					slog.String("path", r.URL.RequestURI()),
+					slog.Int("status", status),
+					slog.Int64("bytes", bytes),
+					slog.Float64("duration_ms", milliseconds(time.Since(start))),
+					slog.String("cache", req.Cache()),
+					slog.Int("db_queries", queries),
+					slog.Float64("db_ms", milliseconds(queryTime)),
+					slog.String("remote_addr", r.RemoteAddr),
+					slog.String("user_agent", r.UserAgent()),
+				}
+				if aborted {
+					attrs = append(attrs, slog.Bool("aborted", true))
+				}
+				slog.LogAttrs(r.Context(), slog.LevelInfo, "request", attrs...)
+			}()
+			next.ServeHTTP(w, r)
+			aborted = false
+		})
+	}
+}
+
+// ObserveCache records the outcome of a response cache lookup on the request
+// it was made for. Pass it to the cache along with any other observers.
+func ObserveCache(event cache.CacheEvent) {
+	switch event.Type {
+	case cache.CacheEventHit, cache.CacheEventMiss, cache.CacheEventStale, cache.CacheEventRefresh:
+	default:
+		return
+	}
+	if event.Request == nil {
+		return
+	}
+	if req := RequestFromContext(event.Request.Context()); req != nil {
+		req.SetCache(string(event.Type))
+	}
+}
@@ -0,0 +1,133 @@
+// Package logging configures structured logging with log/slog and records
+// what happens while each request is served, so that every line logged for a
+// request can be tied to it and an access log line can summarize it.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/chnm/apiary/internal/httpx"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Output formats.
+const (
+	FormatJSON = "json" // One JSON object per line, for log collectors
+	FormatText = "text" // key=value pairs, for reading in a terminal
+)
+
+// New returns a logger that writes records at or above level to w in the
+// given format. Records logged with the context of a request carry its
+// request ID and route, and its trace and span IDs if it is being traced.
+func New(w io.Writer, format string, level slog.Level) (*slog.Logger, error) {
+	options := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	switch format {
+	case FormatJSON:
+		handler = slog.NewJSONHandler(w, options)
+	case FormatText:
+		handler = slog.NewTextHandler(w, options)
+	default:
+		return nil, fmt.Errorf("unknown log format %q", format)
+	}
+	return slog.New(contextHandler{handler}), nil
+}
+
+// contextHandler adds the attributes of the request being served, if any, to
+// each record.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if req := RequestFromContext(ctx); req != nil {
+		record.AddAttrs(slog.String("request_id", req.ID), slog.String("route", req.Route))
+	} else if id := httpx.RequestIDFromContext(ctx); id != "" {
+		record.AddAttrs(slog.String("request_id", id))
+	}
+	if span := trace.SpanContextFromContext(ctx); span.IsSampled() {
+		record.AddAttrs(slog.String("trace_id", span.TraceID().String()), slog.String("span_id", span.SpanID().String()))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+// Request accumulates what is learned about a request while it is served.
+// Handlers and the response cache run on the request's goroutine, but
+// database drivers may not, so its methods are safe for concurrent use.
+type Request struct {
+	ID    string // X-Request-ID
+	Route string // Route template, such as /pinkertons/activities/{id:[0-9]+}
+
+	mu        sync.Mutex
+	cache     string
+	queries   int
+	queryTime time.Duration
+}
+
+type requestKey struct{}
+
+// WithRequest returns a context that carries req.
+func WithRequest(ctx context.Context, req *Request) context.Context {
+	return context.WithValue(ctx, requestKey{}, req)
+}
+
+// RequestFromContext returns the request being served, or nil.
+func RequestFromContext(ctx context.Context) *Request {
+	req, _ := ctx.Value(requestKey{}).(*Request)
+	return req
+}
+
+// SetCache records how the response cache handled the request, such as hit
+// or miss. The first outcome is kept: a miss that is then stored is a miss.
+func (r *Request) SetCache(outcome string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cache == "" {
+		r.cache = outcome
+	}
+}
+
+// Cache returns how the response cache handled the request, or "bypass" if
+// the request did not go through it.
+func (r *Request) Cache() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cache == "" {
+		return "bypass"
+	}
+	return r.cache
+}
+
+// AddQuery records a database query that took d.
+func (r *Request) AddQuery(d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.queries++
+	r.queryTime += d
+}
+
+// Queries returns the number of database queries made and their total time.
+func (r *Request) Queries() (int, time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.queries, r.queryTime
+}
+
+// milliseconds converts d to fractional milliseconds for log fields, which
+// are easier to read and to aggregate than slog's nanoseconds.
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
@@ -0,0 +1,228 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chnm/apiary/internal/httpx"
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	cache "github.com/victorspringer/http-cache"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// captureLogs sends the default logger's JSON output at debug level and above
+// to a buffer for the rest of the test.
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	logger, err := New(&buf, FormatJSON, slog.LevelDebug)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	previous := slog.Default()
+	slog.SetDefault(logger)
+	t.Cleanup(func() { slog.SetDefault(previous) })
+	return &buf
+}
+
+// decodeLines decodes each JSON log line in buf.
+func decodeLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var lines []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var fields map[string]any
+		if err := json.Unmarshal([]byte(line), &fields); err != nil {
+			t.Fatalf("decode log line %q: %v", line, err)
+		}
+		lines = append(lines, fields)
+	}
+	return lines
+}
+
+func TestNew(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, FormatText, slog.LevelWarn)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	logger.Info("hidden")
+	logger.Warn("shown", "n", 1)
+	if got := buf.String(); strings.Contains(got, "hidden") || !strings.Contains(got, "msg=shown n=1") {
+		t.Fatalf("text output = %q", got)
+	}
+
+	if _, err := New(&buf, "xml", slog.LevelInfo); err == nil {
+		t.Fatal("New() accepted an unknown format")
+	}
+}
+
+func TestContextAttributes(t *testing.T) {
+	buf := captureLogs(t)
+
+	ctx := httpx.ContextWithRequestID(context.Background(), "req-1")
+	slog.InfoContext(ctx, "outside the router")
+	slog.InfoContext(WithRequest(ctx, &Request{ID: "req-1", Route: "/bom/bills"}), "inside the router")
+	slog.Info("no request")
+	traced := trace.ContextWithSpanContext(ctx, trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x4b, 0xf9},
+		SpanID:     trace.SpanID{0x00, 0xf0},
+		TraceFlags: trace.FlagsSampled,
+	}))
+	slog.InfoContext(traced, "traced")
+
+	lines := decodeLines(t, buf)
+	if len(lines) != 4 {
+		t.Fatalf("logged %d lines, want 4", len(lines))
+	}
+	if lines[0]["request_id"] != "req-1" || lines[0]["route"] != nil {
+		t.Errorf("outside the router: %v", lines[0])
+	}
+	if lines[1]["request_id"] != "req-1" || lines[1]["route"] != "/bom/bills" {
+		t.Errorf("inside the router: %v", lines[1])
+	}
+	if _, ok := lines[2]["request_id"]; ok {
+		t.Errorf("no request: %v", lines[2])
+	}
+	if lines[3]["trace_id"] != "4bf90000000000000000000000000000" || lines[3]["span_id"] != "00f0000000000000" {
+		t.Errorf("traced: %v", lines[3])
+	}
+	if _, ok := lines[1]["trace_id"]; ok {
+		t.Errorf("untraced line has a trace ID: %v", lines[1])
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	buf := captureLogs(t)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/items/{id:[0-9]+}", func(w http.ResponseWriter, r *http.Request) {
+		ObserveCache(cache.CacheEvent{Type: cache.CacheEventMiss, Request: r})
+		ObserveCache(cache.CacheEvent{Type: cache.CacheEventStore, Request: r})
+		RequestFromContext(r.Context()).AddQuery(3 * time.Millisecond)
+		slog.ErrorContext(r.Context(), "error querying items", "error", errors.New("boom"))
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte("failed"))
+	})
+	router.Use(Middleware(true))
+	handler := httpx.RequestID(router)
+
+	request := httptest.NewRequest(http.MethodGet, "/items/7?verbose=1", nil)
+	request.Header.Set(httpx.RequestIDHeader, "req-7")
+	handler.ServeHTTP(httptest.NewRecorder(), request)
+
+	lines := decodeLines(t, buf)
+	if len(lines) != 2 {
+		t.Fatalf("logged %d lines, want 2:\n%s", len(lines), buf)
+	}
+	for _, line := range lines {
+		if line["request_id"] != "req-7" || line["route"] != "/items/{id:[0-9]+}" {
+			t.Errorf("line is not tied to the request: %v", line)
+		}
+	}
+	access := lines[1]
+	want := map[string]any{
+		"msg":        "request",
+		"method":     "GET",
+		"path":       "/items/7?verbose=1",
+		"status":     float64(500),
+		"bytes":      float64(6),
+		"cache":      "miss",
+		"db_queries": float64(1),
+		"db_ms":      float64(3),
+	}
+	for key, value := range want {
+		if access[key] != value {
+			t.Errorf("access log %s = %v, want %v", key, access[key], value)
+		}
+	}
+	if _, ok := access["duration_ms"].(float64); !ok {
+		t.Errorf("access log duration_ms = %v", access["duration_ms"])
+	}
+}
+
+func TestMiddlewareWithoutAccessLog(t *testing.T) {
+	buf := captureLogs(t)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/items", func(w http.ResponseWriter, r *http.Request) {
+		slog.WarnContext(r.Context(), "something odd")
+	})
+	router.Use(Middleware(false))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/items", nil))
+
+	lines := decodeLines(t, buf)
+	if len(lines) != 1 || lines[0]["route"] != "/items" {
+		t.Fatalf("logged %v, want only the handler's line with its route", lines)
+	}
+}
+
+func TestMiddlewareLogsAbortedRequests(t *testing.T) {
+	buf := captureLogs(t)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/stream", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("{}\n"))
+		panic(http.ErrAbortHandler)
+	})
+	router.Use(Middleware(true))
+
+	func() {
+		defer func() {
+			if recovered := recover(); recovered != http.ErrAbortHandler {
+				t.Errorf("recovered %v, want http.ErrAbortHandler", recovered)
+			}
+		}()
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/stream", nil))
+	}()
+
+	lines := decodeLines(t, buf)
+	if len(lines) != 1 || lines[0]["aborted"] != true || lines[0]["bytes"] != float64(3) {
+		t.Fatalf("logged %v, want an aborted access log line", lines)
+	}
+}
+
+func TestQueryTracer(t *testing.T) {
+	buf := captureLogs(t)
+	tracer := QueryTracer{SlowQuery: time.Hour}
+
+	req := &Request{ID: "req-1", Route: "/bom/bills"}
+	ctx := WithRequest(context.Background(), req)
+	ctx = tracer.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{SQL: "\n\tSELECT id\n\tFROM bom.bills\n"})
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{CommandTag: pgconn.NewCommandTag("SELECT 2")})
+
+	failed := tracer.TraceQueryStart(WithRequest(context.Background(), req), nil, pgx.TraceQueryStartData{SQL: "SELECT"})
+	tracer.TraceQueryEnd(failed, nil, pgx.TraceQueryEndData{Err: errors.New("boom")})
+
+	if queries, _ := req.Queries(); queries != 2 {
+		t.Errorf("queries = %d, want 2", queries)
+	}
+	lines := decodeLines(t, buf)
+	if len(lines) != 1 {
+		t.Fatalf("logged %d lines, want 1 for the successful query:\n%s", len(lines), buf)
+	}
+	if lines[0]["level"] != "DEBUG" || lines[0]["sql"] != "SELECT id FROM bom.bills" || lines[0]["rows"] != float64(2) {
+		t.Errorf("query line = %v", lines[0])
+	}
+
+	buf.Reset()
+	slow := QueryTracer{SlowQuery: time.Nanosecond}
+	ctx = slow.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{SQL: "SELECT pg_sleep(1)"})
+	time.Sleep(time.Millisecond)
+	slow.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{})
+	if lines := decodeLines(t, buf); len(lines) != 1 || lines[0]["level"] != "WARN" || lines[0]["msg"] != "slow query" {
+		t.Errorf("slow query lines = %v", lines)
+	}
+}
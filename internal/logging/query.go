@@ -0,0 +1,67 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// QueryTracer times database queries. It adds each query's time to the
+// request it was made for, logs every query at debug level, and logs queries
+// slower than SlowQuery at warning level. Set it as the Tracer of the pool's
+// connection configuration.
+type QueryTracer struct {
+	SlowQuery time.Duration // Zero disables slow query warnings
+}
+
+type queryStartKey struct{}
+
+type queryStart struct {
+	sql  string
+	time time.Time
+}
+
+// TraceQueryStart implements pgx.QueryTracer.
+func (t QueryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	return context.WithValue(ctx, queryStartKey{}, queryStart{sql: data.SQL, time: time.Now()})
+}
+
+// TraceQueryEnd implements pgx.QueryTracer. Failed queries are not logged
+// here, since the caller logs the error with more context.
+func (t QueryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	start, ok := ctx.Value(queryStartKey{}).(queryStart)
+	if !ok {
+		return
+	}
+	elapsed := time.Since(start.time)
+	if req := RequestFromContext(ctx); req != nil {
+		req.AddQuery(elapsed)
+	}
+	if data.Err != nil {
+		return
+	}
+
+	level := slog.LevelDebug
+	message := "query"
+	if t.SlowQuery > 0 && elapsed >= t.SlowQuery {
+		level, message = slog.LevelWarn, "slow query"
+	}
+	logger := slog.Default()
+	if !logger.Enabled(ctx, level) {
+		return
+	}
+	logger.LogAttrs(ctx, level, message,
+		slog.String("sql", compactSQL(start.sql)),
+		slog.Float64("duration_ms", milliseconds(elapsed)),
+		slog.Int64("rows", data.CommandTag.RowsAffected()),
+	)
+}
+
+// compactSQL collapses the whitespace of a query written across several
+// indented lines so that it fits on one log line.
+func compactSQL(sql string) string {
+	return strings.Join(strings.Fields(sql), " ")
+}
@@ -0,0 +1,102 @@
+// Package metrics collects Prometheus metrics for HTTP requests, the response
+// cache, and the database connection pool.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/felixge/httpsnoop"
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	cache "github.com/victorspringer/http-cache"
+)
+
+const namespace = "apiary"
+
+// unmatchedRoute labels requests that did not match any route, so that
+// scanners probing arbitrary paths cannot create unbounded label values.
+const unmatchedRoute = "unmatched"
+
+// Metrics holds the server's collectors and the registry they are served
+// from.
+type Metrics struct {
+	registry *prometheus.Registry
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	cache    *prometheus.CounterVec
+}
+
+// New registers the request, cache, and runtime collectors, and a collector
+// that reads pool statistics at scrape time. pool may be nil when there is no
+// database.
+func New(pool PoolStater) *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "HTTP requests by route template, method, and status code.",
+		}, []string{"route", "method", "code"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "Time taken to serve HTTP requests by route template and method.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method"}),
+		cache: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "cache",
+			Name:      "events_total",
+			Help:      "Response cache events: hit, miss, stale, refresh, store, or purge.",
+		}, []string{"event"}),
+	}
+	// Report hits and misses from the start so that ratios are defined
+	// before the first request.
+	m.cache.WithLabelValues(string(cache.CacheEventHit))
+	m.cache.WithLabelValues(string(cache.CacheEventMiss))
+
+	m.registry.MustRegister(
+		m.requests,
+		m.duration,
+		m.cache,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	if pool != nil {
+		m.registry.MustRegister(newPoolCollector(pool))
+	}
+	return m
+}
+
+// Handler serves the metrics in the Prometheus text format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Middleware counts and times requests. It labels them with the route
+// template, such as /pinkertons/activities/{id}, rather than the raw path, so
+// it must run inside the router.
+func (m *Metrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := unmatchedRoute
+		if current := mux.CurrentRoute(r); current != nil {
+			if template, err := current.GetPathTemplate(); err == nil {
+				route = template
+			}
+		}
+		snoop := httpsnoop.CaptureMetrics(next, w, r)
+		m.requests.WithLabelValues(route, r.Method, strconv.Itoa(snoop.Code)).Inc()
+		m.duration.WithLabelValues(route, r.Method).Observe(snoop.Duration.Seconds())
+	})
+}
+
+// ObserveCache counts a response cache event. Pass it to
+// cache.ClientWithObserver.
+func (m *Metrics) ObserveCache(event cache.CacheEvent) {
+	m.cache.WithLabelValues(string(event.Type)).Inc()
+}
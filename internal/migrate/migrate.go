@@ -0,0 +1,311 @@
+// Package migrate creates and upgrades the database schema that Apiary's
+// datasets are served from.
+//
+// Migrations are numbered pairs of SQL files embedded in the binary, such as
+// 0003_ahcb.up.sql and 0003_ahcb.down.sql. The versions that have been
+// applied are recorded in the apiary.schema_migrations table, and each
+// migration is applied or reverted in the same transaction as its record, so
+// that a failed migration leaves nothing behind.
+package migrate
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+//go:embed migrations/*.sql
+var embedded embed.FS
+
+// lockID identifies the advisory lock that keeps two migrators from running
+// against the same database at once.
+const lockID = 0x61706961727921 // "apiary!"
+
+const createTableQuery = `
+CREATE SCHEMA IF NOT EXISTS apiary;
+CREATE TABLE IF NOT EXISTS apiary.schema_migrations (
+    version    integer PRIMARY KEY,
+    name       text NOT NULL,
+    applied_at timestamptz NOT NULL DEFAULT now()
+);
+`
+
+const appliedQuery = `
+SELECT version, name, applied_at
+FROM apiary.schema_migrations
+ORDER BY version;
+`
+
+// fileName matches migration files, capturing the version, name, and
+// direction.
+var fileName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration is one step in the schema's history.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string // SQL that applies the migration
+	Down    string // SQL that reverts it
+}
+
+// String returns the file name stem of the migration, such as 0003_ahcb.
+func (m Migration) String() string {
+	return fmt.Sprintf("%04d_%s", m.Version, m.Name)
+}
+
+// Load reads the migrations in the top directory of fsys. Every migration
+// must have both an up and a down file. The migrations are sorted by version.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("read migrations: %w", err)
+	}
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		match := fileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("migration %s: name must look like 0001_name.up.sql or 0001_name.down.sql", entry.Name())
+		}
+		version, err := strconv.Atoi(match[1])
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration %s: version must be a positive number", entry.Name())
+		}
+		sql, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("read migration %s: %w", entry.Name(), err)
+		}
+
+		m := byVersion[version]
+		if m == nil {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %s: version %d is also used by %s", entry.Name(), version, m.Name)
+		}
+		if match[3] == "up" {
+			m.Up = string(sql)
+		} else {
+			m.Down = string(sql)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %s: needs both an up and a down file", m)
+		}
+		migrations = append(migrations, *m)
+	}
+	slices.SortFunc(migrations, func(a, b Migration) int { return a.Version - b.Version })
+	return migrations, nil
+}
+
+// Embedded returns the migrations built into Apiary.
+func Embedded() ([]Migration, error) {
+	fsys, err := fs.Sub(embedded, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	return Load(fsys)
+}
+
+// Status describes a migration and whether it has been applied.
+type Status struct {
+	Version   int
+	Name      string
+	AppliedAt time.Time // Zero if the migration is pending
+	// Unknown is set for migrations that have been applied to the database
+	// but are not part of this build, such as by a newer version of Apiary.
+	Unknown bool
+}
+
+// Migrator applies and reverts migrations.
+type Migrator struct {
+	pool       *pgxpool.Pool
+	migrations []Migration
+}
+
+// New returns a migrator for the database behind pool. The migrations must
+// be sorted by version, as Load returns them.
+func New(pool *pgxpool.Pool, migrations []Migration) *Migrator {
+	return &Migrator{pool: pool, migrations: migrations}
+}
+
+// Up applies the pending migrations in order and returns the ones it applied.
+// It stops at the first that fails.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var done []Migration
+	err := m.locked(ctx, func(conn *pgx.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			err := run(ctx, conn, migration.Up,
+				`INSERT INTO apiary.schema_migrations (version, name) VALUES ($1, $2);`,
+				migration.Version, migration.Name)
+			if err != nil {
+				return fmt.Errorf("apply migration %s: %w", migration, err)
+			}
+			slog.InfoContext(ctx, "applied migration", "version", migration.Version, "name", migration.Name)
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down reverts the latest steps applied migrations, newest first, and returns
+// the ones it reverted. It refuses to revert a migration that is not part of
+// this build, since it does not know how.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	if steps <= 0 {
+		return nil, errors.New("steps must be positive")
+	}
+	known := make(map[int]Migration, len(m.migrations))
+	for _, migration := range m.migrations {
+		known[migration.Version] = migration
+	}
+
+	var done []Migration
+	err := m.locked(ctx, func(conn *pgx.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		versions := make([]int, 0, len(applied))
+		for version := range applied {
+			versions = append(versions, version)
+		}
+		slices.Sort(versions)
+		slices.Reverse(versions)
+
+		for _, version := range versions[:min(steps, len(versions))] {
+			migration, ok := known[version]
+			if !ok {
+				return fmt.Errorf("revert migration %d: it is not part of this build of apiary", version)
+			}
+			err := run(ctx, conn, migration.Down,
+				`DELETE FROM apiary.schema_migrations WHERE version = $1;`,
+				migration.Version)
+			if err != nil {
+				return fmt.Errorf("revert migration %s: %w", migration, err)
+			}
+			slog.InfoContext(ctx, "reverted migration", "version", migration.Version, "name", migration.Name)
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Status lists every migration, known or applied, by version. It does not
+// change the database, so a database that has never been migrated shows
+// every migration as pending.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	var exists bool
+	err := m.pool.QueryRow(ctx, `SELECT to_regclass('apiary.schema_migrations') IS NOT NULL;`).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("find applied migrations: %w", err)
+	}
+	applied := make(map[int]Status)
+	if exists {
+		conn, err := m.pool.Acquire(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("find applied migrations: %w", err)
+		}
+		defer conn.Release()
+		if applied, err = appliedVersions(ctx, conn.Conn()); err != nil {
+			return nil, err
+		}
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := Status{Version: migration.Version, Name: migration.Name}
+		if record, ok := applied[migration.Version]; ok {
+			status.AppliedAt = record.AppliedAt
+			delete(applied, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+	for _, record := range applied {
+		record.Unknown = true
+		statuses = append(statuses, record)
+	}
+	slices.SortFunc(statuses, func(a, b Status) int { return a.Version - b.Version })
+	return statuses, nil
+}
+
+// locked runs f on a connection that holds the migration lock and has the
+// bookkeeping table. The connection is closed afterward rather than returned
+// to the pool, which releases the lock even if f was interrupted.
+func (m *Migrator) locked(ctx context.Context, f func(*pgx.Conn) error) error {
+	pooled, err := m.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("connect to the database: %w", err)
+	}
+	conn := pooled.Hijack()
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, `SELECT pg_advisory_lock($1);`, lockID); err != nil {
+		return fmt.Errorf("lock migrations: %w", err)
+	}
+	if _, err := conn.Exec(ctx, createTableQuery); err != nil {
+		return fmt.Errorf("create migrations table: %w", err)
+	}
+	return f(conn)
+}
+
+// appliedVersions returns the applied migrations by version.
+func appliedVersions(ctx context.Context, conn *pgx.Conn) (map[int]Status, error) {
+	rows, err := conn.Query(ctx, appliedQuery)
+	if err != nil {
+		return nil, fmt.Errorf("find applied migrations: %w", err)
+	}
+	applied := make(map[int]Status)
+	var status Status
+	_, err = pgx.ForEachRow(rows, []any{&status.Version, &status.Name, &status.AppliedAt}, func() error {
+		applied[status.Version] = status
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("find applied migrations: %w", err)
+	}
+	return applied, nil
+}
+
+// run executes sql and then the bookkeeping statement in one transaction.
+// The migration is run without arguments, so that pgx sends it with the
+// simple protocol, which allows several statements.
+func run(ctx context.Context, conn *pgx.Conn, sql, record string, args ...any) error {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(context.Background())
+	if _, err := tx.Exec(ctx, sql); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, record, args...); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,83 @@
+package migrate
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0002_second.up.sql":   {Data: []byte("CREATE TABLE b ();")},
+		"0002_second.down.sql": {Data: []byte("DROP TABLE b;")},
+		"0001_first.up.sql":    {Data: []byte("CREATE TABLE a ();")},
+		"0001_first.down.sql":  {Data: []byte("DROP TABLE a;")},
+		"README/notes.txt":     {Data: []byte("Subdirectories are ignored.")},
+	}
+	migrations, err := Load(fsys)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	want := []Migration{
+		{Version: 1, Name: "first", Up: "CREATE TABLE a ();", Down: "DROP TABLE a;"},
+		{Version: 2, Name: "second", Up: "CREATE TABLE b ();", Down: "DROP TABLE b;"},
+	}
+	if len(migrations) != len(want) {
+		t.Fatalf("Load() = %+v, want %+v", migrations, want)
+	}
+	for i := range want {
+		if migrations[i] != want[i] {
+			t.Errorf("migration %d = %+v, want %+v", i, migrations[i], want[i])
+		}
+	}
+	if got := migrations[0].String(); got != "0001_first" {
+		t.Errorf("String() = %q, want 0001_first", got)
+	}
+}
+
+func TestLoadRejectsBadMigrations(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		fsys fstest.MapFS
+		want string
+	}{
+		{"missing down", fstest.MapFS{
+			"0001_first.up.sql": {Data: []byte("SELECT 1;")},
+		}, "needs both an up and a down file"},
+		{"shared version", fstest.MapFS{
+			"0001_first.up.sql":    {Data: []byte("SELECT 1;")},
+			"0001_first.down.sql":  {Data: []byte("SELECT 1;")},
+			"0001_second.up.sql":   {Data: []byte("SELECT 1;")},
+			"0001_second.down.sql": {Data: []byte("SELECT 1;")},
+		}, "version 1 is also used by"},
+		{"bad name", fstest.MapFS{
+			"first.sql": {Data: []byte("SELECT 1;")},
+		}, "name must look like"},
+		{"version zero", fstest.MapFS{
+			"0000_first.up.sql":   {Data: []byte("SELECT 1;")},
+			"0000_first.down.sql": {Data: []byte("SELECT 1;")},
+		}, "version must be a positive number"},
+	} {
+		_, err := Load(tt.fsys)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: Load() error = %v, want one containing %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestEmbedded(t *testing.T) {
+	migrations, err := Embedded()
+	if err != nil {
+		t.Fatalf("Embedded() error = %v", err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("Embedded() returned no migrations")
+	}
+	// Versions are numbered from one without gaps, so that a merge that
+	// reuses a number shows up here.
+	for i, migration := range migrations {
+		if migration.Version != i+1 {
+			t.Errorf("migration %s is number %d in order, want versions without gaps", migration, i+1)
+		}
+	}
+}
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS apiary.api_keys;
@@ -0,0 +1,9 @@
+-- API keys that unlock restricted datasets when auth.database is on. Only the
+-- hex SHA-256 hash of each key is stored.
+CREATE TABLE IF NOT EXISTS apiary.api_keys (
+    name       text PRIMARY KEY,
+    key_hash   text NOT NULL UNIQUE,
+    datasets   text[] NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    revoked_at timestamptz
+);
@@ -0,0 +1,2 @@
+-- Fails while anything else in the database still uses PostGIS.
+DROP EXTENSION IF EXISTS postgis;
@@ -0,0 +1,3 @@
+-- The spatial datasets store their boundaries and points as PostGIS
+-- geometries.
+CREATE EXTENSION IF NOT EXISTS postgis;
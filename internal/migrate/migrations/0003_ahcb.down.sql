@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS ahcb_counties;
+DROP TABLE IF EXISTS ahcb_states;
@@ -0,0 +1,31 @@
+-- Atlas of Historical County Boundaries. Each row is one version of a state,
+-- territory, or county, valid from start_date to end_date, so an ID appears
+-- once for each change to its boundary.
+CREATE TABLE IF NOT EXISTS ahcb_states (
+    id         text NOT NULL,
+    name       text NOT NULL,
+    abbr_name  text,
+    area_sqmi  numeric,
+    terr_type  text,
+    start_date date NOT NULL,
+    end_date   date NOT NULL,
+    geom_01    geometry(MultiPolygon, 4326),
+    PRIMARY KEY (id, start_date)
+);
+CREATE INDEX IF NOT EXISTS ahcb_states_dates_idx ON ahcb_states (start_date, end_date);
+
+CREATE TABLE IF NOT EXISTS ahcb_counties (
+    id            text NOT NULL,
+    name          text NOT NULL,
+    state_terr    text,
+    state_terr_id text,
+    state_code    text,
+    area_sqmi     numeric,
+    start_date    date NOT NULL,
+    end_date      date NOT NULL,
+    geom_01       geometry(MultiPolygon, 4326),
+    PRIMARY KEY (id, start_date)
+);
+CREATE INDEX IF NOT EXISTS ahcb_counties_dates_idx ON ahcb_counties (start_date, end_date);
+CREATE INDEX IF NOT EXISTS ahcb_counties_state_terr_id_idx ON ahcb_counties (state_terr_id);
+CREATE INDEX IF NOT EXISTS ahcb_counties_state_code_idx ON ahcb_counties (state_code);
@@ -0,0 +1,14 @@
+DROP TABLE IF EXISTS apb.verse_peaks;
+DROP TABLE IF EXISTS apb.top_verses;
+DROP TABLE IF EXISTS apb.rate_quotations_bible;
+DROP TABLE IF EXISTS apb.count_quotations_verses;
+DROP TABLE IF EXISTS apb.wordcounts;
+DROP TABLE IF EXISTS apb.quotations;
+DROP TABLE IF EXISTS apb.chronam_pages;
+DROP TABLE IF EXISTS apb.chronam_newspaper_places;
+DROP TABLE IF EXISTS apb.chronam_newspapers;
+DROP TABLE IF EXISTS apb.verse_cleanup;
+DROP TABLE IF EXISTS apb.scriptures_intraversion_pairs;
+DROP TABLE IF EXISTS apb.scriptures;
+-- Fails if the schema holds anything else.
+DROP SCHEMA IF EXISTS apb;
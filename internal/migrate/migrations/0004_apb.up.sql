@@ -0,0 +1,99 @@
+-- America's Public Bible: the text of the Bible in several versions, the
+-- quotations of its verses found in newspapers, and the counts and rates
+-- that the APB pipeline computes from them.
+CREATE SCHEMA IF NOT EXISTS apb;
+
+CREATE TABLE IF NOT EXISTS apb.scriptures (
+    verse_id     integer PRIMARY KEY,
+    version      text NOT NULL,
+    reference_id text NOT NULL, -- Such as "Genesis 1:1"
+    book         text NOT NULL,
+    part         text NOT NULL, -- Old Testament, New Testament, or Apocrypha
+    book_order   integer NOT NULL,
+    chapter      integer NOT NULL,
+    verse        integer NOT NULL,
+    text         text NOT NULL
+);
+CREATE INDEX IF NOT EXISTS scriptures_reference_id_idx ON apb.scriptures (reference_id, version);
+CREATE INDEX IF NOT EXISTS scriptures_order_idx ON apb.scriptures (version, book_order, chapter, verse);
+
+-- Pairs of similar verses within one version.
+CREATE TABLE IF NOT EXISTS apb.scriptures_intraversion_pairs (
+    version text NOT NULL,
+    a       integer NOT NULL,
+    b       integer NOT NULL,
+    score   real NOT NULL,
+    PRIMARY KEY (version, a, b)
+);
+
+-- Which verses are shown, and the reference that stands for duplicates.
+CREATE TABLE IF NOT EXISTS apb.verse_cleanup (
+    reference_id  text PRIMARY KEY,
+    reference_use text,
+    use           boolean,
+    display       boolean
+);
+CREATE INDEX IF NOT EXISTS verse_cleanup_reference_use_idx ON apb.verse_cleanup (reference_use);
+
+CREATE TABLE IF NOT EXISTS apb.chronam_newspapers (
+    lccn        text PRIMARY KEY,
+    title_clean text
+);
+
+CREATE TABLE IF NOT EXISTS apb.chronam_newspaper_places (
+    lccn  text NOT NULL,
+    state text
+);
+CREATE INDEX IF NOT EXISTS chronam_newspaper_places_lccn_idx ON apb.chronam_newspaper_places (lccn);
+
+CREATE TABLE IF NOT EXISTS apb.chronam_pages (
+    doc_id text PRIMARY KEY,
+    lccn   text NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS apb.quotations (
+    corpus       text NOT NULL,
+    reference_id text NOT NULL,
+    doc_id       text NOT NULL,
+    date         date,
+    probability  real,
+    PRIMARY KEY (corpus, reference_id, doc_id)
+);
+
+CREATE TABLE IF NOT EXISTS apb.wordcounts (
+    corpus    text NOT NULL,
+    year      integer NOT NULL,
+    wordcount bigint NOT NULL,
+    PRIMARY KEY (corpus, year)
+);
+
+-- Computed by the pipeline: quotations of each verse per year.
+CREATE TABLE IF NOT EXISTS apb.count_quotations_verses (
+    corpus       text NOT NULL,
+    reference_id text NOT NULL,
+    year         integer NOT NULL,
+    n            integer NOT NULL,
+    PRIMARY KEY (corpus, reference_id, year)
+);
+
+-- Computed by the pipeline: quotations of the whole Bible per year, with the
+-- year's word count.
+CREATE TABLE IF NOT EXISTS apb.rate_quotations_bible (
+    corpus    text NOT NULL,
+    year      integer NOT NULL,
+    n         integer NOT NULL,
+    wordcount bigint NOT NULL,
+    PRIMARY KEY (corpus, year)
+);
+
+-- Computed by the pipeline: total quotations of each verse, and the year in
+-- which it was quoted most.
+CREATE TABLE IF NOT EXISTS apb.top_verses (
+    reference_id text PRIMARY KEY,
+    n            integer NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS apb.verse_peaks (
+    reference_id text PRIMARY KEY,
+    year         integer NOT NULL
+);
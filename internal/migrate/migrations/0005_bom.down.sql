@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS bom.parishes_shp;
+DROP TABLE IF EXISTS bom.christenings;
+DROP TABLE IF EXISTS bom.christening_locations;
+DROP TABLE IF EXISTS bom.causes_of_death;
+DROP TABLE IF EXISTS bom.bill_of_mortality;
+DROP TABLE IF EXISTS bom.parishes;
+DROP TABLE IF EXISTS bom.week;
+DROP TABLE IF EXISTS bom.year;
+-- Fails if the schema holds anything else.
+DROP SCHEMA IF EXISTS bom;
@@ -0,0 +1,97 @@
+-- Bills of Mortality: the weekly and general bills of London, transcribed by
+-- parish, with causes of death and christenings.
+CREATE SCHEMA IF NOT EXISTS bom;
+
+CREATE TABLE IF NOT EXISTS bom.year (
+    year integer PRIMARY KEY
+);
+
+-- A week of bills. split_year is the year as printed, such as 1664/5.
+CREATE TABLE IF NOT EXISTS bom.week (
+    joinid      text PRIMARY KEY,
+    year        integer NOT NULL REFERENCES bom.year,
+    week_number integer NOT NULL,
+    start_day   integer,
+    start_month text,
+    end_day     integer,
+    end_month   text,
+    split_year  text NOT NULL
+);
+CREATE INDEX IF NOT EXISTS week_year_idx ON bom.week (year, week_number);
+
+CREATE TABLE IF NOT EXISTS bom.parishes (
+    id              integer PRIMARY KEY,
+    parish_name     text NOT NULL,
+    canonical_name  text NOT NULL,
+    bills_subunit   text,
+    foundation_year text,
+    notes           text
+);
+
+CREATE TABLE IF NOT EXISTS bom.bill_of_mortality (
+    id                bigint GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    parish_id         integer NOT NULL REFERENCES bom.parishes,
+    week_id           text NOT NULL REFERENCES bom.week,
+    year              integer NOT NULL REFERENCES bom.year,
+    bill_type         text NOT NULL CHECK (bill_type IN ('weekly', 'general', 'total')),
+    count_type        text NOT NULL CHECK (count_type IN ('buried', 'plague')),
+    count             integer,
+    missing           boolean,
+    illegible         boolean,
+    source            text,
+    unique_identifier text
+);
+CREATE INDEX IF NOT EXISTS bill_of_mortality_year_idx ON bom.bill_of_mortality (bill_type, year);
+CREATE INDEX IF NOT EXISTS bill_of_mortality_parish_id_idx ON bom.bill_of_mortality (parish_id);
+CREATE INDEX IF NOT EXISTS bill_of_mortality_week_id_idx ON bom.bill_of_mortality (week_id);
+
+-- Causes of death as printed (original_name) and normalized (name). A name
+-- can join several causes with " & ".
+CREATE TABLE IF NOT EXISTS bom.causes_of_death (
+    id                bigint GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    week_id           text NOT NULL REFERENCES bom.week,
+    bill_type         text NOT NULL,
+    original_name     text NOT NULL,
+    name              text,
+    count             integer,
+    definition        text,
+    definition_source text
+);
+CREATE INDEX IF NOT EXISTS causes_of_death_week_id_idx ON bom.causes_of_death (week_id);
+CREATE INDEX IF NOT EXISTS causes_of_death_name_idx ON bom.causes_of_death (name, bill_type);
+
+CREATE TABLE IF NOT EXISTS bom.christening_locations (
+    id   integer PRIMARY KEY,
+    name text NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS bom.christenings (
+    id          bigint GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    christening text NOT NULL,
+    bill_type   text,
+    count       integer,
+    year        integer NOT NULL REFERENCES bom.year,
+    week_number integer,
+    start_day   integer,
+    start_month text,
+    end_day     integer,
+    end_month   text
+);
+CREATE INDEX IF NOT EXISTS christenings_year_idx ON bom.christenings (year, week_number);
+
+-- Parish boundaries from the shapefiles, in the British National Grid. A
+-- parish can have several versions, starting in different years.
+CREATE TABLE IF NOT EXISTS bom.parishes_shp (
+    id        integer NOT NULL,
+    par       text,
+    civ_par   text,
+    dbn_par   text,
+    omeka_par text,
+    subunit   text,
+    city_cnty text,
+    start_yr  integer,
+    sp_total  numeric,
+    sp_per    numeric,
+    geom_01   geometry(MultiPolygon, 27700)
+);
+CREATE INDEX IF NOT EXISTS parishes_shp_id_idx ON bom.parishes_shp (id);
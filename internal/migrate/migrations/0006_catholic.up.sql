@@ -0,0 +1,14 @@
+-- Catholic dioceses in North America, with the dates they were erected,
+-- raised to metropolitan sees, and suppressed.
+CREATE TABLE IF NOT EXISTS catholic_dioceses (
+    id                integer GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    city              text NOT NULL,
+    state             text NOT NULL,
+    country           text NOT NULL,
+    rite              text NOT NULL,
+    date_erected      date NOT NULL,
+    date_metropolitan date,
+    date_destroyed    date,
+    geometry          geometry(Point, 4326) NOT NULL
+);
+CREATE INDEX IF NOT EXISTS catholic_dioceses_date_erected_idx ON catholic_dioceses (date_erected);
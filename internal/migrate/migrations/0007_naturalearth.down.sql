@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS naturalearth.countries;
+-- Fails if the schema holds anything else.
+DROP SCHEMA IF EXISTS naturalearth;
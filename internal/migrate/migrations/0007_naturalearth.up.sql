@@ -0,0 +1,10 @@
+-- Country boundaries from Natural Earth at 1:50m.
+CREATE SCHEMA IF NOT EXISTS naturalearth;
+
+CREATE TABLE IF NOT EXISTS naturalearth.countries (
+    adm0_a3   text PRIMARY KEY,
+    name      text NOT NULL,
+    continent text NOT NULL,
+    geom_50m  geometry(MultiPolygon, 4326)
+);
+CREATE INDEX IF NOT EXISTS countries_continent_idx ON naturalearth.countries (continent);
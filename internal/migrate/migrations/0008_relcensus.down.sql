@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS relcensus.membership_city;
+DROP TABLE IF EXISTS relcensus.denominations;
+DROP TABLE IF EXISTS relcensus.cities_25k;
+DROP TABLE IF EXISTS relcensus.popplaces_1926;
+-- Fails if the schema holds anything else.
+DROP SCHEMA IF EXISTS relcensus;
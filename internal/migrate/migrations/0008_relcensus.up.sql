@@ -0,0 +1,49 @@
+-- U.S. Census of Religious Bodies: membership by city and denomination, and
+-- the populated places of 1926 that the popplaces dataset serves too.
+CREATE SCHEMA IF NOT EXISTS relcensus;
+
+CREATE TABLE IF NOT EXISTS relcensus.popplaces_1926 (
+    place_id     integer PRIMARY KEY,
+    place        text NOT NULL,
+    county       text NOT NULL,
+    county_ahcb  text NOT NULL, -- The county's ID in ahcb_counties
+    state        text NOT NULL,
+    map_name     text NOT NULL,
+    lat          double precision NOT NULL,
+    lon          double precision NOT NULL,
+    pop_est_1926 integer
+);
+CREATE INDEX IF NOT EXISTS popplaces_1926_state_idx ON relcensus.popplaces_1926 (state);
+CREATE INDEX IF NOT EXISTS popplaces_1926_county_ahcb_idx ON relcensus.popplaces_1926 (county_ahcb);
+
+-- Cities of at least 25,000 people.
+CREATE TABLE IF NOT EXISTS relcensus.cities_25k (
+    city     text NOT NULL,
+    state    text NOT NULL,
+    place_id integer REFERENCES relcensus.popplaces_1926,
+    geometry geometry(Point, 4326) NOT NULL,
+    PRIMARY KEY (city, state)
+);
+
+-- Denominations as the census named them, grouped into families by the
+-- census and by the project.
+CREATE TABLE IF NOT EXISTS relcensus.denominations (
+    denomination_id text,
+    name            text NOT NULL,
+    short_name      text NOT NULL,
+    family_census   text,
+    family_relec    text NOT NULL,
+    year            integer NOT NULL,
+    PRIMARY KEY (name, year)
+);
+CREATE INDEX IF NOT EXISTS denominations_family_relec_idx ON relcensus.denominations (year, family_relec);
+
+CREATE TABLE IF NOT EXISTS relcensus.membership_city (
+    year          integer NOT NULL,
+    denomination  text NOT NULL,
+    city          text NOT NULL,
+    state         text NOT NULL,
+    churches      integer,
+    members_total integer,
+    PRIMARY KEY (year, denomination, city, state)
+);
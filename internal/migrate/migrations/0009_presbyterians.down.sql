@@ -0,0 +1 @@
+DROP TABLE IF EXISTS presbyterians_weber;
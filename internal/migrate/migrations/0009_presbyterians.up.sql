@@ -0,0 +1,8 @@
+-- Presbyterian membership and churches by year, from Weber's statistics.
+CREATE TABLE IF NOT EXISTS presbyterians_weber (
+    id       integer GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    year     integer NOT NULL,
+    members  integer,
+    churches integer
+);
+CREATE INDEX IF NOT EXISTS presbyterians_weber_year_idx ON presbyterians_weber (year);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS detectives.activity_locations;
+DROP TABLE IF EXISTS detectives.locations;
+DROP TABLE IF EXISTS detectives.activities;
+-- Fails if the schema holds anything else.
+DROP SCHEMA IF EXISTS detectives;
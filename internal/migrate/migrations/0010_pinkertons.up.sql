@@ -0,0 +1,44 @@
+-- Pinkerton detective reports: what operatives did and where. Dates and
+-- times are kept as transcribed, as ISO 8601 text.
+CREATE SCHEMA IF NOT EXISTS detectives;
+
+CREATE TABLE IF NOT EXISTS detectives.activities (
+    id               integer PRIMARY KEY,
+    source           text,
+    operative        text,
+    date             text,
+    time             text,
+    duration         text,
+    activity         text,
+    mode             text,
+    activity_notes   text,
+    subject          text,
+    information      text,
+    information_type text,
+    edited           text,
+    edit_type        text,
+    investigation    text
+);
+CREATE INDEX IF NOT EXISTS activities_date_idx ON detectives.activities (date, time, id);
+CREATE INDEX IF NOT EXISTS activities_operative_idx ON detectives.activities (operative);
+CREATE INDEX IF NOT EXISTS activities_subject_idx ON detectives.activities (subject);
+
+CREATE TABLE IF NOT EXISTS detectives.locations (
+    id                     integer PRIMARY KEY,
+    locality               text,
+    street_address         text,
+    location_name          text,
+    location_type          text,
+    specific_location_type text,
+    location_notes         text,
+    visits                 integer,
+    latitude               double precision,
+    longitude              double precision
+);
+
+CREATE TABLE IF NOT EXISTS detectives.activity_locations (
+    activity_id integer NOT NULL REFERENCES detectives.activities ON DELETE CASCADE,
+    location_id integer NOT NULL REFERENCES detectives.locations ON DELETE CASCADE,
+    PRIMARY KEY (activity_id, location_id)
+);
+CREATE INDEX IF NOT EXISTS activity_locations_location_id_idx ON detectives.activity_locations (location_id);
@@ -0,0 +1,305 @@
+// Package openapi generates an OpenAPI 3.0 document from the endpoint
+// catalogs that datasets publish.
+package openapi
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/chnm/apiary/internal/httpx"
+)
+
+// Version is the OpenAPI specification version of generated documents.
+const Version = "3.0.3"
+
+// Document is an OpenAPI document.
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Servers    []Server             `json:"servers,omitempty"`
+	Tags       []Tag                `json:"tags,omitempty"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+}
+
+// Info describes the API.
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// Server is a base URL at which the API is available.
+type Server struct {
+	URL string `json:"url"`
+}
+
+// Tag groups operations, one tag per dataset.
+type Tag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem holds the operations available on a path. Apiary only serves GET
+// and HEAD, and HEAD is implied by GET.
+type PathItem struct {
+	Get *Operation `json:"get,omitempty"`
+}
+
+// Operation describes a single API operation.
+type Operation struct {
+	OperationID string               `json:"operationId"`
+	Summary     string               `json:"summary"`
+	Tags        []string             `json:"tags,omitempty"`
+	Parameters  []Parameter          `json:"parameters,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+// Parameter describes a path or query parameter.
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Style       string  `json:"style,omitempty"`
+	Explode     *bool   `json:"explode,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// Response describes a response for a status code.
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType pairs a content type with the schema of its body.
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Components holds schemas shared between operations.
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+// Builder accumulates dataset catalogs into a Document.
+type Builder struct {
+	doc     Document
+	schemas *schemaRegistry
+	ids     map[string]bool
+}
+
+// NewBuilder returns a builder for a document describing info.
+func NewBuilder(info Info) *Builder {
+	return &Builder{
+		doc: Document{
+			OpenAPI: Version,
+			Info:    info,
+			Paths:   make(map[string]*PathItem),
+		},
+		schemas: newSchemaRegistry(),
+		ids:     make(map[string]bool),
+	}
+}
+
+// AddServer records a base URL at which the API is served.
+func (b *Builder) AddServer(url string) {
+	b.doc.Servers = append(b.doc.Servers, Server{URL: url})
+}
+
+// AddTag declares a tag and its description.
+func (b *Builder) AddTag(name, description string) {
+	b.doc.Tags = append(b.doc.Tags, Tag{Name: name, Description: description})
+}
+
+// AddEndpoints adds an operation for each endpoint, tagged with tag. It
+// reports catalog mistakes, such as a missing path or a path parameter that is
+// not declared, so that tests catch them before clients do.
+func (b *Builder) AddEndpoints(tag string, endpoints []httpx.Endpoint) error {
+	for _, endpoint := range endpoints {
+		if err := b.addEndpoint(tag, endpoint); err != nil {
+			return fmt.Errorf("endpoint %q: %w", endpoint.Name, err)
+		}
+	}
+	return nil
+}
+
+// Document returns the accumulated document.
+func (b *Builder) Document() Document {
+	doc := b.doc
+	doc.Components.Schemas = b.schemas.components
+	return doc
+}
+
+// problemType describes every error response.
+var problemType = reflect.TypeFor[httpx.Problem]()
+
+var pathParameterPattern = regexp.MustCompile(`\{([^{}]+)\}`)
+
+func (b *Builder) addEndpoint(tag string, endpoint httpx.Endpoint) error {
+	if !strings.HasPrefix(endpoint.Path, "/") {
+		return fmt.Errorf("path %q must start with /", endpoint.Path)
+	}
+	if item, ok := b.doc.Paths[endpoint.Path]; ok && item.Get != nil {
+		return fmt.Errorf("path %s is already documented", endpoint.Path)
+	}
+
+	var pathParameters []string
+	for _, match := range pathParameterPattern.FindAllStringSubmatch(endpoint.Path, -1) {
+		pathParameters = append(pathParameters, match[1])
+	}
+
+	op := &Operation{
+		OperationID: b.operationID(endpoint.Path),
+		Summary:     endpoint.Name,
+		Tags:        []string{tag},
+		Responses:   make(map[string]*Response),
+	}
+	var declared []string
+	for _, p := range endpoint.Parameters {
+		parameter, err := b.parameter(p)
+		if err != nil {
+			return err
+		}
+		if p.In == httpx.InPath {
+			if !slices.Contains(pathParameters, p.Name) {
+				return fmt.Errorf("path parameter %q does not appear in %s", p.Name, endpoint.Path)
+			}
+			declared = append(declared, p.Name)
+		}
+		op.Parameters = append(op.Parameters, parameter)
+	}
+	for _, name := range pathParameters {
+		if !slices.Contains(declared, name) {
+			return fmt.Errorf("path parameter %q is not declared", name)
+		}
+	}
+
+	contentType := endpoint.ContentType
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	var body *Schema
+	if endpoint.Response == nil {
+		body = &Schema{}
+	} else {
+		body = b.schemas.schemaFor(reflect.TypeOf(endpoint.Response))
+	}
+	op.Responses["200"] = &Response{
+		Description: "Successful response",
+		Content:     map[string]MediaType{contentType: {Schema: body}},
+	}
+	if endpoint.CSV {
+		if !slices.ContainsFunc(endpoint.Parameters, func(p httpx.Parameter) bool { return p.Name == httpx.FormatParameter.Name }) {
+			return fmt.Errorf("CSV endpoint does not declare the %s parameter", httpx.FormatParameter.Name)
+		}
+		op.Responses["200"].Content["text/csv"] = MediaType{Schema: &Schema{Type: "string"}}
+	}
+	if endpoint.StreamRow != nil {
+		if !slices.ContainsFunc(endpoint.Parameters, func(p httpx.Parameter) bool {
+			return p.Name == httpx.StreamFormatParameter.Name && slices.Contains(p.Enum, any(httpx.FormatNDJSON))
+		}) {
+			return fmt.Errorf("streaming endpoint does not declare the %s parameter with %s", httpx.StreamFormatParameter.Name, httpx.FormatNDJSON)
+		}
+		// OpenAPI 3.0 cannot describe a sequence of documents, so the schema
+		// is that of a single line.
+		op.Responses["200"].Content[httpx.NDJSONContentType] = MediaType{Schema: b.schemas.schemaFor(reflect.TypeOf(endpoint.StreamRow))}
+	}
+	op.Responses["304"] = &Response{
+		Description: "Not modified since the version named by If-None-Match or If-Modified-Since",
+	}
+	op.Responses["default"] = &Response{
+		Description: "Error",
+		Content: map[string]MediaType{
+			httpx.ProblemContentType: {Schema: b.schemas.schemaFor(problemType)},
+		},
+	}
+
+	b.doc.Paths[endpoint.Path] = &PathItem{Get: op}
+	return nil
+}
+
+func (b *Builder) parameter(p httpx.Parameter) (Parameter, error) {
+	switch p.In {
+	case httpx.InQuery, httpx.InPath:
+	default:
+		return Parameter{}, fmt.Errorf("parameter %q has unknown location %q", p.Name, p.In)
+	}
+	switch p.Type {
+	case httpx.TypeString, httpx.TypeInteger, httpx.TypeNumber, httpx.TypeBoolean:
+	default:
+		return Parameter{}, fmt.Errorf("parameter %q has unknown type %q", p.Name, p.Type)
+	}
+	if p.List && p.Repeated {
+		return Parameter{}, fmt.Errorf("parameter %q cannot be both a list and repeated", p.Name)
+	}
+
+	value := &Schema{
+		Type:    p.Type,
+		Format:  p.Format,
+		Enum:    p.Enum,
+		Minimum: p.Minimum,
+		Maximum: p.Maximum,
+	}
+	parameter := Parameter{
+		Name:        p.Name,
+		In:          p.In,
+		Description: p.Description,
+		Required:    p.Required || p.In == httpx.InPath,
+		Schema:      value,
+	}
+	switch {
+	case p.List:
+		explode := false
+		parameter.Style = "form"
+		if p.In == httpx.InPath {
+			parameter.Style = "simple"
+		}
+		parameter.Explode = &explode
+		parameter.Schema = &Schema{Type: "array", Items: value, Default: p.Default}
+	case p.Repeated:
+		explode := true
+		parameter.Style = "form"
+		parameter.Explode = &explode
+		parameter.Schema = &Schema{Type: "array", Items: value, Default: p.Default}
+	default:
+		value.Default = p.Default
+	}
+	return parameter, nil
+}
+
+// operationID derives a stable identifier from a path, such as
+// getPinkertonsActivitiesByID for /pinkertons/activities/{id}.
+func (b *Builder) operationID(path string) string {
+	var id strings.Builder
+	id.WriteString("get")
+	for _, segment := range strings.Split(path, "/") {
+		if segment == "" {
+			continue
+		}
+		if strings.HasPrefix(segment, "{") {
+			id.WriteString("By")
+			segment = strings.Trim(segment, "{}")
+		}
+		for _, word := range strings.FieldsFunc(segment, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		}) {
+			if strings.EqualFold(word, "id") {
+				id.WriteString("ID")
+				continue
+			}
+			id.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+
+	operationID := id.String()
+	for n := 2; b.ids[operationID]; n++ {
+		operationID = fmt.Sprintf("%s%d", id.String(), n)
+	}
+	b.ids[operationID] = true
+	return operationID
+}
@@ -0,0 +1,234 @@
+package openapi
+
+import (
+	"database/sql"
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/chnm/apiary/internal/httpx"
+)
+
+type row struct {
+	ID       int              `json:"id"`
+	Name     httpx.NullString `json:"name"`
+	Count    httpx.NullInt64  `json:"count"`
+	Missing  *bool            `json:"missing"`
+	Child    *child           `json:"child,omitempty"`
+	Children []child          `json:"children"`
+	Ignored  string           `json:"-"`
+	hidden   string
+	embedded
+}
+
+type child struct {
+	Rate float64 `json:"rate"`
+}
+
+type embedded struct {
+	Source string `json:"source"`
+}
+
+type nullFloat struct {
+	sql.NullFloat64
+}
+
+func (v nullFloat) MarshalJSON() ([]byte, error) { return json.Marshal(nil) }
+
+func TestSchemaForStruct(t *testing.T) {
+	r := newSchemaRegistry()
+	ref := r.schemaFor(reflect.TypeFor[[]row]())
+	if ref.Type != "array" || ref.Items.Ref != "#/components/schemas/row" {
+		t.Fatalf("schema = %+v, want array of row references", ref)
+	}
+
+	s := r.components["row"]
+	want := map[string]Schema{
+		"id":      {Type: "integer"},
+		"name":    {Type: "string", Nullable: true},
+		"count":   {Type: "integer", Format: "int64", Nullable: true},
+		"missing": {Type: "boolean", Nullable: true},
+		"child":   {Ref: "#/components/schemas/child"},
+		"source":  {Type: "string"},
+	}
+	for name, w := range want {
+		got := s.Properties[name]
+		if got == nil {
+			t.Errorf("property %q missing", name)
+			continue
+		}
+		if got.Type != w.Type || got.Format != w.Format || got.Nullable != w.Nullable || got.Ref != w.Ref {
+			t.Errorf("property %q = %+v, want %+v", name, *got, w)
+		}
+	}
+	for _, name := range []string{"Ignored", "hidden"} {
+		if _, ok := s.Properties[name]; ok {
+			t.Errorf("property %q should not be described", name)
+		}
+	}
+	if slices.Contains(s.Required, "child") || !slices.Contains(s.Required, "id") {
+		t.Errorf("required = %v, want id but not omitempty child", s.Required)
+	}
+	if r.components["child"] == nil {
+		t.Error("child component was not registered")
+	}
+}
+
+type page[T any] struct {
+	Data []T `json:"data"`
+}
+
+func TestSchemaForGenericStruct(t *testing.T) {
+	r := newSchemaRegistry()
+	if ref := r.schemaFor(reflect.TypeFor[page[child]]()); ref.Ref != "#/components/schemas/page_child" {
+		t.Fatalf("schema = %+v, want a reference to page_child", ref)
+	}
+	if s := r.components["page_child"]; s == nil || s.Properties["data"].Items.Ref != "#/components/schemas/child" {
+		t.Fatalf("page_child = %+v, want data of child references", s)
+	}
+	if name := r.componentName(reflect.TypeFor[page[child]]()); name != "openapi.page_openapi.child" {
+		t.Fatalf("name of a second page[child] = %q, want it qualified", name)
+	}
+}
+
+func TestSchemaForCustomMarshalers(t *testing.T) {
+	r := newSchemaRegistry()
+	if s := r.schemaFor(reflect.TypeFor[nullFloat]()); s.Type != "number" || !s.Nullable {
+		t.Errorf("nullable wrapper schema = %+v, want nullable number", s)
+	}
+	if s := r.schemaFor(reflect.TypeFor[json.RawMessage]()); s.Type != "" {
+		t.Errorf("raw message schema = %+v, want unconstrained", s)
+	}
+}
+
+func TestBuilderDocumentsEndpoints(t *testing.T) {
+	b := NewBuilder(Info{Title: "test", Version: "1"})
+	err := b.AddEndpoints("things", []httpx.Endpoint{
+		{
+			Name: "Things",
+			Path: "/things",
+			Parameters: []httpx.Parameter{
+				{Name: "id", In: httpx.InQuery, Type: httpx.TypeInteger, List: true},
+				{Name: "place", In: httpx.InQuery, Type: httpx.TypeString, Repeated: true},
+				{Name: "sort", In: httpx.InQuery, Type: httpx.TypeString, Default: "year", Enum: []any{"year", "name"}},
+			},
+			Response: []child{},
+		},
+		{
+			Name:       "Thing rows",
+			Path:       "/things.csv",
+			Parameters: []httpx.Parameter{httpx.StreamFormatParameter},
+			Response:   []child{},
+			CSV:        true,
+			StreamRow:  child{},
+		},
+		{
+			Name:       "Thing",
+			Path:       "/things/{id}",
+			Parameters: []httpx.Parameter{{Name: "id", In: httpx.InPath, Type: httpx.TypeInteger}},
+			Response:   child{},
+		},
+	})
+	if err != nil {
+		t.Fatalf("AddEndpoints() error = %v", err)
+	}
+	doc := b.Document()
+
+	list := doc.Paths["/things"].Get
+	if list.OperationID != "getThings" || list.Tags[0] != "things" {
+		t.Errorf("operation = %s tagged %v", list.OperationID, list.Tags)
+	}
+	id, place, sort := list.Parameters[0], list.Parameters[1], list.Parameters[2]
+	if id.Schema.Type != "array" || *id.Explode || id.Style != "form" {
+		t.Errorf("list parameter = %+v, want unexploded form array", id)
+	}
+	if place.Schema.Type != "array" || !*place.Explode {
+		t.Errorf("repeated parameter = %+v, want exploded array", place)
+	}
+	if sort.Schema.Default != "year" || len(sort.Schema.Enum) != 2 {
+		t.Errorf("sort schema = %+v", sort.Schema)
+	}
+
+	rows := doc.Paths["/things.csv"].Get
+	if _, ok := rows.Responses["200"].Content["text/csv"]; !ok {
+		t.Errorf("CSV endpoint content = %v, want text/csv", rows.Responses["200"].Content)
+	}
+	if got := rows.Responses["200"].Content[httpx.NDJSONContentType].Schema; got == nil || got.Ref != "#/components/schemas/child" {
+		t.Errorf("NDJSON line schema = %+v, want child", got)
+	}
+
+	item := doc.Paths["/things/{id}"].Get
+	if item.OperationID != "getThingsByID" || !item.Parameters[0].Required {
+		t.Errorf("operation = %s, parameters = %+v", item.OperationID, item.Parameters)
+	}
+	if got := item.Responses["200"].Content["application/json"].Schema.Ref; got != "#/components/schemas/child" {
+		t.Errorf("response schema ref = %q", got)
+	}
+	if _, ok := item.Responses["304"]; !ok {
+		t.Error("endpoint does not document 304 Not Modified")
+	}
+	if got := item.Responses["default"].Content[httpx.ProblemContentType].Schema.Ref; got != "#/components/schemas/Problem" {
+		t.Errorf("error schema ref = %q", got)
+	}
+	if _, ok := item.Responses["200"].Content["text/csv"]; ok {
+		t.Errorf("JSON-only endpoint documents text/csv")
+	}
+	if problem := doc.Components.Schemas["Problem"]; problem.Properties["code"] == nil || problem.Properties["request_id"] == nil {
+		t.Errorf("Problem schema = %+v", problem)
+	}
+}
+
+func TestBuilderRejectsInconsistentEndpoints(t *testing.T) {
+	tests := []struct {
+		name     string
+		endpoint httpx.Endpoint
+		want     string
+	}{
+		{
+			name:     "missing path",
+			endpoint: httpx.Endpoint{Name: "x"},
+			want:     "must start with /",
+		},
+		{
+			name:     "undeclared path parameter",
+			endpoint: httpx.Endpoint{Name: "x", Path: "/x/{id}"},
+			want:     `path parameter "id" is not declared`,
+		},
+		{
+			name: "unknown path parameter",
+			endpoint: httpx.Endpoint{Name: "x", Path: "/x", Parameters: []httpx.Parameter{
+				{Name: "id", In: httpx.InPath, Type: httpx.TypeInteger},
+			}},
+			want: "does not appear",
+		},
+		{
+			name: "unknown type",
+			endpoint: httpx.Endpoint{Name: "x", Path: "/x", Parameters: []httpx.Parameter{
+				{Name: "q", In: httpx.InQuery, Type: "date"},
+			}},
+			want: "unknown type",
+		},
+		{
+			name:     "CSV without format parameter",
+			endpoint: httpx.Endpoint{Name: "x", Path: "/x", CSV: true},
+			want:     "does not declare the format parameter",
+		},
+		{
+			name: "stream without ndjson format",
+			endpoint: httpx.Endpoint{Name: "x", Path: "/x", Parameters: []httpx.Parameter{httpx.FormatParameter},
+				StreamRow: child{}},
+			want: "does not declare the format parameter with ndjson",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewBuilder(Info{}).AddEndpoints("x", []httpx.Endpoint{tt.endpoint})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("AddEndpoints() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,213 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Schema is the subset of the OpenAPI 3.0 schema object that Apiary uses.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Default              any                `json:"default,omitempty"`
+	Minimum              *int               `json:"minimum,omitempty"`
+	Maximum              *int               `json:"maximum,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+var (
+	marshalerType = reflect.TypeFor[json.Marshaler]()
+	timeType      = reflect.TypeFor[time.Time]()
+)
+
+// schemaRegistry turns Go types into schemas, collecting named struct types as
+// reusable components.
+type schemaRegistry struct {
+	components map[string]*Schema
+	names      map[reflect.Type]string
+}
+
+func newSchemaRegistry() *schemaRegistry {
+	return &schemaRegistry{
+		components: make(map[string]*Schema),
+		names:      make(map[reflect.Type]string),
+	}
+}
+
+// schemaFor describes the JSON encoding of values of type t.
+func (r *schemaRegistry) schemaFor(t reflect.Type) *Schema {
+	if t == nil {
+		return &Schema{}
+	}
+	if t.Kind() == reflect.Pointer {
+		s := r.schemaFor(t.Elem())
+		if s.Ref != "" {
+			// A $ref may not have siblings in OpenAPI 3.0.
+			return s
+		}
+		s.Nullable = true
+		return s
+	}
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+	if t.Implements(marshalerType) {
+		if value, ok := nullableValue(t); ok {
+			s := r.schemaFor(value)
+			s.Nullable = true
+			return s
+		}
+		// The type controls its own encoding, so its shape is unknown.
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Uint:
+		return &Schema{Type: "integer"}
+	case reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: r.schemaFor(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: r.schemaFor(t.Elem())}
+	case reflect.Struct:
+		return r.structRef(t)
+	default:
+		// Interfaces and anything else may hold any JSON value.
+		return &Schema{}
+	}
+}
+
+// structRef registers t as a component and returns a reference to it.
+func (r *schemaRegistry) structRef(t reflect.Type) *Schema {
+	if t.Name() == "" {
+		return r.structSchema(t)
+	}
+	name, ok := r.names[t]
+	if !ok {
+		name = r.componentName(t)
+		r.names[t] = name
+		// Reserve the name before describing fields so recursive types work.
+		r.components[name] = nil
+		r.components[name] = r.structSchema(t)
+	}
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// componentName prefers the bare type name and qualifies it with the package
+// name only when two packages export types with the same name. The type
+// arguments of generic types are named the same way, so that Page[Parish]
+// becomes Page_Parish, or Page_bom.Parish if that is taken.
+func (r *schemaRegistry) componentName(t reflect.Type) string {
+	name := componentChars.ReplaceAllString(typeArgPackages.ReplaceAllString(t.Name(), ""), "_")
+	name = strings.TrimRight(name, "_")
+	if _, taken := r.components[name]; !taken {
+		return name
+	}
+	pkg := t.PkgPath()
+	if i := strings.LastIndex(pkg, "/"); i >= 0 {
+		pkg = pkg[i+1:]
+	}
+	name = componentChars.ReplaceAllString(typeArgPaths.ReplaceAllString(t.Name(), ""), "_")
+	return pkg + "." + strings.TrimRight(name, "_")
+}
+
+var (
+	// typeArgPaths matches the import path before the package name of a
+	// type argument, as in the name of Page[github.com/chnm/apiary/bom.Parish].
+	typeArgPaths = regexp.MustCompile(`[\w.\-/]*/`)
+	// typeArgPackages matches the import path and package name.
+	typeArgPackages = regexp.MustCompile(`[\w.\-/]*\.`)
+	// componentChars matches what OpenAPI does not allow in component names.
+	componentChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+)
+
+func (r *schemaRegistry) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	r.addFields(s, t)
+	return s
+}
+
+// addFields follows encoding/json: unexported and "-" fields are skipped,
+// untagged embedded structs are flattened, and fields without omitempty are
+// always present.
+func (r *schemaRegistry) addFields(s *Schema, t reflect.Type) {
+	for i := range t.NumField() {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				r.addFields(s, embedded)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		s.Properties[name] = r.schemaFor(field.Type)
+		if !strings.Contains(options, "omitempty") {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
+
+// nullableValue recognizes the database/sql Null* shape, including wrappers
+// that embed one: a struct holding a single value field and a Valid flag.
+// Such types marshal as either the value or null.
+func nullableValue(t reflect.Type) (reflect.Type, bool) {
+	if t.Kind() != reflect.Struct {
+		return nil, false
+	}
+	if t.NumField() == 1 && t.Field(0).Anonymous {
+		return nullableValue(t.Field(0).Type)
+	}
+	if t.NumField() != 2 {
+		return nil, false
+	}
+	var value reflect.Type
+	var valid bool
+	for i := range 2 {
+		field := t.Field(i)
+		if field.Name == "Valid" && field.Type.Kind() == reflect.Bool {
+			valid = true
+		} else {
+			value = field.Type
+		}
+	}
+	return value, valid && value != nil
+}
@@ -1,4 +1,3 @@
-// Package params contains shared request-parameter parsing helpers.
 package params
 
 import "time"
@@ -0,0 +1,464 @@
+// Package params parses and validates request parameters from declarations
+// that also describe them in the endpoint catalog.
+//
+// A handler declares the parameters it reads as a Set of Specs:
+//
+//	var billsParams = params.NewSet(
+//		params.Int("start-year", "First year to include").Default(1648),
+//		params.Int("parish", "Parish IDs from /bom/parishes").List().Min(1),
+//		params.Enum("bill-type", "Restrict results to one type of bill", "weekly", "general", "total"),
+//	)
+//
+// Set.Parse reads a request against the declarations and returns its Values,
+// or an *httpx.ParameterError that names the parameter at fault. Query
+// parameters that the set does not declare are rejected. Set.Parameters
+// describes the same declarations for the endpoint's catalog entry, so that
+// the catalog and OpenAPI document always match what the handler accepts.
+package params
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chnm/apiary/internal/httpx"
+	"github.com/gorilla/mux"
+)
+
+// RefreshParameter asks the response cache for a fresh response. Every
+// endpoint accepts it.
+const RefreshParameter = "nocache"
+
+type kind int
+
+const (
+	kindString kind = iota
+	kindInt
+	kindBool
+	kindDate
+	kindEnum
+)
+
+// Spec declares a parameter: its catalog description, and how its values are
+// parsed and checked. Its methods return a modified copy.
+type Spec struct {
+	parameter        httpx.Parameter
+	kind             kind
+	enum             []string
+	choices          []int     // The values an integer parameter may have, if limited
+	fallback         any       // The value when a request does not give the parameter
+	minDate, maxDate time.Time // Dates are clamped to this range; a zero time leaves that end open
+}
+
+// String declares a parameter whose value is any text.
+func String(name, description string) Spec {
+	return Spec{parameter: httpx.Parameter{Name: name, In: httpx.InQuery, Type: httpx.TypeString, Description: description}, kind: kindString}
+}
+
+// Int declares an integer parameter.
+func Int(name, description string) Spec {
+	return Spec{parameter: httpx.Parameter{Name: name, In: httpx.InQuery, Type: httpx.TypeInteger, Description: description}, kind: kindInt}
+}
+
+// Bool declares a parameter that is true or false.
+func Bool(name, description string) Spec {
+	return Spec{parameter: httpx.Parameter{Name: name, In: httpx.InQuery, Type: httpx.TypeBoolean, Description: description}, kind: kindBool}
+}
+
+// Date declares a parameter that is a date formatted as YYYY-MM-DD.
+func Date(name, description string) Spec {
+	return Spec{parameter: httpx.Parameter{Name: name, In: httpx.InQuery, Type: httpx.TypeString, Format: "date", Description: description}, kind: kindDate}
+}
+
+// Enum declares a parameter that is one of values. Values match without
+// regard to case, and parse to the value as declared.
+func Enum(name, description string, values ...string) Spec {
+	enum := make([]any, len(values))
+	for i, value := range values {
+		enum[i] = value
+	}
+	return Spec{
+		parameter: httpx.Parameter{Name: name, In: httpx.InQuery, Type: httpx.TypeString, Enum: enum, Description: description},
+		kind:      kindEnum,
+		enum:      values,
+	}
+}
+
+// InPath moves the parameter from the query into the route's path.
+func (s Spec) InPath() Spec {
+	s.parameter.In = httpx.InPath
+	s.parameter.Required = true
+	return s
+}
+
+// Required means requests must give the parameter.
+func (s Spec) Required() Spec {
+	s.parameter.Required = true
+	return s
+}
+
+// Default sets the value of the parameter when a request does not give it.
+// Its type must match the parameter's: int, bool, string, or time.Time.
+func (s Spec) Default(value any) Spec {
+	if s.parameter.List || s.parameter.Repeated {
+		panic(fmt.Sprintf("params: %s: lists have no default", s.parameter.Name))
+	}
+	var ok bool
+	s.fallback, s.parameter.Default = value, value
+	switch s.kind {
+	case kindInt:
+		_, ok = value.(int)
+	case kindBool:
+		_, ok = value.(bool)
+	case kindDate:
+		var date time.Time
+		if date, ok = value.(time.Time); ok {
+			s.parameter.Default = date.Format(time.DateOnly)
+		}
+	default:
+		_, ok = value.(string)
+	}
+	if !ok {
+		panic(fmt.Sprintf("params: %s: default %v has the wrong type", s.parameter.Name, value))
+	}
+	return s
+}
+
+// Min is the least value an integer parameter may have.
+func (s Spec) Min(n int) Spec {
+	s.mustBe(kindInt, "Min")
+	s.parameter.Minimum = httpx.Bound(n)
+	return s
+}
+
+// Max is the greatest value an integer parameter may have.
+func (s Spec) Max(n int) Spec {
+	s.mustBe(kindInt, "Max")
+	s.parameter.Maximum = httpx.Bound(n)
+	return s
+}
+
+// Between bounds an integer parameter to the range from min to max.
+func (s Spec) Between(min, max int) Spec {
+	return s.Min(min).Max(max)
+}
+
+// OneOf limits an integer parameter to values.
+func (s Spec) OneOf(values ...int) Spec {
+	s.mustBe(kindInt, "OneOf")
+	s.choices = values
+	s.parameter.Enum = make([]any, len(values))
+	for i, value := range values {
+		s.parameter.Enum[i] = value
+	}
+	return s
+}
+
+// Clamp moves dates before min or after max to the nearest end of the range,
+// rather than rejecting them.
+func (s Spec) Clamp(min, max time.Time) Spec {
+	s.mustBe(kindDate, "Clamp")
+	s.minDate, s.maxDate = min, max
+	return s
+}
+
+// List means the value is a comma-separated list, as in ?parish=1,3,17.
+func (s Spec) List() Spec {
+	s.mustBeListable("List")
+	s.parameter.List = true
+	return s
+}
+
+// Repeated means the parameter may be given more than once, as in
+// ?location=Europe&location=Asia.
+func (s Spec) Repeated() Spec {
+	s.mustBeListable("Repeated")
+	s.parameter.Repeated = true
+	return s
+}
+
+func (s Spec) mustBe(k kind, method string) {
+	if s.kind != k {
+		panic(fmt.Sprintf("params: %s: %s does not apply to %s parameters", s.parameter.Name, method, s.describeKind()))
+	}
+}
+
+func (s Spec) mustBeListable(method string) {
+	if s.kind == kindBool || s.kind == kindDate {
+		panic(fmt.Sprintf("params: %s: %s does not apply to %s parameters", s.parameter.Name, method, s.describeKind()))
+	}
+	if s.parameter.Default != nil {
+		panic(fmt.Sprintf("params: %s: lists have no default", s.parameter.Name))
+	}
+}
+
+func (s Spec) describeKind() string {
+	switch s.kind {
+	case kindInt:
+		return "integer"
+	case kindBool:
+		return "boolean"
+	case kindDate:
+		return "date"
+	case kindEnum:
+		return "enum"
+	}
+	return "string"
+}
+
+func (s Spec) many() bool {
+	return s.parameter.List || s.parameter.Repeated
+}
+
+// parse returns the value of the parameter given raw, which holds each time
+// the request gives it, and whether raw gives it a value. Without one, the
+// value is the default, or nil if there is none.
+func (s Spec) parse(raw []string) (value any, given bool, err error) {
+	name := s.parameter.Name
+	var items []string
+	for _, text := range raw {
+		switch {
+		case text == "":
+		case !s.parameter.List:
+			items = append(items, text)
+		default:
+			for _, item := range strings.Split(text, ",") {
+				if item = strings.TrimSpace(item); item == "" {
+					return nil, false, httpx.InvalidParameterf(name, "%s must not have empty items", name)
+				}
+				items = append(items, item)
+			}
+		}
+	}
+
+	switch {
+	case len(items) == 0 && s.parameter.Required:
+		return nil, false, httpx.MissingParameterError(name)
+	case len(items) == 0:
+		return s.fallback, false, nil
+	case len(raw) > 1 && !s.parameter.Repeated:
+		return nil, false, httpx.InvalidParameterf(name, "%s may only be given once", name)
+	}
+
+	value, err = s.convert(items)
+	return value, err == nil, err
+}
+
+// convert parses the items of a parameter that the request gives.
+func (s Spec) convert(items []string) (any, error) {
+	name := s.parameter.Name
+	switch s.kind {
+	case kindInt:
+		ints := make([]int, len(items))
+		for i, item := range items {
+			n, err := strconv.Atoi(strings.TrimSpace(item))
+			if err != nil {
+				if s.many() {
+					return nil, httpx.InvalidParameterf(name, "%s must be a list of integers", name)
+				}
+				return nil, httpx.InvalidParameterf(name, "%s must be an integer", name)
+			}
+			if err := s.checkBounds(n); err != nil {
+				return nil, err
+			}
+			ints[i] = n
+		}
+		if s.many() {
+			return ints, nil
+		}
+		return ints[0], nil
+	case kindBool:
+		b, err := strconv.ParseBool(items[0])
+		if err != nil {
+			return nil, httpx.InvalidParameterf(name, "%s must be true or false", name)
+		}
+		return b, nil
+	case kindDate:
+		date, err := time.Parse(time.DateOnly, items[0])
+		if err != nil {
+			return nil, httpx.InvalidParameterf(name, "%s must be a date formatted as YYYY-MM-DD", name)
+		}
+		if !s.minDate.IsZero() && date.Before(s.minDate) {
+			return s.minDate, nil
+		}
+		if !s.maxDate.IsZero() && date.After(s.maxDate) {
+			return s.maxDate, nil
+		}
+		return date, nil
+	case kindEnum:
+		for i, item := range items {
+			j := slices.IndexFunc(s.enum, func(value string) bool { return strings.EqualFold(value, item) })
+			if j < 0 {
+				return nil, httpx.InvalidParameterf(name, "%s must be %s", name, join(s.enum, "or"))
+			}
+			items[i] = s.enum[j]
+		}
+	}
+	if s.many() {
+		return items, nil
+	}
+	return items[0], nil
+}
+
+func (s Spec) checkBounds(n int) error {
+	min, max := s.parameter.Minimum, s.parameter.Maximum
+	name := s.parameter.Name
+	switch {
+	case min != nil && max != nil && (n < *min || n > *max):
+		return httpx.InvalidParameterf(name, "%s must be from %d to %d", name, *min, *max)
+	case min != nil && n < *min:
+		return httpx.InvalidParameterf(name, "%s must be at least %d", name, *min)
+	case max != nil && n > *max:
+		return httpx.InvalidParameterf(name, "%s must be at most %d", name, *max)
+	case s.choices != nil && !slices.Contains(s.choices, n):
+		choices := make([]string, len(s.choices))
+		for i, choice := range s.choices {
+			choices[i] = strconv.Itoa(choice)
+		}
+		return httpx.InvalidParameterf(name, "%s must be %s", name, join(choices, "or"))
+	}
+	return nil
+}
+
+// join lists values in a sentence, as in "weekly, general, or total".
+func join(values []string, conjunction string) string {
+	switch len(values) {
+	case 1:
+		return values[0]
+	case 2:
+		return values[0] + " " + conjunction + " " + values[1]
+	}
+	return strings.Join(values[:len(values)-1], ", ") + ", " + conjunction + " " + values[len(values)-1]
+}
+
+// Set is the parameters of an endpoint.
+type Set struct {
+	specs    []Spec
+	accepted []httpx.Parameter
+}
+
+// NewSet returns a Set of specs. It panics if two share a name.
+func NewSet(specs ...Spec) Set {
+	return Set{}.with(specs, nil)
+}
+
+// Accepting returns s with parameters that another parser reads, such as
+// the paging or format parameters. Parse allows them without reading them,
+// except to check that the values of one with an Enum are among it, and
+// Parameters describes them after the Set's own.
+func (s Set) Accepting(parameters ...httpx.Parameter) Set {
+	return s.with(nil, parameters)
+}
+
+func (s Set) with(specs []Spec, accepted []httpx.Parameter) Set {
+	next := Set{
+		specs:    slices.Concat(s.specs, specs),
+		accepted: slices.Concat(s.accepted, accepted),
+	}
+	names := make(map[string]bool)
+	for _, p := range next.Parameters() {
+		if names[p.Name] {
+			panic(fmt.Sprintf("params: %s is declared twice", p.Name))
+		}
+		names[p.Name] = true
+	}
+	return next
+}
+
+// Parameters describes the Set for an endpoint's catalog entry.
+func (s Set) Parameters() []httpx.Parameter {
+	parameters := make([]httpx.Parameter, 0, len(s.specs)+len(s.accepted))
+	for _, spec := range s.specs {
+		parameters = append(parameters, spec.parameter)
+	}
+	return append(parameters, s.accepted...)
+}
+
+// Parse reads the parameters of r. The error is an *httpx.ParameterError for
+// the first parameter at fault: one that is required and missing, one whose
+// value is invalid, or one that the Set does not declare.
+func (s Set) Parse(r *http.Request) (Values, error) {
+	query := r.URL.Query()
+	if err := s.checkNames(query); err != nil {
+		return Values{}, err
+	}
+	if err := s.checkAccepted(query); err != nil {
+		return Values{}, err
+	}
+
+	vars := mux.Vars(r)
+	values := Values{
+		values: make(map[string]any, len(s.specs)),
+		given:  make(map[string]bool, len(s.specs)),
+		specs:  make(map[string]Spec, len(s.specs)),
+	}
+	for _, spec := range s.specs {
+		name := spec.parameter.Name
+		raw := query[name]
+		if spec.parameter.In == httpx.InPath {
+			raw = nil
+			if value, ok := vars[name]; ok {
+				raw = []string{value}
+			}
+		}
+		value, given, err := spec.parse(raw)
+		if err != nil {
+			return Values{}, err
+		}
+		values.specs[name] = spec
+		values.given[name] = given
+		if value != nil {
+			values.values[name] = value
+		}
+	}
+	return values, nil
+}
+
+// checkNames rejects the first query parameter, in alphabetical order, that
+// the Set does not declare.
+func (s Set) checkNames(query url.Values) error {
+	var known []string
+	for _, p := range s.Parameters() {
+		if p.In == httpx.InQuery {
+			known = append(known, p.Name)
+		}
+	}
+	var unknown []string
+	for name := range query {
+		if name != RefreshParameter && !slices.Contains(known, name) {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+	slices.Sort(unknown)
+	if len(known) == 0 {
+		return httpx.InvalidParameterf(unknown[0], "unknown parameter %s; this endpoint takes no query parameters", unknown[0])
+	}
+	return httpx.InvalidParameterf(unknown[0], "unknown parameter %s; this endpoint takes %s", unknown[0], join(known, "and"))
+}
+
+// checkAccepted rejects a value of an accepted parameter that is not among
+// its Enum, without regard to case. Empty values are left to its parser.
+func (s Set) checkAccepted(query url.Values) error {
+	for _, p := range s.accepted {
+		if p.In != httpx.InQuery || len(p.Enum) == 0 {
+			continue
+		}
+		choices := make([]string, len(p.Enum))
+		for i, value := range p.Enum {
+			choices[i] = fmt.Sprint(value)
+		}
+		for _, value := range query[p.Name] {
+			if value != "" && !slices.ContainsFunc(choices, func(choice string) bool { return strings.EqualFold(choice, value) }) {
+				return httpx.InvalidParameterf(p.Name, "%s must be %s", p.Name, join(choices, "or"))
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,234 @@
+package params
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/chnm/apiary/internal/httpx"
+	"github.com/gorilla/mux"
+)
+
+var (
+	firstDate = time.Date(1629, time.March, 4, 0, 0, 0, 0, time.UTC)
+	lastDate  = time.Date(2000, time.December, 31, 0, 0, 0, 0, time.UTC)
+)
+
+var testSet = NewSet(
+	Int("start-year", "First year").Default(1648),
+	Int("week", "Week number").Between(1, 53),
+	Int("census", "Census year").OneOf(1906, 1916),
+	Int("parish", "Parish IDs").List().Min(1),
+	Enum("type", "Bill type", "weekly", "general"),
+	Enum("location", "Continents", "Europe", "North America").Repeated(),
+	Bool("missing", "Missing records"),
+	Date("date", "Date of the boundaries").Clamp(firstDate, lastDate),
+	String("name", "Parish name"),
+).Accepting(httpx.FormatParameter)
+
+func parse(t *testing.T, set Set, target string) Values {
+	t.Helper()
+	values, err := set.Parse(httptest.NewRequest(http.MethodGet, target, nil))
+	if err != nil {
+		t.Fatalf("Parse(%s): %v", target, err)
+	}
+	return values
+}
+
+func TestParseDefaults(t *testing.T) {
+	values := parse(t, testSet, "/bills?nocache=1&format=csv")
+
+	if got := values.Int("start-year"); got != 1648 {
+		t.Errorf("start-year = %d, want the default 1648", got)
+	}
+	if values.Has("start-year") || values.Has("missing") {
+		t.Error("Has reports parameters that the request does not give")
+	}
+	if got := values.Ints("parish"); got != nil {
+		t.Errorf("parish = %v, want none", got)
+	}
+	if got := values.String("type"); got != "" {
+		t.Errorf("type = %q, want none", got)
+	}
+	if got := values.Date("date"); !got.IsZero() {
+		t.Errorf("date = %v, want the zero time", got)
+	}
+}
+
+func TestParseValues(t *testing.T) {
+	values := parse(t, testSet, "/bills?start-year=1665&week=53&census=1916&parish=1,%203&type=WEEKLY&location=europe&location=North+America&missing=true&date=1844-05-08&name=All+Hallows")
+
+	if got := values.Int("start-year"); got != 1665 || !values.Has("start-year") {
+		t.Errorf("start-year = %d, want 1665", got)
+	}
+	if got := values.Int("week"); got != 53 {
+		t.Errorf("week = %d, want 53", got)
+	}
+	if got := values.Int("census"); got != 1916 {
+		t.Errorf("census = %d, want 1916", got)
+	}
+	if got := values.Ints("parish"); !reflect.DeepEqual(got, []int{1, 3}) {
+		t.Errorf("parish = %v, want [1 3]", got)
+	}
+	if got := values.String("type"); got != "weekly" {
+		t.Errorf("type = %q, want the declared weekly", got)
+	}
+	if got := values.Strings("location"); !reflect.DeepEqual(got, []string{"Europe", "North America"}) {
+		t.Errorf("location = %q, want Europe and North America", got)
+	}
+	if !values.Bool("missing") || !values.Has("missing") {
+		t.Error("missing = false, want true")
+	}
+	if got := values.Date("date"); !got.Equal(time.Date(1844, time.May, 8, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("date = %v, want 1844-05-08", got)
+	}
+	if got := values.String("name"); got != "All Hallows" {
+		t.Errorf("name = %q, want All Hallows", got)
+	}
+}
+
+func TestParseClampsDates(t *testing.T) {
+	tests := []struct {
+		date string
+		want time.Time
+	}{
+		{date: "1492-10-12", want: firstDate},
+		{date: "2020-01-01", want: lastDate},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.date, func(t *testing.T) {
+			if got := parse(t, testSet, "/bills?date="+tt.date).Date("date"); !got.Equal(tt.want) {
+				t.Fatalf("date = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRejectsInvalidParameters(t *testing.T) {
+	tests := []struct {
+		target    string
+		parameter string
+		detail    string
+	}{
+		{target: "/bills?start-year=later", parameter: "start-year", detail: "start-year must be an integer"},
+		{target: "/bills?start-year=1665&start-year=1666", parameter: "start-year", detail: "start-year may only be given once"},
+		{target: "/bills?week=0", parameter: "week", detail: "week must be from 1 to 53"},
+		{target: "/bills?census=1926", parameter: "census", detail: "census must be 1906 or 1916"},
+		{target: "/bills?parish=0", parameter: "parish", detail: "parish must be at least 1"},
+		{target: "/bills?parish=1,two", parameter: "parish", detail: "parish must be a list of integers"},
+		{target: "/bills?parish=1,,2", parameter: "parish", detail: "parish must not have empty items"},
+		{target: "/bills?type=yearly", parameter: "type", detail: "type must be weekly or general"},
+		{target: "/bills?location=Atlantis", parameter: "location", detail: "location must be Europe or North America"},
+		{target: "/bills?missing=maybe", parameter: "missing", detail: "missing must be true or false"},
+		{target: "/bills?date=1844-13-01", parameter: "date", detail: "date must be a date formatted as YYYY-MM-DD"},
+		{target: "/bills?format=ndjson", parameter: "format", detail: "format must be json or csv"},
+		{target: "/bills?format=xml", parameter: "format", detail: "format must be json or csv"},
+		{target: "/bills?start_year=1665&year=1665", parameter: "start_year", detail: "unknown parameter start_year; this endpoint takes start-year, week, census, parish, type, location, missing, date, name, and format"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			_, err := testSet.Parse(httptest.NewRequest(http.MethodGet, tt.target, nil))
+			var parameterErr *httpx.ParameterError
+			if !errors.As(err, &parameterErr) || parameterErr.Parameter != tt.parameter || parameterErr.Detail != tt.detail {
+				t.Fatalf("Parse() error = %v, want %s: %s", err, tt.parameter, tt.detail)
+			}
+		})
+	}
+}
+
+func TestParseChecksAcceptedValues(t *testing.T) {
+	set := NewSet().Accepting(httpx.StreamFormatParameter)
+	for _, target := range []string{"/bills?format=NDJSON", "/bills?format=csv", "/bills?format="} {
+		parse(t, set, target)
+	}
+}
+
+func TestParseRequiredParameters(t *testing.T) {
+	set := NewSet(Enum("type", "Bill type", "weekly", "general").Required())
+
+	_, err := set.Parse(httptest.NewRequest(http.MethodGet, "/totalbills?type=", nil))
+	var parameterErr *httpx.ParameterError
+	if !errors.As(err, &parameterErr) || !parameterErr.Missing || parameterErr.Parameter != "type" {
+		t.Fatalf("Parse() error = %v, want type to be missing", err)
+	}
+}
+
+func TestParseRejectsQueriesToEndpointsWithoutParameters(t *testing.T) {
+	_, err := NewSet().Parse(httptest.NewRequest(http.MethodGet, "/bible-trend?corpus=ncnp", nil))
+	var parameterErr *httpx.ParameterError
+	if !errors.As(err, &parameterErr) || parameterErr.Detail != "unknown parameter corpus; this endpoint takes no query parameters" {
+		t.Fatalf("Parse() error = %v, want corpus to be unknown", err)
+	}
+}
+
+func TestParsePathParameters(t *testing.T) {
+	set := NewSet(
+		Date("date", "Date of the boundaries").InPath(),
+		String("id", "County IDs").InPath().List(),
+	)
+	request := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/counties/1844-05-08/id/mas_essex,mas_middlesex/?id=ignored", nil),
+		map[string]string{"date": "1844-05-08", "id": "mas_essex,mas_middlesex"})
+
+	_, err := set.Parse(request)
+	var parameterErr *httpx.ParameterError
+	if !errors.As(err, &parameterErr) || parameterErr.Parameter != "id" {
+		t.Fatalf("Parse() error = %v, want the id query parameter to be unknown", err)
+	}
+
+	request.URL.RawQuery = ""
+	values, err := set.Parse(request)
+	if err != nil {
+		t.Fatalf("Parse(): %v", err)
+	}
+	if got := values.Strings("id"); !reflect.DeepEqual(got, []string{"mas_essex", "mas_middlesex"}) {
+		t.Errorf("id = %q, want both counties", got)
+	}
+}
+
+func TestParametersDescribeTheSet(t *testing.T) {
+	parameters := NewSet(
+		Int("start-year", "First year").Default(1648),
+		Date("date", "Date of the boundaries").InPath().Default(firstDate),
+		Int("census", "Census year").OneOf(1906, 1916),
+	).Accepting(httpx.FormatParameter).Parameters()
+
+	want := []httpx.Parameter{
+		{Name: "start-year", In: httpx.InQuery, Type: httpx.TypeInteger, Default: 1648, Description: "First year"},
+		{Name: "date", In: httpx.InPath, Type: httpx.TypeString, Format: "date", Required: true, Default: "1629-03-04", Description: "Date of the boundaries"},
+		{Name: "census", In: httpx.InQuery, Type: httpx.TypeInteger, Enum: []any{1906, 1916}, Description: "Census year"},
+		httpx.FormatParameter,
+	}
+	if !reflect.DeepEqual(parameters, want) {
+		t.Fatalf("Parameters() = %+v, want %+v", parameters, want)
+	}
+}
+
+func TestMisdeclarationsPanic(t *testing.T) {
+	tests := map[string]func(){
+		"duplicate name":     func() { NewSet(Int("year", ""), String("year", "")) },
+		"duplicate accepted": func() { NewSet(String("format", "")).Accepting(httpx.FormatParameter) },
+		"bound on a string":  func() { String("name", "").Min(1) },
+		"list of booleans":   func() { Bool("missing", "").List() },
+		"default of a list":  func() { Int("parish", "").List().Default(1) },
+		"mistyped default":   func() { Int("year", "").Default("1665") },
+		"undeclared value":   func() { parse(t, testSet, "/bills").Int("year") },
+		"mistyped value":     func() { parse(t, testSet, "/bills").String("week") },
+		"list as one value":  func() { parse(t, testSet, "/bills").Int("parish") },
+	}
+
+	for name, declare := range tests {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Fatal("did not panic")
+				}
+			}()
+			declare()
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package params
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Values are the parameters of a request, parsed by Set.Parse. Each getter
+// returns the parameter's default, or the zero value, when the request does
+// not give it. Getters panic when asked for a parameter the Set does not
+// declare, or one of another type, since that is a mistake in the handler.
+type Values struct {
+	values map[string]any
+	given  map[string]bool
+	specs  map[string]Spec
+}
+
+// Has reports whether the request gave the parameter.
+func (v Values) Has(name string) bool {
+	v.spec(name)
+	return v.given[name]
+}
+
+// String returns a string or enum parameter.
+func (v Values) String(name string) string {
+	return get[string](v, name, false, kindString, kindEnum)
+}
+
+// Int returns an integer parameter.
+func (v Values) Int(name string) int {
+	return get[int](v, name, false, kindInt)
+}
+
+// Bool returns a boolean parameter.
+func (v Values) Bool(name string) bool {
+	return get[bool](v, name, false, kindBool)
+}
+
+// Date returns a date parameter.
+func (v Values) Date(name string) time.Time {
+	return get[time.Time](v, name, false, kindDate)
+}
+
+// Strings returns a list of strings or enum values.
+func (v Values) Strings(name string) []string {
+	return get[[]string](v, name, true, kindString, kindEnum)
+}
+
+// Ints returns a list of integers.
+func (v Values) Ints(name string) []int {
+	return get[[]int](v, name, true, kindInt)
+}
+
+func (v Values) spec(name string) Spec {
+	spec, ok := v.specs[name]
+	if !ok {
+		panic(fmt.Sprintf("params: %s is not declared", name))
+	}
+	return spec
+}
+
+func get[T any](v Values, name string, many bool, kinds ...kind) T {
+	spec := v.spec(name)
+	if spec.many() != many || !slices.Contains(kinds, spec.kind) {
+		panic(fmt.Sprintf("params: %s is not a parameter of this type", name))
+	}
+	value, _ := v.values[name].(T)
+	return value
+}
@@ -0,0 +1,74 @@
+package ratelimit
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// ClientIP returns the address of the client that made r. If the request came
+// from a trusted proxy, the client is the nearest address in X-Forwarded-For
+// that is not itself a trusted proxy; addresses farther along the chain could
+// have been written by the client, so they are not believed. It returns the
+// zero Addr if the address cannot be parsed.
+func ClientIP(r *http.Request, trustedProxies []netip.Prefix) netip.Addr {
+	addr := parseAddr(r.RemoteAddr)
+	if !addr.IsValid() || !contains(trustedProxies, addr) {
+		return addr
+	}
+
+	var hops []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := parseAddr(strings.TrimSpace(hops[i]))
+		if !hop.IsValid() {
+			// A malformed entry ends the part of the chain that can be
+			// trusted, so blame the proxy that passed it on.
+			return addr
+		}
+		addr = hop
+		if !contains(trustedProxies, addr) {
+			return addr
+		}
+	}
+	return addr
+}
+
+// parseAddr parses an address with or without a port, unmapping IPv4 addresses
+// written in IPv6 form so that they match IPv4 prefixes.
+func parseAddr(s string) netip.Addr {
+	if host, _, err := net.SplitHostPort(s); err == nil {
+		s = host
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Addr{}
+	}
+	return addr.Unmap().WithZone("")
+}
+
+// ParsePrefixes parses a list of addresses and CIDR prefixes, such as
+// 10.0.0.0/8 or 192.0.2.10. A bare address is a prefix of one address.
+func ParsePrefixes(values []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(values))
+	for _, value := range values {
+		if strings.Contains(value, "/") {
+			prefix, err := netip.ParsePrefix(value)
+			if err != nil {
+				return nil, err
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(value)
+		if err != nil {
+			return nil, err
+		}
+		addr = addr.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
@@ -0,0 +1,42 @@
+package ratelimit
+
+import (
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	trusted, err := ParsePrefixes([]string{"10.0.0.0/8", "192.0.2.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  []string
+		want       string
+	}{
+		{"direct", "203.0.113.5:4000", nil, "203.0.113.5"},
+		{"untrusted peer's header is ignored", "203.0.113.5:4000", []string{"198.51.100.1"}, "203.0.113.5"},
+		{"trusted proxy", "10.0.0.2:4000", []string{"198.51.100.1"}, "198.51.100.1"},
+		{"spoofed entries before the client", "10.0.0.2:4000", []string{"1.2.3.4, 198.51.100.1"}, "198.51.100.1"},
+		{"chain of trusted proxies", "10.0.0.2:4000", []string{"198.51.100.1, 192.0.2.1", "10.0.0.3"}, "198.51.100.1"},
+		{"only proxies", "10.0.0.2:4000", []string{"10.0.0.3"}, "10.0.0.3"},
+		{"malformed entry", "10.0.0.2:4000", []string{"198.51.100.1, garbage"}, "10.0.0.2"},
+		{"mapped IPv4", "[::ffff:10.0.0.2]:4000", []string{"198.51.100.1"}, "198.51.100.1"},
+		{"IPv6", "[2001:db8::1]:4000", nil, "2001:db8::1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest("GET", "/", nil)
+			request.RemoteAddr = tt.remoteAddr
+			for _, value := range tt.forwarded {
+				request.Header.Add("X-Forwarded-For", value)
+			}
+			if got := ClientIP(request, trusted); got != netip.MustParseAddr(tt.want) {
+				t.Errorf("ClientIP() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,295 @@
+// Package ratelimit limits how fast each client can make requests, with a
+// token bucket per client for each class of route.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/chnm/apiary/internal/httpx"
+	"github.com/gorilla/mux"
+	"golang.org/x/time/rate"
+)
+
+// Class names a group of routes that share a limit.
+type Class string
+
+// Route classes. Routes that declare none are in Default.
+const (
+	Default Class = "default"
+	// Bulk routes return large results or are walked page by page, and so
+	// cost the database more per request.
+	Bulk Class = "bulk"
+	// AuthFailures counts requests with an invalid API key rather than
+	// requests to a group of routes. Its buckets are always by address, and
+	// are checked before a key is looked up; see Refuse and Fail.
+	AuthFailures Class = "auth_failures"
+)
+
+// Limit is the rate at which a client may make requests to a class of routes.
+type Limit struct {
+	PerMinute int // Sustained requests per minute
+	Burst     int // Requests allowed at once after a quiet period
+}
+
+// Options configure a Limiter.
+type Options struct {
+	Limits         map[Class]Limit
+	TrustedProxies []netip.Prefix // Proxies whose X-Forwarded-For is believed
+	Exempt         []netip.Prefix // Clients that are never limited
+}
+
+// ipv6ClientBits is the length of the prefix that identifies an IPv6 client.
+// Hosts are usually given a whole /64, and could otherwise rotate through it
+// to escape their limit.
+const ipv6ClientBits = 64
+
+// idleSweepInterval is how often buckets that have refilled are discarded.
+const idleSweepInterval = time.Minute
+
+// Limiter tracks the token buckets of every client.
+type Limiter struct {
+	limits         map[Class]Limit
+	trustedProxies []netip.Prefix
+	exempt         []netip.Prefix
+	now            func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[bucketKey]*rate.Limiter
+	lastSweep time.Time
+}
+
+type bucketKey struct {
+	class  Class
+	client string
+}
+
+// New returns a Limiter. There must be a limit for Default and for every
+// class that routes declare.
+func New(options Options) (*Limiter, error) {
+	if _, ok := options.Limits[Default]; !ok {
+		return nil, fmt.Errorf("no limit for the %s route class", Default)
+	}
+	for class, limit := range options.Limits {
+		if limit.PerMinute < 1 || limit.Burst < 1 {
+			return nil, fmt.Errorf("the %s route class must allow at least one request per minute and a burst of one", class)
+		}
+	}
+	return &Limiter{
+		limits:         options.Limits,
+		trustedProxies: options.TrustedProxies,
+		exempt:         options.Exempt,
+		now:            time.Now,
+		buckets:        make(map[bucketKey]*rate.Limiter),
+	}, nil
+}
+
+// classHandler carries a route's class alongside its handler so that the
+// middleware can find it from the matched route.
+type classHandler struct {
+	http.Handler
+	class Class
+}
+
+// Unwrap implements httpx.Declaration.
+func (h classHandler) Unwrap() http.Handler { return h.Handler }
+
+// In returns a function that declares that the handlers it wraps belong to
+// class, for use when registering routes:
+//
+//	bulk := ratelimit.In(ratelimit.Bulk)
+//	router.Handle("/bom/bills", cached(bulk(h.BillsHandler())))
+func In(class Class) func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return classHandler{Handler: h, class: class}
+	}
+}
+
+// ClassFor returns the class declared by the route that matched r.
+func ClassFor(r *http.Request) Class {
+	if c, ok := httpx.RouteDeclaration[classHandler](mux.CurrentRoute(r)); ok {
+		return c.class
+	}
+	return Default
+}
+
+type clientIDKey struct{}
+
+// WithClientID returns a context that identifies an authenticated client, such
+// as the holder of an API key. Requests with a client ID are limited by that
+// ID rather than by address. Only set it after verifying the credential, or
+// clients could escape their limit by inventing IDs.
+func WithClientID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, clientIDKey{}, id)
+}
+
+// Middleware rejects requests from clients that have used up their limit for
+// the route's class with 429 Too Many Requests. Every limited response carries
+// RateLimit-Limit (the requests allowed a minute), RateLimit-Policy,
+// RateLimit-Remaining, and RateLimit-Reset headers. It must run inside the
+// router so that it can find the route's class.
+func (l *Limiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		client, ok := l.client(r)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		class := ClassFor(r)
+		limit, ok := l.limits[class]
+		if !ok {
+			class, limit = Default, l.limits[Default]
+		}
+
+		now := l.now()
+		bucket := l.bucket(bucketKey{class: class, client: client}, limit, now)
+		reservation := bucket.ReserveN(now, 1)
+		delay := reservation.DelayFrom(now)
+		if delay > 0 {
+			reservation.CancelAt(now)
+		}
+
+		tokens := max(0, bucket.TokensAt(now))
+		header := w.Header()
+		header.Set("RateLimit-Limit", strconv.Itoa(limit.PerMinute))
+		header.Set("RateLimit-Policy", fmt.Sprintf("%d;w=60;burst=%d", limit.PerMinute, limit.Burst))
+		header.Set("RateLimit-Remaining", strconv.Itoa(int(tokens)))
+		header.Set("RateLimit-Reset", strconv.Itoa(seconds(float64(limit.Burst)-tokens, limit)))
+		if delay <= 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		header.Set("Retry-After", strconv.Itoa(int(math.Ceil(delay.Seconds()))))
+		header.Set("Cache-Control", "no-store")
+		httpx.WriteProblem(w, r, httpx.Problem{
+			Status: http.StatusTooManyRequests,
+			Code:   httpx.CodeRateLimited,
+			Detail: fmt.Sprintf("too many requests; this client may make %d requests a minute to these routes", limit.PerMinute),
+		})
+	})
+}
+
+// Refuse reports whether the address making r has used up its limit for
+// class, and if so writes a 429 problem to w. Unlike Middleware it spends
+// nothing, so that callers can check a limit before doing work that only some
+// requests count against, and spend it with Fail. A nil Limiter refuses
+// nothing.
+func (l *Limiter) Refuse(w http.ResponseWriter, r *http.Request, class Class) bool {
+	if l == nil {
+		return false
+	}
+	limit, ok := l.limits[class]
+	client, limited := l.address(r)
+	if !ok || !limited {
+		return false
+	}
+	now := l.now()
+	bucket := l.bucket(bucketKey{class: class, client: client}, limit, now)
+	tokens := bucket.TokensAt(now)
+	if tokens >= 1 {
+		return false
+	}
+	header := w.Header()
+	header.Set("Retry-After", strconv.Itoa(seconds(1-tokens, limit)))
+	header.Set("Cache-Control", "no-store")
+	httpx.WriteProblem(w, r, httpx.Problem{
+		Status: http.StatusTooManyRequests,
+		Code:   httpx.CodeRateLimited,
+		Detail: fmt.Sprintf("too many failed requests; this client may make %d a minute", limit.PerMinute),
+	})
+	return true
+}
+
+// Fail spends one of the address's requests for class, such as a failed
+// authentication. A nil Limiter counts nothing.
+func (l *Limiter) Fail(r *http.Request, class Class) {
+	if l == nil {
+		return
+	}
+	limit, ok := l.limits[class]
+	client, limited := l.address(r)
+	if !ok || !limited {
+		return
+	}
+	now := l.now()
+	l.bucket(bucketKey{class: class, client: client}, limit, now).AllowN(now, 1)
+}
+
+// client returns the key that identifies the client making r, and false if the
+// client is exempt.
+func (l *Limiter) client(r *http.Request) (string, bool) {
+	if id, ok := r.Context().Value(clientIDKey{}).(string); ok && id != "" {
+		return "id:" + id, true
+	}
+	return l.address(r)
+}
+
+// address returns the key that identifies the address making r, whether or
+// not the request has a client ID, and false if the address is exempt. IPv6
+// addresses are identified by their /64.
+func (l *Limiter) address(r *http.Request) (string, bool) {
+	addr := ClientIP(r, l.trustedProxies)
+	if !addr.IsValid() {
+		// Without an address all such requests share one bucket, which is
+		// better than not limiting them.
+		return "unknown", true
+	}
+	if contains(l.exempt, addr) {
+		return "", false
+	}
+	if addr.Is6() {
+		return netip.PrefixFrom(addr, ipv6ClientBits).Masked().String(), true
+	}
+	return addr.String(), true
+}
+
+// bucket returns the client's bucket for a class, creating it full.
+func (l *Limiter) bucket(key bucketKey, limit Limit, now time.Time) *rate.Limiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if now.Sub(l.lastSweep) >= idleSweepInterval {
+		l.sweep(now)
+	}
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = rate.NewLimiter(rate.Limit(float64(limit.PerMinute)/60), limit.Burst)
+		l.buckets[key] = bucket
+	}
+	return bucket
+}
+
+// sweep discards the buckets that have refilled, since a new bucket would be
+// the same. It is called with l.mu held.
+func (l *Limiter) sweep(now time.Time) {
+	for key, bucket := range l.buckets {
+		if bucket.TokensAt(now) >= float64(bucket.Burst()) {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastSweep = now
+}
+
+// seconds returns how long, rounded up to whole seconds, it takes to earn the
+// given number of tokens.
+func seconds(tokens float64, limit Limit) int {
+	if tokens <= 0 {
+		return 0
+	}
+	return int(math.Ceil(tokens * 60 / float64(limit.PerMinute)))
+}
+
+func contains(prefixes []netip.Prefix, addr netip.Addr) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,252 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/chnm/apiary/internal/httpx"
+	"github.com/chnm/apiary/internal/testsupport"
+	"github.com/gorilla/mux"
+)
+
+// newTestRouter returns a router limited by l, with /light in the default
+// class and /heavy in the bulk class.
+func newTestRouter(l *Limiter) *mux.Router {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { _, _ = w.Write([]byte("ok")) })
+	router := mux.NewRouter()
+	router.Handle("/light", ok)
+	router.Handle("/heavy", In(Bulk)(ok))
+	router.Use(l.Middleware)
+	return router
+}
+
+func newTestLimiter(t *testing.T, options Options) (*Limiter, *time.Time) {
+	t.Helper()
+	if options.Limits == nil {
+		options.Limits = map[Class]Limit{
+			Default: {PerMinute: 60, Burst: 2},
+			Bulk:    {PerMinute: 6, Burst: 1},
+		}
+	}
+	l, err := New(options)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	l.now = func() time.Time { return now }
+	return l, &now
+}
+
+func serve(router http.Handler, path, remoteAddr string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodGet, path, nil)
+	request.RemoteAddr = remoteAddr
+	response := httptest.NewRecorder()
+	router.ServeHTTP(response, request)
+	return response
+}
+
+func TestMiddleware(t *testing.T) {
+	l, now := newTestLimiter(t, Options{})
+	router := newTestRouter(l)
+
+	first := serve(router, "/light", "192.0.2.1:1234")
+	if first.Code != http.StatusOK {
+		t.Fatalf("first status = %d, want %d", first.Code, http.StatusOK)
+	}
+	for name, want := range map[string]string{"RateLimit-Limit": "60", "RateLimit-Policy": "60;w=60;burst=2", "RateLimit-Remaining": "1", "RateLimit-Reset": "1"} {
+		if got := first.Header().Get(name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+
+	serve(router, "/light", "192.0.2.1:1234")
+	limited := serve(router, "/light", "192.0.2.1:1234")
+	testsupport.AssertProblem(t, limited, http.StatusTooManyRequests, httpx.CodeRateLimited, "")
+	if got := limited.Header().Get("Retry-After"); got != "1" {
+		t.Errorf("Retry-After = %q, want 1", got)
+	}
+	if got := limited.Header().Get("RateLimit-Remaining"); got != "0" {
+		t.Errorf("RateLimit-Remaining = %q, want 0", got)
+	}
+
+	// Other clients and other classes have their own allowance.
+	if got := serve(router, "/light", "192.0.2.2:1234").Code; got != http.StatusOK {
+		t.Errorf("other client status = %d, want %d", got, http.StatusOK)
+	}
+	if got := serve(router, "/heavy", "192.0.2.1:1234").Code; got != http.StatusOK {
+		t.Errorf("bulk class status = %d, want %d", got, http.StatusOK)
+	}
+	heavy := serve(router, "/heavy", "192.0.2.1:1234")
+	if heavy.Code != http.StatusTooManyRequests || heavy.Header().Get("Retry-After") != "10" {
+		t.Errorf("second bulk request = %d with Retry-After %q, want 429 with 10", heavy.Code, heavy.Header().Get("Retry-After"))
+	}
+
+	// Tokens are earned back over time.
+	*now = now.Add(time.Second)
+	if got := serve(router, "/light", "192.0.2.1:1234").Code; got != http.StatusOK {
+		t.Errorf("status after refill = %d, want %d", got, http.StatusOK)
+	}
+}
+
+func TestMiddlewareExemptsAllowlist(t *testing.T) {
+	exempt, err := ParsePrefixes([]string{"198.51.100.0/24"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, _ := newTestLimiter(t, Options{Exempt: exempt})
+	router := newTestRouter(l)
+
+	for range 5 {
+		response := serve(router, "/heavy", "198.51.100.7:1234")
+		if response.Code != http.StatusOK {
+			t.Fatalf("exempt client status = %d, want %d", response.Code, http.StatusOK)
+		}
+		if response.Header().Get("RateLimit-Limit") != "" {
+			t.Fatal("exempt client got RateLimit headers")
+		}
+	}
+}
+
+func TestMiddlewareLimitsByClientID(t *testing.T) {
+	l, _ := newTestLimiter(t, Options{})
+	router := newTestRouter(l)
+	withID := func(id string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			router.ServeHTTP(w, r.WithContext(WithClientID(r.Context(), id)))
+		})
+	}
+
+	if got := serve(withID("alpha"), "/heavy", "192.0.2.1:1234").Code; got != http.StatusOK {
+		t.Fatalf("alpha status = %d, want %d", got, http.StatusOK)
+	}
+	// A second client behind the same address has its own allowance.
+	if got := serve(withID("beta"), "/heavy", "192.0.2.1:1234").Code; got != http.StatusOK {
+		t.Errorf("beta status = %d, want %d", got, http.StatusOK)
+	}
+	if got := serve(withID("alpha"), "/heavy", "192.0.2.9:1234").Code; got != http.StatusTooManyRequests {
+		t.Errorf("alpha from another address status = %d, want %d", got, http.StatusTooManyRequests)
+	}
+}
+
+func TestMiddlewareLimitsIPv6ClientsByPrefix(t *testing.T) {
+	l, _ := newTestLimiter(t, Options{})
+	router := newTestRouter(l)
+
+	if got := serve(router, "/heavy", "[2001:db8:1:2::1]:1234").Code; got != http.StatusOK {
+		t.Fatalf("first status = %d, want %d", got, http.StatusOK)
+	}
+	if got := serve(router, "/heavy", "[2001:db8:1:2:abcd::7]:1234").Code; got != http.StatusTooManyRequests {
+		t.Errorf("another address in the /64 status = %d, want %d", got, http.StatusTooManyRequests)
+	}
+	if got := serve(router, "/heavy", "[2001:db8:1:3::1]:1234").Code; got != http.StatusOK {
+		t.Errorf("another /64 status = %d, want %d", got, http.StatusOK)
+	}
+}
+
+func TestRefuseCountsOnlyFailures(t *testing.T) {
+	l, now := newTestLimiter(t, Options{Limits: map[Class]Limit{
+		Default:      {PerMinute: 60, Burst: 2},
+		AuthFailures: {PerMinute: 6, Burst: 1},
+	}})
+	request := httptest.NewRequest(http.MethodGet, "/light", nil)
+	request.RemoteAddr = "192.0.2.1:1234"
+	refused := func(r *http.Request) bool {
+		return l.Refuse(httptest.NewRecorder(), r, AuthFailures)
+	}
+
+	for range 3 {
+		if refused(request) {
+			t.Fatal("Refuse spent the address's allowance")
+		}
+	}
+	l.Fail(request, AuthFailures)
+	response := httptest.NewRecorder()
+	if !l.Refuse(response, request, AuthFailures) {
+		t.Fatal("Refuse allowed an address over its limit")
+	}
+	testsupport.AssertProblem(t, response, http.StatusTooManyRequests, httpx.CodeRateLimited, "")
+	if got := response.Header().Get("Retry-After"); got != "10" {
+		t.Errorf("Retry-After = %q, want 10", got)
+	}
+	// A client ID does not move failures off the address.
+	if !refused(request.WithContext(WithClientID(request.Context(), "alpha"))) {
+		t.Error("Refuse allowed the address under a client ID")
+	}
+
+	*now = now.Add(10 * time.Second)
+	if refused(request) {
+		t.Error("Refuse did not allow the address once its bucket refilled")
+	}
+	var nilLimiter *Limiter
+	if nilLimiter.Refuse(httptest.NewRecorder(), request, AuthFailures) {
+		t.Error("a nil Limiter refused a request")
+	}
+}
+
+func TestSweepDiscardsRefilledBuckets(t *testing.T) {
+	l, now := newTestLimiter(t, Options{})
+	router := newTestRouter(l)
+	serve(router, "/light", "192.0.2.1:1234")
+	serve(router, "/light", "192.0.2.2:1234")
+
+	*now = now.Add(idleSweepInterval)
+	serve(router, "/light", "192.0.2.3:1234")
+	if len(l.buckets) != 1 {
+		t.Errorf("buckets after sweep = %d, want 1", len(l.buckets))
+	}
+}
+
+func TestNewRequiresDefaultLimit(t *testing.T) {
+	if _, err := New(Options{Limits: map[Class]Limit{Bulk: {PerMinute: 1, Burst: 1}}}); err == nil {
+		t.Error("New() without a default limit error = nil")
+	}
+	if _, err := New(Options{Limits: map[Class]Limit{Default: {PerMinute: 0, Burst: 1}}}); err == nil {
+		t.Error("New() with a zero rate error = nil")
+	}
+}
+
+func TestClassFor(t *testing.T) {
+	var got Class
+	record := http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) { got = ClassFor(r) })
+	wrapper := func(h http.Handler) http.Handler { return unwrapper{h} }
+	router := mux.NewRouter()
+	router.Handle("/bulk", In(Bulk)(record))
+	router.Handle("/wrapped", wrapper(In(Bulk)(record)))
+	router.Handle("/plain", record)
+
+	for path, want := range map[string]Class{"/bulk": Bulk, "/wrapped": Bulk, "/plain": Default} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+		if got != want {
+			t.Errorf("ClassFor(%s) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+// unwrapper stands in for another package's route declaration.
+type unwrapper struct{ http.Handler }
+
+func (u unwrapper) Unwrap() http.Handler { return u.Handler }
+
+func TestParsePrefixes(t *testing.T) {
+	got, err := ParsePrefixes([]string{"10.1.2.3/8", "192.0.2.10", "2001:db8::/32", "::ffff:192.0.2.11"})
+	if err != nil {
+		t.Fatalf("ParsePrefixes() error = %v", err)
+	}
+	want := []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("192.0.2.10/32"),
+		netip.MustParsePrefix("2001:db8::/32"),
+		netip.MustParsePrefix("192.0.2.11/32"),
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("prefix %d = %s, want %s", i, got[i], want[i])
+		}
+	}
+	if _, err := ParsePrefixes([]string{"proxy.example.com"}); err == nil {
+		t.Error("ParsePrefixes(hostname) error = nil")
+	}
+}
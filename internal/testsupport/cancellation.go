@@ -6,10 +6,10 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
-	"strings"
 	"testing"
 	"time"
 
+	"github.com/chnm/apiary/internal/httpx"
 	"github.com/gorilla/mux"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -90,12 +90,7 @@ func TestRequestCancellation(t *testing.T, tests []CancellationCase) {
 				t.Fatal("handler did not stop after request cancellation")
 			}
 
-			if response.Code != http.StatusInternalServerError {
-				t.Fatalf("status = %d, want %d", response.Code, http.StatusInternalServerError)
-			}
-			if body := strings.TrimSpace(response.Body.String()); body != http.StatusText(http.StatusInternalServerError) {
-				t.Fatalf("body = %q, want %q", body, http.StatusText(http.StatusInternalServerError))
-			}
+			AssertProblem(t, response, http.StatusInternalServerError, httpx.CodeInternal, "")
 		})
 	}
 }
@@ -0,0 +1,25 @@
+-- States and counties from the Atlas of Historical County Boundaries, with
+-- boundaries simplified to rectangles.
+INSERT INTO ahcb_states (id, name, abbr_name, area_sqmi, terr_type, start_date, end_date, geom_01) VALUES
+    ('va_state', 'Virginia', 'VA', 42774, 'State', '1788-06-25', '2000-12-31',
+        ST_GeomFromText('MULTIPOLYGON(((-83.7 36.5,-75.2 36.5,-75.2 39.5,-83.7 39.5,-83.7 36.5)))', 4326)),
+    ('ga_state', 'Georgia', 'GA', 59425, 'State', '1788-01-02', '2000-12-31',
+        ST_GeomFromText('MULTIPOLYGON(((-85.6 30.4,-80.8 30.4,-80.8 35.0,-85.6 35.0,-85.6 30.4)))', 4326)),
+    ('nd_state', 'North Dakota', 'ND', 70698, 'State', '1889-11-02', '2000-12-31',
+        ST_GeomFromText('MULTIPOLYGON(((-104.0 45.9,-96.6 45.9,-96.6 49.0,-104.0 49.0,-104.0 45.9)))', 4326)),
+    ('dakota_terr', 'Dakota Territory', 'DT', 150932, 'Territory', '1861-03-02', '1889-11-01',
+        ST_GeomFromText('MULTIPOLYGON(((-104.0 42.5,-96.4 42.5,-96.4 49.0,-104.0 49.0,-104.0 42.5)))', 4326));
+
+INSERT INTO ahcb_counties (id, name, state_terr, state_terr_id, state_code, area_sqmi, start_date, end_date, geom_01) VALUES
+    ('vas_fairfax', 'FAIRFAX', 'Virginia', 'va_state', 'va', 395, '1742-06-19', '2000-12-31',
+        ST_GeomFromText('MULTIPOLYGON(((-77.5 38.6,-77.1 38.6,-77.1 39.0,-77.5 39.0,-77.5 38.6)))', 4326)),
+    ('vas_arlington', 'ARLINGTON', 'Virginia', 'va_state', 'va', 26, '1920-03-16', '2000-12-31',
+        ST_GeomFromText('MULTIPOLYGON(((-77.2 38.8,-77.0 38.8,-77.0 38.9,-77.2 38.9,-77.2 38.8)))', 4326)),
+    ('vas_princewilliam', 'PRINCE WILLIAM', 'Virginia', 'va_state', 'va', 338, '1731-07-01', '2000-12-31',
+        ST_GeomFromText('MULTIPOLYGON(((-77.8 38.5,-77.2 38.5,-77.2 38.9,-77.8 38.9,-77.8 38.5)))', 4326)),
+    ('gas_fulton', 'FULTON', 'Georgia', 'ga_state', 'ga', 529, '1853-12-20', '2000-12-31',
+        ST_GeomFromText('MULTIPOLYGON(((-84.8 33.5,-84.2 33.5,-84.2 34.2,-84.8 34.2,-84.8 33.5)))', 4326)),
+    ('nds_cass', 'CASS', 'North Dakota', 'nd_state', 'nd', 1767, '1889-11-02', '2000-12-31',
+        ST_GeomFromText('MULTIPOLYGON(((-97.7 46.6,-96.8 46.6,-96.8 47.2,-97.7 47.2,-97.7 46.6)))', 4326)),
+    ('sds_minnehaha', 'MINNEHAHA', 'South Dakota', 'sd_state', 'sd', 814, '1889-11-02', '2000-12-31',
+        ST_GeomFromText('MULTIPOLYGON(((-97.1 43.5,-96.5 43.5,-96.5 43.9,-97.1 43.9,-97.1 43.5)))', 4326));
@@ -0,0 +1,70 @@
+-- A handful of verses from America's Public Bible, with quotations in
+-- Chronicling America and the counts computed from them. Word counts and
+-- the whole Bible's rates cover every year the trends chart.
+INSERT INTO apb.scriptures (verse_id, version, reference_id, book, part, book_order, chapter, verse, text) VALUES
+    (1, 'KJV', 'Genesis 1:1', 'Genesis', 'Old Testament', 1, 1, 1,
+        'In the beginning God created the heaven and the earth.'),
+    (2, 'KJV', 'Psalms 23:1', 'Psalms', 'Old Testament', 19, 23, 1,
+        'The LORD is my shepherd; I shall not want.'),
+    (3, 'KJV', 'Luke 18:16', 'Luke', 'New Testament', 42, 18, 16,
+        'But Jesus called them unto him, and said, Suffer little children to come unto me, and forbid them not: for of such is the kingdom of God.'),
+    (4, 'KJV', 'Mark 10:14', 'Mark', 'New Testament', 41, 10, 14,
+        'But when Jesus saw it, he was much displeased, and said unto them, Suffer the little children to come unto me, and forbid them not: for of such is the kingdom of God.'),
+    (5, 'KJV', 'John 3:16', 'John', 'New Testament', 43, 3, 16,
+        'For God so loved the world, that he gave his only begotten Son, that whosoever believeth in him should not perish, but have everlasting life.');
+
+INSERT INTO apb.scriptures_intraversion_pairs (version, a, b, score) VALUES
+    ('KJV', 3, 4, 0.91);
+
+-- Mark 10:14 is a near duplicate shown as Luke 18:16.
+INSERT INTO apb.verse_cleanup (reference_id, reference_use, use, display) VALUES
+    ('Genesis 1:1', 'Genesis 1:1', TRUE, TRUE),
+    ('Psalms 23:1', 'Psalms 23:1', TRUE, TRUE),
+    ('Luke 18:16', 'Luke 18:16', TRUE, TRUE),
+    ('Mark 10:14', 'Luke 18:16', FALSE, FALSE),
+    ('John 3:16', 'John 3:16', TRUE, TRUE);
+
+INSERT INTO apb.top_verses (reference_id, n) VALUES
+    ('Genesis 1:1', 812),
+    ('Psalms 23:1', 954),
+    ('Luke 18:16', 1375),
+    ('Mark 10:14', 240),
+    ('John 3:16', 2417);
+
+INSERT INTO apb.verse_peaks (reference_id, year) VALUES
+    ('Genesis 1:1', 1859),
+    ('Psalms 23:1', 1898),
+    ('Luke 18:16', 1887),
+    ('John 3:16', 1911);
+
+INSERT INTO apb.chronam_newspapers (lccn, title_clean) VALUES
+    ('sn83030213', 'New-York Daily Tribune'),
+    ('sn84026749', 'The Washington Times');
+
+INSERT INTO apb.chronam_newspaper_places (lccn, state) VALUES
+    ('sn83030213', 'New York'),
+    ('sn84026749', 'District of Columbia');
+
+INSERT INTO apb.chronam_pages (doc_id, lccn) VALUES
+    ('sn83030213/1859-03-14/ed-1/seq-4', 'sn83030213'),
+    ('sn83030213/1887-11-02/ed-1/seq-2', 'sn83030213'),
+    ('sn84026749/1911-06-18/ed-1/seq-9', 'sn84026749');
+
+INSERT INTO apb.quotations (corpus, reference_id, doc_id, date, probability) VALUES
+    ('chronam', 'Genesis 1:1', 'sn83030213/1859-03-14/ed-1/seq-4', '1859-03-14', 0.98),
+    ('chronam', 'Genesis 1:1', 'sn84026749/1911-06-18/ed-1/seq-9', '1911-06-18', 0.87),
+    ('chronam', 'Luke 18:16', 'sn83030213/1887-11-02/ed-1/seq-2', '1887-11-02', 0.93);
+
+INSERT INTO apb.count_quotations_verses (corpus, reference_id, year, n) VALUES
+    ('chronam', 'Genesis 1:1', 1859, 14),
+    ('chronam', 'Genesis 1:1', 1860, 9),
+    ('chronam', 'Genesis 1:1', 1911, 21),
+    ('chronam', 'Luke 18:16', 1887, 33);
+
+INSERT INTO apb.wordcounts (corpus, year, wordcount)
+SELECT 'chronam', year, 20000000 + (year - 1836) * 1500000
+FROM generate_series(1836, 1922) AS year;
+
+INSERT INTO apb.rate_quotations_bible (corpus, year, n, wordcount)
+SELECT 'chronam', year, 400 + (year % 7) * 60, 20000000 + (year - 1836) * 1500000
+FROM generate_series(1836, 1922) AS year;
@@ -0,0 +1,61 @@
+-- Bills of Mortality for three parishes over a few weeks of the plague of
+-- 1665, with a later year outside of it.
+INSERT INTO bom.year (year) VALUES (1664), (1665), (1666), (1670);
+
+INSERT INTO bom.week (joinid, year, week_number, start_day, start_month, end_day, end_month, split_year) VALUES
+    ('1664-52', 1664, 52, 13, 'December', 20, 'December', '1664'),
+    ('1665-01', 1665, 1, 20, 'December', 27, 'December', '1664/5'),
+    ('1665-38', 1665, 38, 12, 'September', 19, 'September', '1665'),
+    ('1665-52', 1665, 52, 12, 'December', 19, 'December', '1665'),
+    ('1666-01', 1666, 1, 19, 'December', 26, 'December', '1665/6'),
+    ('1670-10', 1670, 10, 22, 'February', 1, 'March', '1669/70');
+
+INSERT INTO bom.parishes (id, parish_name, canonical_name, bills_subunit, foundation_year, notes) VALUES
+    (1, 'Alhallows Barking', 'Allhallows Barking', '97 Parishes Within the Walls', NULL, NULL),
+    (2, 'St Giles Cripplegate', 'St Giles Cripplegate', '16 Parishes Without the Walls', NULL, NULL),
+    (3, 'St Margaret Westminster', 'St Margaret Westminster', 'Distant Parishes', '1660', 'Counted with the Westminster parishes from 1660.');
+
+INSERT INTO bom.bill_of_mortality (parish_id, week_id, year, bill_type, count_type, count, missing, illegible, source, unique_identifier) VALUES
+    (1, '1664-52', 1664, 'weekly', 'buried', 3, FALSE, FALSE, 'Wellcome', 'W1664-52-1-b'),
+    (1, '1664-52', 1664, 'weekly', 'plague', 0, FALSE, FALSE, 'Wellcome', 'W1664-52-1-p'),
+    (2, '1664-52', 1664, 'weekly', 'buried', 11, FALSE, FALSE, 'Wellcome', 'W1664-52-2-b'),
+    (1, '1665-38', 1665, 'weekly', 'buried', 28, FALSE, FALSE, 'Wellcome', 'W1665-38-1-b'),
+    (1, '1665-38', 1665, 'weekly', 'plague', 21, FALSE, FALSE, 'Wellcome', 'W1665-38-1-p'),
+    (2, '1665-38', 1665, 'weekly', 'buried', 344, FALSE, FALSE, 'Wellcome', 'W1665-38-2-b'),
+    (2, '1665-38', 1665, 'weekly', 'plague', 309, FALSE, FALSE, 'Wellcome', 'W1665-38-2-p'),
+    (3, '1665-38', 1665, 'weekly', 'buried', NULL, TRUE, FALSE, 'Wellcome', 'W1665-38-3-b'),
+    (3, '1665-38', 1665, 'weekly', 'plague', 198, FALSE, TRUE, 'Wellcome', 'W1665-38-3-p'),
+    (1, '1665-52', 1665, 'general', 'buried', 330, FALSE, FALSE, 'Guildhall', 'G1665-1-b'),
+    (1, '1665-52', 1665, 'general', 'plague', 226, FALSE, FALSE, 'Guildhall', 'G1665-1-p'),
+    (2, '1665-52', 1665, 'general', 'buried', 8069, FALSE, FALSE, 'Guildhall', 'G1665-2-b'),
+    (2, '1665-52', 1665, 'general', 'plague', 4838, FALSE, FALSE, 'Guildhall', 'G1665-2-p'),
+    (1, '1670-10', 1670, 'weekly', 'buried', 2, FALSE, FALSE, 'Wellcome', 'W1670-10-1-b'),
+    (2, '1670-10', 1670, 'weekly', 'buried', 9, FALSE, FALSE, 'Wellcome', 'W1670-10-2-b'),
+    (3, '1670-10', 1670, 'weekly', 'buried', 7, FALSE, FALSE, 'Wellcome', 'W1670-10-3-b');
+
+INSERT INTO bom.causes_of_death (week_id, bill_type, original_name, name, count, definition, definition_source) VALUES
+    ('1665-38', 'weekly', 'Aged', 'aged', 43, 'Death from old age.', 'OED'),
+    ('1665-38', 'weekly', 'Plague', 'plague', 7165, 'Bubonic plague.', 'OED'),
+    ('1665-38', 'weekly', 'Feaver and Ague', 'fever & ague', 31, NULL, NULL),
+    ('1665-52', 'general', 'Aged', 'aged', 1545, 'Death from old age.', 'OED'),
+    ('1665-52', 'general', 'Drowned', 'drowned', 50, NULL, NULL),
+    ('1670-10', 'weekly', 'Aged', 'aged', 38, 'Death from old age.', 'OED');
+
+INSERT INTO bom.christening_locations (id, name) VALUES
+    (1, 'Christened in the 97 Parishes within the walls'),
+    (2, 'Christened in the 16 Parishes without the walls');
+
+INSERT INTO bom.christenings (christening, bill_type, count, year, week_number, start_day, start_month, end_day, end_month) VALUES
+    ('Christened in the 97 Parishes within the walls', 'weekly', 22, 1665, 38, 12, 'September', 19, 'September'),
+    ('Christened in the 16 Parishes without the walls', 'weekly', 61, 1665, 38, 12, 'September', 19, 'September'),
+    ('Christened in the 97 Parishes within the walls', 'weekly', 41, 1670, 10, 22, 'February', 1, 'March'),
+    ('Christened in the 97 Parishes within the walls', 'general', 1037, 1665, NULL, NULL, NULL, NULL, NULL);
+
+-- Parish boundaries in the British National Grid.
+INSERT INTO bom.parishes_shp (id, par, civ_par, dbn_par, omeka_par, subunit, city_cnty, start_yr, sp_total, sp_per, geom_01) VALUES
+    (1, 'Allhallows Barking', 'Allhallows Barking', 'Allhallows Barking', 'Allhallows Barking', 'City', 'London', 1665, 0.03, 0.01,
+        ST_GeomFromText('MULTIPOLYGON(((533000 180600,533300 180600,533300 180900,533000 180900,533000 180600)))', 27700)),
+    (2, 'St Giles Cripplegate', 'St Giles Cripplegate', 'St Giles Cripplegate', 'St Giles Cripplegate', 'City', 'London', 1665, 0.21, 0.07,
+        ST_GeomFromText('MULTIPOLYGON(((532000 181600,532600 181600,532600 182200,532000 182200,532000 181600)))', 27700)),
+    (3, 'St Margaret Westminster', 'St Margaret Westminster', 'St Margaret Westminster', 'St Margaret Westminster', 'Westminster', 'Middlesex', 1665, 1.4, 0.46,
+        ST_GeomFromText('MULTIPOLYGON(((529000 179000,530200 179000,530200 180200,529000 180200,529000 179000)))', 27700));
@@ -0,0 +1,7 @@
+-- Catholic dioceses erected across four centuries.
+INSERT INTO catholic_dioceses (city, state, country, rite, date_erected, date_metropolitan, date_destroyed, geometry) VALUES
+    ('Mexico City', 'Mexico City', 'Mexico', 'Latin', '1530-09-02', '1546-02-12', NULL, ST_SetSRID(ST_MakePoint(-99.13, 19.43), 4326)),
+    ('Quebec', 'Quebec', 'Canada', 'Latin', '1674-10-01', '1819-01-12', NULL, ST_SetSRID(ST_MakePoint(-71.21, 46.81), 4326)),
+    ('Baltimore', 'Maryland', 'United States', 'Latin', '1789-11-06', '1808-04-08', NULL, ST_SetSRID(ST_MakePoint(-76.61, 39.29), 4326)),
+    ('Bardstown', 'Kentucky', 'United States', 'Latin', '1808-04-08', NULL, '1841-02-13', ST_SetSRID(ST_MakePoint(-85.47, 37.81), 4326)),
+    ('Pittsburgh', 'Pennsylvania', 'United States', 'Byzantine', '1924-05-08', '1969-02-21', NULL, ST_SetSRID(ST_MakePoint(-80.0, 40.44), 4326));
@@ -0,0 +1,11 @@
+-- Countries from Natural Earth, with boundaries simplified to rectangles.
+INSERT INTO naturalearth.countries (adm0_a3, name, continent, geom_50m) VALUES
+    ('USA', 'United States of America', 'North America',
+        ST_GeomFromText('MULTIPOLYGON(((-124.7 24.5,-66.9 24.5,-66.9 49.4,-124.7 49.4,-124.7 24.5)))', 4326)),
+    ('CAN', 'Canada', 'North America',
+        ST_GeomFromText('MULTIPOLYGON(((-141.0 41.7,-52.6 41.7,-52.6 83.1,-141.0 83.1,-141.0 41.7)))', 4326)),
+    ('CHN', 'China', 'Asia',
+        ST_GeomFromText('MULTIPOLYGON(((73.5 18.2,134.8 18.2,134.8 53.6,73.5 53.6,73.5 18.2)))', 4326)),
+    ('FRA', 'France', 'Europe',
+        ST_GeomFromText('MULTIPOLYGON(((-5.1 42.3,8.2 42.3,8.2 51.1,-5.1 51.1,-5.1 42.3)))', 4326)),
+    ('ATA', 'Antarctica', 'Antarctica', NULL);
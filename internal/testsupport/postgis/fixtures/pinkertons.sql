@@ -0,0 +1,19 @@
+-- Reports from two Pinkerton operatives over a few days of 1900 and 1901.
+-- Every activity has a location.
+INSERT INTO detectives.activities (id, source, operative, date, time, duration, activity, mode, activity_notes, subject, information, information_type, edited, edit_type, investigation) VALUES
+    (1, 'Report 1900-04-02', 'Operative 31', '1900-04-02', '09:00', '2 hours', 'Shadowed subject', 'On foot', NULL, 'J. Doe', 'Subject visited the union hall.', 'Observation', NULL, NULL, 'Streetcar strike'),
+    (2, 'Report 1900-04-02', 'Operative 31', '1900-04-02', '14:30', '1 hour', 'Attended meeting', NULL, 'Meeting was closed to the public.', 'J. Doe', 'Strike vote postponed.', 'Hearsay', NULL, NULL, 'Streetcar strike'),
+    (3, 'Report 1900-04-03', 'Operative 44', '1900-04-03', '10:15', NULL, 'Interviewed saloon keeper', NULL, NULL, 'R. Roe', NULL, NULL, 'yes', 'Transcription corrected', 'Streetcar strike'),
+    (4, 'Report 1901-01-15', 'Operative 44', '1901-01-15', '08:00', '3 hours', 'Shadowed subject', 'By streetcar', NULL, NULL, NULL, NULL, NULL, NULL, NULL);
+
+INSERT INTO detectives.locations (id, locality, street_address, location_name, location_type, specific_location_type, location_notes, visits, latitude, longitude) VALUES
+    (1, 'St. Louis', '1000 Franklin Avenue', 'Union Hall', 'Building', 'Meeting hall', NULL, 2, 38.6331, -90.1972),
+    (2, 'St. Louis', '4th and Olive', NULL, 'Saloon', NULL, 'Name not given in the report.', 1, 38.6290, -90.1886),
+    (3, 'East St. Louis', NULL, NULL, 'Street', NULL, NULL, 1, NULL, NULL);
+
+INSERT INTO detectives.activity_locations (activity_id, location_id) VALUES
+    (1, 1),
+    (2, 1),
+    (3, 2),
+    (4, 3),
+    (4, 1);
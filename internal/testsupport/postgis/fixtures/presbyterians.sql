@@ -0,0 +1,8 @@
+-- Presbyterian membership by year, with two bodies counted in 1900.
+INSERT INTO presbyterians_weber (year, members, churches) VALUES
+    (1890, 775903, 6894),
+    (1900, 983433, 7635),
+    (1900, 35417, 187),
+    (1910, 1314548, 9628),
+    (1920, NULL, NULL),
+    (1926, 1894030, 9482);
@@ -0,0 +1,26 @@
+-- Populated places and church membership in three cities from the 1926
+-- Census of Religious Bodies.
+INSERT INTO relcensus.popplaces_1926 (place_id, place, county, county_ahcb, state, map_name, lat, lon, pop_est_1926) VALUES
+    (101, 'Boston', 'Suffolk', 'mas_suffolk', 'MA', 'Boston, MA', 42.3601, -71.0589, 787000),
+    (102, 'Cambridge', 'Middlesex', 'mas_middlesex', 'MA', 'Cambridge, MA', 42.3736, -71.1097, 120000),
+    (103, 'Lowell', 'Middlesex', 'mas_middlesex', 'MA', 'Lowell, MA', 42.6334, -71.3162, 110000),
+    (201, 'Richmond', 'Richmond (Ind. City)', 'vas_richmondcity', 'VA', 'Richmond, VA', 37.5407, -77.4360, 186000);
+
+INSERT INTO relcensus.cities_25k (city, state, place_id, geometry) VALUES
+    ('Boston', 'MA', 101, ST_SetSRID(ST_MakePoint(-71.0589, 42.3601), 4326)),
+    ('Cambridge', 'MA', 102, ST_SetSRID(ST_MakePoint(-71.1097, 42.3736), 4326)),
+    ('Richmond', 'VA', 201, ST_SetSRID(ST_MakePoint(-77.4360, 37.5407), 4326));
+
+INSERT INTO relcensus.denominations (denomination_id, name, short_name, family_census, family_relec, year) VALUES
+    ('133', 'Protestant Episcopal Church', 'Episcopal', 'Protestant Episcopal bodies', 'Episcopalian', 1926),
+    ('144', 'Roman Catholic Church', 'Catholic', NULL, 'Catholic', 1926),
+    ('95', 'Methodist Episcopal Church', 'Methodist Episcopal', 'Methodist bodies', 'Methodist', 1926);
+
+INSERT INTO relcensus.membership_city (year, denomination, city, state, churches, members_total) VALUES
+    (1926, 'Protestant Episcopal Church', 'Boston', 'MA', 32, 21840),
+    (1926, 'Roman Catholic Church', 'Boston', 'MA', 61, 319401),
+    (1926, 'Methodist Episcopal Church', 'Boston', 'MA', 28, 8731),
+    (1926, 'Roman Catholic Church', 'Cambridge', 'MA', 11, 51023),
+    (1926, 'Protestant Episcopal Church', 'Richmond', 'VA', 18, 9510),
+    (1926, 'Methodist Episcopal Church', 'Richmond', 'VA', 7, 2210),
+    (1916, 'Methodist Episcopal Church', 'Boston', 'MA', 30, 8912);
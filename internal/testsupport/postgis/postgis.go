@@ -0,0 +1,117 @@
+// Package postgis runs Apiary against a disposable PostGIS database, so that
+// the HTTP suite can run on any machine with Docker.
+//
+// Start runs PostGIS in a container, applies the schema with the embedded
+// migrations, and loads the fixtures in the fixtures directory: a few rows
+// from each dataset, chosen to exercise every endpoint. Fixtures are loaded
+// in file name order, and may use anything the migrations create.
+package postgis
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	apiary "github.com/chnm/apiary"
+	"github.com/chnm/apiary/db"
+	"github.com/chnm/apiary/internal/config"
+	"github.com/chnm/apiary/internal/migrate"
+	"github.com/orlangure/gnomock"
+	"github.com/orlangure/gnomock/preset/postgres"
+)
+
+// image is PostgreSQL with PostGIS installed.
+const image = "docker.io/postgis/postgis:16-3.4"
+
+const (
+	user     = "apiary"
+	password = "apiary"
+	database = "apiary"
+)
+
+//go:embed fixtures/*.sql
+var fixtures embed.FS
+
+// Database is a PostGIS container with the schema applied and the fixtures
+// loaded.
+type Database struct {
+	URL       string // Connection string for the database
+	container *gnomock.Container
+}
+
+// Start starts a PostGIS container and prepares its database. It needs a
+// Docker daemon, and pulls the image the first time it runs.
+func Start(ctx context.Context) (*Database, error) {
+	container, err := gnomock.Start(
+		postgres.Preset(
+			postgres.WithUser(user, password),
+			postgres.WithDatabase(database),
+		),
+		gnomock.WithCustomImage(image),
+		gnomock.WithContext(ctx),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("start PostGIS container (is Docker running?): %w", err)
+	}
+	d := &Database{
+		URL: fmt.Sprintf("postgres://%s:%s@%s/%s?sslmode=disable",
+			user, password, container.DefaultAddress(), database),
+		container: container,
+	}
+	if err := d.load(ctx); err != nil {
+		return nil, errors.Join(err, d.Close())
+	}
+	return d, nil
+}
+
+// load applies the migrations and loads the fixtures.
+func (d *Database) load(ctx context.Context) error {
+	pool, err := db.Connect(ctx, d.URL)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	migrations, err := migrate.Embedded()
+	if err != nil {
+		return err
+	}
+	if _, err := migrate.New(pool, migrations).Up(ctx); err != nil {
+		return err
+	}
+
+	names, err := fs.Glob(fixtures, "fixtures/*.sql")
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		sql, err := fixtures.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		// Without arguments, pgx sends the file with the simple protocol,
+		// which allows several statements.
+		if _, err := pool.Exec(ctx, string(sql)); err != nil {
+			return fmt.Errorf("load fixture %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Server returns a server for cfg that reads from the database rather than
+// from the database and replicas that cfg names.
+func (d *Database) Server(ctx context.Context, cfg config.Config) (*apiary.Server, error) {
+	cfg.Database.URL = d.URL
+	cfg.Database.Replicas = nil
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return apiary.NewServer(ctx, cfg)
+}
+
+// Close stops and removes the container.
+func (d *Database) Close() error {
+	return gnomock.Stop(d.container)
+}
@@ -0,0 +1,42 @@
+package testsupport
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/chnm/apiary/internal/httpx"
+)
+
+// DecodeProblem checks that response is an application/problem+json response
+// and returns its decoded body.
+func DecodeProblem(t *testing.T, response *httptest.ResponseRecorder) httpx.Problem {
+	t.Helper()
+	if contentType := response.Header().Get("Content-Type"); contentType != httpx.ProblemContentType {
+		t.Fatalf("Content-Type = %q, want %q", contentType, httpx.ProblemContentType)
+	}
+	var problem httpx.Problem
+	if err := json.Unmarshal(response.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("decode problem %q: %v", response.Body.String(), err)
+	}
+	if problem.Status != response.Code {
+		t.Fatalf("problem status = %d, want response status %d", problem.Status, response.Code)
+	}
+	return problem
+}
+
+// AssertProblem checks that response is a problem with the given status, code,
+// and parameter.
+func AssertProblem(t *testing.T, response *httptest.ResponseRecorder, status int, code, parameter string) {
+	t.Helper()
+	if response.Code != status {
+		t.Fatalf("status = %d, want %d; body = %q", response.Code, status, response.Body.String())
+	}
+	problem := DecodeProblem(t, response)
+	if problem.Code != code {
+		t.Fatalf("code = %q, want %q", problem.Code, code)
+	}
+	if problem.Parameter != parameter {
+		t.Fatalf("parameter = %q, want %q", problem.Parameter, parameter)
+	}
+}
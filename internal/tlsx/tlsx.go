@@ -0,0 +1,161 @@
+// Package tlsx serves HTTPS from certificate and key files. The files are
+// reloaded when they change, or when asked, without restarting the server:
+// connections already open keep the certificate they started with, and new
+// ones get the new one.
+package tlsx
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Certificates holds the certificate loaded from a pair of files.
+type Certificates struct {
+	certFile, keyFile string
+	current           atomic.Pointer[tls.Certificate]
+
+	mu     sync.Mutex // Serializes reloads
+	stamps [2]stamp   // Of the files when last loaded
+}
+
+// stamp identifies a version of a file.
+type stamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// Load returns the certificate and key in a pair of PEM files. The
+// certificate file may hold intermediate certificates after the leaf.
+func Load(certFile, keyFile string) (*Certificates, error) {
+	c := &Certificates{certFile: certFile, keyFile: keyFile}
+	if err := c.Reload(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Reload reads the files again. If they cannot be loaded, such as while a
+// new certificate has been written but its key has not, the previous
+// certificate is kept and an error returned.
+func (c *Certificates) Reload() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stamps = c.stat()
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return fmt.Errorf("load TLS certificate: %w", err)
+	}
+	if cert.Leaf == nil {
+		if cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+			return fmt.Errorf("parse TLS certificate: %w", err)
+		}
+	}
+	c.current.Store(&cert)
+	return nil
+}
+
+// stat returns the stamps of the files. A file that cannot be read has a
+// zero stamp, so that it is loaded again once it can be.
+func (c *Certificates) stat() [2]stamp {
+	var stamps [2]stamp
+	for i, name := range []string{c.certFile, c.keyFile} {
+		if info, err := os.Stat(name); err == nil {
+			stamps[i] = stamp{modTime: info.ModTime(), size: info.Size()}
+		}
+	}
+	return stamps
+}
+
+// changed reports whether either file has changed since it was last loaded.
+func (c *Certificates) changed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stat() != c.stamps
+}
+
+// Leaf returns the certificate being served.
+func (c *Certificates) Leaf() *x509.Certificate {
+	return c.current.Load().Leaf
+}
+
+// GetCertificate returns the certificate being served. Use it as the
+// tls.Config field of the same name.
+func (c *Certificates) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return c.current.Load(), nil
+}
+
+// Config returns a TLS configuration that serves the certificate.
+func (c *Certificates) Config() *tls.Config {
+	return &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: c.GetCertificate,
+	}
+}
+
+// Watch reloads the files whenever they change, checking every interval,
+// until ctx is canceled. Files are compared by modification time and size
+// rather than watched for events, so that files replaced by swapping a
+// symbolic link, as mounted secrets are, are noticed too.
+func (c *Certificates) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if c.changed() {
+				c.ReloadAndLog(ctx)
+			}
+		}
+	}
+}
+
+// ReloadAndLog reloads the files, as on SIGHUP, and logs the outcome.
+func (c *Certificates) ReloadAndLog(ctx context.Context) {
+	if err := c.Reload(); err != nil {
+		slog.WarnContext(ctx, "kept the previous TLS certificate", "error", err)
+		return
+	}
+	leaf := c.Leaf()
+	slog.InfoContext(ctx, "loaded TLS certificate", "subject", leaf.Subject.String(), "not_after", leaf.NotAfter)
+}
+
+// RedirectHandler redirects requests to the same host and path over HTTPS on
+// port. GET and HEAD requests are moved permanently; other methods get a
+// permanent redirect, which tells clients to repeat the method and body.
+func RedirectHandler(port int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		} else {
+			host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+		}
+		if host == "" {
+			http.Error(w, "missing Host header", http.StatusBadRequest)
+			return
+		}
+		if port != 443 {
+			host = net.JoinHostPort(host, strconv.Itoa(port))
+		} else if strings.Contains(host, ":") {
+			host = "[" + host + "]" // An IPv6 address
+		}
+		status := http.StatusMovedPermanently
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			status = http.StatusPermanentRedirect
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), status)
+	})
+}
@@ -0,0 +1,159 @@
+package tlsx
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeCertificate writes a self-signed certificate for name and its key to
+// the files.
+func writeCertificate(t *testing.T, certFile, keyFile, name string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatalf("write certificate: %v", err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatalf("write key: %v", err)
+	}
+}
+
+// tempFiles returns the names of certificate and key files in a temporary
+// directory.
+func tempFiles(t *testing.T) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	return filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+}
+
+// servedName returns the common name of the certificate served by a new
+// connection to the server.
+func servedName(t *testing.T, server *httptest.Server) string {
+	t.Helper()
+	conn, err := tls.Dial("tcp", server.Listener.Addr().String(), &tls.Config{InsecureSkipVerify: true})
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	return conn.ConnectionState().PeerCertificates[0].Subject.CommonName
+}
+
+func TestReload(t *testing.T) {
+	certFile, keyFile := tempFiles(t)
+	writeCertificate(t, certFile, keyFile, "first.example.org")
+	certificates, err := Load(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	server := httptest.NewUnstartedServer(http.NotFoundHandler())
+	server.Listener = tls.NewListener(server.Listener, certificates.Config())
+	server.Start()
+	t.Cleanup(server.Close)
+	if got := servedName(t, server); got != "first.example.org" {
+		t.Fatalf("served %q, want first.example.org", got)
+	}
+
+	writeCertificate(t, certFile, keyFile, "second.example.org")
+	if err := certificates.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if got := servedName(t, server); got != "second.example.org" {
+		t.Fatalf("served %q after reload, want second.example.org", got)
+	}
+
+	// A certificate without its key is refused, and the previous one kept.
+	writeCertificate(t, certFile, filepath.Join(t.TempDir(), "other.key"), "third.example.org")
+	if err := certificates.Reload(); err == nil {
+		t.Fatal("Reload() accepted a certificate that does not match its key")
+	}
+	if got := servedName(t, server); got != "second.example.org" {
+		t.Fatalf("served %q after a failed reload, want second.example.org", got)
+	}
+}
+
+func TestLoadFails(t *testing.T) {
+	certFile, keyFile := tempFiles(t)
+	if _, err := Load(certFile, keyFile); err == nil {
+		t.Fatal("Load() accepted missing files")
+	}
+}
+
+func TestWatch(t *testing.T) {
+	certFile, keyFile := tempFiles(t)
+	writeCertificate(t, certFile, keyFile, "first.example.org")
+	certificates, err := Load(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go certificates.Watch(ctx, 10*time.Millisecond)
+
+	// Make sure the modification time changes even on coarse file systems.
+	later := time.Now().Add(time.Minute)
+	writeCertificate(t, certFile, keyFile, "second.example.org")
+	for _, name := range []string{certFile, keyFile} {
+		if err := os.Chtimes(name, later, later); err != nil {
+			t.Fatalf("touch %s: %v", name, err)
+		}
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for certificates.Leaf().Subject.CommonName != "second.example.org" {
+		if time.Now().After(deadline) {
+			t.Fatal("Watch did not reload the changed files")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestRedirectHandler(t *testing.T) {
+	for _, tt := range []struct {
+		method, target string
+		port           int
+		status         int
+		location       string
+	}{
+		{http.MethodGet, "http://data.example.org/bom/bills?year=1665", 443, http.StatusMovedPermanently, "https://data.example.org/bom/bills?year=1665"},
+		{http.MethodHead, "http://data.example.org:8080/", 8443, http.StatusMovedPermanently, "https://data.example.org:8443/"},
+		{http.MethodPost, "http://[::1]:8080/admin", 443, http.StatusPermanentRedirect, "https://[::1]/admin"},
+		{http.MethodGet, "http://[::1]/", 8443, http.StatusMovedPermanently, "https://[::1]:8443/"},
+	} {
+		response := httptest.NewRecorder()
+		RedirectHandler(tt.port).ServeHTTP(response, httptest.NewRequest(tt.method, tt.target, nil))
+		if response.Code != tt.status || response.Header().Get("Location") != tt.location {
+			t.Errorf("%s %s: %d to %q, want %d to %q", tt.method, tt.target, response.Code, response.Header().Get("Location"), tt.status, tt.location)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+// Package tracing sets up OpenTelemetry tracing: a span for each request,
+// named by its route, with the database package adding a child span for each
+// query.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/chnm/apiary/internal/httpx"
+	"github.com/chnm/apiary/internal/logging"
+	"github.com/felixge/httpsnoop"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.32.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Exporters.
+const (
+	ExporterNone   = "none"
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+)
+
+// serviceName is the service.name of the traces unless OTEL_SERVICE_NAME
+// says otherwise.
+const serviceName = "apiary"
+
+// instrumentation names the tracer of the spans this package starts.
+const instrumentation = "github.com/chnm/apiary"
+
+// Options configures Setup.
+type Options struct {
+	Exporter    string
+	Endpoint    string    // OTLP/HTTP traces URL; empty uses the OTEL_EXPORTER_OTLP_* variables
+	SampleRatio float64   // Fraction of new traces sampled; callers' decisions are followed
+	Stdout      io.Writer // Where the stdout exporter writes
+}
+
+// Setup installs a global tracer provider and W3C trace context propagation,
+// unless the exporter is none. Call the returned function during shutdown to
+// flush spans that have not been exported.
+func Setup(ctx context.Context, opts Options) (shutdown func(context.Context) error, err error) {
+	var exporter sdktrace.SpanExporter
+	switch opts.Exporter {
+	case ExporterNone, "":
+		return func(context.Context) error { return nil }, nil
+	case ExporterOTLP:
+		var options []otlptracehttp.Option
+		if opts.Endpoint != "" {
+			options = append(options, otlptracehttp.WithEndpointURL(opts.Endpoint))
+		}
+		exporter, err = otlptracehttp.New(ctx, options...)
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(opts.Stdout), stdouttrace.WithPrettyPrint())
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", opts.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("create %s trace exporter: %w", opts.Exporter, err)
+	}
+
+	// Attributes from OTEL_RESOURCE_ATTRIBUTES and OTEL_SERVICE_NAME come
+	// last so that they override the defaults.
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName(serviceName)),
+		resource.WithTelemetrySDK(),
+		resource.WithHost(),
+		resource.WithFromEnv(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("describe trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return provider.Shutdown, nil
+}
+
+// Handler starts a span for each request to next, continuing the caller's
+// trace if the request carries a traceparent header. The span is named by
+// the method until Middleware adds the route. Without Setup the span does
+// nothing.
+func Handler(next http.Handler) http.Handler {
+	return otelhttp.NewHandler(next, serviceName,
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string { return r.Method }),
+	)
+}
+
+// Middleware names the request's span by its route template, as in
+// "GET /bom/bills", and adds the route, the request ID, and the response
+// cache outcome to it. It must run inside the router, inside Handler, and
+// inside logging.Middleware.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		span := trace.SpanFromContext(r.Context())
+		if !span.IsRecording() {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if current := mux.CurrentRoute(r); current != nil {
+			if template, err := current.GetPathTemplate(); err == nil {
+				span.SetName(r.Method + " " + template)
+				span.SetAttributes(semconv.HTTPRoute(template))
+			}
+		}
+		if id := httpx.RequestIDFromContext(r.Context()); id != "" {
+			span.SetAttributes(attribute.String("http.request.id", id))
+		}
+		next.ServeHTTP(w, r)
+		if req := logging.RequestFromContext(r.Context()); req != nil {
+			span.SetAttributes(attribute.String("apiary.cache", req.Cache()))
+		}
+	})
+}
+
+// Writes times writing the response in a child span that starts with the
+// first write and ends when the handler returns. Placed just inside the
+// compression middleware, the span includes compressing the response, which
+// separates that cost from building it.
+func Writes(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !trace.SpanFromContext(r.Context()).IsRecording() {
+			next.ServeHTTP(w, r)
+			return
+		}
+		var span trace.Span
+		var bytes int64
+		start := func() {
+			if span == nil {
+				_, span = otel.Tracer(instrumentation).Start(r.Context(), "write response")
+			}
+		}
+		w = httpsnoop.Wrap(w, httpsnoop.Hooks{
+			Write: func(next httpsnoop.WriteFunc) httpsnoop.WriteFunc {
+				return func(b []byte) (int, error) {
+					start()
+					n, err := next(b)
+					bytes += int64(n)
+					return n, err
+				}
+			},
+			ReadFrom: func(next httpsnoop.ReadFromFunc) httpsnoop.ReadFromFunc {
+				return func(src io.Reader) (int64, error) {
+					start()
+					n, err := next(src)
+					bytes += n
+					return n, err
+				}
+			},
+		})
+		defer func() {
+			if span != nil {
+				span.SetAttributes(attribute.Int64("apiary.bytes_written", bytes))
+				span.End()
+			}
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,153 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/chnm/apiary/internal/httpx"
+	"github.com/chnm/apiary/internal/logging"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// recordSpans installs a tracer provider that records every span for the
+// rest of the test.
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
+
+// attributes returns the attributes of span as a map.
+func attributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	values := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes() {
+		values[kv.Key] = kv.Value
+	}
+	return values
+}
+
+func TestHandler(t *testing.T) {
+	recorder := recordSpans(t)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/items/{id:[0-9]+}", func(w http.ResponseWriter, r *http.Request) {
+		logging.RequestFromContext(r.Context()).SetCache("miss")
+		_, _ = w.Write([]byte("first "))
+		_, _ = w.Write([]byte("second"))
+	})
+	router.Use(logging.Middleware(false))
+	router.Use(Middleware)
+	router.Use(Writes)
+	handler := httpx.RequestID(Handler(router))
+
+	request := httptest.NewRequest(http.MethodGet, "/items/7", nil)
+	request.Header.Set(httpx.RequestIDHeader, "req-7")
+	handler.ServeHTTP(httptest.NewRecorder(), request)
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("recorded %d spans, want 2", len(spans))
+	}
+	write, server := spans[0], spans[1]
+	if server.Name() != "GET /items/{id:[0-9]+}" {
+		t.Errorf("server span name = %q", server.Name())
+	}
+	attrs := attributes(server)
+	if got := attrs["http.route"].AsString(); got != "/items/{id:[0-9]+}" {
+		t.Errorf("http.route = %q", got)
+	}
+	if got := attrs["http.request.id"].AsString(); got != "req-7" {
+		t.Errorf("http.request.id = %q", got)
+	}
+	if got := attrs["apiary.cache"].AsString(); got != "miss" {
+		t.Errorf("apiary.cache = %q", got)
+	}
+
+	if write.Name() != "write response" || write.Parent().SpanID() != server.SpanContext().SpanID() {
+		t.Errorf("write span = %q beneath %s, want write response beneath the server span", write.Name(), write.Parent().SpanID())
+	}
+	if got := attributes(write)["apiary.bytes_written"].AsInt64(); got != 12 {
+		t.Errorf("apiary.bytes_written = %d, want 12", got)
+	}
+}
+
+func TestHandlerContinuesTraces(t *testing.T) {
+	recorder := recordSpans(t)
+	previous := otel.GetTextMapPropagator()
+	t.Cleanup(func() { otel.SetTextMapPropagator(previous) })
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	handler := Handler(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	request.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	handler.ServeHTTP(httptest.NewRecorder(), request)
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("recorded %d spans, want 1", len(spans))
+	}
+	if got := spans[0].SpanContext().TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("trace ID = %s, want the caller's", got)
+	}
+}
+
+func TestWritesWithoutTracing(t *testing.T) {
+	recorder := recordSpans(t)
+
+	handler := Writes(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if response.Body.String() != "ok" || len(recorder.Ended()) != 0 {
+		t.Fatalf("body = %q with %d spans, want ok and no spans", response.Body, len(recorder.Ended()))
+	}
+}
+
+func TestSetup(t *testing.T) {
+	previousProvider, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+
+	shutdown, err := Setup(context.Background(), Options{Exporter: ExporterNone})
+	if err != nil || shutdown(context.Background()) != nil {
+		t.Fatalf("Setup() with no exporter error = %v", err)
+	}
+	if otel.GetTracerProvider() != previousProvider {
+		t.Error("Setup() with no exporter replaced the tracer provider")
+	}
+
+	var stdout bytes.Buffer
+	shutdown, err = Setup(context.Background(), Options{Exporter: ExporterStdout, SampleRatio: 1, Stdout: &stdout})
+	if err != nil {
+		t.Fatalf("Setup() error = %v", err)
+	}
+	_, span := otel.Tracer("test").Start(context.Background(), "checked")
+	span.End()
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown error = %v", err)
+	}
+	if got := stdout.String(); !strings.Contains(got, `"Name": "checked"`) || !strings.Contains(got, `"Value": "apiary"`) {
+		t.Errorf("stdout exporter wrote:\n%s", got)
+	}
+
+	if _, err := Setup(context.Background(), Options{Exporter: "zipkin"}); err == nil {
+		t.Error("Setup() accepted an unknown exporter")
+	}
+}
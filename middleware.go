@@ -1,43 +1,116 @@
 package apiary
 
 import (
+	"fmt"
+	"log/slog"
 	"net/http"
-	"os"
+	"runtime/debug"
 
+	"github.com/chnm/apiary/internal/auth"
+	"github.com/chnm/apiary/internal/deadline"
+	"github.com/chnm/apiary/internal/httpx"
+	"github.com/chnm/apiary/internal/logging"
+	"github.com/chnm/apiary/internal/tracing"
 	"github.com/gorilla/handlers"
+	"github.com/gorilla/mux"
 )
 
 // Middleware registers the middleware functions that should be used.
 func (s *Server) Middleware() {
-	if s.Config.logging {
-		s.Router.Use(loggingMiddleware)
+	if s.Metrics != nil {
+		s.Router.Use(s.Metrics.Middleware)
+	}
+	s.Router.Use(s.loggingMiddleware)
+	s.Router.Use(tracing.Middleware) // Inside logging, whose record of the request it reads
+	if s.Deadlines != nil {
+		s.Router.Use(s.Deadlines.Middleware) // Outside the writers that cannot extend write deadlines
+	}
+	if s.CORS != nil {
+		s.Router.Use(s.CORS.Middleware)
+	}
+	authenticator := s.Auth
+	if authenticator == nil {
+		// Without a key store no key is valid, but restricted routes must
+		// still be refused.
+		authenticator = auth.New(auth.Stores{}, nil)
+	}
+	s.Router.Use(authenticator.Middleware) // Before the limiter, which limits keys by name
+	if s.Limiter != nil {
+		s.Router.Use(s.Limiter.Middleware)
+	}
+	if s.Cursors != nil {
+		s.Router.Use(s.Cursors.Middleware)
 	}
-	s.Router.Use(corsMiddleware)
 	s.Router.Use(clientCacheMiddleware)
+	s.Router.Use(httpx.Conditional)        // ETags and 304s; must wrap compression
 	s.Router.Use(handlers.CompressHandler) // gzip requests
-	s.Router.Use(s.Cache.Middleware)
-	s.Router.Use(handlers.RecoveryHandler()) // Recover from runtime panics
+	s.Router.Use(tracing.Writes)           // Times compression separately from the handler
+	s.Router.Use(formatKeyMiddleware)
+	s.Router.Use(s.cacheMiddleware)
+	s.Router.Use(recoveryMiddleware) // Recover from runtime panics
 }
 
-// Log requests in the Apache Common Log format
-func loggingMiddleware(next http.Handler) http.Handler {
-	return handlers.LoggingHandler(os.Stdout, next)
+// Handler returns the handler for the HTTP server. Request IDs are assigned
+// outside the router so that 404 and 405 responses carry one too. Unless the
+// admin listener serves them, probes, /metrics, and the admin API are served
+// here too, outside the router so that they skip its caching, compression,
+// logging, and tracing middleware.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	if !s.Config.Admin.Listens() {
+		s.handleOperational(mux)
+	}
+	mux.Handle("/", tracing.Handler(s.Router))
+	return httpx.RequestID(mux)
 }
 
-// Allow Cross-Origin Request Sharing
-func corsMiddleware(next http.Handler) http.Handler {
+// loggingMiddleware ties the lines logged while serving a request to its
+// route, and writes the access log if it is enabled.
+func (s *Server) loggingMiddleware(next http.Handler) http.Handler {
+	return logging.Middleware(s.Config.Logging.AccessLog)(next)
+}
+
+// formatKeyHeader holds the negotiated response format so that the response
+// cache, which varies on it, stores JSON and CSV responses separately. It is
+// derived from the raw Accept header rather than keying on Accept itself,
+// which would give every distinct browser Accept string its own entry.
+const formatKeyHeader = "X-Apiary-Format"
+
+// formatKeyMiddleware records the response format the client negotiated in
+// formatKeyHeader, overwriting any value the client sent.
+func formatKeyMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		(w).Header().Set("Access-Control-Allow-Origin", "*")
+		r.Header.Set(formatKeyHeader, httpx.Format(r))
 		next.ServeHTTP(w, r)
 	})
 }
 
+// cacheMiddleware serves responses from the response cache. Streamed
+// responses bypass it, since the cache holds a whole response in memory
+// before sending any of it. Only routes declared deadline.Streaming stream;
+// an NDJSON request to any other route gets its usual cached response.
+func (s *Server) cacheMiddleware(next http.Handler) http.Handler {
+	cached := s.Cache.Middleware(next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if httpx.Format(r) == httpx.FormatNDJSON && deadline.RouteStreams(mux.CurrentRoute(r)) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		cached.ServeHTTP(w, r)
+	})
+}
+
 // clientCacheMiddleware sets HTTP headers to permit client-side caching of
-// successful responses. Error responses are marked no-store so clients do
-// not cache them.
+// successful responses. Responses from restricted routes may only be cached
+// by the client, not by shared caches. Error responses are marked no-store so
+// clients do not cache them.
 func clientCacheMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Cache-Control", "max-age=604800") // One week
+		if _, restricted := auth.DatasetFor(r); restricted {
+			w.Header().Set("Cache-Control", "private, max-age=604800")
+		} else {
+			w.Header().Set("Cache-Control", "max-age=604800") // One week
+		}
 		next.ServeHTTP(&noStoreOnError{ResponseWriter: w}, r)
 	})
 }
@@ -55,10 +128,59 @@ func (w *noStoreOnError) WriteHeader(status int) {
 	w.ResponseWriter.WriteHeader(status)
 }
 
+// Flush lets streamed responses reach the client as they are written.
+func (w *noStoreOnError) Flush() {
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (w *noStoreOnError) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// recoveryMiddleware turns a panic in a handler into a 500 problem response
+// and logs the stack trace with the request ID.
+func recoveryMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			err := recover()
+			if err == nil {
+				return
+			}
+			if err == http.ErrAbortHandler {
+				// The server suppresses the log for this sentinel.
+				panic(err)
+			}
+			slog.ErrorContext(r.Context(), "panic serving request",
+				"path", r.URL.Path, "panic", fmt.Sprint(err), "stack", string(debug.Stack()))
+			httpx.WriteProblem(w, r, httpx.Problem{
+				Status: http.StatusInternalServerError,
+				Code:   httpx.CodeInternal,
+			})
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
 // NotFoundHandler returns 404 errors
 func (s *Server) NotFoundHandler() http.HandlerFunc {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		http.Error(w, "404 Not found.", http.StatusNotFound)
+		httpx.WriteProblem(w, r, httpx.Problem{
+			Status: http.StatusNotFound,
+			Code:   httpx.CodeRouteNotFound,
+			Detail: "no endpoint matches this path; see / for the list of endpoints",
+		})
 	})
+}
 
+// MethodNotAllowedHandler returns 405 errors for routes that exist but do not
+// accept the request method.
+func (s *Server) MethodNotAllowedHandler() http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		httpx.WriteProblem(w, r, httpx.Problem{
+			Status: http.StatusMethodNotAllowed,
+			Code:   httpx.CodeMethodNotAllowed,
+			Detail: "only GET, HEAD, and OPTIONS requests are supported",
+		})
+	})
 }
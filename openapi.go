@@ -0,0 +1,72 @@
+package apiary
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/chnm/apiary/internal/auth"
+	"github.com/chnm/apiary/internal/openapi"
+)
+
+// apiVersion is the version of the API contract reported in the OpenAPI
+// document. Bump it when a URL or JSON contract changes.
+const apiVersion = "1.0.0"
+
+// OpenAPIDocument builds an OpenAPI document from the catalogs of the enabled
+// datasets, using baseURL as the server address. Like the root catalog, it
+// describes only the endpoints that key may use; pass the zero Key for an
+// anonymous caller.
+func (s *Server) OpenAPIDocument(baseURL string, key auth.Key) (openapi.Document, error) {
+	b := openapi.NewBuilder(openapi.Info{
+		Title:       "Apiary: the RRCHNM Data API",
+		Description: "Historical datasets published by the Roy Rosenzweig Center for History and New Media.",
+		Version:     apiVersion,
+	})
+	b.AddServer(baseURL)
+	for _, d := range s.Datasets.Datasets() {
+		endpoints := s.visibleEndpoints(key, d.Endpoints(baseURL))
+		if len(endpoints) == 0 {
+			continue
+		}
+		metadata := d.Metadata()
+		description := metadata.Title
+		if metadata.Description != "" {
+			description += ": " + metadata.Description
+		}
+		b.AddTag(d.Name(), description)
+		if err := b.AddEndpoints(d.Name(), endpoints); err != nil {
+			return openapi.Document{}, err
+		}
+	}
+	return b.Document(), nil
+}
+
+// OpenAPIHandler serves the OpenAPI document for the enabled datasets.
+func (s *Server) OpenAPIHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		proto := "http://"
+		if r.TLS != nil {
+			proto = "https://"
+		}
+
+		key, _ := auth.KeyFromContext(r.Context())
+		doc, err := s.OpenAPIDocument(proto+r.Host, key)
+		if err != nil {
+			internalServerError(w, r, "error building OpenAPI document", err)
+			return
+		}
+		response, err := json.MarshalIndent(doc, "", "  ")
+		if err != nil {
+			internalServerError(w, r, "error marshaling OpenAPI document", err)
+			return
+		}
+		if len(s.restrictedPaths) > 0 {
+			w.Header().Add("Vary", "Authorization")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write(response); err != nil {
+			slog.WarnContext(r.Context(), "error writing OpenAPI document", "error", err)
+		}
+	}
+}
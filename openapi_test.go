@@ -0,0 +1,57 @@
+package apiary
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/chnm/apiary/internal/openapi"
+)
+
+func TestOpenAPIHandler(t *testing.T) {
+	server := &Server{Datasets: newDatasetRegistry(nil)}
+	response := httptest.NewRecorder()
+
+	server.OpenAPIHandler().ServeHTTP(
+		response,
+		httptest.NewRequest(http.MethodGet, "http://data.example/openapi.json", nil),
+	)
+
+	if response.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", response.Code, http.StatusOK, response.Body)
+	}
+	if contentType := response.Header().Get("Content-Type"); contentType != "application/json" {
+		t.Fatalf("Content-Type = %q, want application/json", contentType)
+	}
+	var doc openapi.Document
+	if err := json.Unmarshal(response.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("unmarshal OpenAPI document: %v", err)
+	}
+	if doc.OpenAPI != openapi.Version {
+		t.Fatalf("openapi = %q, want %q", doc.OpenAPI, openapi.Version)
+	}
+	if len(doc.Paths) != 41 {
+		t.Fatalf("path count = %d, want 41", len(doc.Paths))
+	}
+	if len(doc.Servers) != 1 || doc.Servers[0].URL != "http://data.example" {
+		t.Fatalf("servers = %+v", doc.Servers)
+	}
+	if len(doc.Tags) != len(server.Datasets.Names()) {
+		t.Fatalf("tag count = %d, want one per dataset", len(doc.Tags))
+	}
+
+	bills := doc.Paths["/bom/bills"].Get
+	var sort *openapi.Parameter
+	for i := range bills.Parameters {
+		if bills.Parameters[i].Name == "sort" {
+			sort = &bills.Parameters[i]
+		}
+	}
+	if sort == nil || len(sort.Schema.Enum) != 3 || sort.Schema.Default != "year" {
+		t.Fatalf("/bom/bills sort parameter = %+v", sort)
+	}
+	if _, ok := doc.Components.Schemas["ParishByYear"]; !ok {
+		t.Fatal("ParishByYear schema is missing from components")
+	}
+}
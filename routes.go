@@ -1,6 +1,13 @@
 package apiary
 
 import (
+	"fmt"
+	"net/http"
+
+	"github.com/chnm/apiary/db"
+	"github.com/chnm/apiary/internal/auth"
+	"github.com/chnm/apiary/internal/cachex"
+	"github.com/chnm/apiary/internal/datasets"
 	"github.com/chnm/apiary/internal/datasets/ahcb"
 	"github.com/chnm/apiary/internal/datasets/apb"
 	"github.com/chnm/apiary/internal/datasets/bom"
@@ -12,23 +19,51 @@ import (
 	"github.com/chnm/apiary/internal/datasets/relcensus"
 )
 
+// newDatasetRegistry returns every dataset this server knows how to serve, in
+// catalog order. Adding a dataset package requires only a registration here;
+// its routes and catalog entries are then mounted together.
+func newDatasetRegistry(pool db.Querier) *datasets.Registry {
+	return datasets.NewRegistry(
+		ahcb.New(pool),
+		apb.New(pool),
+		bom.New(pool),
+		catholic.New(pool),
+		naturalearth.New(pool),
+		popplaces.New(pool),
+		presbyterians.New(pool),
+		relcensus.New(pool),
+		pinkertons.New(pool),
+	)
+}
+
 // Routes registers the handlers for the URLs that should be served.
 func (s *Server) Routes() {
-	ahcb.New(s.DB).RegisterRoutes(s.Router)
-	apb.New(s.DB).RegisterRoutes(s.Router)
-	bom.New(s.DB).RegisterRoutes(s.Router)
-	catholic.New(s.DB).RegisterRoutes(s.Router)
-	naturalearth.New(s.DB).RegisterRoutes(s.Router)
-	popplaces.New(s.DB).RegisterRoutes(s.Router)
-	presbyterians.New(s.DB).RegisterRoutes(s.Router)
-	relcensus.New(s.DB).RegisterRoutes(s.Router)
-	pinkertons.New(s.DB).RegisterRoutes(s.Router)
-	s.Router.HandleFunc("/", s.EndpointsHandler()).Methods("GET", "HEAD")
+	s.Datasets.RegisterRoutes(s.Router)
+	restrictedPaths, err := auth.RestrictedPaths(s.Router)
+	if err != nil {
+		// Every dataset route has a path template, so this cannot happen.
+		panic(fmt.Sprintf("find restricted routes: %v", err))
+	}
+	s.restrictedPaths = restrictedPaths
+	// The catalog is built in memory, so there is nothing to gain by caching it
+	s.Router.Handle("/", cachex.NoCache(s.EndpointsHandler())).Methods("GET", "HEAD")
+	s.Router.Handle("/openapi.json", cachex.NoCache(s.OpenAPIHandler())).Methods("GET", "HEAD")
+
+	// Routes accept only GET and HEAD, so OPTIONS requests reach the 405
+	// handler, which answers them for every route
+	var notFound, methodNotAllowed http.Handler = s.NotFoundHandler(), s.MethodNotAllowedHandler()
+	if s.CORS != nil {
+		methodNotAllowed = s.CORS.Preflight(s.Router, methodNotAllowed)
+	}
 
-	// Make sure to log 404 errors
-	if s.Config.logging {
-		s.Router.NotFoundHandler = loggingMiddleware(s.NotFoundHandler())
-	} else {
-		s.Router.NotFoundHandler = s.NotFoundHandler()
+	// Router middleware only runs for matched routes, so make sure to count
+	// and log 404 and 405 errors
+	notFound = s.loggingMiddleware(notFound)
+	methodNotAllowed = s.loggingMiddleware(methodNotAllowed)
+	if s.Metrics != nil {
+		notFound = s.Metrics.Middleware(notFound)
+		methodNotAllowed = s.Metrics.Middleware(methodNotAllowed)
 	}
+	s.Router.NotFoundHandler = notFound
+	s.Router.MethodNotAllowedHandler = methodNotAllowed
 }
@@ -2,13 +2,29 @@ package apiary
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
-	"log"
-	"math"
+	"log/slog"
 	"net/http"
+	"sync/atomic"
 	"time"
 
 	"github.com/chnm/apiary/db"
+	"github.com/chnm/apiary/internal/auth"
+	"github.com/chnm/apiary/internal/cachex"
+	"github.com/chnm/apiary/internal/config"
+	"github.com/chnm/apiary/internal/cors"
+	"github.com/chnm/apiary/internal/datasets"
+	"github.com/chnm/apiary/internal/deadline"
+	"github.com/chnm/apiary/internal/httpx/pagination"
+	"github.com/chnm/apiary/internal/lifecycle"
+	"github.com/chnm/apiary/internal/logging"
+	"github.com/chnm/apiary/internal/metrics"
+	"github.com/chnm/apiary/internal/ratelimit"
+	"github.com/chnm/apiary/internal/tlsx"
 	"github.com/gorilla/mux"
 	"github.com/jackc/pgx/v5/pgxpool"
 
@@ -16,98 +32,410 @@ import (
 	"github.com/victorspringer/http-cache/adapter/memory"
 )
 
-// The Config type stores configuration which is read from environment variables.
-type Config struct {
-	dbconn  string
-	logging bool   // Whether or not to write access logs; errors/status are always logged
-	address string // The address at which this will be hosted, e.g.: localhost:8090
-}
-
 // The Server type shares access to the database.
 type Server struct {
-	Server *http.Server
-	DB     *pgxpool.Pool
-	Router *mux.Router
-	Config Config
-	Cache  *cache.Client
+	Server    *http.Server
+	DB        *pgxpool.Pool // The primary
+	Cluster   *db.Cluster   // Reads for datasets, from replicas if there are any
+	Router    *mux.Router
+	Config    config.Config
+	Cache     *cachex.Cache
+	Datasets  *datasets.Registry
+	Metrics   *metrics.Metrics
+	Limiter   *ratelimit.Limiter // Nil when rate limiting is off
+	Auth      *auth.Authenticator
+	Deadlines *deadline.Policy
+	CORS      *cors.Policy
+	Cursors   *pagination.Signer
+
+	Admin        *http.Server       // Nil unless operational endpoints have their own listener
+	Redirect     *http.Server       // Nil unless plain HTTP is redirected to HTTPS
+	Certificates *tlsx.Certificates // Nil when serving plain HTTP
+
+	restrictedPaths map[string]string // Dataset of each restricted route template
+	draining        atomic.Bool       // Set once shutdown begins; fails readiness checks
 }
 
-// NewServer creates a new Server and connects to the database or fails trying.
-func NewServer(ctx context.Context) *Server {
-	s := Server{}
+// NewServer creates a new Server from a validated configuration and connects
+// to the database.
+func NewServer(ctx context.Context, cfg config.Config) (*Server, error) {
+	s := Server{Config: cfg}
 
-	// Read the configuration from environment variables. The `getEnv()` function
-	// will provide a default.
-	s.Config.dbconn = getEnv("APIARY_DB", "")
-	s.Config.logging = getEnv("APIARY_LOGGING", "on") == "on"
-	s.Config.address = getEnv("APIARY_INTERFACE", "0.0.0.0") + ":" + getEnv("APIARY_PORT", "8090")
+	// Load the certificate first, since connecting to the database can take
+	// a while to fail.
+	if cfg.TLS.Enabled() {
+		certificates, err := tlsx.Load(cfg.TLS.CertFile, cfg.TLS.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		s.Certificates = certificates
+	}
 
 	// Connect to the database then store the database in the struct.
-	log.Println("connecting to the database")
-	dbTimeout, cancel := context.WithTimeout(ctx, 60*time.Second)
+	slog.Info("connecting to the database")
+	poolConfig, err := cfg.Database.PoolConfig()
+	if err != nil {
+		return nil, errors.New("invalid database connection string")
+	}
+	poolConfig.ConnConfig.Tracer = logging.QueryTracer{SlowQuery: cfg.Logging.SlowQuery.Duration}
+	dbTimeout, cancel := context.WithTimeout(ctx, cfg.Database.ConnectTimeout.Duration)
 	defer cancel()
-	pool, err := db.Connect(dbTimeout, s.Config.dbconn)
+	pool, err := db.ConnectConfig(dbTimeout, poolConfig)
 	if err != nil {
-		log.Fatalln("error connecting to the database:", err)
+		return nil, fmt.Errorf("connect to the database: %w", err)
 	}
 	s.DB = pool
 
-	// Set up the in-memory cache
-	memcached, err := memory.NewAdapter(
-		memory.AdapterWithAlgorithm(memory.LRU),
-		memory.AdapterWithCapacity(int(math.Pow(1000, 2))), // 1000^3 = gigabyte
-	)
+	// Dataset queries go to the read replicas, if there are any. They are
+	// connected in the background, so that one that is down does not stop
+	// the server from starting.
+	replicaConfigs, err := cfg.Database.ReplicaPoolConfigs()
+	if err != nil {
+		pool.Close()
+		return nil, errors.New("invalid read replica connection string")
+	}
+	for _, replicaConfig := range replicaConfigs {
+		replicaConfig.ConnConfig.Tracer = logging.QueryTracer{SlowQuery: cfg.Logging.SlowQuery.Duration}
+	}
+	cluster, err := db.NewCluster(pool, replicaConfigs)
 	if err != nil {
-		log.Fatal("error setting up memory cache:", err)
+		pool.Close()
+		return nil, fmt.Errorf("set up read replicas: %w", err)
+	}
+	s.Cluster = cluster
+	if len(replicaConfigs) > 0 {
+		slog.Info("reading datasets from replicas", "replicas", len(replicaConfigs))
 	}
 
-	cacheClient, err := cache.NewClient(
-		cache.ClientWithAdapter(memcached),
-		cache.ClientWithTTL(1*time.Hour),
+	// Select the datasets that this deployment serves.
+	registry, err := newDatasetRegistry(s.Cluster).Select(cfg.Datasets.Enabled, cfg.Datasets.Disabled)
+	if err != nil {
+		s.closeDatabase()
+		return nil, fmt.Errorf("select datasets: %w", err)
+	}
+	s.Datasets = registry
+	slog.Info("serving datasets", "datasets", s.Datasets.Names())
+
+	s.Metrics = metrics.New(s.DB)
+
+	// Set up the response cache. Routes choose their own TTLs; the
+	// configured TTL applies to the rest.
+	adapter, err := newCacheAdapter(ctx, cfg.Cache)
+	if err != nil {
+		s.closeDatabase()
+		return nil, fmt.Errorf("set up %s cache: %w", cfg.Cache.Backend, err)
+	}
+	observeCache := func(event cache.CacheEvent) {
+		s.Metrics.ObserveCache(event)
+		logging.ObserveCache(event)
+	}
+	responseCache, err := cachex.New(adapter, cfg.Cache.TTL.Duration, observeCache,
 		cache.ClientWithRefreshKey("nocache"),
+		cache.ClientWithVaryHeaders([]string{formatKeyHeader}),
 	)
 	if err != nil {
-		log.Fatal("error setting up memory cache:", err)
+		s.closeDatabase()
+		return nil, fmt.Errorf("set up %s cache: %w", cfg.Cache.Backend, err)
+	}
+	s.Cache = responseCache
+	slog.Info("caching responses", "backend", cfg.Cache.Backend)
+
+	if cfg.RateLimit.Enabled {
+		limiter, err := newRateLimiter(cfg.RateLimit)
+		if err != nil {
+			s.closeDatabase()
+			return nil, fmt.Errorf("set up rate limits: %w", err)
+		}
+		s.Limiter = limiter
+	}
+
+	authStore, err := newAuthStore(cfg.Auth, s.DB)
+	if err != nil {
+		s.closeDatabase()
+		return nil, fmt.Errorf("set up API keys: %w", err)
+	}
+	failures, err := newAuthFailureLimiter(cfg.RateLimit)
+	if err != nil {
+		s.closeDatabase()
+		return nil, fmt.Errorf("set up API key limits: %w", err)
+	}
+	s.Auth = auth.New(authStore, failures)
+
+	deadlines, err := newDeadlinePolicy(cfg)
+	if err != nil {
+		s.closeDatabase()
+		return nil, fmt.Errorf("set up deadlines: %w", err)
+	}
+	s.Deadlines = deadlines
+
+	policy, err := cors.New(cors.Options{
+		AllowedOrigins: cfg.CORS.AllowedOrigins,
+		AllowedHeaders: cfg.CORS.AllowedHeaders,
+		ExposedHeaders: cfg.CORS.ExposedHeaders,
+		MaxAge:         cfg.CORS.MaxAge.Duration,
+	})
+	if err != nil {
+		s.closeDatabase()
+		return nil, fmt.Errorf("set up CORS: %w", err)
+	}
+	s.CORS = policy
+
+	s.Cursors = pagination.NewSigner(cursorSecret(cfg))
+	if cfg.Pagination.CursorSecret == "" {
+		if poolConfig, err := pgxpool.ParseConfig(cfg.Database.URL); err == nil && poolConfig.ConnConfig.Password == "" {
+			slog.Warn("signing pagination cursors with a secret derived from a database URL without a password, so cursors can be forged; set pagination.cursor_secret")
+		}
 	}
-	s.Cache = cacheClient
 
 	// Create the router, store it in the struct, initialize the routes, and
 	// register the middleware.
 	router := mux.NewRouter()
 	s.Router = router
 	s.Routes()
+	if err := s.Deadlines.Check(router); err != nil {
+		s.closeDatabase()
+		return nil, fmt.Errorf("timeouts.routes: %w", err)
+	}
 	s.Middleware()
 
 	s.Server = &http.Server{
-		Addr:         s.Config.address,
-		WriteTimeout: time.Second * 15,
-		ReadTimeout:  time.Second * 15,
-		IdleTimeout:  time.Second * 60,
-		Handler:      s.Router,
+		Addr:         cfg.Address(),
+		WriteTimeout: cfg.HTTP.WriteTimeout.Duration,
+		ReadTimeout:  cfg.HTTP.ReadTimeout.Duration,
+		IdleTimeout:  cfg.HTTP.IdleTimeout.Duration,
+		Handler:      s.Handler(),
+		ErrorLog:     slog.NewLogLogger(slog.Default().Handler(), slog.LevelWarn),
+	}
+	if s.Certificates != nil {
+		s.Server.TLSConfig = s.Certificates.Config()
+		go s.Certificates.Watch(ctx, certificatePollInterval)
+		if cfg.TLS.RedirectPort != 0 {
+			s.Redirect = &http.Server{
+				Addr:              cfg.RedirectAddress(),
+				Handler:           tlsx.RedirectHandler(cfg.HTTP.Port),
+				ReadHeaderTimeout: cfg.HTTP.ReadTimeout.Duration,
+				IdleTimeout:       cfg.HTTP.IdleTimeout.Duration,
+				ErrorLog:          s.Server.ErrorLog,
+			}
+		}
+	}
+
+	if cfg.Admin.Listens() {
+		// Profiles can take longer than the API's write timeout, so the
+		// admin listener has none.
+		s.Admin = &http.Server{
+			Addr:              cfg.AdminAddress(),
+			Handler:           s.AdminHandler(),
+			ReadHeaderTimeout: cfg.HTTP.ReadTimeout.Duration,
+			IdleTimeout:       cfg.HTTP.IdleTimeout.Duration,
+			ErrorLog:          s.Server.ErrorLog,
+		}
 	}
 
-	return &s
+	return &s, nil
+}
+
+// Servers returns what lifecycle.Run coordinates: the server itself, and the
+// admin listener if there is one.
+func (s *Server) Servers() []lifecycle.Server {
+	servers := []lifecycle.Server{s}
+	if s.Admin != nil {
+		servers = append(servers, adminServer{s.Admin})
+	}
+	return servers
+}
+
+// adminServer runs the admin listener. It is shut down apart from the API
+// server, so that probes keep answering while the API drains.
+type adminServer struct {
+	*http.Server
+}
+
+func (a adminServer) Run() error {
+	slog.Info("starting the admin listener", "address", "http://"+a.Addr)
+	return ignoreClosed(a.ListenAndServe())
 }
 
-// Run starts the API server.
+// certificatePollInterval is how often the TLS certificate files are checked
+// for changes.
+const certificatePollInterval = 30 * time.Second
+
+// Run starts the API server, and the server that redirects plain HTTP to
+// HTTPS if there is one. If either fails, both are closed.
 func (s *Server) Run() error {
-	log.Printf("starting the server on http://%s\n", s.Config.address)
-	err := s.Server.ListenAndServe()
-	if err == http.ErrServerClosed {
+	if s.Certificates == nil {
+		slog.Info("starting the server", "address", "http://"+s.Config.Address())
+		return ignoreClosed(s.Server.ListenAndServe())
+	}
+
+	servers := 1
+	errs := make(chan error, 2)
+	slog.Info("starting the server", "address", "https://"+s.Config.Address(), "not_after", s.Certificates.Leaf().NotAfter)
+	go func() { errs <- ignoreClosed(s.Server.ListenAndServeTLS("", "")) }()
+	if s.Redirect != nil {
+		servers++
+		slog.Info("redirecting plain HTTP to HTTPS", "address", "http://"+s.Redirect.Addr)
+		go func() { errs <- ignoreClosed(s.Redirect.ListenAndServe()) }()
+	}
+
+	var first error
+	for range servers {
+		if err := <-errs; err != nil && first == nil {
+			first = err
+			_ = s.Server.Close()
+			if s.Redirect != nil {
+				_ = s.Redirect.Close()
+			}
+		}
+	}
+	return first
+}
+
+// ignoreClosed returns nil for the error servers return once shut down.
+func ignoreClosed(err error) error {
+	if errors.Is(err, http.ErrServerClosed) {
 		return nil
 	}
 	return err
 }
 
+// ReloadCertificates reads the TLS certificate and key files again, as on
+// SIGHUP. It does nothing when serving plain HTTP.
+func (s *Server) ReloadCertificates(ctx context.Context) {
+	if s.Certificates != nil {
+		s.Certificates.ReloadAndLog(ctx)
+	}
+}
+
 // Shutdown stops accepting requests, drains active requests, and then closes
 // the database connection pool.
 func (s *Server) Shutdown(ctx context.Context) error {
-	log.Println("shutting down the web server")
+	slog.Info("shutting down the web server")
+	if s.Redirect != nil {
+		if err := s.Redirect.Shutdown(ctx); err != nil {
+			return fmt.Errorf("shut down HTTP redirect server: %w", err)
+		}
+	}
 	if err := s.Server.Shutdown(ctx); err != nil {
 		return fmt.Errorf("shut down HTTP server: %w", err)
 	}
 
-	log.Println("closing the connection to the database")
-	s.DB.Close()
+	slog.Info("closing the connection to the database")
+	s.closeDatabase()
+
+	if s.Cache != nil {
+		if err := s.Cache.Close(); err != nil {
+			return fmt.Errorf("close cache: %w", err)
+		}
+	}
 	return nil
 }
+
+// closeDatabase closes the connections to the replicas and the primary.
+func (s *Server) closeDatabase() {
+	if s.Cluster != nil {
+		s.Cluster.Close()
+	}
+	s.DB.Close()
+}
+
+// newCacheAdapter opens the cache backend selected by the configuration.
+func newCacheAdapter(ctx context.Context, cfg config.Cache) (cache.Adapter, error) {
+	switch cfg.Backend {
+	case config.CacheDisk:
+		return cachex.NewDiskAdapter(cfg.Dir, cfg.Capacity)
+	case config.CacheRedis:
+		return cachex.NewRedisAdapter(ctx, cfg.RedisURL)
+	default:
+		return memory.NewAdapter(
+			memory.AdapterWithAlgorithm(memory.LRU),
+			memory.AdapterWithCapacity(cfg.Capacity),
+		)
+	}
+}
+
+// newRateLimiter builds the rate limiter from a validated configuration.
+func newRateLimiter(cfg config.RateLimit) (*ratelimit.Limiter, error) {
+	return newLimiter(cfg, map[ratelimit.Class]ratelimit.Limit{
+		ratelimit.Default: {PerMinute: cfg.Default.PerMinute, Burst: cfg.Default.Burst},
+		ratelimit.Bulk:    {PerMinute: cfg.Bulk.PerMinute, Burst: cfg.Bulk.Burst},
+	})
+}
+
+// newAuthFailureLimiter builds the limit on requests with invalid API keys
+// from a validated configuration. It applies whether or not rate limiting is
+// enabled, since it keeps guessed keys from reaching the database.
+func newAuthFailureLimiter(cfg config.RateLimit) (*ratelimit.Limiter, error) {
+	limit := ratelimit.Limit{PerMinute: cfg.AuthFailures.PerMinute, Burst: cfg.AuthFailures.Burst}
+	// The limiter is never used as middleware, but New requires a default.
+	return newLimiter(cfg, map[ratelimit.Class]ratelimit.Limit{
+		ratelimit.Default:      limit,
+		ratelimit.AuthFailures: limit,
+	})
+}
+
+// newLimiter builds a limiter with the given limits and the configured
+// proxies and exemptions.
+func newLimiter(cfg config.RateLimit, limits map[ratelimit.Class]ratelimit.Limit) (*ratelimit.Limiter, error) {
+	trustedProxies, err := ratelimit.ParsePrefixes(cfg.TrustedProxies)
+	if err != nil {
+		return nil, fmt.Errorf("trusted proxies: %w", err)
+	}
+	exempt, err := ratelimit.ParsePrefixes(cfg.Exempt)
+	if err != nil {
+		return nil, fmt.Errorf("exempt clients: %w", err)
+	}
+	return ratelimit.New(ratelimit.Options{
+		Limits:         limits,
+		TrustedProxies: trustedProxies,
+		Exempt:         exempt,
+	})
+}
+
+// cursorSecret returns the secret that signs pagination cursors. Without a
+// configured one, it is derived from the database URL, which holds the
+// database password and which every instance of a deployment shares, so that
+// cursors keep working across restarts and between instances.
+func cursorSecret(cfg config.Config) string {
+	if cfg.Pagination.CursorSecret != "" {
+		return cfg.Pagination.CursorSecret
+	}
+	mac := hmac.New(sha256.New, []byte(cfg.Database.URL))
+	mac.Write([]byte("apiary pagination cursors"))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// newDeadlinePolicy builds the route deadlines from a validated configuration.
+func newDeadlinePolicy(cfg config.Config) (*deadline.Policy, error) {
+	routes := make(map[string]time.Duration, len(cfg.Timeouts.Routes))
+	for template, timeout := range cfg.Timeouts.Routes {
+		routes[template] = timeout.Duration
+	}
+	return deadline.New(deadline.Options{
+		Classes: map[deadline.Class]time.Duration{
+			deadline.Default: cfg.Timeouts.Default.Duration,
+			deadline.Bulk:    cfg.Timeouts.Bulk.Duration,
+			deadline.Spatial: cfg.Timeouts.Spatial.Duration,
+		},
+		Routes:       routes,
+		WriteTimeout: cfg.HTTP.WriteTimeout.Duration,
+	})
+}
+
+// newAuthStore builds the store of API keys from a validated configuration:
+// the keys in the configuration file, then those in the database if enabled.
+func newAuthStore(cfg config.Auth, pool *pgxpool.Pool) (auth.Store, error) {
+	keys := make([]auth.Key, len(cfg.Keys))
+	for i, key := range cfg.Keys {
+		keys[i] = auth.Key{Name: key.Name, Hash: key.Hash, Datasets: key.Datasets}
+	}
+	static, err := auth.NewStaticStore(keys)
+	if err != nil {
+		return nil, err
+	}
+	stores := auth.Stores{static}
+	if cfg.Database {
+		stores = append(stores, auth.NewPostgresStore(pool))
+	}
+	return stores, nil
+}